@@ -16,6 +16,7 @@ type WorkerAPI interface {
 
 	TaskTypes(context.Context) (map[types.TaskType]struct{}, error) // TaskType -> Weight
 	Paths(context.Context) ([]stores.StoragePath, error)
+	Transfers(context.Context) ([]storiface.TransferProgress, error)
 	Info(context.Context) (storiface.WorkerInfo, error)
 
 	storiface.WorkerCalls
@@ -0,0 +1,108 @@
+package impl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/mitchellh/go-homedir"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/lib/backupds"
+	"github.com/filecoin-project/venus-sealer/models"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+)
+
+// BackupBasePathEnv must be set for CreateBackup, backups can only be written into this path
+const BackupBasePathEnv = "VENUS_SEALER_BACKUP_BASE_PATH"
+
+// BackupRepoFiles are the files in the repo dir that are put into a backup besides the config,
+// the jwt secret lives in the config
+var BackupRepoFiles = []string{"token", "storage.json"}
+
+// BackupConfigFile is the name of the config file in a backup
+const BackupConfigFile = "config.toml"
+
+func backup(r repo.Repo, cfg *config.StorageMiner, fpath string) error {
+	bb, ok := os.LookupEnv(BackupBasePathEnv)
+	if !ok {
+		return xerrors.Errorf("%s env var not set", BackupBasePathEnv)
+	}
+
+	bb, err := homedir.Expand(bb)
+	if err != nil {
+		return xerrors.Errorf("expanding base path: %w", err)
+	}
+
+	bb, err = filepath.Abs(bb)
+	if err != nil {
+		return xerrors.Errorf("getting absolute base path: %w", err)
+	}
+
+	fpath, err = homedir.Expand(fpath)
+	if err != nil {
+		return xerrors.Errorf("expanding file path: %w", err)
+	}
+
+	fpath, err = filepath.Abs(fpath)
+	if err != nil {
+		return xerrors.Errorf("getting absolute file path: %w", err)
+	}
+
+	if !strings.HasPrefix(fpath, bb) {
+		return xerrors.Errorf("backup file name (%s) must be inside base path (%s)", fpath, bb)
+	}
+
+	mds := dssync.MutexWrap(datastore.NewMapDatastore())
+
+	cfgData, err := ioutil.ReadFile(cfg.ConfigPath)
+	if err != nil {
+		return xerrors.Errorf("read config: %w", err)
+	}
+	if err := mds.Put(models.BackupFileKey(BackupConfigFile), cfgData); err != nil {
+		return err
+	}
+
+	dataDir, err := homedir.Expand(cfg.DataDir)
+	if err != nil {
+		return err
+	}
+	for _, name := range BackupRepoFiles {
+		data, err := ioutil.ReadFile(filepath.Join(dataDir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return xerrors.Errorf("read %s: %w", name, err)
+		}
+		if err := mds.Put(models.BackupFileKey(name), data); err != nil {
+			return err
+		}
+	}
+
+	if err := models.DumpRepo(r, mds); err != nil {
+		return xerrors.Errorf("dump database: %w", err)
+	}
+
+	out, err := os.OpenFile(fpath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return xerrors.Errorf("open %s: %w", fpath, err)
+	}
+
+	if err := backupds.Wrap(mds).Backup(out); err != nil {
+		if cerr := out.Close(); cerr != nil {
+			log.Errorw("error closing backup file while handling backup error", "closeErr", cerr, "backupErr", err)
+		}
+		return xerrors.Errorf("backup error: %w", err)
+	}
+
+	if err := out.Close(); err != nil {
+		return xerrors.Errorf("closing backup file: %w", err)
+	}
+
+	return nil
+}
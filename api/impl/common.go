@@ -2,6 +2,7 @@ package impl
 
 import (
 	"context"
+	"time"
 
 	"github.com/filecoin-project/go-jsonrpc/auth"
 	"github.com/gbrlsnchs/jwt/v3"
@@ -13,6 +14,7 @@ import (
 	"github.com/filecoin-project/venus-sealer/api"
 	"github.com/filecoin-project/venus-sealer/config"
 	"github.com/filecoin-project/venus-sealer/constants"
+	"github.com/filecoin-project/venus-sealer/service"
 	"github.com/filecoin-project/venus-sealer/types"
 )
 
@@ -25,10 +27,15 @@ type CommonAPI struct {
 	ShutdownChan  types.ShutdownChan
 	NetworkParams *config.NetParamsConfig
 	APIToken      types.APIToken
+	Tokens        *service.TokenService `optional:"true"`
 }
 
 type jwtPayload struct {
 	Allow []auth.Permission
+
+	// set on tokens minted by TokenCreate
+	ID      string `json:",omitempty"`
+	Expires int64  `json:",omitempty"` // unix seconds
 }
 
 func (a *CommonAPI) AuthVerify(ctx context.Context, token string) ([]auth.Permission, error) {
@@ -37,7 +44,19 @@ func (a *CommonAPI) AuthVerify(ctx context.Context, token string) ([]auth.Permis
 		return nil, xerrors.Errorf("JWT Verification failed: %w", err)
 	}
 
-	return payload.Allow, nil
+	if payload.Expires != 0 && time.Now().Unix() >= payload.Expires {
+		return nil, xerrors.Errorf("token expired")
+	}
+	if payload.ID != "" {
+		if a.Tokens == nil {
+			return nil, xerrors.Errorf("token %s can't be checked", payload.ID)
+		}
+		if err := a.Tokens.Check(payload.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return api.ExpandPerms(payload.Allow), nil
 }
 
 func (a *CommonAPI) AuthNew(ctx context.Context, perms []auth.Permission) ([]byte, error) {
@@ -0,0 +1,210 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/xerrors"
+
+	commpffi "github.com/filecoin-project/go-commp-utils/ffiwrapper"
+	"github.com/filecoin-project/go-state-types/abi"
+	market2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/market"
+
+	"github.com/filecoin-project/venus/app/submodule/apitypes"
+	"github.com/filecoin-project/venus/pkg/specactors/adt"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin/market"
+	"github.com/filecoin-project/venus/pkg/types"
+
+	"github.com/filecoin-project/venus-sealer/api"
+	"github.com/filecoin-project/venus-sealer/lib/blockstore"
+	"github.com/filecoin-project/venus-sealer/lib/bufbstore"
+	sealing "github.com/filecoin-project/venus-sealer/storage-sealing"
+	"github.com/filecoin-project/venus-sealer/storage-sealing/lib/nullreader"
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+// findPublishedDeal returns the deal id and on-chain deal of this miner whose proposal cid is propCid,
+// as published by the publish deals message publishCid
+func (sm *StorageMinerAPI) findPublishedDeal(ctx context.Context, propCid, publishCid cid.Cid) (abi.DealID, *apitypes.MarketDeal, error) {
+	pubmsg, err := sm.Full.ChainGetMessage(ctx, publishCid)
+	if err != nil {
+		return 0, nil, xerrors.Errorf("getting publish deals message %s: %w", publishCid, err)
+	}
+
+	var params market2.PublishStorageDealsParams
+	if err := params.UnmarshalCBOR(bytes.NewReader(pubmsg.Params)); err != nil {
+		return 0, nil, xerrors.Errorf("unmarshalling publish deals message %s params: %w", publishCid, err)
+	}
+
+	var proposal *market2.DealProposal
+	for i := range params.Deals {
+		nd, err := params.Deals[i].Proposal.Cid()
+		if err != nil {
+			return 0, nil, xerrors.Errorf("compute proposal cid of deal %d in %s: %w", i, publishCid, err)
+		}
+		if nd.Equals(propCid) {
+			proposal = &params.Deals[i].Proposal
+			break
+		}
+	}
+	if proposal == nil {
+		return 0, nil, xerrors.Errorf("proposal %s isn't published by message %s", propCid, publishCid)
+	}
+	if proposal.Provider != sm.Miner.Address() {
+		return 0, nil, xerrors.Errorf("deal %s is for miner %s, not %s", propCid, proposal.Provider, sm.Miner.Address())
+	}
+
+	ts, err := sm.Full.ChainHead(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// deal id from the publish message receipt, deal state by deal id
+	dealInfo := sealing.CurrentDealInfoManager{CDAPI: &sealing.CurrentDealInfoAPIAdapter{CurrentDealInfoTskAPI: sm.Full}}
+	mp := market.DealProposal(*proposal)
+	res, err := dealInfo.GetCurrentDealInfo(ctx, ts.Key().Bytes(), &mp, publishCid)
+	if err != nil {
+		return 0, nil, xerrors.Errorf("getting deal info of %s: %w", propCid, err)
+	}
+
+	return res.DealID, res.MarketDeal, nil
+}
+
+// findDeal returns the deal id and on-chain deal of this miner whose proposal cid is propCid from the market
+// actor state. A deal waiting for its data was published recently, so the deal ids are walked from the newest
+func (sm *StorageMinerAPI) findDeal(ctx context.Context, propCid cid.Cid) (abi.DealID, *apitypes.MarketDeal, error) {
+	act, err := sm.Full.StateGetActor(ctx, market.Address, types.EmptyTSK)
+	if err != nil {
+		return 0, nil, xerrors.Errorf("getting market actor: %w", err)
+	}
+
+	tbs := bufbstore.NewTieredBstore(api.NewAPIBlockstore(sm.Full), blockstore.NewTemporary())
+	state, err := market.Load(adt.WrapStore(ctx, cbor.NewCborStore(tbs)), act)
+	if err != nil {
+		return 0, nil, xerrors.Errorf("loading market actor state: %w", err)
+	}
+	proposals, err := state.Proposals()
+	if err != nil {
+		return 0, nil, xerrors.Errorf("loading deal proposals: %w", err)
+	}
+	next, err := state.NextID()
+	if err != nil {
+		return 0, nil, xerrors.Errorf("getting next deal id: %w", err)
+	}
+
+	for id := next; id > 0; {
+		id--
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+
+		proposal, found, err := proposals.Get(id)
+		if err != nil {
+			return 0, nil, xerrors.Errorf("getting proposal of deal %d: %w", id, err)
+		}
+		if !found || proposal.Provider != sm.Miner.Address() {
+			continue
+		}
+		dp := market2.DealProposal(*proposal)
+		nd, err := dp.Cid()
+		if err != nil {
+			return 0, nil, xerrors.Errorf("compute proposal cid of deal %d: %w", id, err)
+		}
+		if !nd.Equals(propCid) {
+			continue
+		}
+
+		deal, err := sm.Full.StateMarketStorageDeal(ctx, id, types.EmptyTSK)
+		if err != nil {
+			return 0, nil, xerrors.Errorf("getting deal %d: %w", id, err)
+		}
+		return id, deal, nil
+	}
+
+	return 0, nil, xerrors.Errorf("no published deal of miner %s with proposal %s", sm.Miner.Address(), propCid)
+}
+
+// padded returns the content of f zero padded to size
+func padded(f *os.File, fileSize int64, size abi.UnpaddedPieceSize) (io.Reader, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, xerrors.Errorf("seek deal data: %w", err)
+	}
+	return io.MultiReader(f, io.LimitReader(&nullreader.Reader{}, int64(size)-fileSize)), nil
+}
+
+// importDealData verifies the piece commitment of the data of an offline deal and adds it to a sector,
+// publishCid is the publish deals message of the deal if known
+func (sm *StorageMinerAPI) importDealData(ctx context.Context, propCid cid.Cid, publishCid *cid.Cid, fname string) error {
+	var dealID abi.DealID
+	var deal *apitypes.MarketDeal
+	var err error
+	if publishCid != nil {
+		dealID, deal, err = sm.findPublishedDeal(ctx, propCid, *publishCid)
+	} else {
+		dealID, deal, err = sm.findDeal(ctx, propCid)
+	}
+	if err != nil {
+		return err
+	}
+	if deal.State.SectorStartEpoch > 0 {
+		return xerrors.Errorf("deal %d is already active in a sector since epoch %d", dealID, deal.State.SectorStartEpoch)
+	}
+	proposal := market2.DealProposal(deal.Proposal)
+
+	fi, err := os.Open(fname)
+	if err != nil {
+		return xerrors.Errorf("failed to open given file: %w", err)
+	}
+	defer fi.Close() //nolint:errcheck
+
+	st, err := fi.Stat()
+	if err != nil {
+		return xerrors.Errorf("stat deal data: %w", err)
+	}
+
+	pieceSize := proposal.PieceSize.Unpadded()
+	if st.Size() > int64(pieceSize) {
+		return xerrors.Errorf("deal data is larger than the piece, %d > %d", st.Size(), pieceSize)
+	}
+
+	log.Infow("verifying piece commitment of offline deal", "deal", dealID, "proposal", propCid, "file", fname, "size", st.Size())
+
+	r, err := padded(fi, st.Size(), pieceSize)
+	if err != nil {
+		return err
+	}
+	pieceCid, err := commpffi.GeneratePieceCIDFromFile(sm.SealProofType, r, pieceSize)
+	if err != nil {
+		return xerrors.Errorf("compute piece commitment: %w", err)
+	}
+	if !pieceCid.Equals(proposal.PieceCID) {
+		return xerrors.Errorf("piece commitment mismatch, proposal %s, data %s", proposal.PieceCID, pieceCid)
+	}
+
+	log.Infow("adding offline deal data to sector", "deal", dealID, "piece", pieceCid)
+
+	r, err = padded(fi, st.Size(), pieceSize)
+	if err != nil {
+		return err
+	}
+	sn, offset, err := sm.SectorBlocks.AddOfflinePiece(ctx, pieceSize, r, types2.DealInfo{
+		DealID:       dealID,
+		PublishCid:   publishCid,
+		DealProposal: &proposal,
+		DealSchedule: types2.DealSchedule{
+			StartEpoch: proposal.StartEpoch,
+			EndEpoch:   proposal.EndEpoch,
+		},
+		KeepUnsealed: true,
+	})
+	if err != nil {
+		return xerrors.Errorf("add piece of deal %d: %w", dealID, err)
+	}
+
+	log.Infow("offline deal data imported", "deal", dealID, "sector", sn, "offset", offset)
+	return nil
+}
@@ -30,6 +30,8 @@ import (
 
 	"github.com/filecoin-project/venus-sealer/api"
 	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/proof_client"
 	sectorstorage "github.com/filecoin-project/venus-sealer/sector-storage"
 	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
@@ -58,15 +60,23 @@ type StorageMinerAPI struct {
 	AddrSel *storage.AddressSelector
 
 	LogService           *service.LogService
+	SectorEventService   *service.SectorEventService
+	MetadataService      *service.MetadataService
+	DealRefService       *service.DealRefService
+	Repo                 repo.Repo
+	Config               *config.StorageMiner
+	ConfigManager        *config.Manager
+	SealProofType        abi.RegisteredSealProof
+	ProofEvent           *proof_client.ProofEventManager
 	NetParams            *config.NetParamsConfig
 	SetSealingConfigFunc types2.SetSealingConfigFunc
 	GetSealingConfigFunc types2.GetSealingConfigFunc
 }
 
 func (sm *StorageMinerAPI) ServeRemote(w http.ResponseWriter, r *http.Request) {
-	if !auth.HasPerm(r.Context(), nil, api.PermAdmin) {
+	if !auth.HasPerm(r.Context(), nil, api.PermWorker) {
 		w.WriteHeader(401)
-		_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing write permission"})
+		_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing worker permission"})
 		return
 	}
 
@@ -455,13 +465,13 @@ func (sm *StorageMinerAPI) SectorGetSealDelay(ctx context.Context) (time.Duratio
 }
 
 func (sm *StorageMinerAPI) SectorSetExpectedSealDuration(ctx context.Context, delay time.Duration) error {
-	//return sm.SetExpectedSealDurationFunc(delay)
-	panic("not impl")
+	return sm.MetadataService.UpdateDealPolicy(func(policy *types2.DealPolicy) {
+		policy.ExpectedSealDuration = delay
+	})
 }
 
 func (sm *StorageMinerAPI) SectorGetExpectedSealDuration(ctx context.Context) (time.Duration, error) {
-	//return sm.GetExpectedSealDurationFunc()
-	panic("not impl")
+	return sm.Miner.ExpectedSealDuration()
 }
 
 func (sm *StorageMinerAPI) SectorsUpdate(ctx context.Context, id abi.SectorNumber, state api.SectorState) error {
@@ -476,6 +486,14 @@ func (sm *StorageMinerAPI) SectorTerminate(ctx context.Context, id abi.SectorNum
 	return sm.Miner.TerminateSector(ctx, id)
 }
 
+func (sm *StorageMinerAPI) SectorsExtend(ctx context.Context, req types2.ExtendRequest) (types2.ExtendPlan, error) {
+	return sm.Miner.ExtendSectors(ctx, req)
+}
+
+func (sm *StorageMinerAPI) SectorsExtendStatus(ctx context.Context, msgIDs []string) ([]types2.ExtendMessage, error) {
+	return sm.Miner.ExtendStatus(ctx, msgIDs)
+}
+
 func (sm *StorageMinerAPI) SectorTerminateFlush(ctx context.Context) (string, error) {
 	return sm.Miner.TerminateFlush(ctx)
 }
@@ -492,6 +510,41 @@ func (sm *StorageMinerAPI) SectorPreCommitPending(ctx context.Context) ([]abi.Se
 	return sm.Miner.SectorPreCommitPending(ctx)
 }
 
+func (sm *StorageMinerAPI) SectorAutoPledgeStatus(ctx context.Context) (types2.AutoPledgeStatus, error) {
+	return sm.Miner.AutoPledgeStatus()
+}
+
+func (sm *StorageMinerAPI) SectorsHistory(ctx context.Context, filter types2.SectorEventFilter) ([]types2.SectorEvent, error) {
+	events, err := sm.SectorEventService.Query(&filter)
+	if err != nil {
+		return nil, xerrors.Errorf("query sector events: %w", err)
+	}
+
+	out := make([]types2.SectorEvent, len(events))
+	for i, event := range events {
+		out[i] = *event
+	}
+	return out, nil
+}
+
+func (sm *StorageMinerAPI) SealingStats(ctx context.Context, since, until time.Time) (*types2.SealingStats, error) {
+	sectors, err := sm.Miner.ListSectors()
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[abi.SectorNumber]abi.SectorSize, len(sectors))
+	for _, sector := range sectors {
+		size, err := sector.SectorType.SectorSize()
+		if err != nil {
+			return nil, xerrors.Errorf("get size of sector %d: %w", sector.SectorNumber, err)
+		}
+		sizes[sector.SectorNumber] = size
+	}
+
+	return sm.SectorEventService.SealingStats(since, until, sizes)
+}
+
 func (sm *StorageMinerAPI) SectorMarkForUpgrade(ctx context.Context, id abi.SectorNumber) error {
 	return sm.Miner.MarkForUpgrade(id)
 }
@@ -524,14 +577,7 @@ func (sm *StorageMinerAPI) SealingAbort(ctx context.Context, call types2.CallID)
 }
 
 func (sm *StorageMinerAPI) MarketImportDealData(ctx context.Context, propCid cid.Cid, path string) error {
-	/*	fi, err := os.Open(path)
-		if err != nil {
-			return xerrors.Errorf("failed to open file: %w", err)
-		}
-		defer fi.Close() //nolint:errcheck
-
-		return sm.StorageProvider.ImportDataForDeal(ctx, propCid, fi)*/
-	panic("not impl")
+	return sm.importDealData(ctx, propCid, nil, path)
 }
 
 func (sm *StorageMinerAPI) listDeals(ctx context.Context) ([]apitypes.MarketDeal, error) {
@@ -566,94 +612,119 @@ func (sm *StorageMinerAPI) RetrievalDealsList(ctx context.Context) (map[retrieva
 }
 
 func (sm *StorageMinerAPI) DealsConsiderOnlineStorageDeals(ctx context.Context) (bool, error) {
-	//return sm.ConsiderOnlineStorageDealsConfigFunc()
-	panic("not impl")
+	policy, err := sm.MetadataService.GetDealPolicy()
+	if err != nil {
+		return false, err
+	}
+	return policy.ConsiderOnlineStorageDeals, nil
 }
 
 func (sm *StorageMinerAPI) DealsSetConsiderOnlineStorageDeals(ctx context.Context, b bool) error {
-	//return sm.SetConsiderOnlineStorageDealsConfigFunc(b)
-	panic("not impl")
+	return sm.MetadataService.UpdateDealPolicy(func(policy *types2.DealPolicy) {
+		policy.ConsiderOnlineStorageDeals = b
+	})
 }
 
 func (sm *StorageMinerAPI) DealsConsiderOnlineRetrievalDeals(ctx context.Context) (bool, error) {
-	//return sm.ConsiderOnlineRetrievalDealsConfigFunc()
-	panic("not impl")
+	policy, err := sm.MetadataService.GetDealPolicy()
+	if err != nil {
+		return false, err
+	}
+	return policy.ConsiderOnlineRetrievalDeals, nil
 }
 
 func (sm *StorageMinerAPI) DealsSetConsiderOnlineRetrievalDeals(ctx context.Context, b bool) error {
-	//return sm.SetConsiderOnlineRetrievalDealsConfigFunc(b)
-	panic("not impl")
+	return sm.MetadataService.UpdateDealPolicy(func(policy *types2.DealPolicy) {
+		policy.ConsiderOnlineRetrievalDeals = b
+	})
 }
 
 func (sm *StorageMinerAPI) DealsConsiderOfflineStorageDeals(ctx context.Context) (bool, error) {
-	//return sm.ConsiderOfflineStorageDealsConfigFunc()
-	panic("not impl")
+	policy, err := sm.MetadataService.GetDealPolicy()
+	if err != nil {
+		return false, err
+	}
+	return policy.ConsiderOfflineStorageDeals, nil
 }
 
 func (sm *StorageMinerAPI) DealsSetConsiderOfflineStorageDeals(ctx context.Context, b bool) error {
-	//return sm.SetConsiderOfflineStorageDealsConfigFunc(b)
-	panic("not impl")
+	return sm.MetadataService.UpdateDealPolicy(func(policy *types2.DealPolicy) {
+		policy.ConsiderOfflineStorageDeals = b
+	})
 }
 
 func (sm *StorageMinerAPI) DealsConsiderOfflineRetrievalDeals(ctx context.Context) (bool, error) {
-	//return sm.ConsiderOfflineRetrievalDealsConfigFunc()
-	panic("not impl")
+	policy, err := sm.MetadataService.GetDealPolicy()
+	if err != nil {
+		return false, err
+	}
+	return policy.ConsiderOfflineRetrievalDeals, nil
 }
 
 func (sm *StorageMinerAPI) DealsSetConsiderOfflineRetrievalDeals(ctx context.Context, b bool) error {
-	//return sm.SetConsiderOfflineRetrievalDealsConfigFunc(b)
-	panic("not impl")
+	return sm.MetadataService.UpdateDealPolicy(func(policy *types2.DealPolicy) {
+		policy.ConsiderOfflineRetrievalDeals = b
+	})
 }
 
 func (sm *StorageMinerAPI) DealsConsiderVerifiedStorageDeals(ctx context.Context) (bool, error) {
-	//return sm.ConsiderVerifiedStorageDealsConfigFunc()
-	panic("not impl")
+	policy, err := sm.MetadataService.GetDealPolicy()
+	if err != nil {
+		return false, err
+	}
+	return policy.ConsiderVerifiedStorageDeals, nil
 }
 
 func (sm *StorageMinerAPI) DealsSetConsiderVerifiedStorageDeals(ctx context.Context, b bool) error {
-	//return sm.SetConsiderVerifiedStorageDealsConfigFunc(b)
-	panic("not impl")
+	return sm.MetadataService.UpdateDealPolicy(func(policy *types2.DealPolicy) {
+		policy.ConsiderVerifiedStorageDeals = b
+	})
 }
 
 func (sm *StorageMinerAPI) DealsConsiderUnverifiedStorageDeals(ctx context.Context) (bool, error) {
-	//return sm.ConsiderUnverifiedStorageDealsConfigFunc()
-	panic("not impl")
+	policy, err := sm.MetadataService.GetDealPolicy()
+	if err != nil {
+		return false, err
+	}
+	return policy.ConsiderUnverifiedStorageDeals, nil
 }
 
 func (sm *StorageMinerAPI) DealsSetConsiderUnverifiedStorageDeals(ctx context.Context, b bool) error {
-	//return sm.SetConsiderUnverifiedStorageDealsConfigFunc(b)
-	panic("not impl")
+	return sm.MetadataService.UpdateDealPolicy(func(policy *types2.DealPolicy) {
+		policy.ConsiderUnverifiedStorageDeals = b
+	})
 }
 
 func (sm *StorageMinerAPI) DealsGetExpectedSealDurationFunc(ctx context.Context) (time.Duration, error) {
-	//return sm.GetExpectedSealDurationFunc()
-	panic("not impl")
+	return sm.Miner.ExpectedSealDuration()
 }
 
 func (sm *StorageMinerAPI) DealsSetExpectedSealDurationFunc(ctx context.Context, d time.Duration) error {
-	//return sm.SetExpectedSealDurationFunc(d)
-	panic("not impl")
+	return sm.MetadataService.UpdateDealPolicy(func(policy *types2.DealPolicy) {
+		policy.ExpectedSealDuration = d
+	})
 }
 
 func (sm *StorageMinerAPI) DealsImportData(ctx context.Context, deal cid.Cid, fname string) error {
-	/*fi, err := os.Open(fname)
-	if err != nil {
-		return xerrors.Errorf("failed to open given file: %w", err)
-	}
-	defer fi.Close() //nolint:errcheck
+	return sm.importDealData(ctx, deal, nil, fname)
+}
 
-	return sm.StorageProvider.ImportDataForDeal(ctx, deal, fi)*/
-	panic("not impl")
+func (sm *StorageMinerAPI) DealsImportPublishedData(ctx context.Context, deal, publishCid cid.Cid, fname string) error {
+	return sm.importDealData(ctx, deal, &publishCid, fname)
 }
 
 func (sm *StorageMinerAPI) DealsPieceCidBlocklist(ctx context.Context) ([]cid.Cid, error) {
-	//return sm.StorageDealPieceCidBlocklistConfigFunc()
-	panic("not impl")
+	policy, err := sm.MetadataService.GetDealPolicy()
+	if err != nil {
+		return nil, err
+	}
+	return policy.PieceCidBlocklist, nil
 }
 
 func (sm *StorageMinerAPI) DealsSetPieceCidBlocklist(ctx context.Context, cids []cid.Cid) error {
-	//return sm.SetStorageDealPieceCidBlocklistConfigFunc(cids)
-	panic("not impl")
+	return sm.MetadataService.UpdateDealPolicy(func(policy *types2.DealPolicy) {
+		policy.PieceCidBlocklist = cids
+	})
 }
 
 func (sm *StorageMinerAPI) StorageAddLocal(ctx context.Context, path string) error {
@@ -665,40 +736,26 @@ func (sm *StorageMinerAPI) StorageAddLocal(ctx context.Context, path string) err
 }
 
 func (sm *StorageMinerAPI) PiecesListPieces(ctx context.Context) ([]cid.Cid, error) {
-	//return sm.PieceStore.ListPieceInfoKeys()
-	panic("not impl")
+	return sm.DealRefService.ListPieceCids()
 }
 
 func (sm *StorageMinerAPI) PiecesListCidInfos(ctx context.Context) ([]cid.Cid, error) {
-	//return sm.PieceStore.ListCidInfoKeys()
-	panic("not impl")
+	return sm.DealRefService.ListPayloadCids()
 }
 
 func (sm *StorageMinerAPI) PiecesGetPieceInfo(ctx context.Context, pieceCid cid.Cid) (*piecestore.PieceInfo, error) {
-	/*pi, err := sm.PieceStore.GetPieceInfo(pieceCid)
-	if err != nil {
-		return nil, err
-	}
-	return &pi, nil*/
-	panic("not impl")
+	return sm.DealRefService.GetPieceInfo(pieceCid)
 }
 
 func (sm *StorageMinerAPI) PiecesGetCIDInfo(ctx context.Context, payloadCid cid.Cid) (*piecestore.CIDInfo, error) {
-	/*ci, err := sm.PieceStore.GetCIDInfo(payloadCid)
-	if err != nil {
-		return nil, err
-	}
-
-	return &ci, nil*/
-	panic("not impl")
+	return sm.DealRefService.GetCIDInfo(payloadCid)
 }
 
 func (sm *StorageMinerAPI) CreateBackup(ctx context.Context, fpath string) error {
-	//return backup(sm.DS, fpath)
-	panic("not impl")
+	return backup(sm.Repo, sm.Config, fpath)
 }
 
-func (sm *StorageMinerAPI) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []sto.SectorRef, expensive bool) (map[abi.SectorNumber]string, error) {
+func (sm *StorageMinerAPI) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []sto.SectorRef, expensive bool) ([]storiface.SectorCheck, error) {
 	var rg storiface.RGetter
 	if expensive {
 		rg = func(ctx context.Context, id abi.SectorID) (cid.Cid, error) {
@@ -714,21 +771,23 @@ func (sm *StorageMinerAPI) CheckProvable(ctx context.Context, pp abi.RegisteredP
 		}
 	}
 
-	bad, err := sm.StorageMgr.CheckProvable(ctx, pp, sectors, rg)
-	if err != nil {
-		return nil, err
-	}
+	return sm.StorageMgr.CheckProvable(ctx, pp, sectors, rg)
+}
 
-	var out = make(map[abi.SectorNumber]string)
-	for sid, err := range bad {
-		out[sid.Number] = err
-	}
+func (sm *StorageMinerAPI) ProofEventStatus(ctx context.Context) ([]types2.ProofGatewayStatus, error) {
+	return sm.ProofEvent.Status(), nil
+}
 
-	return out, nil
+func (sm *StorageMinerAPI) ConfigReload(ctx context.Context) ([]string, error) {
+	return sm.ConfigManager.Reload()
+}
+
+func (sm *StorageMinerAPI) ConfigSet(ctx context.Context, key, value string) ([]string, error) {
+	return sm.ConfigManager.Set(key, value)
 }
 
 func (sm *StorageMinerAPI) ActorAddressConfig(ctx context.Context) (api.AddressConfig, error) {
-	return sm.AddrSel.AddressConfig, nil
+	return sm.AddrSel.Config(), nil
 }
 
 func (sm *StorageMinerAPI) NetParamsConfig(ctx context.Context) (*config.NetParamsConfig, error) {
@@ -0,0 +1,78 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+	"github.com/gbrlsnchs/jwt/v3"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/api"
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+func (sm *StorageMinerAPI) TokenCreate(ctx context.Context, name string, perms []auth.Permission, methods []string, ttl time.Duration) (api.NewToken, error) {
+	if len(perms) == 0 && len(methods) == 0 {
+		return api.NewToken{}, xerrors.Errorf("token needs a permission or a method")
+	}
+	if ttl < 0 {
+		return api.NewToken{}, xerrors.Errorf("negative token ttl %s", ttl)
+	}
+
+	var allow []auth.Permission
+	var permNames []string
+	for _, perm := range perms {
+		if !api.ValidPerm(perm) {
+			return api.NewToken{}, xerrors.Errorf("unknown permission '%s'", perm)
+		}
+		allow = append(allow, perm)
+		permNames = append(permNames, string(perm))
+	}
+
+	known := map[string]struct{}{}
+	for _, method := range api.StorageMinerMethods() {
+		known[method] = struct{}{}
+	}
+	for _, method := range methods {
+		if _, ok := known[method]; !ok {
+			return api.NewToken{}, xerrors.Errorf("unknown method '%s'", method)
+		}
+		allow = append(allow, api.MethodPerm(method))
+	}
+
+	info, err := sm.Tokens.Create(name, permNames, methods, ttl)
+	if err != nil {
+		return api.NewToken{}, err
+	}
+
+	p := jwtPayload{
+		Allow: allow,
+		ID:    info.ID,
+	}
+	if !info.Expires.IsZero() {
+		p.Expires = info.Expires.Unix()
+	}
+	token, err := jwt.Sign(&p, (*jwt.HMACSHA)(sm.APISecret))
+	if err != nil {
+		return api.NewToken{}, xerrors.Errorf("signing token: %w", err)
+	}
+
+	return api.NewToken{TokenInfo: *info, Token: string(token)}, nil
+}
+
+func (sm *StorageMinerAPI) TokenList(ctx context.Context) ([]types2.TokenInfo, error) {
+	tokens, err := sm.Tokens.List()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]types2.TokenInfo, len(tokens))
+	for i, token := range tokens {
+		out[i] = *token
+	}
+	return out, nil
+}
+
+func (sm *StorageMinerAPI) TokenRevoke(ctx context.Context, id string) error {
+	return sm.Tokens.Revoke(id)
+}
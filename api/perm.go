@@ -5,11 +5,70 @@ import "github.com/filecoin-project/go-jsonrpc/auth"
 const (
 	// When changing these, update docs/API.md too
 
-	PermRead  auth.Permission = "read" // default
-	PermWrite auth.Permission = "write"
-	PermSign  auth.Permission = "sign"  // Use wallet keys for signing
-	PermAdmin auth.Permission = "admin" // Manage permissions
+	PermRead   auth.Permission = "read" // default
+	PermWrite  auth.Permission = "write"
+	PermSign   auth.Permission = "sign"   // Use wallet keys for signing
+	PermAdmin  auth.Permission = "admin"  // Manage permissions
+	PermWorker auth.Permission = "worker" // Connect workers, return their results and update the sector index
 )
 
-var AllPermissions = []auth.Permission{PermRead, PermWrite, PermSign, PermAdmin}
+// MethodPermPrefix prefixes the permissions of tokens scoped to named methods, see MethodPerm
+const MethodPermPrefix = "method:"
+
+var AllPermissions = []auth.Permission{PermRead, PermWrite, PermSign, PermAdmin, PermWorker}
 var DefaultPerms = []auth.Permission{PermRead}
+
+// WorkerReadMethods are the read methods a worker calls when it starts, a worker token is scoped to them
+// instead of implying PermRead
+var WorkerReadMethods = []string{"AuthVerify", "Version", "Session", "ActorAddress", "ActorSectorSize"}
+
+// permissions granted along with a permission
+var impliedPerms = map[auth.Permission][]auth.Permission{
+	PermWrite:  {PermRead},
+	PermSign:   {PermRead, PermWrite},
+	PermAdmin:  AllPermissions,
+	PermWorker: MethodPerms(WorkerReadMethods),
+}
+
+// MethodPerm is the permission of a token scoped to method
+func MethodPerm(method string) auth.Permission {
+	return auth.Permission(MethodPermPrefix + method)
+}
+
+// MethodPerms returns the MethodPerm of every method
+func MethodPerms(methods []string) []auth.Permission {
+	out := make([]auth.Permission, len(methods))
+	for i, method := range methods {
+		out[i] = MethodPerm(method)
+	}
+	return out
+}
+
+// ExpandPerms adds the permissions implied by perms, eg. admin grants every permission
+func ExpandPerms(perms []auth.Permission) []auth.Permission {
+	seen := map[auth.Permission]struct{}{}
+	var out []auth.Permission
+	add := func(p auth.Permission) {
+		if _, ok := seen[p]; !ok {
+			seen[p] = struct{}{}
+			out = append(out, p)
+		}
+	}
+	for _, p := range perms {
+		add(p)
+		for _, implied := range impliedPerms[p] {
+			add(implied)
+		}
+	}
+	return out
+}
+
+// ValidPerm reports whether perm is one of AllPermissions
+func ValidPerm(perm auth.Permission) bool {
+	for _, p := range AllPermissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
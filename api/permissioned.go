@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+	"golang.org/x/xerrors"
+)
+
+// PermissionedStorMinerAPI checks every call against the perm tag of the method, tokens scoped to named
+// methods may call those methods whatever their tag
+func PermissionedStorMinerAPI(a StorageMiner) StorageMiner {
+	var out StorageMinerStruct
+	permissionedProxy(a, &out.Internal)
+	permissionedProxy(a, &out.CommonStruct.Internal)
+	return &out
+}
+
+// StorageMinerMethods returns the names of the methods of the StorageMiner api
+func StorageMinerMethods() []string {
+	var out []string
+	for _, internal := range []interface{}{StorageMinerStruct{}.Internal, CommonStruct{}.Internal} {
+		rt := reflect.TypeOf(internal)
+		for i := 0; i < rt.NumField(); i++ {
+			if _, ok := reflect.TypeOf((*StorageMiner)(nil)).Elem().MethodByName(rt.Field(i).Name); ok {
+				out = append(out, rt.Field(i).Name)
+			}
+		}
+	}
+	return out
+}
+
+func permissionedProxy(in interface{}, out interface{}) {
+	rint := reflect.ValueOf(out).Elem()
+	ra := reflect.ValueOf(in)
+
+	for f := 0; f < rint.NumField(); f++ {
+		field := rint.Type().Field(f)
+		fn := ra.MethodByName(field.Name)
+		if !fn.IsValid() {
+			// CommonStruct has methods of the full node api
+			continue
+		}
+
+		requiredPerm := auth.Permission(field.Tag.Get("perm"))
+		if requiredPerm == "" {
+			panic("missing 'perm' tag on " + field.Name)
+		}
+		methodPerm := MethodPerm(field.Name)
+		name := field.Name
+		ftype := field.Type
+
+		rint.Field(f).Set(reflect.MakeFunc(ftype, func(args []reflect.Value) (results []reflect.Value) {
+			ctx := args[0].Interface().(context.Context)
+			if auth.HasPerm(ctx, DefaultPerms, requiredPerm) || auth.HasPerm(ctx, nil, methodPerm) {
+				return fn.Call(args)
+			}
+
+			err := xerrors.Errorf("missing permission to invoke '%s' (need '%s')", name, requiredPerm)
+			rerr := reflect.ValueOf(&err).Elem()
+			if ftype.NumOut() == 2 {
+				return []reflect.Value{reflect.Zero(ftype.Out(0)), rerr}
+			}
+			return []reflect.Value{rerr}
+		}))
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+	"github.com/stretchr/testify/require"
+)
+
+type permTestImpl struct{}
+
+func (permTestImpl) Read(ctx context.Context) (int, error) { return 1, nil }
+func (permTestImpl) Version(ctx context.Context) error     { return nil }
+func (permTestImpl) Return(ctx context.Context) error      { return nil }
+func (permTestImpl) Remove(ctx context.Context) error      { return nil }
+
+type permTestStruct struct {
+	Internal struct {
+		Read    func(ctx context.Context) (int, error) `perm:"read"`
+		Version func(ctx context.Context) error        `perm:"read"`
+		Return  func(ctx context.Context) error        `perm:"worker"`
+		Remove  func(ctx context.Context) error        `perm:"admin"`
+	}
+}
+
+func TestPermissionedProxy(t *testing.T) {
+	var out permTestStruct
+	permissionedProxy(permTestImpl{}, &out.Internal)
+
+	// workers only get the read methods they need
+	worker := auth.WithPerm(context.Background(), ExpandPerms([]auth.Permission{PermWorker}))
+	_, err := out.Internal.Read(worker)
+	require.Error(t, err)
+	require.NoError(t, out.Internal.Version(worker))
+	require.NoError(t, out.Internal.Return(worker))
+	require.Error(t, out.Internal.Remove(worker))
+
+	admin := auth.WithPerm(context.Background(), ExpandPerms([]auth.Permission{PermAdmin}))
+	require.NoError(t, out.Internal.Return(admin))
+	require.NoError(t, out.Internal.Remove(admin))
+	v, err := out.Internal.Read(admin)
+	require.NoError(t, err)
+	require.Equal(t, 1, v)
+
+	scoped := auth.WithPerm(context.Background(), []auth.Permission{MethodPerm("Remove")})
+	require.NoError(t, out.Internal.Remove(scoped))
+	_, err = out.Internal.Read(scoped)
+	require.Error(t, err)
+
+	// unauthenticated calls get the default permissions
+	_, err = out.Internal.Read(context.Background())
+	require.NoError(t, err)
+	require.Error(t, out.Internal.Return(context.Background()))
+}
+
+func TestStorageMinerMethods(t *testing.T) {
+	methods := StorageMinerMethods()
+	require.Contains(t, methods, "WorkerConnect")
+	require.Contains(t, methods, "Version")
+	require.NotContains(t, methods, "NetPeers")
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-fil-markets/piecestore"
+	"github.com/filecoin-project/go-jsonrpc/auth"
 	"github.com/filecoin-project/go-state-types/abi"
 	proof2 "github.com/filecoin-project/specs-actors/v2/actors/runtime/proof"
 	"github.com/filecoin-project/specs-storage/storage"
@@ -70,9 +71,9 @@ type StorageMiner interface {
 	// SectorGetSealDelay gets the time that a newly-created sector
 	// waits for more deals before it starts sealing
 	SectorGetSealDelay(context.Context) (time.Duration, error)
-	// SectorSetExpectedSealDuration sets the expected time for a sector to seal
+	// SectorSetExpectedSealDuration sets the expected time for a sector to seal, 0 measures it from the sector logs
 	SectorSetExpectedSealDuration(context.Context, time.Duration) error
-	// SectorGetExpectedSealDuration gets the expected time for a sector to seal
+	// SectorGetExpectedSealDuration gets the expected time for a sector to seal, deals starting earlier are rejected
 	SectorGetExpectedSealDuration(context.Context) (time.Duration, error)
 	SectorsUpdate(context.Context, abi.SectorNumber, SectorState) error
 	// SectorRemove removes the sector from storage. It doesn't terminate it on-chain, which can
@@ -81,6 +82,11 @@ type StorageMiner interface {
 	// SectorTerminate terminates the sector on-chain (adding it to a termination batch first), then
 	// automatically removes it from storage
 	SectorTerminate(context.Context, abi.SectorNumber) error
+	// SectorsExtend plans the ExtendSectorExpiration messages extending the requested sectors and sends them
+	// unless req.DryRun is set
+	SectorsExtend(ctx context.Context, req types.ExtendRequest) (types.ExtendPlan, error) //perm:admin
+	// SectorsExtendStatus returns the state of the given extend messages, or of the ones sent since start
+	SectorsExtendStatus(ctx context.Context, msgIDs []string) ([]types.ExtendMessage, error) //perm:read
 	// SectorTerminateFlush immediately sends a terminate message with sectors batched for termination.
 	// Returns null if message wasn't sent
 	SectorTerminateFlush(ctx context.Context) (string, error)
@@ -97,10 +103,20 @@ type StorageMiner interface {
 	SectorCommitFlush(ctx context.Context) ([]sealiface.CommitBatchRes, error) //perm:admin
 	// SectorCommitPending returns a list of pending Commit sectors to be sent in the next aggregate message
 	SectorCommitPending(ctx context.Context) ([]abi.SectorID, error) //perm:admin
+	// SectorAutoPledgeStatus returns the state of the automatic CC pledging which keeps the pipeline at TargetSealingSectors
+	SectorAutoPledgeStatus(ctx context.Context) (types.AutoPledgeStatus, error) //perm:read
+	// SectorsHistory returns the recorded state transitions matching the filter, oldest first
+	SectorsHistory(ctx context.Context, filter types.SectorEventFilter) ([]types.SectorEvent, error) //perm:read
+	// SealingStats returns the stage durations, throughput and failure rates of the sectors sealed in [since, until),
+	// zero times are unbounded
+	SealingStats(ctx context.Context, since, until time.Time) (*types.SealingStats, error) //perm:read
 
 	StorageList(ctx context.Context) (map[stores.ID][]stores.Decl, error)
 	StorageLocal(ctx context.Context) (map[stores.ID]string, error)
 	StorageStat(ctx context.Context, id stores.ID) (fsutil.FsStat, error)
+	// StorageHealth returns the heartbeat state of the storages in the index, Restored storages were
+	// loaded from the database and didn't come back since the sealer started
+	StorageHealth(ctx context.Context) (map[stores.ID]stores.StorageHealth, error) //perm:admin
 
 	// WorkerConnect tells the node to connect to workers RPC
 	WorkerConnect(context.Context, string) error
@@ -115,6 +131,8 @@ type StorageMiner interface {
 	stores.SectorIndex
 
 	DealsImportData(ctx context.Context, dealPropCid cid.Cid, file string) error
+	// DealsImportPublishedData imports the data of the deal dealPropCid published by the message publishCid
+	DealsImportPublishedData(ctx context.Context, dealPropCid, publishCid cid.Cid, file string) error
 	DealsList(ctx context.Context) ([]apitypes.MarketDeal, error)
 	DealsConsiderOnlineStorageDeals(context.Context) (bool, error)
 	DealsSetConsiderOnlineStorageDeals(context.Context, bool) error
@@ -130,6 +148,8 @@ type StorageMiner interface {
 	DealsSetConsiderVerifiedStorageDeals(context.Context, bool) error
 	DealsConsiderUnverifiedStorageDeals(context.Context) (bool, error)
 	DealsSetConsiderUnverifiedStorageDeals(context.Context, bool) error
+	DealsGetExpectedSealDurationFunc(context.Context) (time.Duration, error)
+	DealsSetExpectedSealDurationFunc(context.Context, time.Duration) error
 
 	StorageAddLocal(ctx context.Context, path string) error
 
@@ -140,11 +160,28 @@ type StorageMiner interface {
 
 	// CreateBackup creates node backup onder the specified file name. The
 	// method requires that the venus-sealer is running with the
-	// LOTUS_BACKUP_BASE_PATH environment variable set to some path, and that
-	// the path specified when calling CreateBackup is within the base path
+	// VENUS_SEALER_BACKUP_BASE_PATH environment variable set to some path, and that
+	// the path specified when calling CreateBackup is within the base path.
+	// The backup holds the sealer database, config and token, restore it with `venus-sealer init restore`
 	CreateBackup(ctx context.Context, fpath string) error
 
-	CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, expensive bool) (map[abi.SectorNumber]string, error)
+	// CheckProvable checks the files of sectors, expensive checks also generate a vanilla proof of each sector
+	CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, expensive bool) ([]storiface.SectorCheck, error)
+
+	// ProofEventStatus returns the connection state and winning PoSt counters of every gateway
+	ProofEventStatus(ctx context.Context) ([]types.ProofGatewayStatus, error)
+
+	// ConfigReload re-reads the config file and applies the reloadable sections, it returns the changed keys
+	ConfigReload(ctx context.Context) ([]string, error)
+	// ConfigSet sets one config key, writes the config file and applies it, it returns the changed keys
+	ConfigSet(ctx context.Context, key, value string) ([]string, error)
+
+	// TokenCreate mints a token allowed to call the methods of perms and the named methods, a zero ttl never expires
+	TokenCreate(ctx context.Context, name string, perms []auth.Permission, methods []string, ttl time.Duration) (NewToken, error)
+	// TokenList lists the tokens minted by TokenCreate
+	TokenList(ctx context.Context) ([]types.TokenInfo, error)
+	// TokenRevoke revokes a token minted by TokenCreate
+	TokenRevoke(ctx context.Context, id string) error
 
 	//messager
 	MessagerWaitMessage(ctx context.Context, uuid string, confidence uint64) (*chain.MsgLookup, error)
@@ -179,6 +216,8 @@ type StorageMinerStruct struct {
 		SectorsUpdate                 func(context.Context, abi.SectorNumber, SectorState) error                                       `perm:"admin"`
 		SectorRemove                  func(context.Context, abi.SectorNumber) error                                                    `perm:"admin"`
 		SectorTerminate               func(context.Context, abi.SectorNumber) error                                                    `perm:"admin"`
+		SectorsExtend                 func(ctx context.Context, req types.ExtendRequest) (types.ExtendPlan, error)                     `perm:"admin"`
+		SectorsExtendStatus           func(ctx context.Context, msgIDs []string) ([]types.ExtendMessage, error)                        `perm:"read"`
 		SectorTerminateFlush          func(ctx context.Context) (string, error)                                                        `perm:"admin"`
 		SectorTerminatePending        func(ctx context.Context) ([]abi.SectorID, error)                                                `perm:"admin"`
 		SectorMarkForUpgrade          func(ctx context.Context, id abi.SectorNumber) error                                             `perm:"admin"`
@@ -186,38 +225,47 @@ type StorageMinerStruct struct {
 		SectorPreCommitPending        func(ctx context.Context) ([]abi.SectorID, error)                                                `perm:"admin"`
 		SectorCommitFlush             func(ctx context.Context) ([]sealiface.CommitBatchRes, error)                                    `perm:"admin"`
 		SectorCommitPending           func(ctx context.Context) ([]abi.SectorID, error)                                                `perm:"admin"`
+		SectorAutoPledgeStatus        func(ctx context.Context) (types.AutoPledgeStatus, error)                                        `perm:"read"`
+		SectorsHistory                func(ctx context.Context, filter types.SectorEventFilter) ([]types.SectorEvent, error)           `perm:"read"`
+		SealingStats                  func(ctx context.Context, since, until time.Time) (*types.SealingStats, error)                   `perm:"read"`
 
-		WorkerConnect func(context.Context, string) error                                `perm:"admin" retry:"true"` // TODO: worker perm
+		WorkerConnect func(context.Context, string) error                                `perm:"worker" retry:"true"`
 		WorkerStats   func(context.Context) (map[uuid.UUID]storiface.WorkerStats, error) `perm:"admin"`
 		WorkerJobs    func(context.Context) (map[uuid.UUID][]storiface.WorkerJob, error) `perm:"admin"`
 
-		ReturnAddPiece        func(ctx context.Context, callID types.CallID, pi abi.PieceInfo, err *storiface.CallError) error          `perm:"admin" retry:"true"`
-		ReturnSealPreCommit1  func(ctx context.Context, callID types.CallID, p1o storage.PreCommit1Out, err *storiface.CallError) error `perm:"admin" retry:"true"`
-		ReturnSealPreCommit2  func(ctx context.Context, callID types.CallID, sealed storage.SectorCids, err *storiface.CallError) error `perm:"admin" retry:"true"`
-		ReturnSealCommit1     func(ctx context.Context, callID types.CallID, out storage.Commit1Out, err *storiface.CallError) error    `perm:"admin" retry:"true"`
-		ReturnSealCommit2     func(ctx context.Context, callID types.CallID, proof storage.Proof, err *storiface.CallError) error       `perm:"admin" retry:"true"`
-		ReturnFinalizeSector  func(ctx context.Context, callID types.CallID, err *storiface.CallError) error                            `perm:"admin" retry:"true"`
-		ReturnReleaseUnsealed func(ctx context.Context, callID types.CallID, err *storiface.CallError) error                            `perm:"admin" retry:"true"`
-		ReturnMoveStorage     func(ctx context.Context, callID types.CallID, err *storiface.CallError) error                            `perm:"admin" retry:"true"`
-		ReturnUnsealPiece     func(ctx context.Context, callID types.CallID, err *storiface.CallError) error                            `perm:"admin" retry:"true"`
-		ReturnReadPiece       func(ctx context.Context, callID types.CallID, ok bool, err *storiface.CallError) error                   `perm:"admin" retry:"true"`
-		ReturnFetch           func(ctx context.Context, callID types.CallID, err *storiface.CallError) error                            `perm:"admin" retry:"true"`
+		ReturnAddPiece        func(ctx context.Context, callID types.CallID, pi abi.PieceInfo, err *storiface.CallError) error          `perm:"worker" retry:"true"`
+		ReturnSealPreCommit1  func(ctx context.Context, callID types.CallID, p1o storage.PreCommit1Out, err *storiface.CallError) error `perm:"worker" retry:"true"`
+		ReturnSealPreCommit2  func(ctx context.Context, callID types.CallID, sealed storage.SectorCids, err *storiface.CallError) error `perm:"worker" retry:"true"`
+		ReturnSealCommit1     func(ctx context.Context, callID types.CallID, out storage.Commit1Out, err *storiface.CallError) error    `perm:"worker" retry:"true"`
+		ReturnSealCommit2     func(ctx context.Context, callID types.CallID, proof storage.Proof, err *storiface.CallError) error       `perm:"worker" retry:"true"`
+		ReturnFinalizeSector  func(ctx context.Context, callID types.CallID, err *storiface.CallError) error                            `perm:"worker" retry:"true"`
+		ReturnReleaseUnsealed func(ctx context.Context, callID types.CallID, err *storiface.CallError) error                            `perm:"worker" retry:"true"`
+		ReturnMoveStorage     func(ctx context.Context, callID types.CallID, err *storiface.CallError) error                            `perm:"worker" retry:"true"`
+		ReturnUnsealPiece     func(ctx context.Context, callID types.CallID, err *storiface.CallError) error                            `perm:"worker" retry:"true"`
+		ReturnReadPiece       func(ctx context.Context, callID types.CallID, ok bool, err *storiface.CallError) error                   `perm:"worker" retry:"true"`
+		ReturnFetch           func(ctx context.Context, callID types.CallID, err *storiface.CallError) error                            `perm:"worker" retry:"true"`
+
+		ReturnGenerateWindowPoSt        func(ctx context.Context, callID types.CallID, res storiface.WindowPoStResult, err *storiface.CallError) error        `perm:"worker" retry:"true"`
+		ReturnGenerateWindowPoStVanilla func(ctx context.Context, callID types.CallID, res storiface.WindowPoStVanillaResult, err *storiface.CallError) error `perm:"worker" retry:"true"`
+		ReturnGenerateWinningPoSt       func(ctx context.Context, callID types.CallID, proofs []proof2.PoStProof, err *storiface.CallError) error             `perm:"worker" retry:"true"`
 
 		SealingSchedDiag func(context.Context, bool) (interface{}, error)   `perm:"admin"`
 		SealingAbort     func(ctx context.Context, call types.CallID) error `perm:"admin"`
 
-		StorageList          func(context.Context) (map[stores.ID][]stores.Decl, error)                                                                                   `perm:"admin"`
-		StorageLocal         func(context.Context) (map[stores.ID]string, error)                                                                                          `perm:"admin"`
-		StorageStat          func(context.Context, stores.ID) (fsutil.FsStat, error)                                                                                      `perm:"admin"`
-		StorageAttach        func(context.Context, stores.StorageInfo, fsutil.FsStat) error                                                                               `perm:"admin"`
-		StorageDeclareSector func(context.Context, stores.ID, abi.SectorID, storiface.SectorFileType, bool) error                                                         `perm:"admin"`
-		StorageDropSector    func(context.Context, stores.ID, abi.SectorID, storiface.SectorFileType) error                                                               `perm:"admin"`
-		StorageFindSector    func(context.Context, abi.SectorID, storiface.SectorFileType, abi.SectorSize, bool) ([]stores.SectorStorageInfo, error)                      `perm:"admin"`
-		StorageInfo          func(context.Context, stores.ID) (stores.StorageInfo, error)                                                                                 `perm:"admin"`
-		StorageBestAlloc     func(ctx context.Context, allocate storiface.SectorFileType, ssize abi.SectorSize, sealing storiface.PathType) ([]stores.StorageInfo, error) `perm:"admin"`
-		StorageReportHealth  func(ctx context.Context, id stores.ID, report stores.HealthReport) error                                                                    `perm:"admin"`
-		StorageLock          func(ctx context.Context, sector abi.SectorID, read storiface.SectorFileType, write storiface.SectorFileType) error                          `perm:"admin"`
-		StorageTryLock       func(ctx context.Context, sector abi.SectorID, read storiface.SectorFileType, write storiface.SectorFileType) (bool, error)                  `perm:"admin"`
+		StorageList          func(context.Context) (map[stores.ID][]stores.Decl, error)                                                                                                        `perm:"admin"`
+		StorageLocal         func(context.Context) (map[stores.ID]string, error)                                                                                                               `perm:"admin"`
+		StorageStat          func(context.Context, stores.ID) (fsutil.FsStat, error)                                                                                                           `perm:"admin"`
+		StorageHealth        func(context.Context) (map[stores.ID]stores.StorageHealth, error)                                                                                                 `perm:"admin"`
+		StorageAttach        func(context.Context, stores.StorageInfo, fsutil.FsStat) error                                                                                                    `perm:"worker"`
+		StorageDeclareSector func(context.Context, stores.ID, abi.SectorID, storiface.SectorFileType, bool) error                                                                              `perm:"worker"`
+		StorageDropSector    func(context.Context, stores.ID, abi.SectorID, storiface.SectorFileType) error                                                                                    `perm:"worker"`
+		StorageFindSector    func(context.Context, abi.SectorID, storiface.SectorFileType, abi.SectorSize, bool) ([]stores.SectorStorageInfo, error)                                           `perm:"worker"`
+		StorageInfo          func(context.Context, stores.ID) (stores.StorageInfo, error)                                                                                                      `perm:"worker"`
+		StorageBestAlloc     func(ctx context.Context, allocate storiface.SectorFileType, ssize abi.SectorSize, sealing storiface.PathType, sector abi.SectorID) ([]stores.StorageInfo, error) `perm:"worker"`
+		StorageSetSectorKind func(ctx context.Context, sector abi.SectorID, kind storiface.SectorKind) error                                                                                   `perm:"admin"`
+		StorageReportHealth  func(ctx context.Context, id stores.ID, report stores.HealthReport) error                                                                                         `perm:"worker"`
+		StorageLock          func(ctx context.Context, sector abi.SectorID, read storiface.SectorFileType, write storiface.SectorFileType) error                                               `perm:"worker"`
+		StorageTryLock       func(ctx context.Context, sector abi.SectorID, read storiface.SectorFileType, write storiface.SectorFileType) (bool, error)                                       `perm:"worker"`
 
 		DealsImportData                        func(ctx context.Context, dealPropCid cid.Cid, file string) error `perm:"write"`
 		DealsList                              func(ctx context.Context) ([]apitypes.MarketDeal, error)          `perm:"read"`
@@ -235,6 +283,10 @@ type StorageMinerStruct struct {
 		DealsSetConsiderUnverifiedStorageDeals func(context.Context, bool) error                                 `perm:"admin"`
 		DealsPieceCidBlocklist                 func(context.Context) ([]cid.Cid, error)                          `perm:"read"`
 		DealsSetPieceCidBlocklist              func(context.Context, []cid.Cid) error                            `perm:"admin"`
+		DealsGetExpectedSealDurationFunc       func(context.Context) (time.Duration, error)                      `perm:"read"`
+		DealsSetExpectedSealDurationFunc       func(context.Context, time.Duration) error                        `perm:"admin"`
+
+		DealsImportPublishedData func(ctx context.Context, dealPropCid, publishCid cid.Cid, file string) error `perm:"write"`
 
 		StorageAddLocal func(ctx context.Context, path string) error `perm:"admin"`
 
@@ -245,11 +297,20 @@ type StorageMinerStruct struct {
 
 		CreateBackup func(ctx context.Context, fpath string) error `perm:"admin"`
 
-		CheckProvable func(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, expensive bool) (map[abi.SectorNumber]string, error) `perm:"admin"`
+		CheckProvable func(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, expensive bool) ([]storiface.SectorCheck, error) `perm:"admin"`
+
+		ProofEventStatus func(ctx context.Context) ([]types.ProofGatewayStatus, error) `perm:"read"`
+
+		ConfigReload func(ctx context.Context) ([]string, error)                    `perm:"admin"`
+		ConfigSet    func(ctx context.Context, key, value string) ([]string, error) `perm:"admin"`
 
-		MessagerWaitMessage func(ctx context.Context, uuid string, confidence uint64) (*chain.MsgLookup, error)
-		MessagerPushMessage func(ctx context.Context, msg *types2.Message, meta *types3.MsgMeta) (string, error)
-		MessagerGetMessage  func(ctx context.Context, uuid string) (*types3.Message, error)
+		TokenCreate func(ctx context.Context, name string, perms []auth.Permission, methods []string, ttl time.Duration) (NewToken, error) `perm:"admin"`
+		TokenList   func(ctx context.Context) ([]types.TokenInfo, error)                                                                   `perm:"admin"`
+		TokenRevoke func(ctx context.Context, id string) error                                                                             `perm:"admin"`
+
+		MessagerWaitMessage func(ctx context.Context, uuid string, confidence uint64) (*chain.MsgLookup, error)  `perm:"read"`
+		MessagerPushMessage func(ctx context.Context, msg *types2.Message, meta *types3.MsgMeta) (string, error) `perm:"sign"`
+		MessagerGetMessage  func(ctx context.Context, uuid string) (*types3.Message, error)                      `perm:"read"`
 	}
 }
 
@@ -332,6 +393,14 @@ func (c *StorageMinerStruct) SectorTerminate(ctx context.Context, number abi.Sec
 	return c.Internal.SectorTerminate(ctx, number)
 }
 
+func (c *StorageMinerStruct) SectorsExtend(ctx context.Context, req types.ExtendRequest) (types.ExtendPlan, error) {
+	return c.Internal.SectorsExtend(ctx, req)
+}
+
+func (c *StorageMinerStruct) SectorsExtendStatus(ctx context.Context, msgIDs []string) ([]types.ExtendMessage, error) {
+	return c.Internal.SectorsExtendStatus(ctx, msgIDs)
+}
+
 func (c *StorageMinerStruct) SectorTerminateFlush(ctx context.Context) (string, error) {
 	return c.Internal.SectorTerminateFlush(ctx)
 }
@@ -360,6 +429,18 @@ func (c *StorageMinerStruct) SectorCommitPending(ctx context.Context) ([]abi.Sec
 	return c.Internal.SectorCommitPending(ctx)
 }
 
+func (c *StorageMinerStruct) SectorAutoPledgeStatus(ctx context.Context) (types.AutoPledgeStatus, error) {
+	return c.Internal.SectorAutoPledgeStatus(ctx)
+}
+
+func (c *StorageMinerStruct) SectorsHistory(ctx context.Context, filter types.SectorEventFilter) ([]types.SectorEvent, error) {
+	return c.Internal.SectorsHistory(ctx, filter)
+}
+
+func (c *StorageMinerStruct) SealingStats(ctx context.Context, since, until time.Time) (*types.SealingStats, error) {
+	return c.Internal.SealingStats(ctx, since, until)
+}
+
 func (c *StorageMinerStruct) WorkerConnect(ctx context.Context, url string) error {
 	return c.Internal.WorkerConnect(ctx, url)
 }
@@ -416,6 +497,18 @@ func (c *StorageMinerStruct) ReturnFetch(ctx context.Context, callID types.CallI
 	return c.Internal.ReturnFetch(ctx, callID, err)
 }
 
+func (c *StorageMinerStruct) ReturnGenerateWindowPoSt(ctx context.Context, callID types.CallID, res storiface.WindowPoStResult, err *storiface.CallError) error {
+	return c.Internal.ReturnGenerateWindowPoSt(ctx, callID, res, err)
+}
+
+func (c *StorageMinerStruct) ReturnGenerateWindowPoStVanilla(ctx context.Context, callID types.CallID, res storiface.WindowPoStVanillaResult, err *storiface.CallError) error {
+	return c.Internal.ReturnGenerateWindowPoStVanilla(ctx, callID, res, err)
+}
+
+func (c *StorageMinerStruct) ReturnGenerateWinningPoSt(ctx context.Context, callID types.CallID, proofs []proof2.PoStProof, err *storiface.CallError) error {
+	return c.Internal.ReturnGenerateWinningPoSt(ctx, callID, proofs, err)
+}
+
 func (c *StorageMinerStruct) SealingSchedDiag(ctx context.Context, doSched bool) (interface{}, error) {
 	return c.Internal.SealingSchedDiag(ctx, doSched)
 }
@@ -452,12 +545,20 @@ func (c *StorageMinerStruct) StorageStat(ctx context.Context, id stores.ID) (fsu
 	return c.Internal.StorageStat(ctx, id)
 }
 
+func (c *StorageMinerStruct) StorageHealth(ctx context.Context) (map[stores.ID]stores.StorageHealth, error) {
+	return c.Internal.StorageHealth(ctx)
+}
+
 func (c *StorageMinerStruct) StorageInfo(ctx context.Context, id stores.ID) (stores.StorageInfo, error) {
 	return c.Internal.StorageInfo(ctx, id)
 }
 
-func (c *StorageMinerStruct) StorageBestAlloc(ctx context.Context, allocate storiface.SectorFileType, ssize abi.SectorSize, pt storiface.PathType) ([]stores.StorageInfo, error) {
-	return c.Internal.StorageBestAlloc(ctx, allocate, ssize, pt)
+func (c *StorageMinerStruct) StorageBestAlloc(ctx context.Context, allocate storiface.SectorFileType, ssize abi.SectorSize, pt storiface.PathType, sector abi.SectorID) ([]stores.StorageInfo, error) {
+	return c.Internal.StorageBestAlloc(ctx, allocate, ssize, pt, sector)
+}
+
+func (c *StorageMinerStruct) StorageSetSectorKind(ctx context.Context, sector abi.SectorID, kind storiface.SectorKind) error {
+	return c.Internal.StorageSetSectorKind(ctx, sector, kind)
 }
 
 func (c *StorageMinerStruct) StorageReportHealth(ctx context.Context, id stores.ID, report stores.HealthReport) error {
@@ -476,6 +577,10 @@ func (c *StorageMinerStruct) DealsImportData(ctx context.Context, dealPropCid ci
 	return c.Internal.DealsImportData(ctx, dealPropCid, file)
 }
 
+func (c *StorageMinerStruct) DealsImportPublishedData(ctx context.Context, dealPropCid, publishCid cid.Cid, file string) error {
+	return c.Internal.DealsImportPublishedData(ctx, dealPropCid, publishCid, file)
+}
+
 func (c *StorageMinerStruct) DealsList(ctx context.Context) ([]apitypes.MarketDeal, error) {
 	return c.Internal.DealsList(ctx)
 }
@@ -536,6 +641,14 @@ func (c *StorageMinerStruct) DealsSetConsiderUnverifiedStorageDeals(ctx context.
 	return c.Internal.DealsSetConsiderUnverifiedStorageDeals(ctx, b)
 }
 
+func (c *StorageMinerStruct) DealsGetExpectedSealDurationFunc(ctx context.Context) (time.Duration, error) {
+	return c.Internal.DealsGetExpectedSealDurationFunc(ctx)
+}
+
+func (c *StorageMinerStruct) DealsSetExpectedSealDurationFunc(ctx context.Context, d time.Duration) error {
+	return c.Internal.DealsSetExpectedSealDurationFunc(ctx, d)
+}
+
 func (c *StorageMinerStruct) StorageAddLocal(ctx context.Context, path string) error {
 	return c.Internal.StorageAddLocal(ctx, path)
 }
@@ -560,10 +673,34 @@ func (c *StorageMinerStruct) CreateBackup(ctx context.Context, fpath string) err
 	return c.Internal.CreateBackup(ctx, fpath)
 }
 
-func (c *StorageMinerStruct) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, expensive bool) (map[abi.SectorNumber]string, error) {
+func (c *StorageMinerStruct) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, expensive bool) ([]storiface.SectorCheck, error) {
 	return c.Internal.CheckProvable(ctx, pp, sectors, expensive)
 }
 
+func (c *StorageMinerStruct) ProofEventStatus(ctx context.Context) ([]types.ProofGatewayStatus, error) {
+	return c.Internal.ProofEventStatus(ctx)
+}
+
+func (c *StorageMinerStruct) ConfigReload(ctx context.Context) ([]string, error) {
+	return c.Internal.ConfigReload(ctx)
+}
+
+func (c *StorageMinerStruct) ConfigSet(ctx context.Context, key, value string) ([]string, error) {
+	return c.Internal.ConfigSet(ctx, key, value)
+}
+
+func (c *StorageMinerStruct) TokenCreate(ctx context.Context, name string, perms []auth.Permission, methods []string, ttl time.Duration) (NewToken, error) {
+	return c.Internal.TokenCreate(ctx, name, perms, methods, ttl)
+}
+
+func (c *StorageMinerStruct) TokenList(ctx context.Context) ([]types.TokenInfo, error) {
+	return c.Internal.TokenList(ctx)
+}
+
+func (c *StorageMinerStruct) TokenRevoke(ctx context.Context, id string) error {
+	return c.Internal.TokenRevoke(ctx, id)
+}
+
 func (c *StorageMinerStruct) ComputeProof(ctx context.Context, sectorInfos []proof2.SectorInfo, randomness abi.PoStRandomness) ([]proof2.PoStProof, error) {
 	return c.Internal.ComputeProof(ctx, sectorInfos, randomness)
 }
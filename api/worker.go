@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/actors/runtime/proof"
 	"github.com/filecoin-project/specs-storage/storage"
 	"github.com/filecoin-project/venus-sealer/constants"
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
@@ -19,9 +20,10 @@ type WorkerStruct struct {
 
 		Version func(context.Context) (constants.Version, error) `perm:"admin"`
 
-		TaskTypes func(context.Context) (map[types.TaskType]struct{}, error) `perm:"admin"`
-		Paths     func(context.Context) ([]stores.StoragePath, error)        `perm:"admin"`
-		Info      func(context.Context) (storiface.WorkerInfo, error)        `perm:"admin"`
+		TaskTypes func(context.Context) (map[types.TaskType]struct{}, error)  `perm:"admin"`
+		Paths     func(context.Context) ([]stores.StoragePath, error)         `perm:"admin"`
+		Transfers func(context.Context) ([]storiface.TransferProgress, error) `perm:"admin"`
+		Info      func(context.Context) (storiface.WorkerInfo, error)         `perm:"admin"`
 
 		AddPiece        func(ctx context.Context, sector storage.SectorRef, pieceSizes []abi.UnpaddedPieceSize, newPieceSize abi.UnpaddedPieceSize, pieceData storage.Data) (types.CallID, error)                 `perm:"admin"`
 		SealPreCommit1  func(ctx context.Context, sector storage.SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) (types.CallID, error)                                                              `perm:"admin"`
@@ -35,6 +37,10 @@ type WorkerStruct struct {
 		ReadPiece       func(context.Context, io.Writer, storage.SectorRef, storiface.UnpaddedByteIndex, abi.UnpaddedPieceSize) (types.CallID, error)                                                             `perm:"admin"`
 		Fetch           func(context.Context, storage.SectorRef, storiface.SectorFileType, storiface.PathType, storiface.AcquireMode) (types.CallID, error)                                                       `perm:"admin"`
 
+		GenerateWindowPoSt        func(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) `perm:"admin"`
+		GenerateWindowPoStVanilla func(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) `perm:"admin"`
+		GenerateWinningPoSt       func(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) `perm:"admin"`
+
 		TaskDisable func(ctx context.Context, tt types.TaskType) error `perm:"admin"`
 		TaskEnable  func(ctx context.Context, tt types.TaskType) error `perm:"admin"`
 
@@ -65,6 +71,10 @@ func (w *WorkerStruct) Paths(ctx context.Context) ([]stores.StoragePath, error)
 	return w.Internal.Paths(ctx)
 }
 
+func (w *WorkerStruct) Transfers(ctx context.Context) ([]storiface.TransferProgress, error) {
+	return w.Internal.Transfers(ctx)
+}
+
 func (w *WorkerStruct) Info(ctx context.Context) (storiface.WorkerInfo, error) {
 	return w.Internal.Info(ctx)
 }
@@ -113,6 +123,18 @@ func (w *WorkerStruct) Fetch(ctx context.Context, id storage.SectorRef, fileType
 	return w.Internal.Fetch(ctx, id, fileType, ptype, am)
 }
 
+func (w *WorkerStruct) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	return w.Internal.GenerateWindowPoSt(ctx, minerID, sectorInfo, randomness)
+}
+
+func (w *WorkerStruct) GenerateWindowPoStVanilla(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	return w.Internal.GenerateWindowPoStVanilla(ctx, minerID, sectorInfo, randomness)
+}
+
+func (w *WorkerStruct) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	return w.Internal.GenerateWinningPoSt(ctx, minerID, sectorInfo, randomness)
+}
+
 func (w *WorkerStruct) TaskDisable(ctx context.Context, tt types.TaskType) error {
 	return w.Internal.TaskDisable(ctx, tt)
 }
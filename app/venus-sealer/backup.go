@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/api"
+)
+
+var backupCmd = &cli.Command{
+	Name:  "backup",
+	Usage: "Create a backup of the sealer repo and metadata",
+	Description: `The backup includes the config, token, storage.json and all sealer metadata
+(sectors, logs, deal refs, worker calls/states and the sector counter).
+
+The backup file is written by the running sealer, the VENUS_SEALER_BACKUP_BASE_PATH env var
+must be set on the sealer and the file path must be inside that path.
+
+Restore a repo with 'venus-sealer init restore [backupFile]'.`,
+	ArgsUsage: "[backup file path]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+
+		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if err := storageAPI.CreateBackup(api.ReqContext(cctx), cctx.Args().First()); err != nil {
+			return err
+		}
+
+		fmt.Println("Success")
+		return nil
+	},
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/multierr"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/api"
+	"github.com/filecoin-project/venus-sealer/config"
+)
+
+var configCmd = &cli.Command{
+	Name:  "config",
+	Usage: "Manage the sealer config",
+	Subcommands: []*cli.Command{
+		configDefaultCmd,
+		configShowCmd,
+		configDiffCmd,
+		configValidateCmd,
+		configReloadCmd,
+		configSetCmd,
+	},
+}
+
+var configNetworkFlag = &cli.StringFlag{
+	Name:  "network",
+	Usage: "network of the defaults: one of mainnet,calibration,2k&nerpa",
+	Value: "mainnet",
+}
+
+var configDefaultCmd = &cli.Command{
+	Name:  "default",
+	Usage: "Print the default config",
+	Flags: []cli.Flag{
+		configNetworkFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		cfg, err := config.GetDefaultStorageConfig(cctx.String("network"))
+		if err != nil {
+			return err
+		}
+
+		return toml.NewEncoder(os.Stdout).Encode(cfg)
+	},
+}
+
+var configShowCmd = &cli.Command{
+	Name:  "show",
+	Usage: "Print the effective config, the config file with defaults and env overrides, secrets are redacted",
+	Action: func(cctx *cli.Context) error {
+		cfg, err := config.MinerFromFile(cctx.String("config"))
+		if err != nil {
+			return xerrors.Errorf("loading config: %w", err)
+		}
+
+		return toml.NewEncoder(os.Stdout).Encode(cfg.Redact())
+	},
+}
+
+var configDiffCmd = &cli.Command{
+	Name:  "diff",
+	Usage: "Print the keys of the config file which differ from the defaults, secrets are redacted",
+	Flags: []cli.Flag{
+		configNetworkFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		def, err := config.GetDefaultStorageConfig(cctx.String("network"))
+		if err != nil {
+			return err
+		}
+		cfg, err := config.MinerFromFile(cctx.String("config"))
+		if err != nil {
+			return xerrors.Errorf("loading config: %w", err)
+		}
+
+		changes, err := config.Diff(def.Redact(), cfg.Redact())
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			fmt.Println("No differences")
+			return nil
+		}
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+		return nil
+	},
+}
+
+var configValidateCmd = &cli.Command{
+	Name:      "validate",
+	Usage:     "Check a config file before starting the sealer with it",
+	ArgsUsage: "[config file, defaults to the sealer config]",
+	Action: func(cctx *cli.Context) error {
+		path := cctx.String("config")
+		if cctx.Args().Present() {
+			path = cctx.Args().First()
+		}
+
+		if _, err := config.CheckMinerFile(path); err != nil {
+			for _, err := range multierr.Errors(err) {
+				fmt.Println(err)
+			}
+			return xerrors.Errorf("%s is invalid", path)
+		}
+
+		fmt.Printf("%s is valid\n", path)
+		return nil
+	},
+}
+
+var configReloadCmd = &cli.Command{
+	Name:  "reload",
+	Usage: "Re-read the config file of the running sealer",
+	Description: fmt.Sprintf(`The %s sections are applied without a restart,
+changes to other keys are saved but only take effect after a restart.
+
+The running sealer also reloads the config file when it changes.`, strings.Join(config.ReloadableSections, ", ")),
+	Action: func(cctx *cli.Context) error {
+		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		changes, err := storageAPI.ConfigReload(api.ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		printConfigChanges(changes)
+		return nil
+	},
+}
+
+var configSetCmd = &cli.Command{
+	Name:      "set",
+	Usage:     "Set a config key on the running sealer and save it to the config file",
+	ArgsUsage: "[key, e.g. Sealing.MaxSealingSectors] [value, in toml syntax]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 2 {
+			return xerrors.Errorf("expected 2 arguments")
+		}
+
+		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		changes, err := storageAPI.ConfigSet(api.ReqContext(cctx), cctx.Args().Get(0), cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		printConfigChanges(changes)
+		return nil
+	},
+}
+
+func printConfigChanges(changes []string) {
+	if len(changes) == 0 {
+		fmt.Println("No changes")
+		return
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/api"
+)
+
+var dealsCmd = &cli.Command{
+	Name:  "deals",
+	Usage: "Manage storage deals",
+	Subcommands: []*cli.Command{
+		dealsImportDataCmd,
+	},
+}
+
+var dealsImportDataCmd = &cli.Command{
+	Name:  "import-data",
+	Usage: "Import data of an offline deal into a sector",
+	Description: `The file is the deal data sent by the client (usually a CAR file), it is read by the running
+sealer. The piece commitment of the file is checked against the published deal proposal before
+the piece is added to a sector, see 'venus-sealer sectors status --log' for the import progress.
+Without --publish-cid the deal is looked up in the market actor state.`,
+	ArgsUsage: "<proposal CID> <file>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "publish-cid",
+			Usage: "cid of the publish deals message of the deal",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 2 {
+			return xerrors.Errorf("expected 2 arguments")
+		}
+
+		propCid, err := cid.Decode(cctx.Args().Get(0))
+		if err != nil {
+			return xerrors.Errorf("parse proposal cid: %w", err)
+		}
+
+		var publishCid *cid.Cid
+		if cctx.IsSet("publish-cid") {
+			c, err := cid.Decode(cctx.String("publish-cid"))
+			if err != nil {
+				return xerrors.Errorf("parse publish message cid: %w", err)
+			}
+			publishCid = &c
+		}
+
+		fpath, err := filepath.Abs(cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := api.ReqContext(cctx)
+		if publishCid != nil {
+			err = storageAPI.DealsImportPublishedData(ctx, propCid, *publishCid, fpath)
+		} else {
+			err = storageAPI.DealsImportData(ctx, propCid, fpath)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Success")
+		return nil
+	},
+}
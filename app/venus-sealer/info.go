@@ -273,6 +273,14 @@ func infoCmdAct(cctx *cli.Context) error {
 
 	fmt.Println()
 
+	if err := gatewaysInfo(ctx, storageAPI); err != nil {
+		return err
+	}
+
+	if err := autoPledgeInfo(ctx, storageAPI); err != nil {
+		return err
+	}
+
 	if !cctx.Bool("hide-sectors-info") {
 		fmt.Println("Sectors:")
 		err = sectorsInfo(ctx, storageAPI)
@@ -317,6 +325,72 @@ func sectorsInfo(ctx context.Context, napi api.StorageMiner) error {
 	return nil
 }
 
+func gatewaysInfo(ctx context.Context, napi api.StorageMiner) error {
+	gateways, err := napi.ProofEventStatus(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting gateway status: %w", err)
+	}
+	if len(gateways) == 0 {
+		return nil
+	}
+
+	fmt.Println("Gateways:")
+	for _, gw := range gateways {
+		var state string
+		switch gw.State {
+		case types2.GatewayConnected:
+			state = color.GreenString("%s since %s", gw.State, gw.ConnectedAt.Format(time.Stamp))
+		case types2.GatewayBackoff:
+			state = color.RedString("%s, retry in %s", gw.State, time.Until(gw.NextRetry).Truncate(time.Second))
+		default:
+			state = color.YellowString("%s", gw.State)
+		}
+		fmt.Printf("\t%s: %s (reconnects: %d)\n", gw.URL, state, gw.Reconnects)
+		fmt.Printf("\t\tWinning PoSt: %d served, %d failed, last took %s\n", gw.ProofsServed, gw.ProofsFailed, gw.LastProofLatency.Truncate(time.Millisecond))
+		if gw.LastError != "" {
+			fmt.Printf("\t\tLast error (%s): %s\n", gw.LastErrorAt.Format(time.Stamp), gw.LastError)
+		}
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func autoPledgeInfo(ctx context.Context, napi api.StorageMiner) error {
+	st, err := napi.SectorAutoPledgeStatus(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting auto pledge status: %w", err)
+	}
+	if st.TargetSealing == 0 {
+		fmt.Printf("Auto Pledge: %s\n\n", color.YellowString("disabled"))
+		return nil
+	}
+
+	targetSectors := "no limit"
+	if st.TargetSectors > 0 {
+		targetSectors = fmt.Sprint(st.TargetSectors)
+	}
+	fmt.Println("Auto Pledge:")
+	fmt.Printf("\tSealing: %d / %d target\n", st.Sealing, st.TargetSealing)
+	fmt.Printf("\tSectors: %d / %s target\n", st.Total, targetSectors)
+	if st.Pledged > 0 {
+		fmt.Printf("\tPledged: %d, last at %s\n", st.Pledged, st.LastPledge.Format(time.Stamp))
+	} else {
+		fmt.Printf("\tPledged: 0\n")
+	}
+	switch {
+	case st.LastCheck.IsZero():
+		fmt.Printf("\tState: %s\n", color.YellowString("starting"))
+	case st.Waiting == "":
+		fmt.Printf("\tState: %s\n", color.GreenString("pledging"))
+	default:
+		fmt.Printf("\tState: %s\n", color.YellowString("waiting, %s", st.Waiting))
+	}
+	fmt.Println()
+
+	return nil
+}
+
 func colorTokenAmount(format string, amount abi.TokenAmount) {
 	if amount.GreaterThan(big.Zero()) {
 		color.Green(format, types.FIL(amount).Short())
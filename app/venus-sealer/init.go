@@ -50,6 +50,9 @@ import (
 var initCmd = &cli.Command{
 	Name:  "init",
 	Usage: "Initialize a venus sealer repo",
+	Subcommands: []*cli.Command{
+		initRestoreCmd,
+	},
 	Flags: []cli.Flag{
 		&cli.StringFlag{
 			Name:  "actor",
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/mitchellh/go-homedir"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/api/impl"
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/lib/backupds"
+	"github.com/filecoin-project/venus-sealer/models"
+)
+
+var initRestoreCmd = &cli.Command{
+	Name:      "restore",
+	Usage:     "Initialize a venus sealer repo from a backup",
+	ArgsUsage: "[backupFile]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+
+		log.Info("Reading backup")
+
+		bf, err := homedir.Expand(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("expand backup file path: %w", err)
+		}
+
+		f, err := os.Open(bf)
+		if err != nil {
+			return xerrors.Errorf("opening backup file: %w", err)
+		}
+		defer f.Close() // nolint:errcheck
+
+		// entries are only committed to mds after the checksum is verified
+		mds := dssync.MutexWrap(datastore.NewMapDatastore())
+		if err := backupds.RestoreInto(f, mds); err != nil {
+			return xerrors.Errorf("validate backup: %w", err)
+		}
+
+		cfgPath := cctx.String("config")
+		exist, err := config.ConfigExist(cfgPath)
+		if err != nil {
+			return err
+		}
+		if exist {
+			return xerrors.Errorf("repo is already initialized, config %s exists", cfgPath)
+		}
+
+		log.Info("Restoring config")
+
+		cfgData, err := mds.Get(models.BackupFileKey(impl.BackupConfigFile))
+		if err != nil {
+			return xerrors.Errorf("read config from backup: %w", err)
+		}
+		cfg, err := config.MinerFromReader(bytes.NewReader(cfgData))
+		if err != nil {
+			return xerrors.Errorf("parse config from backup: %w", err)
+		}
+		if cctx.IsSet("repo") {
+			cfg.DataDir = cctx.String("repo")
+		}
+		dataDir, err := homedir.Expand(cfg.DataDir)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dataDir, 0755); err != nil {
+			return xerrors.Errorf("create repo dir: %w", err)
+		}
+
+		for _, name := range impl.BackupRepoFiles {
+			data, err := mds.Get(models.BackupFileKey(name))
+			if xerrors.Is(err, datastore.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return xerrors.Errorf("read %s from backup: %w", name, err)
+			}
+			if err := ioutil.WriteFile(filepath.Join(dataDir, name), data, 0600); err != nil {
+				return xerrors.Errorf("write %s: %w", name, err)
+			}
+		}
+
+		log.Info("Restoring metadata")
+
+		repo, err := models.SetDataBase(config.HomeDir(cfg.DataDir), &cfg.DB)
+		if err != nil {
+			return err
+		}
+		defer repo.DbClose() //nolint:errcheck
+
+		if err := repo.AutoMigrate(); err != nil {
+			return err
+		}
+
+		if err := models.LoadRepo(mds, repo); err != nil {
+			return xerrors.Errorf("restore metadata: %w", err)
+		}
+
+		// the config marks the repo initialized, a failed restore can be run again until it is written
+		if err := config.SaveConfig(cfgPath, cfg); err != nil {
+			return xerrors.Errorf("save config: %w", err)
+		}
+
+		log.Info("Sealer repo restored")
+		return nil
+	},
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strconv"
 	"text/tabwriter"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/urfave/cli/v2"
@@ -439,11 +440,9 @@ var provingCheckProvableCmd = &cli.Command{
 		}
 
 		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
-		_, _ = fmt.Fprintln(tw, "deadline\tpartition\tsector\tstatus")
+		_, _ = fmt.Fprintln(tw, "deadline\tpartition\tsector\tstatus\tchecked by\ttook")
 
 		for parIdx, par := range partitions {
-			sectors := make(map[abi.SectorNumber]struct{})
-
 			sectorInfos, err := nodeAPI.StateMinerSectors(ctx, addr, &par.LiveSectors, types.EmptyTSK)
 			if err != nil {
 				return err
@@ -451,7 +450,6 @@ var provingCheckProvableCmd = &cli.Command{
 
 			var tocheck []storage.SectorRef
 			for _, info := range sectorInfos {
-				sectors[info.SectorNumber] = struct{}{}
 				tocheck = append(tocheck, storage.SectorRef{
 					ProofType: info.SealProof,
 					ID: abi.SectorID{
@@ -461,17 +459,24 @@ var provingCheckProvableCmd = &cli.Command{
 				})
 			}
 
-			bad, err := storageAPI.CheckProvable(ctx, info.WindowPoStProofType, tocheck, cctx.Bool("slow"))
+			checks, err := storageAPI.CheckProvable(ctx, info.WindowPoStProofType, tocheck, cctx.Bool("slow"))
 			if err != nil {
 				return err
 			}
 
-			for s := range sectors {
-				if err, exist := bad[s]; exist {
-					_, _ = fmt.Fprintf(tw, "%d\t%d\t%d\t%s\n", dlIdx, parIdx, s, color.RedString("bad")+fmt.Sprintf(" (%s)", err))
-				} else if !cctx.Bool("only-bad") {
-					_, _ = fmt.Fprintf(tw, "%d\t%d\t%d\t%s\n", dlIdx, parIdx, s, color.GreenString("good"))
+			for _, check := range checks {
+				checkedBy := "sealer"
+				if check.Remote != "" {
+					checkedBy = check.Remote
+				}
+
+				status := color.GreenString("good")
+				if check.Faulty() {
+					status = color.RedString("bad") + fmt.Sprintf(" (%s: %s)", check.Code, check.Error)
+				} else if cctx.Bool("only-bad") {
+					continue
 				}
+				_, _ = fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%s\t%s\n", dlIdx, parIdx, check.Sector.Number, status, checkedBy, check.Took.Truncate(time.Millisecond))
 			}
 		}
 
@@ -20,6 +20,7 @@ import (
 	"github.com/filecoin-project/venus-sealer/config"
 	"github.com/filecoin-project/venus-sealer/constants"
 	"github.com/filecoin-project/venus-sealer/lib/ulimit"
+	"github.com/filecoin-project/venus-sealer/metrics"
 	"github.com/filecoin-project/venus-sealer/types"
 )
 
@@ -109,7 +110,7 @@ var runCmd = &cli.Command{
 		ctx := api.DaemonContext(cctx)
 
 		// Register all metric views
-		if err := view.Register(); err != nil {
+		if err := view.Register(metrics.DefaultViews...); err != nil {
 			log.Fatalf("Cannot register the view: %v", err)
 		}
 
@@ -29,6 +29,7 @@ var sealingCmd = &cli.Command{
 		sealingWorkersCmd,
 		sealingSchedDiagCmd,
 		sealingAbortCmd,
+		sealingStatsCmd,
 	},
 }
 
@@ -188,7 +189,7 @@ var sealingJobsCmd = &cli.Command{
 		}
 
 		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
-		_, _ = fmt.Fprintf(tw, "ID\tSector\tWorker\tHostname\tTask\tState\tTime\n")
+		_, _ = fmt.Fprintf(tw, "ID\tSector\tWorker\tHostname\tTask\tState\tTime\tTransfer\n")
 
 		for _, l := range lines {
 			state := "running"
@@ -215,14 +216,23 @@ var sealingJobsCmd = &cli.Command{
 				hostname = l.Hostname
 			}
 
-			_, _ = fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			transfer := ""
+			if l.Transfer != nil {
+				transfer = fmt.Sprintf("%s/%s (%s/s)",
+					types.SizeStr(types.NewInt(uint64(l.Transfer.Done))),
+					types.SizeStr(types.NewInt(uint64(l.Transfer.Size))),
+					types.SizeStr(types.NewInt(uint64(l.Transfer.Rate()))))
+			}
+
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
 				hex.EncodeToString(l.ID.ID[:4]),
 				l.Sector.Number,
 				hex.EncodeToString(l.wid[:4]),
 				hostname,
 				l.Task.Short(),
 				state,
-				dur)
+				dur,
+				transfer)
 		}
 
 		return tw.Flush()
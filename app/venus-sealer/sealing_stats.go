@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/filecoin-project/venus/pkg/types"
+
+	"github.com/filecoin-project/venus-sealer/api"
+	"github.com/filecoin-project/venus-sealer/lib/tablewriter"
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+var sealingStatsCmd = &cli.Command{
+	Name:  "stats",
+	Usage: "Print sealing stage durations, throughput and failure rates",
+	Description: `Stats are computed from the recorded sector history, a stage is counted in the window it finished.
+PreCommitLanding and CommitLanding are the time spent getting the messages on chain, they are grouped
+without a worker.`,
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "since",
+			Usage: "only stages finished within the duration",
+			Value: 24 * time.Hour,
+		},
+		&cli.DurationFlag{
+			Name:  "until",
+			Usage: "only stages finished before the duration",
+		},
+		&cli.BoolFlag{
+			Name:  "by-worker",
+			Usage: "group by worker and sector size",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "output format: table or json",
+			Value: "table",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		now := time.Now()
+		var since, until time.Time
+		if d := cctx.Duration("since"); d > 0 {
+			since = now.Add(-d)
+		}
+		if cctx.IsSet("until") {
+			until = now.Add(-cctx.Duration("until"))
+		}
+
+		stats, err := nodeApi.SealingStats(api.ReqContext(cctx), since, until)
+		if err != nil {
+			return err
+		}
+
+		if cctx.String("output") == "json" {
+			return printJSON(stats)
+		}
+
+		fmt.Printf("%s - %s: %d sectors completed, %.2f/hour, %.1f/day\n\n",
+			stats.Since.Format("2006-01-02 15:04"), stats.Until.Format("2006-01-02 15:04"), stats.Completed, stats.PerHour, stats.PerDay)
+
+		tw := tablewriter.New(
+			tablewriter.Col("Worker"),
+			tablewriter.Col("Size"),
+			tablewriter.Col("Stage"),
+			tablewriter.Col("Count"),
+			tablewriter.Col("Failed"),
+			tablewriter.Col("FailRate"),
+			tablewriter.Col("P50"),
+			tablewriter.Col("P90"),
+			tablewriter.Col("P99"),
+			tablewriter.Col("Max"),
+		)
+		write := func(worker, size string, stages []types2.StageStats) {
+			for _, st := range stages {
+				tw.Write(map[string]interface{}{
+					"Worker":   worker,
+					"Size":     size,
+					"Stage":    st.Stage,
+					"Count":    st.Count,
+					"Failed":   st.Failures,
+					"FailRate": fmt.Sprintf("%.1f%%", st.FailureRate*100),
+					"P50":      st.P50.Truncate(time.Second),
+					"P90":      st.P90.Truncate(time.Second),
+					"P99":      st.P99.Truncate(time.Second),
+					"Max":      st.Max.Truncate(time.Second),
+				})
+			}
+		}
+
+		if !cctx.Bool("by-worker") {
+			write("*", "*", stats.Stages)
+			return tw.Flush(os.Stdout)
+		}
+		for _, group := range stats.Groups {
+			worker := group.Worker
+			if worker == "" {
+				worker = "chain"
+			}
+			write(worker, types.SizeStr(types.NewInt(uint64(group.SectorSize))), group.Stages)
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus/pkg/types"
+
+	"github.com/filecoin-project/venus-sealer/api"
+	"github.com/filecoin-project/venus-sealer/lib/tablewriter"
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+var sectorsExtendCmd = &cli.Command{
+	Name:  "extend",
+	Usage: "Extend sector expiration",
+	Description: `The sealer groups the sectors by deadline and partition and splits them into as many
+ExtendSectorExpiration messages as the network limits require. Run with --dry-run first to review the plan.
+
+Extend sectors 1 and 2 to epoch 3000000:
+   venus-sealer sectors extend --new-expiration 3000000 1 2
+Extend every CC sector expiring in the next 30 days to its maximum lifetime:
+   venus-sealer sectors extend --all --only-cc --expiration-cutoff 86400`,
+	ArgsUsage: "<sectorNumbers...>",
+	Subcommands: []*cli.Command{
+		sectorsExtendStatusCmd,
+	},
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "new-expiration",
+			Usage: "new expiration epoch, the maximum lifetime of each sector if unset",
+		},
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "extend all active sectors matching the filters",
+		},
+		&cli.BoolFlag{
+			Name:  "v1-sectors",
+			Usage: "extend all v1 sectors up to the maximum possible lifetime",
+		},
+		&cli.BoolFlag{
+			Name:  "only-cc",
+			Usage: "skip sectors with deals",
+		},
+		&cli.Int64Flag{
+			Name:  "tolerance",
+			Value: 20160,
+			Usage: "when extending many sectors, don't try to extend sectors by fewer than this number of epochs",
+		},
+		&cli.Int64Flag{
+			Name:  "expiration-cutoff",
+			Usage: "skip sectors whose current expiration is more than <cutoff> epochs from now (infinity if unspecified)",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "print the messages without sending them",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "output format: table or json",
+			Value: "table",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := api.ReqContext(cctx)
+
+		req := types2.ExtendRequest{
+			NewExpiration: abi.ChainEpoch(cctx.Int64("new-expiration")),
+			Cutoff:        abi.ChainEpoch(cctx.Int64("expiration-cutoff")),
+			Tolerance:     abi.ChainEpoch(cctx.Int64("tolerance")),
+			OnlyCC:        cctx.Bool("only-cc"),
+			V1Only:        cctx.Bool("v1-sectors"),
+			DryRun:        cctx.Bool("dry-run"),
+		}
+
+		switch {
+		case cctx.Args().Present():
+			if !cctx.IsSet("new-expiration") {
+				return xerrors.Errorf("must pass the new expiration of the sectors")
+			}
+			for i, s := range cctx.Args().Slice() {
+				id, err := strconv.ParseUint(s, 10, 64)
+				if err != nil {
+					return xerrors.Errorf("could not parse sector %d: %w", i, err)
+				}
+				req.Sectors = append(req.Sectors, abi.SectorNumber(id))
+			}
+			// extend the given sectors to exactly the new expiration
+			if !cctx.IsSet("tolerance") {
+				req.Tolerance = 0
+			}
+		case cctx.Bool("all"), cctx.Bool("v1-sectors"):
+		default:
+			return xerrors.Errorf("must pass sector numbers, --all or --v1-sectors")
+		}
+
+		plan, err := nodeApi.SectorsExtend(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		if cctx.String("output") == "json" {
+			return printJSON(plan)
+		}
+
+		if len(plan.Messages) == 0 {
+			fmt.Printf("nothing to extend (%d sectors skipped)\n", plan.Skipped)
+			return nil
+		}
+		fmt.Printf("%d sectors in %d messages, %d sectors skipped, estimated fee %s\n", plan.Sectors, len(plan.Messages), plan.Skipped, types.FIL(plan.EstimatedFee).Short())
+		return printExtendMessages(plan.Messages)
+	},
+}
+
+var sectorsExtendStatusCmd = &cli.Command{
+	Name:      "status",
+	Usage:     "Print the state of extend messages",
+	ArgsUsage: "[message ids, defaults to the messages sent since the sealer started]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "output format: table or json",
+			Value: "table",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		msgs, err := nodeApi.SectorsExtendStatus(api.ReqContext(cctx), cctx.Args().Slice())
+		if err != nil {
+			return err
+		}
+
+		if cctx.String("output") == "json" {
+			return printJSON(msgs)
+		}
+		return printExtendMessages(msgs)
+	},
+}
+
+func printExtendMessages(msgs []types2.ExtendMessage) error {
+	tw := tablewriter.New(
+		tablewriter.Col("#"),
+		tablewriter.Col("Sectors"),
+		tablewriter.Col("Partitions"),
+		tablewriter.Col("Expirations"),
+		tablewriter.Col("GasLimit"),
+		tablewriter.Col("EstimatedFee"),
+		tablewriter.Col("MsgID"),
+		tablewriter.Col("State"),
+		tablewriter.NewLineCol("Error"),
+	)
+
+	for i, msg := range msgs {
+		var minExp, maxExp abi.ChainEpoch
+		for j, ext := range msg.Extensions {
+			if j == 0 || ext.NewExpiration < minExp {
+				minExp = ext.NewExpiration
+			}
+			if ext.NewExpiration > maxExp {
+				maxExp = ext.NewExpiration
+			}
+		}
+		exps := fmt.Sprint(minExp)
+		if maxExp != minExp {
+			exps = fmt.Sprintf("%d-%d", minExp, maxExp)
+		}
+
+		row := map[string]interface{}{
+			"#":     i,
+			"MsgID": msg.MsgID,
+			"State": msg.State,
+			"Error": msg.Error,
+		}
+		if len(msg.Extensions) > 0 {
+			row["Sectors"] = msg.Sectors
+			row["Partitions"] = len(msg.Extensions)
+			row["Expirations"] = exps
+			row["GasLimit"] = msg.GasLimit
+			row["EstimatedFee"] = types.FIL(msg.EstimatedFee).Short()
+		}
+		tw.Write(row)
+	}
+
+	return tw.Flush(os.Stdout)
+}
+
+func printJSON(v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/api"
+	"github.com/filecoin-project/venus-sealer/lib/tablewriter"
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+var sectorsHistoryCmd = &cli.Command{
+	Name:  "history",
+	Usage: "Print the recorded state transitions of sectors",
+	Description: `Every state transition of a sector is recorded with the event which caused it, the worker
+which ran the latest task of the sector, the time spent in the previous state and the error class of failures.
+
+Failures of the last day:
+   venus-sealer sectors history --since 24h --errors
+Sectors which failed PreCommit2 on a worker:
+   venus-sealer sectors history --error-class pc2 --worker worker-1`,
+	ArgsUsage: "[sectorNumbers...]",
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "since",
+			Usage: "only events newer than the duration",
+		},
+		&cli.DurationFlag{
+			Name:  "until",
+			Usage: "only events older than the duration",
+		},
+		&cli.StringFlag{
+			Name:  "state",
+			Usage: "only events entering or leaving the state",
+		},
+		&cli.StringFlag{
+			Name:  "event",
+			Usage: "only events of the type, e.g. SectorPreCommit1",
+		},
+		&cli.StringFlag{
+			Name:  "worker",
+			Usage: "only events of sectors sealed on the worker hostname",
+		},
+		&cli.StringFlag{
+			Name:  "error-class",
+			Usage: "only failures of the class: addpiece, pc1, pc2, precommit-chain, compute-proof, commit-chain, deals, ticket, finalize, terminate, remove, fatal, other",
+		},
+		&cli.BoolFlag{
+			Name:  "errors",
+			Usage: "only failures",
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "print only the latest <limit> events",
+			Value: 100,
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "output format: table or json",
+			Value: "table",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		filter := types2.SectorEventFilter{
+			State:      types2.SectorState(cctx.String("state")),
+			Event:      cctx.String("event"),
+			Worker:     cctx.String("worker"),
+			ErrorClass: cctx.String("error-class"),
+			OnlyErrors: cctx.Bool("errors"),
+			Limit:      cctx.Int("limit"),
+		}
+		now := time.Now()
+		if cctx.IsSet("since") {
+			filter.Since = now.Add(-cctx.Duration("since"))
+		}
+		if cctx.IsSet("until") {
+			filter.Until = now.Add(-cctx.Duration("until"))
+		}
+		for _, arg := range cctx.Args().Slice() {
+			id, err := strconv.ParseUint(arg, 10, 64)
+			if err != nil {
+				return xerrors.Errorf("could not parse sector number %s: %w", arg, err)
+			}
+			filter.Sectors = append(filter.Sectors, abi.SectorNumber(id))
+		}
+
+		events, err := nodeApi.SectorsHistory(api.ReqContext(cctx), filter)
+		if err != nil {
+			return err
+		}
+
+		if cctx.String("output") == "json" {
+			return printJSON(events)
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Time"),
+			tablewriter.Col("Sector"),
+			tablewriter.Col("Event"),
+			tablewriter.Col("From"),
+			tablewriter.Col("To"),
+			tablewriter.Col("Took"),
+			tablewriter.Col("Worker"),
+			tablewriter.Col("Class"),
+			tablewriter.NewLineCol("Error"),
+		)
+		for _, event := range events {
+			tw.Write(map[string]interface{}{
+				"Time":   event.Time.Format("2006-01-02 15:04:05"),
+				"Sector": event.SectorNumber,
+				"Event":  event.Event,
+				"From":   event.From,
+				"To":     event.To,
+				"Took":   event.Duration.Truncate(time.Second),
+				"Worker": event.Worker,
+				"Class":  event.ErrorClass,
+				"Error":  event.Error,
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
@@ -68,6 +68,14 @@ Data for the sealing process will be stored here
 Store
 Finalized sectors that will be moved here for long term storage and be proven
 over time
+
+Groups, AllowTo
+Sector files in a path with AllowTo set are only moved or fetched to paths in
+one of the listed groups
+
+AllowTypes, DenyTypes, AllowKinds
+Restrict the sector file types and the kinds of sectors (cc, deal) allocated in
+the path
    `,
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
@@ -87,6 +95,26 @@ over time
 			Name:  "store",
 			Usage: "(for init) use path for long-term storage",
 		},
+		&cli.StringSliceFlag{
+			Name:  "groups",
+			Usage: "(for init) storage groups the path belongs to",
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow-to",
+			Usage: "(for init) groups sector files in the path may be moved to (any storage if not specified)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow-types",
+			Usage: "(for init) sector file types allocated in the path: unsealed, sealed, cache (all if not specified)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "deny-types",
+			Usage: "(for init) sector file types never allocated in the path",
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow-kinds",
+			Usage: "(for init) kinds of sectors allocated in the path: cc, deal (all if not specified)",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
@@ -126,10 +154,20 @@ over time
 				CanSeal:  cctx.Bool("seal"),
 				CanStore: cctx.Bool("store"),
 			}
+			cfg.Groups = cctx.StringSlice("groups")
+			cfg.AllowTo = cctx.StringSlice("allow-to")
+			cfg.AllowTypes = cctx.StringSlice("allow-types")
+			cfg.DenyTypes = cctx.StringSlice("deny-types")
+			for _, kind := range cctx.StringSlice("allow-kinds") {
+				cfg.AllowKinds = append(cfg.AllowKinds, storiface.SectorKind(kind))
+			}
 
 			if !(cfg.CanStore || cfg.CanSeal) {
 				return xerrors.Errorf("must specify at least one of --store or --seal")
 			}
+			if err := cfg.PlacementRules.Validate(); err != nil {
+				return err
+			}
 
 			b, err := json.MarshalIndent(cfg, "", "  ")
 			if err != nil {
@@ -174,6 +212,11 @@ var storageListCmd = &cli.Command{
 			return err
 		}
 
+		health, err := storageAPI.StorageHealth(ctx)
+		if err != nil {
+			return err
+		}
+
 		type fsInfo struct {
 			stores.ID
 			sectors []stores.Decl
@@ -211,6 +254,17 @@ var storageListCmd = &cli.Command{
 
 			fmt.Printf("%s:\n", s.ID)
 
+			if h, ok := health[s.ID]; ok {
+				switch {
+				case h.Restored:
+					fmt.Printf("\t%s: not back since restart, last heartbeat %s\n", color.RedString("Missing"), lastHeartbeat(h.LastHeartbeat))
+				case h.Err != "":
+					fmt.Printf("\t%s: %s\n", color.YellowString("Unhealthy"), h.Err)
+				case time.Since(h.LastHeartbeat) > stores.SkippedHeartbeatThresh:
+					fmt.Printf("\t%s: last heartbeat %s\n", color.YellowString("Stale"), lastHeartbeat(h.LastHeartbeat))
+				}
+			}
+
 			pingStart := time.Now()
 			st, err := storageAPI.StorageStat(ctx, s.ID)
 			if err != nil {
@@ -264,6 +318,16 @@ var storageListCmd = &cli.Command{
 				fmt.Print(color.HiYellowString("Use: ReadOnly"))
 			}
 
+			if len(si.Groups) > 0 {
+				fmt.Printf("\tGroups: %s\n", strings.Join(si.Groups, ", "))
+			}
+			if len(si.AllowTo) > 0 {
+				fmt.Printf("\tAllowTo: %s\n", strings.Join(si.AllowTo, ", "))
+			}
+			if len(si.AllowTypes) > 0 || len(si.DenyTypes) > 0 || len(si.AllowKinds) > 0 {
+				fmt.Printf("\tAllowTypes: %v; DenyTypes: %v; AllowKinds: %v\n", si.AllowTypes, si.DenyTypes, si.AllowKinds)
+			}
+
 			if localPath, ok := local[s.ID]; ok {
 				fmt.Printf("\tLocal: %s\n", color.GreenString(localPath))
 			}
@@ -282,6 +346,13 @@ var storageListCmd = &cli.Command{
 	},
 }
 
+func lastHeartbeat(at time.Time) string {
+	if at.IsZero() {
+		return "never"
+	}
+	return time.Since(at).Truncate(time.Second).String() + " ago"
+}
+
 type storedSector struct {
 	id    stores.ID
 	store stores.SectorStorageInfo
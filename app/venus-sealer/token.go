@@ -2,15 +2,27 @@ package main
 
 import (
 	"fmt"
-	"github.com/filecoin-project/venus-sealer/api"
 	_ "net/http/pprof"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/filecoin-project/go-jsonrpc/auth"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/api"
 )
 
 var tokenCmd = &cli.Command{
 	Name:  "token",
 	Usage: "Print venus sealer token",
+	Subcommands: []*cli.Command{
+		tokenCreateCmd,
+		tokenListCmd,
+		tokenRevokeCmd,
+	},
 	Action: func(cctx *cli.Context) error {
 		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
 		if err != nil {
@@ -25,4 +37,114 @@ var tokenCmd = &cli.Command{
 		fmt.Println("token: ", string(token))
 		return nil
 	},
-}
\ No newline at end of file
+}
+
+var tokenCreateCmd = &cli.Command{
+	Name:  "create",
+	Usage: "Mint a token scoped to permissions or methods",
+	Description: `Permissions:
+   read     read-only monitoring
+   write    read and change the sealing state
+   sign     write and send messages
+   admin    every method
+   worker   connect workers, return their results and update the sector index, give this to venus-worker
+
+Methods can be allowed by name with --method, eg. --method SectorsSummary --method SectorsList.
+Minted tokens are listed by 'token list' and can be revoked with 'token revoke'.`,
+	ArgsUsage: "[name]",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "perm",
+			Usage: "permission of the token: read, write, sign, admin or worker",
+		},
+		&cli.StringSliceFlag{
+			Name:  "method",
+			Usage: "api method the token may call",
+		},
+		&cli.DurationFlag{
+			Name:  "expiry",
+			Usage: "time until the token expires, 0 never expires",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+
+		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		var perms []auth.Permission
+		for _, perm := range cctx.StringSlice("perm") {
+			perms = append(perms, auth.Permission(perm))
+		}
+
+		token, err := storageAPI.TokenCreate(api.ReqContext(cctx), cctx.Args().First(), perms, cctx.StringSlice("method"), cctx.Duration("expiry"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("id:    ", token.ID)
+		fmt.Println("token: ", token.Token)
+		return nil
+	},
+}
+
+var tokenListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the minted tokens",
+	Action: func(cctx *cli.Context) error {
+		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		tokens, err := storageAPI.TokenList(api.ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "ID\tName\tPerms\tMethods\tCreated\tExpires\tState\n")
+		for _, token := range tokens {
+			expires := "never"
+			if !token.Expires.IsZero() {
+				expires = token.Expires.Format(time.RFC3339)
+			}
+			state := "active"
+			switch {
+			case token.Revoked:
+				state = "revoked"
+			case token.Expired(now):
+				state = "expired"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", token.ID, token.Name, strings.Join(token.Perms, ","),
+				strings.Join(token.Methods, ","), token.Created.Format(time.RFC3339), expires, state)
+		}
+		return tw.Flush()
+	},
+}
+
+var tokenRevokeCmd = &cli.Command{
+	Name:      "revoke",
+	Usage:     "Revoke a minted token",
+	ArgsUsage: "[token id]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+
+		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return storageAPI.TokenRevoke(api.ReqContext(cctx), cctx.Args().First())
+	},
+}
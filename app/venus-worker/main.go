@@ -20,6 +20,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/docker/go-units"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	logging "github.com/ipfs/go-log/v2"
@@ -133,11 +134,41 @@ var runCmd = &cli.Command{
 			Usage: "enable commit (32G sectors: all cores or GPUs, 128GiB Memory + 64GiB swap)",
 			Value: true,
 		},
+		&cli.BoolFlag{
+			Name:  "windowpost",
+			Usage: "enable window post for the sectors in the worker's own storage (32G sectors: all cores or GPUs, 96GiB Memory)",
+			Value: false,
+		},
+		&cli.BoolFlag{
+			Name:  "winningpost",
+			Usage: "enable winning post for the sectors in the worker's own storage, for low latency hosts (32G sectors: 1GiB Memory)",
+			Value: false,
+		},
 		&cli.IntFlag{
 			Name:  "parallel-fetch-limit",
 			Usage: "maximum fetch operations to run in parallel",
 			Value: 5,
 		},
+		&cli.StringFlag{
+			Name:  "fetch-host-bandwidth",
+			Usage: "maximum bytes per second fetched from one host, eg. 100MiB, 0 for no limit",
+			Value: "0",
+		},
+		&cli.StringFlag{
+			Name:  "fetch-path-bandwidth",
+			Usage: "maximum bytes per second fetched into one local storage path, eg. 100MiB, 0 for no limit",
+			Value: "0",
+		},
+		&cli.IntFlag{
+			Name:  "serve-fetch-limit",
+			Usage: "maximum sector transfers served to other workers in parallel, 0 for no limit",
+			Value: 0,
+		},
+		&cli.BoolFlag{
+			Name:  "compress-fetch",
+			Usage: "ask remotes to compress cache directory transfers with zstd",
+			Value: false,
+		},
 		&cli.StringFlag{
 			Name:  "miner-addr",
 			Usage: "miner address to connect",
@@ -265,7 +296,7 @@ var runCmd = &cli.Command{
 			return err
 		}
 
-		if cctx.Bool("commit") {
+		if cctx.Bool("commit") || cctx.Bool("windowpost") || cctx.Bool("winningpost") {
 			ps, err := asset.Asset("fixtures/_assets/proof-params/parameters.json")
 			if err != nil {
 				return err
@@ -298,6 +329,12 @@ var runCmd = &cli.Command{
 		if cctx.Bool("commit") {
 			taskTypes = append(taskTypes, types.TTCommit2)
 		}
+		if cctx.Bool("windowpost") {
+			taskTypes = append(taskTypes, types.TTGenerateWindowPoSt)
+		}
+		if cctx.Bool("winningpost") {
+			taskTypes = append(taskTypes, types.TTGenerateWinningPoSt)
+		}
 
 		if len(taskTypes) == 0 {
 			return xerrors.Errorf("no task types specified")
@@ -379,11 +416,22 @@ var runCmd = &cli.Command{
 		remote := stores.NewRemote(localStore, nodeApi, cfg.Sealer.AuthHeader(), cctx.Int("parallel-fetch-limit"),
 			&stores.DefaultPartialFileHandler{})
 
-		fh := &stores.FetchHandler{Local: localStore, PfHandler: &stores.DefaultPartialFileHandler{}}
+		hostBandwidth, err := units.RAMInBytes(cctx.String("fetch-host-bandwidth"))
+		if err != nil {
+			return xerrors.Errorf("parsing fetch-host-bandwidth: %w", err)
+		}
+		pathBandwidth, err := units.RAMInBytes(cctx.String("fetch-path-bandwidth"))
+		if err != nil {
+			return xerrors.Errorf("parsing fetch-path-bandwidth: %w", err)
+		}
+		remote.SetBandwidthLimits(hostBandwidth, pathBandwidth)
+		remote.SetCompression(cctx.Bool("compress-fetch"))
+
+		fh := &stores.FetchHandler{Local: localStore, PfHandler: &stores.DefaultPartialFileHandler{}, Check: sectorstorage.CheckSector, MaxServing: cctx.Int("serve-fetch-limit")}
 		remoteHandler := func(w http.ResponseWriter, r *http.Request) {
-			if !auth.HasPerm(r.Context(), nil, api.PermAdmin) {
+			if !auth.HasPerm(r.Context(), nil, api.PermWorker) {
 				w.WriteHeader(401)
-				_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing admin permission"})
+				_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing worker permission"})
 				return
 			}
 
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
 	"github.com/google/uuid"
 	"github.com/mitchellh/go-homedir"
 	"github.com/urfave/cli/v2"
@@ -45,6 +46,26 @@ var storageAttachCmd = &cli.Command{
 			Name:  "store",
 			Usage: "(for init) use path for long-term storage",
 		},
+		&cli.StringSliceFlag{
+			Name:  "groups",
+			Usage: "(for init) storage groups the path belongs to",
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow-to",
+			Usage: "(for init) groups sector files in the path may be moved to (any storage if not specified)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow-types",
+			Usage: "(for init) sector file types allocated in the path: unsealed, sealed, cache (all if not specified)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "deny-types",
+			Usage: "(for init) sector file types never allocated in the path",
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow-kinds",
+			Usage: "(for init) kinds of sectors allocated in the path: cc, deal (all if not specified)",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		workerApi, closer, err := api.GetWorkerAPI(cctx)
@@ -84,10 +105,20 @@ var storageAttachCmd = &cli.Command{
 				CanSeal:  cctx.Bool("seal"),
 				CanStore: cctx.Bool("store"),
 			}
+			cfg.Groups = cctx.StringSlice("groups")
+			cfg.AllowTo = cctx.StringSlice("allow-to")
+			cfg.AllowTypes = cctx.StringSlice("allow-types")
+			cfg.DenyTypes = cctx.StringSlice("deny-types")
+			for _, kind := range cctx.StringSlice("allow-kinds") {
+				cfg.AllowKinds = append(cfg.AllowKinds, storiface.SectorKind(kind))
+			}
 
 			if !(cfg.CanStore || cfg.CanSeal) {
 				return xerrors.Errorf("must specify at least one of --store or --seal")
 			}
+			if err := cfg.PlacementRules.Validate(); err != nil {
+				return err
+			}
 
 			b, err := json.MarshalIndent(cfg, "", "  ")
 			if err != nil {
@@ -26,6 +26,9 @@ var allowSetting = map[types.TaskType]struct{}{
 	types.TTPreCommit2: {},
 	types.TTCommit2:    {},
 	types.TTUnseal:     {},
+
+	types.TTGenerateWindowPoSt:  {},
+	types.TTGenerateWinningPoSt: {},
 }
 
 var settableStr = func() string {
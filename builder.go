@@ -47,6 +47,8 @@ const (
 
 	//proof
 	StartProofEventKey
+
+	StartConfigManagerKey
 	_nInvokes // keep this last
 )
 
@@ -118,11 +120,12 @@ func Online(cfg *config.StorageMiner) Option {
 
 		Override(new(types.SetSealingConfigFunc), NewSetSealConfigFunc),
 		Override(new(types.GetSealingConfigFunc), NewGetSealConfigFunc),
+		Override(new(config.GetFeeConfigFunc), NewGetFeeConfigFunc),
 
 		Override(new(api.Common), From(new(impl.CommonAPI))),
 		Override(new(sectorstorage.StorageAuth), StorageAuth),
 
-		Override(new(*stores.Index), stores.NewIndex),
+		Override(new(*stores.Index), SectorIndex),
 		Override(new(stores.SectorIndex), From(new(*stores.Index))),
 		Override(new(types.MinerID), MinerID),
 		Override(new(types.MinerAddress), MinerAddress),
@@ -140,13 +143,15 @@ func Online(cfg *config.StorageMiner) Option {
 		Override(new(storiface.WorkerReturn), From(new(sectorstorage.SectorManager))),
 
 		Override(new(*sectorblocks.SectorBlocks), sectorblocks.NewSectorBlocks),
-		Override(new(*storage.Miner), StorageMiner(config.DefaultMainnetStorageMiner().Fees)),
+		Override(new(*storage.Miner), StorageMiner()),
 		Override(new(*storage.AddressSelector), AddressSelector(nil)),
 		Override(new(types.NetworkName), StorageNetworkName),
 		Override(GetParamsKey, GetParams),
 		Override(AutoMigrateKey, models.AutoMigrate),
 		Override(SetNetParamsKey, SetupNetParams),
+		Override(new(*proof_client.ProofEventManager), proof_client.NewProofEventManager),
 		Override(StartProofEventKey, proof_client.StartProofEvent),
+		Override(StartConfigManagerKey, StartConfigManager),
 	)
 }
 
@@ -161,18 +166,21 @@ func Repo(cfg *config.StorageMiner) Option {
 			Override(new(*storage.AddressSelector), AddressSelector(&cfg.Addresses)),
 			Override(new(*config.DbConfig), &cfg.DB),
 			Override(new(*config.StorageMiner), cfg),
+			Override(new(*config.Manager), config.NewManager),
 			Override(new(*config.MessagerConfig), &cfg.Messager),
 			Override(new(*config.RegisterProofConfig), &cfg.RegisterProof),
 			ConfigAPI(cfg),
 
 			Override(new(api.IMessager), api.NewMessageRPC),
-			Override(new(*proof_client.ProofEventClient), proof_client.NewProofEventClient),
 			Override(new(repo.Repo), models.SetDataBase),
 			Providers(
 				service.NewDealRefServiceService,
 				service.NewLogService,
+				service.NewSectorEventService,
+				service.NewStorageIndexService,
 				service.NewMetadataService,
 				service.NewSectorInfoService,
+				service.NewTokenService,
 			//	service.NewWorkCallService,
 			//	service.NewWorkStateService,
 			),
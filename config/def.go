@@ -168,10 +168,11 @@ type SealingConfig struct {
 	TerminateBatchMin  uint64
 	TerminateBatchWait Duration
 
-	// Keep this many sectors in sealing pipeline, start CC if needed
-	// todo TargetSealingSectors uint64
+	// Keep this many sectors in sealing pipeline, CC sectors are pledged when workers are idle, 0 = disabled
+	TargetSealingSectors uint64
 
-	// todo TargetSectors - stop auto-pleding new sectors after this many sectors are sealed, default CC upgrade for deals sectors if above
+	// Stop auto-pledging new sectors once the sealer has this many sectors sealing or sealed, 0 = no limit
+	TargetSectors uint64
 }
 
 type BatchFeeConfig struct {
@@ -192,6 +193,7 @@ type MinerFeeConfig struct {
 	MaxCommitBatchGasFee    BatchFeeConfig
 
 	MaxTerminateGasFee     types.FIL
+	MaxExtendGasFee        types.FIL
 	MaxWindowPoStGasFee    types.FIL
 	MaxPublishDealsFee     types.FIL
 	MaxMarketBalanceAddFee types.FIL
@@ -102,6 +102,7 @@ func DefaultMainnetStorageMiner() *StorageMiner {
 			},
 
 			MaxTerminateGasFee:     types.MustParseFIL("0.5"),
+			MaxExtendGasFee:        types.MustParseFIL("0.5"),
 			MaxWindowPoStGasFee:    types.MustParseFIL("5"),
 			MaxPublishDealsFee:     types.MustParseFIL("0.05"),
 			MaxMarketBalanceAddFee: types.MustParseFIL("0.007"),
@@ -203,6 +204,7 @@ func DefaultNerpaStorageMiner() *StorageMiner {
 			},
 
 			MaxTerminateGasFee:     types.MustParseFIL("0.5"),
+			MaxExtendGasFee:        types.MustParseFIL("0.5"),
 			MaxWindowPoStGasFee:    types.MustParseFIL("5"),
 			MaxPublishDealsFee:     types.MustParseFIL("0.05"),
 			MaxMarketBalanceAddFee: types.MustParseFIL("0.007"),
@@ -305,6 +307,7 @@ func DefaultCalibrationStorageMiner() *StorageMiner {
 			},
 
 			MaxTerminateGasFee:     types.MustParseFIL("0.5"),
+			MaxExtendGasFee:        types.MustParseFIL("0.5"),
 			MaxWindowPoStGasFee:    types.MustParseFIL("5"),
 			MaxPublishDealsFee:     types.MustParseFIL("0.05"),
 			MaxMarketBalanceAddFee: types.MustParseFIL("0.007"),
@@ -408,6 +411,7 @@ func Default2kStorageMiner() *StorageMiner {
 			},
 
 			MaxTerminateGasFee:     types.MustParseFIL("0.5"),
+			MaxExtendGasFee:        types.MustParseFIL("0.5"),
 			MaxWindowPoStGasFee:    types.MustParseFIL("5"),
 			MaxPublishDealsFee:     types.MustParseFIL("0.05"),
 			MaxMarketBalanceAddFee: types.MustParseFIL("0.007"),
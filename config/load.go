@@ -105,8 +105,7 @@ func UpdateConfig(path string, cfg interface{}) error {
 
 // MinerFromReader loads config from a reader instance.
 func MinerFromReader(reader io.Reader) (*StorageMiner, error) {
-	cfg := DefaultMainnetStorageMiner()
-	_, err := toml.DecodeReader(reader, cfg)
+	cfg, err := minerFromReaderNoEnv(reader)
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +118,31 @@ func MinerFromReader(reader io.Reader) (*StorageMiner, error) {
 	return cfg, nil
 }
 
+// minerFromFileNoEnv loads the config file without the MINER_ env var overrides, to be written back
+func minerFromFileNoEnv(path string) (*StorageMiner, error) {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() // nolint:errcheck
+
+	return minerFromReaderNoEnv(file)
+}
+
+func minerFromReaderNoEnv(reader io.Reader) (*StorageMiner, error) {
+	cfg := DefaultMainnetStorageMiner()
+	_, err := toml.DecodeReader(reader, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 // WorkerFromReader loads config from a reader instance.
 func WorkerFromReader(reader io.Reader) (*StorageWorker, error) {
 	cfg := GetDefaultWorkerConfig()
@@ -0,0 +1,429 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fsnotify/fsnotify"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("config")
+
+// ReloadableSections are the sections of the miner config which are applied without restart
+var ReloadableSections = []string{"Sealing", "Fees", "Addresses", "Storage"}
+
+// keys of reloadable sections which still take effect after a restart only
+var restartKeys = []string{
+	"Storage.AllowAddPiece",
+	"Storage.AllowPreCommit1",
+	"Storage.AllowPreCommit2",
+	"Storage.AllowCommit",
+	"Storage.AllowUnseal",
+	"Storage.ResourceFiltering",
+	"Storage.ServeFetchLimit",
+}
+
+// delay between a change of the config file and its reload, editors write files in several steps
+var reloadDelay = time.Second
+
+// GetFeeConfigFunc returns the running fee config
+type GetFeeConfigFunc func() MinerFeeConfig
+
+// ConfigApplier applies the reloadable sections of cfg to a running component
+type ConfigApplier func(cfg *StorageMiner) error
+
+type namedApplier struct {
+	name  string
+	apply ConfigApplier
+}
+
+// Manager holds the running miner config. Changes of the config file are validated and the reloadable
+// sections are applied to the subscribed components, other sections need a restart.
+type Manager struct {
+	path string
+
+	lk  sync.RWMutex
+	cfg *StorageMiner
+
+	// serializes reloads
+	applyLk  sync.Mutex
+	appliers []namedApplier
+}
+
+func NewManager(cfg *StorageMiner) *Manager {
+	running := *cfg
+	return &Manager{
+		path: cfg.ConfigPath,
+		cfg:  &running,
+	}
+}
+
+// Get returns the running config
+func (m *Manager) Get() StorageMiner {
+	m.lk.RLock()
+	defer m.lk.RUnlock()
+	return *m.cfg
+}
+
+// Fees returns the running fee config
+func (m *Manager) Fees() MinerFeeConfig {
+	m.lk.RLock()
+	defer m.lk.RUnlock()
+	return m.cfg.Fees
+}
+
+// Subscribe registers apply to be called with the new config on every reload
+func (m *Manager) Subscribe(name string, apply ConfigApplier) {
+	m.applyLk.Lock()
+	defer m.applyLk.Unlock()
+	m.appliers = append(m.appliers, namedApplier{name: name, apply: apply})
+}
+
+// Reload reads the config file and applies its changes, it returns the changed keys
+func (m *Manager) Reload() ([]string, error) {
+	m.applyLk.Lock()
+	defer m.applyLk.Unlock()
+
+	next, err := MinerFromFile(m.path)
+	if err != nil {
+		return nil, xerrors.Errorf("loading config: %w", err)
+	}
+	changes, _, err := m.apply(next)
+	return changes, err
+}
+
+// Set sets key (eg. Fees.MaxPreCommitGasFee) to value in the config file and applies it
+func (m *Manager) Set(key, value string) ([]string, error) {
+	return m.Update(func(cfg *StorageMiner) error {
+		return SetKey(cfg, key, value)
+	})
+}
+
+// Update applies mutator to the config file, applies the result and writes the file once it is applied
+func (m *Manager) Update(mutator func(*StorageMiner) error) ([]string, error) {
+	m.applyLk.Lock()
+	defer m.applyLk.Unlock()
+
+	// the env var overrides are applied on top of the file but never written to it
+	file, err := minerFromFileNoEnv(m.path)
+	if err != nil {
+		return nil, xerrors.Errorf("loading config: %w", err)
+	}
+	if err := mutator(file); err != nil {
+		return nil, err
+	}
+	if err := file.Validate(); err != nil {
+		return nil, xerrors.Errorf("invalid config: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(file); err != nil {
+		return nil, xerrors.Errorf("encoding config: %w", err)
+	}
+	next, err := MinerFromReader(buf)
+	if err != nil {
+		return nil, xerrors.Errorf("loading config: %w", err)
+	}
+	next.ConfigPath = m.path
+
+	changes, undo, err := m.apply(next)
+	if err != nil {
+		return nil, err
+	}
+	if err := UpdateConfig(m.path, file); err != nil {
+		undo()
+		return nil, xerrors.Errorf("writing config: %w", err)
+	}
+	return changes, nil
+}
+
+// apply applies the reloadable sections of next, m.applyLk must be held. Appliers which already took next
+// get the running config back if a later one fails, undo does the same for all of them once apply succeeded.
+func (m *Manager) apply(next *StorageMiner) ([]string, func(), error) {
+	if err := next.Validate(); err != nil {
+		return nil, nil, xerrors.Errorf("invalid config: %w", err)
+	}
+
+	cur := m.Get()
+	changes, err := Diff(&cur, next)
+	if err != nil {
+		return nil, nil, err
+	}
+	// secrets are compared but never logged or returned
+	if err := redactChanges(changes, &cur, next); err != nil {
+		return nil, nil, err
+	}
+
+	applied := cur
+	applied.Sealing = next.Sealing
+	applied.Fees = next.Fees
+	applied.Addresses = next.Addresses
+	storage := next.Storage
+	storage.AllowAddPiece = cur.Storage.AllowAddPiece
+	storage.AllowPreCommit1 = cur.Storage.AllowPreCommit1
+	storage.AllowPreCommit2 = cur.Storage.AllowPreCommit2
+	storage.AllowCommit = cur.Storage.AllowCommit
+	storage.AllowUnseal = cur.Storage.AllowUnseal
+	storage.ResourceFiltering = cur.Storage.ResourceFiltering
+	storage.ServeFetchLimit = cur.Storage.ServeFetchLimit
+	applied.Storage = storage
+
+	var out []string
+	live := false
+	for _, change := range changes {
+		if reloadable(change.Key) {
+			live = true
+			log.Infof("config changed: %s", change)
+			out = append(out, change.String())
+		} else {
+			log.Warnf("config changed: %s, takes effect after restart", change)
+			out = append(out, change.String()+" (requires restart)")
+		}
+	}
+	if !live {
+		return out, func() {}, nil
+	}
+
+	revert := func(appliers []namedApplier) {
+		for i := len(appliers) - 1; i >= 0; i-- {
+			prev := cur
+			if err := appliers[i].apply(&prev); err != nil {
+				log.Errorf("reverting config of %s: %+v", appliers[i].name, err)
+			}
+		}
+	}
+
+	for i, a := range m.appliers {
+		if err := a.apply(&applied); err != nil {
+			revert(m.appliers[:i])
+			return nil, nil, xerrors.Errorf("applying config to %s: %w", a.name, err)
+		}
+	}
+
+	m.lk.Lock()
+	m.cfg = &applied
+	m.lk.Unlock()
+
+	undo := func() {
+		revert(m.appliers)
+		m.lk.Lock()
+		m.cfg = &cur
+		m.lk.Unlock()
+	}
+	return out, undo, nil
+}
+
+// redactChanges replaces the values of the changes with the ones of the Redact copies of the configs
+func redactChanges(changes []Change, a, b *StorageMiner) error {
+	ra, err := Flatten(a.Redact())
+	if err != nil {
+		return err
+	}
+	rb, err := Flatten(b.Redact())
+	if err != nil {
+		return err
+	}
+	for i := range changes {
+		changes[i].Old, changes[i].New = ra[changes[i].Key], rb[changes[i].Key]
+	}
+	return nil
+}
+
+func reloadable(key string) bool {
+	for _, k := range restartKeys {
+		if key == k {
+			return false
+		}
+	}
+	for _, section := range ReloadableSections {
+		if strings.HasPrefix(key, section+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch reloads the config when the config file changes until ctx is done
+func (m *Manager) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return xerrors.Errorf("creating watcher: %w", err)
+	}
+	// watch the directory, editors replace the file when saving
+	if err := watcher.Add(filepath.Dir(m.path)); err != nil {
+		_ = watcher.Close()
+		return xerrors.Errorf("watching %s: %w", m.path, err)
+	}
+
+	go func() {
+		defer watcher.Close() // nolint
+
+		var reload <-chan time.Time
+		for {
+			select {
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) == filepath.Clean(m.path) && ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					reload = time.After(reloadDelay)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Errorf("watching config file: %+v", err)
+			case <-reload:
+				reload = nil
+				if _, err := m.Reload(); err != nil {
+					log.Errorf("reloading config: %+v", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Change is a changed config key
+type Change struct {
+	Key      string
+	Old, New string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Key, c.Old, c.New)
+}
+
+// Diff returns the keys which differ between a and b
+func Diff(a, b interface{}) ([]Change, error) {
+	fa, err := Flatten(a)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := Flatten(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Change
+	for k, va := range fa {
+		if vb, ok := fb[k]; !ok || va != vb {
+			out = append(out, Change{Key: k, Old: va, New: vb})
+		}
+	}
+	for k, vb := range fb {
+		if _, ok := fa[k]; !ok {
+			out = append(out, Change{Key: k, New: vb})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Key < out[j].Key
+	})
+	return out, nil
+}
+
+// Flatten returns the toml values of cfg keyed by their dotted path
+func Flatten(cfg interface{}) (map[string]string, error) {
+	m, err := toMap(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]string{}
+	var walk func(prefix string, m map[string]interface{})
+	walk = func(prefix string, m map[string]interface{}) {
+		for k, v := range m {
+			if sub, ok := v.(map[string]interface{}); ok {
+				walk(prefix+k+".", sub)
+				continue
+			}
+			out[prefix+k] = fmt.Sprintf("%v", v)
+		}
+	}
+	walk("", m)
+	return out, nil
+}
+
+func toMap(cfg interface{}) (map[string]interface{}, error) {
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(cfg); err != nil {
+		return nil, xerrors.Errorf("encoding config: %w", err)
+	}
+	m := map[string]interface{}{}
+	if _, err := toml.Decode(buf.String(), &m); err != nil {
+		return nil, xerrors.Errorf("decoding config: %w", err)
+	}
+	return m, nil
+}
+
+// SetKey sets the dotted key of cfg to value, value is parsed as a toml value and used as a string if it
+// isn't one, eg. 10, true, "0.05 FIL", 0.05 FIL and ["f01234"] are all valid
+func SetKey(cfg *StorageMiner, key, value string) error {
+	m, err := toMap(cfg)
+	if err != nil {
+		return err
+	}
+
+	path := strings.Split(key, ".")
+	parent := m
+	for _, k := range path[:len(path)-1] {
+		sub, ok := parent[k].(map[string]interface{})
+		if !ok {
+			return xerrors.Errorf("unknown config section %s", key)
+		}
+		parent = sub
+	}
+
+	var parsed struct{ V interface{} }
+	if _, err := toml.Decode("V = "+value, &parsed); err != nil {
+		parsed.V = value
+	}
+
+	leaf := path[len(path)-1]
+	parent[leaf] = parsed.V
+	next, err := fromMap(m)
+	if err != nil {
+		if _, isString := parsed.V.(string); isString {
+			return xerrors.Errorf("setting %s: %w", key, err)
+		}
+		// eg. a number for a string field
+		parent[leaf] = value
+		if next, err = fromMap(m); err != nil {
+			return xerrors.Errorf("setting %s: %w", key, err)
+		}
+	}
+
+	next.ConfigPath = cfg.ConfigPath
+	*cfg = *next
+	return nil
+}
+
+func fromMap(m map[string]interface{}) (*StorageMiner, error) {
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(m); err != nil {
+		return nil, xerrors.Errorf("encoding config: %w", err)
+	}
+
+	// the map holds every key of the config, the defaults only initialize the FIL values
+	cfg := DefaultMainnetStorageMiner()
+	md, err := toml.Decode(buf.String(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, xerrors.Errorf("unknown config key %s", undecoded[0])
+	}
+	return cfg, nil
+}
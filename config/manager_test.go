@@ -0,0 +1,125 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func TestSetKey(t *testing.T) {
+	c := DefaultMainnetStorageMiner()
+
+	require.NoError(t, SetKey(c, "Sealing.MaxSealingSectors", "12"))
+	require.Equal(t, uint64(12), c.Sealing.MaxSealingSectors)
+
+	require.NoError(t, SetKey(c, "Fees.MaxPreCommitGasFee", "0.05 FIL"))
+	require.Equal(t, "0.05 FIL", c.Fees.MaxPreCommitGasFee.String())
+
+	require.NoError(t, SetKey(c, "Addresses.CommitControl", `["f01234"]`))
+	require.Equal(t, []string{"f01234"}, c.Addresses.CommitControl)
+
+	require.Error(t, SetKey(c, "Sealing.NoSuchKey", "1"))
+	require.Error(t, SetKey(c, "NoSuchSection.Key", "1"))
+	require.Error(t, SetKey(c, "Sealing.MaxSealingSectors", "many"))
+}
+
+func TestManagerReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestManagerReload")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint
+
+	c := DefaultMainnetStorageMiner()
+	c.ConfigPath = filepath.Join(dir, "config.toml")
+	require.NoError(t, SaveConfig(c.ConfigPath, c))
+
+	m := NewManager(c)
+	var applied *StorageMiner
+	m.Subscribe("test", func(cfg *StorageMiner) error {
+		applied = cfg
+		return nil
+	})
+
+	changes, err := m.Set("Sealing.MaxSealingSectors", "12")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Sealing.MaxSealingSectors: 0 -> 12"}, changes)
+	require.Equal(t, uint64(12), applied.Sealing.MaxSealingSectors)
+	require.Equal(t, uint64(12), m.Get().Sealing.MaxSealingSectors)
+
+	// restart-only keys are saved but not applied
+	changes, err = m.Set("Storage.AllowUnseal", "false")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Storage.AllowUnseal: true -> false (requires restart)"}, changes)
+	require.True(t, m.Get().Storage.AllowUnseal)
+
+	// invalid configs are rejected and leave the file untouched
+	_, err = m.Set("Addresses.CommitControl", `["not an address"]`)
+	require.Error(t, err)
+
+	saved, err := MinerFromFile(c.ConfigPath)
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), saved.Sealing.MaxSealingSectors)
+	require.Empty(t, saved.Addresses.CommitControl)
+
+	changes, err = m.Reload()
+	require.NoError(t, err)
+	require.Equal(t, []string{"Storage.AllowUnseal: true -> false (requires restart)"}, changes)
+}
+
+func TestManagerUpdateRollback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestManagerUpdateRollback")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint
+
+	c := DefaultMainnetStorageMiner()
+	c.ConfigPath = filepath.Join(dir, "config.toml")
+	c.Node.Token = "node-token"
+	require.NoError(t, SaveConfig(c.ConfigPath, c))
+
+	// env overrides are applied but never written to the file
+	require.NoError(t, os.Setenv("MINER_SEALING_MAXWAITDEALSSECTORS", "7"))
+	defer os.Unsetenv("MINER_SEALING_MAXWAITDEALSSECTORS") // nolint
+
+	m := NewManager(c)
+	var applied []uint64
+	m.Subscribe("first", func(cfg *StorageMiner) error {
+		applied = append(applied, cfg.Sealing.MaxSealingSectors)
+		return nil
+	})
+	failing := false
+	m.Subscribe("second", func(cfg *StorageMiner) error {
+		if failing && cfg.Sealing.MaxSealingSectors == 20 {
+			return xerrors.New("refused")
+		}
+		return nil
+	})
+
+	_, err = m.Set("Sealing.MaxSealingSectors", "12")
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), m.Get().Sealing.MaxWaitDealsSectors)
+
+	saved, err := minerFromFileNoEnv(c.ConfigPath)
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), saved.Sealing.MaxSealingSectors)
+	require.Equal(t, c.Sealing.MaxWaitDealsSectors, saved.Sealing.MaxWaitDealsSectors)
+
+	// a failing applier reverts the ones applied before it, the running config and the file keep the old value
+	failing = true
+	applied = nil
+	_, err = m.Set("Sealing.MaxSealingSectors", "20")
+	require.Error(t, err)
+	require.Equal(t, []uint64{20, 12}, applied)
+	require.Equal(t, uint64(12), m.Get().Sealing.MaxSealingSectors)
+
+	saved, err = minerFromFileNoEnv(c.ConfigPath)
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), saved.Sealing.MaxSealingSectors)
+
+	// secrets are redacted in the returned changes
+	changes, err := m.Set("Node.Token", "other-token")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Node.Token: " + Redacted + " -> " + Redacted + " (requires restart)"}, changes)
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/mitchellh/go-homedir"
+	"github.com/multiformats/go-multiaddr"
+	"go.uber.org/multierr"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+// Redacted is shown in place of secrets
+const Redacted = "<redacted>"
+
+// Validate checks the values which can't be checked by decoding, it reports every invalid value
+func (cfg *StorageMiner) Validate() error {
+	var errs error
+	fail := func(format string, args ...interface{}) {
+		errs = multierr.Append(errs, xerrors.Errorf(format, args...))
+	}
+
+	if _, err := multiaddr.NewMultiaddr(cfg.API.ListenAddress); err != nil {
+		fail("API.ListenAddress: %w", err)
+	}
+	if err := validateAPIURL(cfg.Node.Url); err != nil {
+		fail("Node.Url: %w", err)
+	}
+	if cfg.Messager.Url != "" {
+		if err := validateAPIURL(cfg.Messager.Url); err != nil {
+			fail("Messager.Url: %w", err)
+		}
+	}
+	for _, u := range cfg.RegisterProof.Urls {
+		if err := validateAPIURL(u); err != nil {
+			fail("RegisterProof.Urls: %w", err)
+		}
+	}
+
+	for _, d := range []struct {
+		name string
+		d    Duration
+	}{
+		{"API.Timeout", cfg.API.Timeout},
+		{"Sealing.WaitDealsDelay", cfg.Sealing.WaitDealsDelay},
+		{"Sealing.PreCommitBatchWait", cfg.Sealing.PreCommitBatchWait},
+		{"Sealing.PreCommitBatchSlack", cfg.Sealing.PreCommitBatchSlack},
+		{"Sealing.CommitBatchWait", cfg.Sealing.CommitBatchWait},
+		{"Sealing.CommitBatchSlack", cfg.Sealing.CommitBatchSlack},
+		{"Sealing.TerminateBatchWait", cfg.Sealing.TerminateBatchWait},
+	} {
+		if d.d < 0 {
+			fail("%s: negative duration %s", d.name, time.Duration(d.d))
+		}
+	}
+
+	for _, f := range []struct {
+		name string
+		fil  types.FIL
+	}{
+		{"Sealing.AggregateAboveBaseFee", cfg.Sealing.AggregateAboveBaseFee},
+		{"Fees.MaxPreCommitGasFee", cfg.Fees.MaxPreCommitGasFee},
+		{"Fees.MaxCommitGasFee", cfg.Fees.MaxCommitGasFee},
+		{"Fees.MaxPreCommitBatchGasFee.Base", cfg.Fees.MaxPreCommitBatchGasFee.Base},
+		{"Fees.MaxPreCommitBatchGasFee.PerSector", cfg.Fees.MaxPreCommitBatchGasFee.PerSector},
+		{"Fees.MaxCommitBatchGasFee.Base", cfg.Fees.MaxCommitBatchGasFee.Base},
+		{"Fees.MaxCommitBatchGasFee.PerSector", cfg.Fees.MaxCommitBatchGasFee.PerSector},
+		{"Fees.MaxTerminateGasFee", cfg.Fees.MaxTerminateGasFee},
+		{"Fees.MaxExtendGasFee", cfg.Fees.MaxExtendGasFee},
+		{"Fees.MaxWindowPoStGasFee", cfg.Fees.MaxWindowPoStGasFee},
+		{"Fees.MaxPublishDealsFee", cfg.Fees.MaxPublishDealsFee},
+		{"Fees.MaxMarketBalanceAddFee", cfg.Fees.MaxMarketBalanceAddFee},
+	} {
+		if v := big.Int(f.fil); v.Int != nil && v.Sign() < 0 {
+			fail("%s: negative amount %s", f.name, f.fil)
+		}
+	}
+
+	for _, list := range []struct {
+		name  string
+		addrs []string
+	}{
+		{"PreCommitControl", cfg.Addresses.PreCommitControl},
+		{"CommitControl", cfg.Addresses.CommitControl},
+		{"TerminateControl", cfg.Addresses.TerminateControl},
+	} {
+		for _, s := range list.addrs {
+			if _, err := address.NewFromString(s); err != nil {
+				fail("Addresses.%s: parsing %s: %w", list.name, s, err)
+			}
+		}
+	}
+
+	if cfg.Sealing.MaxCommitBatch > 0 && cfg.Sealing.MinCommitBatch > cfg.Sealing.MaxCommitBatch {
+		fail("Sealing.MinCommitBatch %d is above MaxCommitBatch %d", cfg.Sealing.MinCommitBatch, cfg.Sealing.MaxCommitBatch)
+	}
+	if cfg.Sealing.TerminateBatchMax > 0 && cfg.Sealing.TerminateBatchMin > cfg.Sealing.TerminateBatchMax {
+		fail("Sealing.TerminateBatchMin %d is above TerminateBatchMax %d", cfg.Sealing.TerminateBatchMin, cfg.Sealing.TerminateBatchMax)
+	}
+
+	if cfg.Storage.ParallelFetchLimit <= 0 {
+		fail("Storage.ParallelFetchLimit must be positive")
+	}
+	if cfg.Storage.FetchHostBandwidth < 0 || cfg.Storage.FetchPathBandwidth < 0 {
+		fail("Storage fetch bandwidth can't be negative")
+	}
+	if err := cfg.Storage.Scheduler.Validate(); err != nil {
+		fail("Storage.Scheduler: %w", err)
+	}
+
+	switch cfg.DB.Type {
+	case "sqlite", "mysql":
+	default:
+		fail("DB.Type: unknown database type '%s'", cfg.DB.Type)
+	}
+
+	return errs
+}
+
+// validateAPIURL checks an API address, either a multiaddr or a http(s)/ws(s) url
+func validateAPIURL(s string) error {
+	if strings.HasPrefix(s, "/") {
+		_, err := multiaddr.NewMultiaddr(s)
+		return err
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "http", "https", "ws", "wss":
+	default:
+		return xerrors.Errorf("'%s' is neither a multiaddr nor a http or ws url", s)
+	}
+	if u.Host == "" {
+		return xerrors.Errorf("'%s' has no host", s)
+	}
+	return nil
+}
+
+// Redact returns a copy of cfg with the tokens, secrets and passwords replaced by Redacted
+func (cfg StorageMiner) Redact() *StorageMiner {
+	redact := func(s *string) {
+		if *s != "" {
+			*s = Redacted
+		}
+	}
+	redact(&cfg.JWT.Secret)
+	redact(&cfg.Node.Token)
+	redact(&cfg.Messager.Token)
+	redact(&cfg.RegisterProof.Token)
+	redact(&cfg.DB.MySql.Pass)
+	return &cfg
+}
+
+// CheckMinerFile loads and validates the config file at path, unlike MinerFromFile it fails on unknown keys
+func CheckMinerFile(path string) (*StorageMiner, error) {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := DefaultMainnetStorageMiner()
+	md, err := toml.Decode(string(b), cfg)
+	if err != nil {
+		return nil, err
+	}
+	var errs error
+	for _, key := range md.Undecoded() {
+		errs = multierr.Append(errs, xerrors.Errorf("unknown config key %s", key))
+	}
+	if err := envconfig.Process("MINER", cfg); err != nil {
+		errs = multierr.Append(errs, xerrors.Errorf("processing env vars overrides: %w", err))
+	}
+	return cfg, multierr.Append(errs, cfg.Validate())
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+func TestValidate(t *testing.T) {
+	for _, network := range []string{"mainnet", "calibration", "2k", "nerpa"} {
+		c, err := GetDefaultStorageConfig(network)
+		require.NoError(t, err)
+		require.NoError(t, c.Validate(), network)
+	}
+
+	c := DefaultMainnetStorageMiner()
+	c.API.ListenAddress = "127.0.0.1:2345"
+	c.Node.Url = "http://"
+	c.Sealing.CommitBatchWait = Duration(-1)
+	c.Fees.MaxCommitGasFee = types.MustParseFIL("-1")
+	c.Addresses.CommitControl = []string{"f0abc"}
+	require.Len(t, multierr.Errors(c.Validate()), 5)
+
+	c = DefaultMainnetStorageMiner()
+	c.Node.Url = "ws://127.0.0.1:3453/rpc/v0"
+	c.RegisterProof.Urls = []string{"/ip4/127.0.0.1/tcp/45132"}
+	require.NoError(t, c.Validate())
+}
+
+func TestRedact(t *testing.T) {
+	c := DefaultMainnetStorageMiner()
+	c.Messager.Token = "token"
+
+	r := c.Redact()
+	require.Equal(t, Redacted, r.JWT.Secret)
+	require.Equal(t, Redacted, r.Node.Token)
+	require.Equal(t, Redacted, r.Messager.Token)
+	require.Equal(t, "", r.RegisterProof.Token)
+	require.Equal(t, "token", c.Messager.Token)
+}
+
+func TestCheckMinerFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestCheckMinerFile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint
+
+	path := filepath.Join(dir, "config.toml")
+	require.NoError(t, SaveConfig(path, DefaultMainnetStorageMiner()))
+	_, err = CheckMinerFile(path)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("[Sealing]\nMaxSealingSector = 1\n"), 0644))
+	_, err = CheckMinerFile(path)
+	require.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"net/http"
+
+	"contrib.go.opencensus.io/exporter/prometheus"
+	logging "github.com/ipfs/go-log/v2"
+	promclient "github.com/prometheus/client_golang/prometheus"
+)
+
+var log = logging.Logger("metrics")
+
+// Exporter returns the handler serving the registered views in the prometheus format
+func Exporter() http.Handler {
+	// the prometheus globals are interfaces but the exporter needs the *Registry behind them
+	registry, ok := promclient.DefaultRegisterer.(*promclient.Registry)
+	if !ok {
+		log.Warnf("failed to export default prometheus registry; some metrics will be unavailable; unexpected type: %T", promclient.DefaultRegisterer)
+	}
+	exporter, err := prometheus.NewExporter(prometheus.Options{
+		Registry:  registry,
+		Namespace: "venus_sealer",
+	})
+	if err != nil {
+		log.Errorf("could not create the prometheus stats exporter: %v", err)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "prometheus stats exporter unavailable", http.StatusInternalServerError)
+		})
+	}
+
+	return exporter
+}
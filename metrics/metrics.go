@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// Distribution
+var defaultMillisecondsDistribution = view.Distribution(100, 250, 500, 1000, 2000, 3000, 5000, 10000, 15000, 20000, 30000, 60000)
+
+// Tags
+var (
+	Gateway, _ = tag.NewKey("gateway")
+)
+
+// Measures
+var (
+	WinningPoStServed   = stats.Int64("winningpost/served", "Counter for winning PoSt requests served to gateways", stats.UnitDimensionless)
+	WinningPoStFailed   = stats.Int64("winningpost/failed", "Counter for winning PoSt requests which failed", stats.UnitDimensionless)
+	WinningPoStDuration = stats.Float64("winningpost/duration_ms", "Duration of computing winning PoSt for gateways", stats.UnitMilliseconds)
+)
+
+var (
+	WinningPoStServedView = &view.View{
+		Measure:     WinningPoStServed,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{Gateway},
+	}
+	WinningPoStFailedView = &view.View{
+		Measure:     WinningPoStFailed,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{Gateway},
+	}
+	WinningPoStDurationView = &view.View{
+		Measure:     WinningPoStDuration,
+		Aggregation: defaultMillisecondsDistribution,
+		TagKeys:     []tag.Key{Gateway},
+	}
+)
+
+// DefaultViews is the set of views registered by the sealer daemon
+var DefaultViews = []*view.View{
+	WinningPoStServedView,
+	WinningPoStFailedView,
+	WinningPoStDurationView,
+}
+
+// SinceInMilliseconds returns the duration of time since the provide time as a float64.
+func SinceInMilliseconds(startTime time.Time) float64 {
+	return float64(time.Since(startTime).Nanoseconds()) / 1e6
+}
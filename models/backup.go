@@ -0,0 +1,356 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+)
+
+// keys of the rows DumpRepo writes, every row is a json value
+const (
+	backupMetadataKey       = "/db/metadata"
+	backupDealPolicyKey     = "/db/dealpolicy"
+	backupSectorPrefix      = "/db/sectors"
+	backupLogPrefix         = "/db/logs"
+	backupDealRefPrefix     = "/db/dealrefs"
+	backupWorkerCallPrefix  = "/db/workercalls"
+	backupWorkStatePrefix   = "/db/workerstates"
+	backupTokenPrefix       = "/db/tokens"
+	backupEventPrefix       = "/db/sectorevents"
+	backupStoragePrefix     = "/db/storages"
+	backupStorageDeclPrefix = "/db/storagedecls"
+
+	// BackupFilesPrefix is the prefix of repo files (config, token ...) in a backup
+	BackupFilesPrefix = "/files"
+)
+
+// BackupFileKey is the key of a repo file in a backup
+func BackupFileKey(name string) datastore.Key {
+	return datastore.NewKey(BackupFilesPrefix).ChildString(name)
+}
+
+// rows are numbered so that LoadRepo restores them in the same order
+func seqKey(prefix string, seq int) datastore.Key {
+	return datastore.NewKey(prefix).ChildString(fmt.Sprintf("%020d", seq))
+}
+
+func putJSON(ds datastore.Datastore, key datastore.Key, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return xerrors.Errorf("marshal %s: %w", key, err)
+	}
+	return ds.Put(key, data)
+}
+
+// DumpRepo writes every table of r into ds
+func DumpRepo(r repo.Repo, ds datastore.Datastore) error {
+	// metadata
+	mAddr, err := r.MetaDataRepo().GetMinerAddress()
+	switch {
+	case err == nil:
+		count, err := r.MetaDataRepo().GetStorageCounter()
+		if err != nil {
+			return xerrors.Errorf("read sector counter: %w", err)
+		}
+		if err := putJSON(ds, datastore.NewKey(backupMetadataKey), metadataRow{MinerAddress: mAddr.String(), SectorCount: count}); err != nil {
+			return err
+		}
+	case xerrors.Is(err, gorm.ErrRecordNotFound):
+	default:
+		return xerrors.Errorf("read miner address: %w", err)
+	}
+
+	// deal policy
+	policy, err := r.MetaDataRepo().GetDealPolicy()
+	switch {
+	case err == nil:
+		if err := putJSON(ds, datastore.NewKey(backupDealPolicyKey), policy); err != nil {
+			return err
+		}
+	case xerrors.Is(err, gorm.ErrRecordNotFound):
+	default:
+		return xerrors.Errorf("read deal policy: %w", err)
+	}
+
+	// sectors
+	sectors, err := r.SectorInfoRepo().GetAllSectorInfos()
+	if err != nil {
+		return xerrors.Errorf("read sectors: %w", err)
+	}
+	for _, sector := range sectors {
+		if err := putJSON(ds, seqKey(backupSectorPrefix, int(sector.SectorNumber)), sector); err != nil {
+			return err
+		}
+	}
+
+	// logs
+	logs, err := r.LogRepo().ListAll()
+	if err != nil {
+		return xerrors.Errorf("read logs: %w", err)
+	}
+	for index, l := range logs {
+		if err := putJSON(ds, seqKey(backupLogPrefix, index), l); err != nil {
+			return err
+		}
+	}
+
+	// deal refs
+	dealRefs, err := r.DealRefRepo().ListAll()
+	if err != nil {
+		return xerrors.Errorf("read deal refs: %w", err)
+	}
+	for index, ref := range dealRefs {
+		if err := putJSON(ds, seqKey(backupDealRefPrefix, index), ref); err != nil {
+			return err
+		}
+	}
+
+	// worker calls
+	seq := 0
+	for _, role := range workerCallRoles {
+		calls, err := r.WorkerCallRepo().GetAllCall(role)
+		if err != nil {
+			return xerrors.Errorf("read %s worker calls: %w", role, err)
+		}
+		for _, call := range calls {
+			if err := putJSON(ds, seqKey(backupWorkerCallPrefix, seq), newWorkerCallRow(role, call)); err != nil {
+				return err
+			}
+			seq++
+		}
+	}
+
+	// worker states
+	states, err := r.WorkerStateRepo().GetAllWorkState()
+	if err != nil {
+		return xerrors.Errorf("read worker states: %w", err)
+	}
+	for index, state := range states {
+		if err := putJSON(ds, seqKey(backupWorkStatePrefix, index), state); err != nil {
+			return err
+		}
+	}
+
+	// api tokens
+	tokens, err := r.TokenRepo().List()
+	if err != nil {
+		return xerrors.Errorf("read api tokens: %w", err)
+	}
+	for index, token := range tokens {
+		if err := putJSON(ds, seqKey(backupTokenPrefix, index), token); err != nil {
+			return err
+		}
+	}
+
+	// sector events
+	events, err := r.SectorEventRepo().Query(&types.SectorEventFilter{})
+	if err != nil {
+		return xerrors.Errorf("read sector events: %w", err)
+	}
+	for index, event := range events {
+		if err := putJSON(ds, seqKey(backupEventPrefix, index), event); err != nil {
+			return err
+		}
+	}
+
+	// storage index
+	storages, err := r.StorageIndexRepo().ListStorages()
+	if err != nil {
+		return xerrors.Errorf("read storages: %w", err)
+	}
+	for index, storage := range storages {
+		if err := putJSON(ds, seqKey(backupStoragePrefix, index), storage); err != nil {
+			return err
+		}
+	}
+
+	decls, err := r.StorageIndexRepo().ListSectorDecls()
+	if err != nil {
+		return xerrors.Errorf("read storage sector decls: %w", err)
+	}
+	for index, decl := range decls {
+		if err := putJSON(ds, seqKey(backupStorageDeclPrefix, index), decl); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadRows calls cb with the value of every row under prefix, in key order
+func loadRows(ds datastore.Datastore, prefix string, cb func(data []byte) error) error {
+	qr, err := ds.Query(query.Query{Prefix: prefix})
+	if err != nil {
+		return xerrors.Errorf("query %s: %w", prefix, err)
+	}
+	entries, err := qr.Rest()
+	if err != nil {
+		return xerrors.Errorf("query %s: %w", prefix, err)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	for _, entry := range entries {
+		if err := cb(entry.Value); err != nil {
+			return xerrors.Errorf("load %s: %w", entry.Key, err)
+		}
+	}
+	return nil
+}
+
+// LoadRepo writes rows dumped by DumpRepo into r, r must be empty. The rows are written in a single transaction,
+// a failed load leaves r empty
+func LoadRepo(ds datastore.Datastore, r repo.Repo) error {
+	return r.Transaction(func(tx repo.Repo) error {
+		if err := ensureEmpty(tx); err != nil {
+			return err
+		}
+		return loadTables(ds, tx)
+	})
+}
+
+func loadTables(ds datastore.Datastore, r repo.Repo) error {
+	// metadata
+	data, err := ds.Get(datastore.NewKey(backupMetadataKey))
+	switch {
+	case err == nil:
+		var meta metadataRow
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return xerrors.Errorf("unmarshal metadata: %w", err)
+		}
+		mAddr, err := address.NewFromString(meta.MinerAddress)
+		if err != nil {
+			return xerrors.Errorf("parse miner address: %w", err)
+		}
+		if err := r.MetaDataRepo().SaveMinerAddress(mAddr); err != nil {
+			return xerrors.Errorf("save miner address: %w", err)
+		}
+		if err := r.MetaDataRepo().SetStorageCounter(meta.SectorCount); err != nil {
+			return xerrors.Errorf("save sector counter: %w", err)
+		}
+	case xerrors.Is(err, datastore.ErrNotFound):
+	default:
+		return xerrors.Errorf("read metadata: %w", err)
+	}
+
+	// deal policy
+	data, err = ds.Get(datastore.NewKey(backupDealPolicyKey))
+	switch {
+	case err == nil:
+		var policy types.DealPolicy
+		if err := json.Unmarshal(data, &policy); err != nil {
+			return xerrors.Errorf("unmarshal deal policy: %w", err)
+		}
+		if err := r.MetaDataRepo().SaveDealPolicy(&policy); err != nil {
+			return xerrors.Errorf("save deal policy: %w", err)
+		}
+	case xerrors.Is(err, datastore.ErrNotFound):
+	default:
+		return xerrors.Errorf("read deal policy: %w", err)
+	}
+
+	err = loadRows(ds, backupSectorPrefix, func(data []byte) error {
+		var sector types.SectorInfo
+		if err := json.Unmarshal(data, &sector); err != nil {
+			return err
+		}
+		return r.SectorInfoRepo().Save(&sector)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = loadRows(ds, backupLogPrefix, func(data []byte) error {
+		var l types.Log
+		if err := json.Unmarshal(data, &l); err != nil {
+			return err
+		}
+		return r.LogRepo().Append(&l)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = loadRows(ds, backupDealRefPrefix, func(data []byte) error {
+		var ref types.PieceRef
+		if err := json.Unmarshal(data, &ref); err != nil {
+			return err
+		}
+		return r.DealRefRepo().SaveRef(&ref)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = loadRows(ds, backupWorkerCallPrefix, func(data []byte) error {
+		var row workerCallRow
+		if err := json.Unmarshal(data, &row); err != nil {
+			return err
+		}
+		return r.WorkerCallRepo().Save(row.Role, row.ID, row.Call())
+	})
+	if err != nil {
+		return err
+	}
+
+	err = loadRows(ds, backupWorkStatePrefix, func(data []byte) error {
+		var state types.WorkState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return err
+		}
+		return r.WorkerStateRepo().Save(state.ID, &state)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = loadRows(ds, backupTokenPrefix, func(data []byte) error {
+		var token types.TokenInfo
+		if err := json.Unmarshal(data, &token); err != nil {
+			return err
+		}
+		return r.TokenRepo().Save(&token)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = loadRows(ds, backupEventPrefix, func(data []byte) error {
+		var event types.SectorEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		return r.SectorEventRepo().Append(&event)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = loadRows(ds, backupStoragePrefix, func(data []byte) error {
+		var storage types.StorageRecord
+		if err := json.Unmarshal(data, &storage); err != nil {
+			return err
+		}
+		return r.StorageIndexRepo().SaveStorage(&storage)
+	})
+	if err != nil {
+		return err
+	}
+
+	return loadRows(ds, backupStorageDeclPrefix, func(data []byte) error {
+		var decl types.SectorDeclRecord
+		if err := json.Unmarshal(data, &decl); err != nil {
+			return err
+		}
+		return r.StorageIndexRepo().DeclareSector(&decl)
+	})
+}
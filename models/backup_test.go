@@ -0,0 +1,56 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus-sealer/lib/backupds"
+)
+
+func TestBackupRestore(t *testing.T) {
+	from := openTestRepo(t, "from.db")
+	to := openTestRepo(t, "to.db")
+	fillTestRepo(t, from)
+
+	mds := dssync.MutexWrap(datastore.NewMapDatastore())
+	require.NoError(t, DumpRepo(from, mds))
+
+	var buf bytes.Buffer
+	require.NoError(t, backupds.Wrap(mds).Backup(&buf))
+
+	// a corrupted backup must be rejected before anything is loaded
+	corrupted := append([]byte{}, buf.Bytes()...)
+	corrupted[len(corrupted)-1] ^= 0xff
+	require.Error(t, backupds.RestoreInto(bytes.NewReader(corrupted), dssync.MutexWrap(datastore.NewMapDatastore())))
+
+	restored := dssync.MutexWrap(datastore.NewMapDatastore())
+	require.NoError(t, backupds.RestoreInto(&buf, restored))
+	require.NoError(t, LoadRepo(restored, to))
+
+	fromSummaries, err := Summarize(from)
+	require.NoError(t, err)
+	toSummaries, err := Summarize(to)
+	require.NoError(t, err)
+	require.Equal(t, fromSummaries, toSummaries)
+
+	// destination is not empty anymore
+	require.Error(t, LoadRepo(restored, to))
+}
+
+func TestLoadRepoFailed(t *testing.T) {
+	from := openTestRepo(t, "from.db")
+	to := openTestRepo(t, "to.db")
+	fillTestRepo(t, from)
+
+	mds := dssync.MutexWrap(datastore.NewMapDatastore())
+	require.NoError(t, DumpRepo(from, mds))
+
+	// sectors and logs are loaded before the broken deal ref
+	require.NoError(t, mds.Put(seqKey(backupDealRefPrefix, 0), []byte("{")))
+	require.Error(t, LoadRepo(mds, to))
+	require.NoError(t, ensureEmpty(to))
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/models/mysql"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/models/sqlite"
+	"golang.org/x/xerrors"
+)
+
+// ParseDSN converts a dsn into a db config, either sqlite:<path to db file> or mysql://<user>:<pass>@<host:port>/<db name>
+func ParseDSN(dsn string) (*config.DbConfig, error) {
+	switch {
+	case strings.HasPrefix(dsn, "sqlite:"):
+		path := strings.TrimPrefix(strings.TrimPrefix(dsn, "sqlite:"), "//")
+		if len(path) == 0 {
+			return nil, xerrors.Errorf("sqlite dsn %s has no path", dsn)
+		}
+		return &config.DbConfig{Type: "sqlite", Sqlite: config.SqliteConfig{Path: path}}, nil
+	case strings.HasPrefix(dsn, "mysql://"):
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return nil, xerrors.Errorf("parse mysql dsn: %w", err)
+		}
+		name := strings.TrimPrefix(u.Path, "/")
+		if len(u.Host) == 0 || len(name) == 0 {
+			return nil, xerrors.Errorf("mysql dsn must be mysql://<user>:<pass>@<host:port>/<db name>")
+		}
+		pass, _ := u.User.Password()
+		return &config.DbConfig{Type: "mysql", MySql: config.MySqlConfig{
+			Addr: u.Host,
+			User: u.User.Username(),
+			Pass: pass,
+			Name: name,
+		}}, nil
+	default:
+		return nil, xerrors.Errorf("unsupport dsn %s, expect sqlite:<path> or mysql://<user>:<pass>@<host:port>/<db name>", dsn)
+	}
+}
+
+// OpenDSN opens a repo from dsn without touching its schema
+func OpenDSN(dsn string) (repo.Repo, error) {
+	cfg, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Type {
+	case "sqlite":
+		return sqlite.OpenSqlite(&cfg.Sqlite)
+	default:
+		return mysql.OpenMysql(&cfg.MySql)
+	}
+}
@@ -0,0 +1,420 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+)
+
+// roles of worker calls, see service.NewWorkCallService
+var workerCallRoles = []string{"sealer", "worker"}
+
+type metadataRow struct {
+	MinerAddress string
+	SectorCount  uint64
+}
+
+// types.ManyBytes has no json encoding, keep the result as raw bytes
+type workerCallRow struct {
+	Role    string
+	ID      types.CallID
+	RetType types.ReturnType
+	State   types.CallState
+	Result  []byte
+}
+
+func newWorkerCallRow(role string, call *types.Call) workerCallRow {
+	return workerCallRow{
+		Role:    role,
+		ID:      call.ID,
+		RetType: call.RetType,
+		State:   call.State,
+		Result:  call.Result.Bytes(),
+	}
+}
+
+func (row workerCallRow) Call() *types.Call {
+	return &types.Call{
+		ID:      row.ID,
+		RetType: row.RetType,
+		State:   row.State,
+		Result:  types.NewManyBytes(row.Result),
+	}
+}
+
+// TableSummary is the row count and content checksum of one table
+type TableSummary struct {
+	Table    string
+	Rows     int
+	Checksum string
+}
+
+// Summarize reads every table through the repo interfaces and computes row counts and checksums.
+// rows are sorted before hashing, so repos with the same content have the same summary whatever the backend.
+func Summarize(r repo.Repo) ([]TableSummary, error) {
+	var summaries []TableSummary
+	add := func(table string, rows int, content interface{}) error {
+		data, err := json.Marshal(content)
+		if err != nil {
+			return xerrors.Errorf("marshal %s: %w", table, err)
+		}
+		hash := sha256.Sum256(data)
+		summaries = append(summaries, TableSummary{Table: table, Rows: rows, Checksum: hex.EncodeToString(hash[:])})
+		return nil
+	}
+
+	// metadata
+	var metas []metadataRow
+	mAddr, err := r.MetaDataRepo().GetMinerAddress()
+	switch {
+	case err == nil:
+		count, err := r.MetaDataRepo().GetStorageCounter()
+		if err != nil {
+			return nil, xerrors.Errorf("read sector counter: %w", err)
+		}
+		metas = append(metas, metadataRow{MinerAddress: mAddr.String(), SectorCount: count})
+	case xerrors.Is(err, gorm.ErrRecordNotFound):
+	default:
+		return nil, xerrors.Errorf("read miner address: %w", err)
+	}
+	if err := add("metadata", len(metas), metas); err != nil {
+		return nil, err
+	}
+
+	// deal policy
+	var policies []*types.DealPolicy
+	policy, err := r.MetaDataRepo().GetDealPolicy()
+	switch {
+	case err == nil:
+		policies = append(policies, policy)
+	case xerrors.Is(err, gorm.ErrRecordNotFound):
+	default:
+		return nil, xerrors.Errorf("read deal policy: %w", err)
+	}
+	if err := add("deal_policy", len(policies), policies); err != nil {
+		return nil, err
+	}
+
+	// sectors
+	sectors, err := r.SectorInfoRepo().GetAllSectorInfos()
+	if err != nil {
+		return nil, xerrors.Errorf("read sectors: %w", err)
+	}
+	sort.Slice(sectors, func(i, j int) bool {
+		return sectors[i].SectorNumber < sectors[j].SectorNumber
+	})
+	if err := add("sectors_infos", len(sectors), sectors); err != nil {
+		return nil, err
+	}
+
+	// logs, keep the append order within a sector
+	logs, err := r.LogRepo().ListAll()
+	if err != nil {
+		return nil, xerrors.Errorf("read logs: %w", err)
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].SectorNumber < logs[j].SectorNumber
+	})
+	if err := add("logs", len(logs), logs); err != nil {
+		return nil, err
+	}
+
+	// deal refs
+	dealRefs, err := r.DealRefRepo().ListAll()
+	if err != nil {
+		return nil, xerrors.Errorf("read deal refs: %w", err)
+	}
+	sort.Slice(dealRefs, func(i, j int) bool {
+		if dealRefs[i].DealID != dealRefs[j].DealID {
+			return dealRefs[i].DealID < dealRefs[j].DealID
+		}
+		if dealRefs[i].SectorID != dealRefs[j].SectorID {
+			return dealRefs[i].SectorID < dealRefs[j].SectorID
+		}
+		return dealRefs[i].Offset < dealRefs[j].Offset
+	})
+	if err := add("deal_refs", len(dealRefs), dealRefs); err != nil {
+		return nil, err
+	}
+
+	// worker calls
+	var calls []workerCallRow
+	for _, role := range workerCallRoles {
+		roleCalls, err := r.WorkerCallRepo().GetAllCall(role)
+		if err != nil {
+			return nil, xerrors.Errorf("read %s worker calls: %w", role, err)
+		}
+		for _, call := range roleCalls {
+			calls = append(calls, newWorkerCallRow(role, call))
+		}
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].Role != calls[j].Role {
+			return calls[i].Role < calls[j].Role
+		}
+		return calls[i].ID.String() < calls[j].ID.String()
+	})
+	if err := add("worker_calls", len(calls), calls); err != nil {
+		return nil, err
+	}
+
+	// worker states
+	states, err := r.WorkerStateRepo().GetAllWorkState()
+	if err != nil {
+		return nil, xerrors.Errorf("read worker states: %w", err)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].ID.String() < states[j].ID.String()
+	})
+	if err := add("worker_states", len(states), states); err != nil {
+		return nil, err
+	}
+
+	// api tokens
+	tokens, err := r.TokenRepo().List()
+	if err != nil {
+		return nil, xerrors.Errorf("read api tokens: %w", err)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].ID < tokens[j].ID
+	})
+	if err := add("api_tokens", len(tokens), tokens); err != nil {
+		return nil, err
+	}
+
+	// sector events, ids are assigned by the database so they are left out
+	events, err := r.SectorEventRepo().Query(&types.SectorEventFilter{})
+	if err != nil {
+		return nil, xerrors.Errorf("read sector events: %w", err)
+	}
+	for _, event := range events {
+		event.ID = 0
+	}
+	if err := add("sector_events", len(events), events); err != nil {
+		return nil, err
+	}
+
+	// storage index, listed in primary key order by the repo
+	storages, err := r.StorageIndexRepo().ListStorages()
+	if err != nil {
+		return nil, xerrors.Errorf("read storages: %w", err)
+	}
+	if err := add("sector_storages", len(storages), storages); err != nil {
+		return nil, err
+	}
+
+	decls, err := r.StorageIndexRepo().ListSectorDecls()
+	if err != nil {
+		return nil, xerrors.Errorf("read storage sector decls: %w", err)
+	}
+	if err := add("sector_storage_decls", len(decls), decls); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
+
+func ensureEmpty(r repo.Repo) error {
+	summaries, err := Summarize(r)
+	if err != nil {
+		return xerrors.Errorf("read destination: %w", err)
+	}
+	for _, summary := range summaries {
+		if summary.Rows > 0 {
+			return xerrors.Errorf("destination is not empty, table %s has %d rows", summary.Table, summary.Rows)
+		}
+	}
+	return nil
+}
+
+// CheckSchema fails if the schema of r isn't at the version this binary migrates to, the repos can't read it then
+func CheckSchema(r repo.Repo) error {
+	current, latest, err := r.SchemaVersion()
+	if err != nil {
+		return xerrors.Errorf("read schema version: %w", err)
+	}
+	if current != latest {
+		return xerrors.Errorf("database schema version %d, supported version %d", current, latest)
+	}
+	return nil
+}
+
+// CopyRepo copies every table of from into to, to must be empty. The rows are written in a single transaction of
+// to, a failed copy leaves it empty
+func CopyRepo(from, to repo.Repo) error {
+	return to.Transaction(func(tx repo.Repo) error {
+		if err := ensureEmpty(tx); err != nil {
+			return err
+		}
+		return copyTables(from, tx)
+	})
+}
+
+func copyTables(from, to repo.Repo) error {
+	// metadata
+	mAddr, err := from.MetaDataRepo().GetMinerAddress()
+	switch {
+	case err == nil:
+		count, err := from.MetaDataRepo().GetStorageCounter()
+		if err != nil {
+			return xerrors.Errorf("read sector counter: %w", err)
+		}
+		if err := to.MetaDataRepo().SaveMinerAddress(mAddr); err != nil {
+			return xerrors.Errorf("save miner address: %w", err)
+		}
+		if err := to.MetaDataRepo().SetStorageCounter(count); err != nil {
+			return xerrors.Errorf("save sector counter: %w", err)
+		}
+	case xerrors.Is(err, gorm.ErrRecordNotFound):
+	default:
+		return xerrors.Errorf("read miner address: %w", err)
+	}
+
+	// deal policy
+	policy, err := from.MetaDataRepo().GetDealPolicy()
+	switch {
+	case err == nil:
+		if err := to.MetaDataRepo().SaveDealPolicy(policy); err != nil {
+			return xerrors.Errorf("save deal policy: %w", err)
+		}
+	case xerrors.Is(err, gorm.ErrRecordNotFound):
+	default:
+		return xerrors.Errorf("read deal policy: %w", err)
+	}
+
+	// sectors
+	sectors, err := from.SectorInfoRepo().GetAllSectorInfos()
+	if err != nil {
+		return xerrors.Errorf("read sectors: %w", err)
+	}
+	for _, sector := range sectors {
+		if err := to.SectorInfoRepo().Save(sector); err != nil {
+			return xerrors.Errorf("save sector %d: %w", sector.SectorNumber, err)
+		}
+	}
+
+	// logs
+	logs, err := from.LogRepo().ListAll()
+	if err != nil {
+		return xerrors.Errorf("read logs: %w", err)
+	}
+	for _, l := range logs {
+		if err := to.LogRepo().Append(l); err != nil {
+			return xerrors.Errorf("save log of sector %d: %w", l.SectorNumber, err)
+		}
+	}
+
+	// deal refs
+	dealRefs, err := from.DealRefRepo().ListAll()
+	if err != nil {
+		return xerrors.Errorf("read deal refs: %w", err)
+	}
+	for _, ref := range dealRefs {
+		if err := to.DealRefRepo().SaveRef(ref); err != nil {
+			return xerrors.Errorf("save deal ref %d: %w", ref.DealID, err)
+		}
+	}
+
+	// worker calls
+	for _, role := range workerCallRoles {
+		calls, err := from.WorkerCallRepo().GetAllCall(role)
+		if err != nil {
+			return xerrors.Errorf("read %s worker calls: %w", role, err)
+		}
+		for _, call := range calls {
+			if err := to.WorkerCallRepo().Save(role, call.ID, call); err != nil {
+				return xerrors.Errorf("save worker call %s: %w", call.ID, err)
+			}
+		}
+	}
+
+	// worker states
+	states, err := from.WorkerStateRepo().GetAllWorkState()
+	if err != nil {
+		return xerrors.Errorf("read worker states: %w", err)
+	}
+	for _, state := range states {
+		if err := to.WorkerStateRepo().Save(state.ID, state); err != nil {
+			return xerrors.Errorf("save worker state %s: %w", state.ID, err)
+		}
+	}
+
+	// api tokens
+	tokens, err := from.TokenRepo().List()
+	if err != nil {
+		return xerrors.Errorf("read api tokens: %w", err)
+	}
+	for _, token := range tokens {
+		if err := to.TokenRepo().Save(token); err != nil {
+			return xerrors.Errorf("save api token %s: %w", token.ID, err)
+		}
+	}
+
+	// sector events
+	events, err := from.SectorEventRepo().Query(&types.SectorEventFilter{})
+	if err != nil {
+		return xerrors.Errorf("read sector events: %w", err)
+	}
+	for _, event := range events {
+		if err := to.SectorEventRepo().Append(event); err != nil {
+			return xerrors.Errorf("save event of sector %d: %w", event.SectorNumber, err)
+		}
+	}
+
+	// storage index
+	storages, err := from.StorageIndexRepo().ListStorages()
+	if err != nil {
+		return xerrors.Errorf("read storages: %w", err)
+	}
+	for _, storage := range storages {
+		if err := to.StorageIndexRepo().SaveStorage(storage); err != nil {
+			return xerrors.Errorf("save storage %s: %w", storage.ID, err)
+		}
+	}
+
+	decls, err := from.StorageIndexRepo().ListSectorDecls()
+	if err != nil {
+		return xerrors.Errorf("read storage sector decls: %w", err)
+	}
+	for _, decl := range decls {
+		if err := to.StorageIndexRepo().DeclareSector(decl); err != nil {
+			return xerrors.Errorf("save decl of sector %d in storage %s: %w", decl.Number, decl.StorageID, err)
+		}
+	}
+
+	return nil
+}
+
+// MigrateRepo copies from into to and checks that both have the same row counts and checksums
+func MigrateRepo(from, to repo.Repo) ([]TableSummary, error) {
+	if err := CopyRepo(from, to); err != nil {
+		return nil, err
+	}
+
+	fromSummaries, err := Summarize(from)
+	if err != nil {
+		return nil, xerrors.Errorf("summarize source: %w", err)
+	}
+
+	toSummaries, err := Summarize(to)
+	if err != nil {
+		return nil, xerrors.Errorf("summarize destination: %w", err)
+	}
+
+	for index, summary := range fromSummaries {
+		toSummary := toSummaries[index]
+		if summary.Rows != toSummary.Rows {
+			return nil, xerrors.Errorf("table %s row count mismatch, source %d destination %d", summary.Table, summary.Rows, toSummary.Rows)
+		}
+		if summary.Checksum != toSummary.Checksum {
+			return nil, xerrors.Errorf("table %s checksum mismatch, source %s destination %s", summary.Table, summary.Checksum, toSummary.Checksum)
+		}
+	}
+	return fromSummaries, nil
+}
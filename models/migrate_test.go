@@ -0,0 +1,118 @@
+package models
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/v2/actors/builtin/market"
+	tutils "github.com/filecoin-project/specs-actors/v2/support/testing"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestRepo(t *testing.T, name string) repo.Repo {
+	r, err := OpenDSN("sqlite:" + filepath.Join(t.TempDir(), name))
+	require.NoError(t, err)
+	require.NoError(t, r.AutoMigrate())
+	return r
+}
+
+func fillTestRepo(t *testing.T, from repo.Repo) {
+	mAddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	require.NoError(t, from.MetaDataRepo().SaveMinerAddress(mAddr))
+	require.NoError(t, from.MetaDataRepo().SetStorageCounter(12))
+
+	policy := types.DefaultDealPolicy()
+	policy.ConsiderOfflineStorageDeals = false
+	require.NoError(t, from.MetaDataRepo().SaveDealPolicy(policy))
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, from.SectorInfoRepo().Save(&types.SectorInfo{State: types.Proving, SectorNumber: abi.SectorNumber(i)}))
+		require.NoError(t, from.LogRepo().Append(&types.Log{SectorNumber: abi.SectorNumber(i), Timestamp: uint64(100 + i), Kind: "event;a"}))
+		require.NoError(t, from.LogRepo().Append(&types.Log{SectorNumber: abi.SectorNumber(i), Timestamp: uint64(200 + i), Kind: "event;b"}))
+	}
+	require.NoError(t, from.DealRefRepo().Save(1, types.SealedRef{SectorID: 1, Offset: 0, Size: 127}, nil))
+	require.NoError(t, from.DealRefRepo().Save(2, types.SealedRef{SectorID: 2, Offset: 0, Size: 127}, &market.DealProposal{
+		PieceCID: tutils.MakeCID("piece", &market.PieceCIDPrefix),
+		Label:    tutils.MakeCID("payload", nil).String(),
+	}))
+
+	callID := types.CallID{Sector: abi.SectorID{Miner: 1000, Number: 1}, ID: uuid.New()}
+	require.NoError(t, from.WorkerCallRepo().Save("worker", callID, &types.Call{ID: callID, State: types.CallDone, Result: types.NewManyBytes([]byte{1})}))
+
+	workID, err := types.NewWorkID(types.TTFinalize, abi.SectorID{Miner: 1000, Number: 1})
+	require.NoError(t, err)
+	require.NoError(t, from.WorkerStateRepo().Save(workID, &types.WorkState{ID: workID, Status: types.WsDone, WorkerCall: callID}))
+
+	require.NoError(t, from.TokenRepo().Save(&types.TokenInfo{ID: uuid.New().String(), Name: "worker", Perms: []string{"worker"}, Created: time.Unix(1600000000, 0)}))
+
+	require.NoError(t, from.SectorEventRepo().Append(&types.SectorEvent{SectorNumber: 1, Time: time.Unix(1600000000, 0), Event: "SectorPreCommit1", From: types.PreCommit1, To: types.PreCommit2, Worker: "worker-1", Duration: time.Hour}))
+	require.NoError(t, from.SectorEventRepo().Append(&types.SectorEvent{SectorNumber: 1, Time: time.Unix(1600000100, 0), Event: "SectorSealPreCommit2Failed", From: types.PreCommit2, To: types.SealPreCommit2Failed, Worker: "worker-1", Error: "boom", ErrorClass: "pc2"}))
+
+	require.NoError(t, from.StorageIndexRepo().SaveStorage(&types.StorageRecord{ID: "storage-1", URLs: []string{"http://127.0.0.1:2345/remote"}, Weight: 10, CanSeal: true, CanStore: true, LastHeartbeat: time.Unix(1600000000, 0)}))
+	require.NoError(t, from.StorageIndexRepo().DeclareSector(&types.SectorDeclRecord{StorageID: "storage-1", Miner: 1000, Number: 1, FileType: 2, Primary: true}))
+	require.NoError(t, from.StorageIndexRepo().DeclareSector(&types.SectorDeclRecord{StorageID: "storage-1", Miner: 1000, Number: 1, FileType: 4, Primary: true}))
+}
+
+func TestMigrateRepo(t *testing.T) {
+	from := openTestRepo(t, "from.db")
+	to := openTestRepo(t, "to.db")
+	fillTestRepo(t, from)
+
+	summaries, err := MigrateRepo(from, to)
+	require.NoError(t, err)
+
+	rows := map[string]int{}
+	for _, summary := range summaries {
+		rows[summary.Table] = summary.Rows
+	}
+	require.Equal(t, map[string]int{
+		"metadata":             1,
+		"deal_policy":          1,
+		"sectors_infos":        3,
+		"logs":                 6,
+		"deal_refs":            2,
+		"worker_calls":         1,
+		"worker_states":        1,
+		"api_tokens":           1,
+		"sector_events":        2,
+		"sector_storages":      1,
+		"sector_storage_decls": 2,
+	}, rows)
+
+	count, err := to.MetaDataRepo().IncreaseStorageCounter()
+	require.NoError(t, err)
+	require.Equal(t, abi.SectorNumber(13), count)
+
+	// destination is not empty anymore
+	_, err = MigrateRepo(from, to)
+	require.Error(t, err)
+}
+
+func TestCopyRepoFailed(t *testing.T) {
+	from := openTestRepo(t, "from.db")
+	to := openTestRepo(t, "to.db")
+	fillTestRepo(t, from)
+
+	// sectors and logs are copied before the deal refs fail to read
+	require.NoError(t, from.GetDb().Migrator().DropTable("deal_refs"))
+	require.Error(t, CopyRepo(from, to))
+	require.NoError(t, ensureEmpty(to))
+}
+
+func TestCheckSchema(t *testing.T) {
+	r := openTestRepo(t, "sealer.db")
+	require.NoError(t, CheckSchema(r))
+
+	old, err := OpenDSN("sqlite:" + filepath.Join(t.TempDir(), "old.db"))
+	require.NoError(t, err)
+	require.Error(t, CheckSchema(old))
+	// the check doesn't write to the database
+	require.False(t, old.GetDb().Migrator().HasTable("schema_version"))
+}
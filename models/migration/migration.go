@@ -0,0 +1,117 @@
+package migration
+
+import (
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var log = logging.Logger("migration")
+
+// ErrDatabaseTooNew is returned when the database was migrated by a newer venus-sealer
+var ErrDatabaseTooNew = xerrors.New("database schema is newer than this binary")
+
+// Migration is one numbered step of the schema. Versions start at 1 and must be contiguous,
+// a released migration must never be changed, add a new one instead.
+type Migration struct {
+	Version uint64
+	Name    string
+	Migrate func(tx *gorm.DB) error
+}
+
+// schemaVersion has a single row that records the version of the last applied migration
+type schemaVersion struct {
+	ID        uint64 `gorm:"column:id;type:bigint;primary_key;" json:"id"`
+	Version   uint64 `gorm:"column:version;type:bigint;NOT NULL" json:"version"`
+	UpdatedAt uint64 `gorm:"column:updated_at;type:bigint;NOT NULL" json:"updated_at"`
+}
+
+func (schemaVersion) TableName() string {
+	return "schema_version"
+}
+
+const schemaVersionID = 1
+
+// Latest returns the version the database is at after running migrations
+func Latest(migrations []Migration) uint64 {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+func check(migrations []Migration) error {
+	for index, m := range migrations {
+		if m.Version != uint64(index+1) {
+			return xerrors.Errorf("migration %s has version %d, expect %d", m.Name, m.Version, index+1)
+		}
+		if m.Migrate == nil {
+			return xerrors.Errorf("migration %d %s has no migrate func", m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+// Version returns the schema version recorded in db, 0 if no migration has been applied. It doesn't write to db
+func Version(db *gorm.DB) (uint64, error) {
+	if !db.Migrator().HasTable(&schemaVersion{}) {
+		return 0, nil
+	}
+
+	var sv schemaVersion
+	err := db.Take(&sv, "id = ?", schemaVersionID).Error
+	switch {
+	case err == nil:
+		return sv.Version, nil
+	case xerrors.Is(err, gorm.ErrRecordNotFound):
+		return 0, nil
+	default:
+		return 0, xerrors.Errorf("read schema version: %w", err)
+	}
+}
+
+// Run applies every migration newer than the version recorded in db, in order. Each migration runs in
+// its own transaction together with the version update. Note that mysql commits DDL statements implicitly,
+// so migrations there must be safe to run again after a failure.
+// Run refuses to touch a database whose version is newer than the latest migration.
+func Run(db *gorm.DB, migrations []Migration) error {
+	if err := check(migrations); err != nil {
+		return err
+	}
+	// db may be a chained instance, eg. with table options set, whose errors would stick to every later statement
+	db = db.Session(&gorm.Session{})
+
+	if err := db.AutoMigrate(&schemaVersion{}); err != nil {
+		return xerrors.Errorf("create schema version table: %w", err)
+	}
+	current, err := Version(db)
+	if err != nil {
+		return err
+	}
+
+	latest := Latest(migrations)
+	if current > latest {
+		return xerrors.Errorf("database schema version %d, supported version %d: %w", current, latest, ErrDatabaseTooNew)
+	}
+
+	for _, m := range migrations[current:] {
+		log.Infof("migrating database schema to version %d: %s", m.Version, m.Name)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Migrate(tx); err != nil {
+				return err
+			}
+			return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&schemaVersion{
+				ID:        schemaVersionID,
+				Version:   m.Version,
+				UpdatedAt: uint64(time.Now().Unix()),
+			}).Error
+		})
+		if err != nil {
+			return xerrors.Errorf("migrate schema to version %d %s: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
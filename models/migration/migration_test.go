@@ -0,0 +1,120 @@
+package migration
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	ID   uint64 `gorm:"column:id;primary_key;"`
+	Name string `gorm:"column:name;type:varchar(256);"`
+}
+
+func (item) TableName() string {
+	return "items"
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	require.NoError(t, err)
+	return db
+}
+
+func testMigrations(applied *[]uint64) []Migration {
+	return []Migration{
+		{
+			Version: 1,
+			Name:    "create items",
+			Migrate: func(tx *gorm.DB) error {
+				*applied = append(*applied, 1)
+				return tx.AutoMigrate(&item{})
+			},
+		},
+		{
+			Version: 2,
+			Name:    "upper case names",
+			Migrate: func(tx *gorm.DB) error {
+				*applied = append(*applied, 2)
+				return tx.Exec("UPDATE items SET name = UPPER(name)").Error
+			},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	db := openTestDB(t)
+	var applied []uint64
+	migrations := testMigrations(&applied)
+
+	version, err := Version(db)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), version)
+
+	require.NoError(t, Run(db, migrations[:1]))
+	require.Equal(t, []uint64{1}, applied)
+	require.NoError(t, db.Create(&item{ID: 1, Name: "a"}).Error)
+
+	require.NoError(t, Run(db, migrations))
+	require.Equal(t, []uint64{1, 2}, applied)
+
+	var it item
+	require.NoError(t, db.Take(&it, "id = ?", 1).Error)
+	require.Equal(t, "A", it.Name)
+
+	version, err = Version(db)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), version)
+
+	// nothing left to apply
+	require.NoError(t, Run(db, migrations))
+	require.Equal(t, []uint64{1, 2}, applied)
+
+	// an older binary must refuse the database
+	err = Run(db, migrations[:1])
+	require.True(t, xerrors.Is(err, ErrDatabaseTooNew))
+}
+
+// a db carrying settings, as the mysql table options, is not reusable, the steps must not see the errors of the
+// version lookup
+func TestRunSettings(t *testing.T) {
+	db := openTestDB(t).Set("gorm:table_options", "")
+	var applied []uint64
+
+	require.NoError(t, Run(db, testMigrations(&applied)))
+	require.Equal(t, []uint64{1, 2}, applied)
+}
+
+func TestRunFailed(t *testing.T) {
+	db := openTestDB(t)
+	var applied []uint64
+	migrations := append(testMigrations(&applied), Migration{
+		Version: 3,
+		Name:    "broken",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.Exec("UPDATE items SET name = 'x'").Error; err != nil {
+				return err
+			}
+			return xerrors.New("broken migration")
+		},
+	})
+
+	require.Error(t, Run(db, migrations))
+
+	version, err := Version(db)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), version)
+}
+
+func TestRunInvalid(t *testing.T) {
+	db := openTestDB(t)
+	var applied []uint64
+	migrations := testMigrations(&applied)
+
+	require.Error(t, Run(db, migrations[1:]))
+	require.Empty(t, applied)
+}
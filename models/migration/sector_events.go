@@ -0,0 +1,267 @@
+package migration
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// the rows of the sector log and sector events read and written by BackfillSectorEvents, only the columns
+// both backends share as of the migration which added the sector events
+
+type sectorLogRow struct {
+	Id           int64  `gorm:"column:id"`
+	SectorNumber uint64 `gorm:"column:sector_number"`
+	Timestamp    uint64 `gorm:"column:timestamp"`
+	Trace        string `gorm:"column:trace"`
+	Message      string `gorm:"column:message"`
+	Kind         string `gorm:"column:kind"`
+}
+
+func (sectorLogRow) TableName() string {
+	return "logs"
+}
+
+type sectorEventRow struct {
+	Id           int64  `gorm:"column:id;primary_key;autoIncrement"`
+	SectorNumber uint64 `gorm:"column:sector_number"`
+	Time         int64  `gorm:"column:time"`
+	Event        string `gorm:"column:event"`
+	FromState    string `gorm:"column:from_state"`
+	ToState      string `gorm:"column:to_state"`
+	Worker       string `gorm:"column:worker"`
+	Duration     int64  `gorm:"column:duration"`
+	Error        string `gorm:"column:error"`
+	ErrorClass   string `gorm:"column:error_class"`
+}
+
+func (sectorEventRow) TableName() string {
+	return "sector_events"
+}
+
+// BackfillSectorEvents rebuilds the sector events of the sectors sealed before they were recorded from the
+// sector log, so their history and stats aren't lost. It does nothing if sector events were recorded already
+func BackfillSectorEvents(tx *gorm.DB) error {
+	var recorded int64
+	if err := tx.Model(&sectorEventRow{}).Count(&recorded).Error; err != nil {
+		return xerrors.Errorf("count sector events: %w", err)
+	}
+	if recorded > 0 {
+		return nil
+	}
+
+	var sectors []uint64
+	if err := tx.Model(&sectorLogRow{}).Distinct("sector_number").Order("sector_number").Pluck("sector_number", &sectors).Error; err != nil {
+		return xerrors.Errorf("list logged sectors: %w", err)
+	}
+
+	for _, sector := range sectors {
+		var rows []sectorLogRow
+		if err := tx.Where("sector_number = ? AND kind LIKE ?", sector, "event;%").Order("id").Find(&rows).Error; err != nil {
+			return xerrors.Errorf("list logs of sector %d: %w", sector, err)
+		}
+
+		logs := make([]*types.Log, len(rows))
+		for i, row := range rows {
+			logs[i] = &types.Log{
+				SectorNumber: abi.SectorNumber(row.SectorNumber),
+				Timestamp:    row.Timestamp,
+				Trace:        row.Trace,
+				Message:      row.Message,
+				Kind:         row.Kind,
+			}
+		}
+
+		events := SectorEventsFromLogs(logs)
+		if len(events) == 0 {
+			continue
+		}
+		out := make([]sectorEventRow, len(events))
+		for i, event := range events {
+			out[i] = sectorEventRow{
+				SectorNumber: uint64(event.SectorNumber),
+				Time:         event.Time.UnixNano(),
+				Event:        event.Event,
+				FromState:    string(event.From),
+				ToState:      string(event.To),
+				Duration:     int64(event.Duration),
+				Error:        event.Error,
+				ErrorClass:   event.ErrorClass,
+			}
+		}
+		if err := tx.CreateInBatches(out, 500).Error; err != nil {
+			return xerrors.Errorf("write events of sector %d: %w", sector, err)
+		}
+	}
+	return nil
+}
+
+// errorClasses of the failure events, as the sealing fsm records them
+var errorClasses = map[string]string{
+	"SectorAddPieceFailed":       "addpiece",
+	"SectorSealPreCommit1Failed": "pc1",
+	"SectorSealPreCommit2Failed": "pc2",
+	"SectorChainPreCommitFailed": "precommit-chain",
+	"SectorComputeProofFailed":   "compute-proof",
+	"SectorCommitFailed":         "commit-chain",
+	"SectorDealsExpired":         "deals",
+	"SectorTicketExpired":        "ticket",
+	"SectorFinalizeFailed":       "finalize",
+	"SectorTerminateFailed":      "terminate",
+	"SectorRemoveFailed":         "remove",
+	"SectorFatalError":           "fatal",
+}
+
+// nextStates is the state each event of the sealing fsm moves a sector to, the events missing here either depend
+// on the state they apply in, see nextState, or don't move the sector
+var nextStates = map[string]types.SectorState{
+	"SectorStart":                 types.WaitDeals,
+	"SectorStartCC":               types.Packing,
+	"SectorPieceAdded":            types.WaitDeals,
+	"SectorAddPieceFailed":        types.AddPieceFailed,
+	"SectorPacked":                types.GetTicket,
+	"SectorTicket":                types.PreCommit1,
+	"SectorOldTicket":             types.GetTicket,
+	"SectorPreCommit1":            types.PreCommit2,
+	"SectorPreCommit2":            types.PreCommitting,
+	"SectorPreCommitBatch":        types.SubmitPreCommitBatch,
+	"SectorPreCommitBatchSent":    types.PreCommitBatchWait,
+	"SectorPreCommitted":          types.PreCommitWait,
+	"SectorPreCommitLanded":       types.WaitSeed,
+	"SectorSeedReady":             types.Committing,
+	"SectorCommitted":             types.SubmitCommit,
+	"SectorProofReady":            types.CommitFinalize,
+	"SectorSubmitCommitAggregate": types.SubmitCommitAggregate,
+	"SectorCommitAggregateSent":   types.CommitWait,
+	"SectorCommitSubmitted":       types.CommitWait,
+	"SectorProving":               types.FinalizeSector,
+	"SectorSealPreCommit1Failed":  types.SealPreCommit1Failed,
+	"SectorSealPreCommit2Failed":  types.SealPreCommit2Failed,
+	"SectorChainPreCommitFailed":  types.PreCommitFailed,
+	"SectorComputeProofFailed":    types.ComputeProofFailed,
+	"SectorCommitFailed":          types.CommitFailed,
+	"SectorDealsExpired":          types.DealsExpired,
+	"SectorInvalidDealIDs":        types.RecoverDealIDs,
+	"SectorTicketExpired":         types.Removing,
+	"SectorRetrySealPreCommit1":   types.PreCommit1,
+	"SectorRetrySealPreCommit2":   types.PreCommit2,
+	"SectorRetryPreCommit":        types.PreCommitting,
+	"SectorRetryPreCommitWait":    types.PreCommitWait,
+	"SectorRetryWaitSeed":         types.WaitSeed,
+	"SectorRetryComputeProof":     types.Committing,
+	"SectorRetryInvalidProof":     types.Committing,
+	"SectorRetryCommitWait":       types.CommitWait,
+	"SectorRetrySubmitCommit":     types.SubmitCommit,
+	"SectorFaultReported":         types.FaultReported,
+	"SectorFaulty":                types.Faulty,
+	"SectorTerminate":             types.Terminating,
+	"SectorTerminating":           types.TerminateWait,
+	"SectorTerminated":            types.TerminateFinality,
+	"SectorTerminateFailed":       types.TerminateFailed,
+	"SectorRemove":                types.Removing,
+	"SectorRemoved":               types.Removed,
+	"SectorRemoveFailed":          types.RemoveFailed,
+}
+
+// nextState returns the state event moves a sector in from to, message is the logged event. ret is the state a
+// sector in RecoverDealIDs returns to
+func nextState(event, message string, from, ret types.SectorState) types.SectorState {
+	switch event {
+	case "SectorAddPiece":
+		return types.AddPiece
+	case "SectorStartPacking":
+		if from == types.AddPiece {
+			return from
+		}
+		return types.Packing
+	case "SectorFinalized":
+		if from == types.CommitFinalize {
+			return types.SubmitCommit
+		}
+		return types.Proving
+	case "SectorFinalizeFailed":
+		if from == types.CommitFinalize {
+			return types.CommitFinalizeFailed
+		}
+		return types.FinalizeFailed
+	case "SectorRetryFinalize":
+		if from == types.CommitFinalizeFailed {
+			return types.CommitFinalize
+		}
+		return types.FinalizeSector
+	case "SectorUpdateDealIDs":
+		if ret != "" {
+			return ret
+		}
+		return from
+	case "SectorForceState":
+		var evt struct{ State types.SectorState }
+		if err := json.Unmarshal([]byte(message), &evt); err == nil && evt.State != "" {
+			return evt.State
+		}
+		return from
+	}
+
+	if to, ok := nextStates[event]; ok {
+		return to
+	}
+	return from
+}
+
+// SectorEventsFromLogs replays the fsm events in the log of one sector, oldest first, into its sector events.
+// The log has no states, workers or Commit1 completions, so the states are derived from the event types and
+// the workers are left empty
+func SectorEventsFromLogs(logs []*types.Log) []*types.SectorEvent {
+	var out []*types.SectorEvent
+	var state, ret types.SectorState
+	var last time.Time
+	for _, l := range logs {
+		if !strings.HasPrefix(l.Kind, "event;") {
+			continue
+		}
+		event := l.Kind[strings.LastIndex(l.Kind, ".")+1:]
+		if event == "SectorRestart" {
+			continue
+		}
+
+		from := state
+		to := nextState(event, l.Message, from, ret)
+		if event == "SectorInvalidDealIDs" {
+			var evt struct{ Return types.ReturnState }
+			if err := json.Unmarshal([]byte(l.Message), &evt); err == nil {
+				ret = types.SectorState(evt.Return)
+			}
+		} else if event == "SectorUpdateDealIDs" {
+			ret = ""
+		}
+		state = to
+
+		record := &types.SectorEvent{
+			SectorNumber: l.SectorNumber,
+			Time:         time.Unix(int64(l.Timestamp), 0),
+			Event:        event,
+			From:         from,
+			To:           to,
+			ErrorClass:   errorClasses[event],
+		}
+		if record.ErrorClass != "" {
+			record.Error = strings.SplitN(l.Trace, "\n", 2)[0]
+		}
+		if from == to && record.ErrorClass == "" {
+			continue
+		}
+		if !last.IsZero() {
+			record.Duration = record.Time.Sub(last)
+		}
+		last = record.Time
+		out = append(out, record)
+	}
+	return out
+}
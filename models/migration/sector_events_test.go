@@ -0,0 +1,113 @@
+package migration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func eventLogs(sector abi.SectorNumber, start uint64, kinds ...string) []*types.Log {
+	var out []*types.Log
+	for i, kind := range kinds {
+		out = append(out, &types.Log{SectorNumber: sector, Timestamp: start + uint64(i)*60, Kind: kind, Message: "{}"})
+	}
+	return out
+}
+
+func TestSectorEventsFromLogs(t *testing.T) {
+	logs := eventLogs(7, 1000,
+		"event;sealing.SectorStartCC",
+		"event;sealing.SectorPacked",
+		"event;sealing.SectorTicket",
+		"event;sealing.SectorSealPreCommit1Failed",
+		"event;sealing.SectorRetrySealPreCommit1",
+		"event;sealing.SectorPreCommit1",
+		"event;sealing.SectorPreCommit2",
+		"event;sealing.SectorPreCommitted",
+		"event;sealing.SectorPreCommitLanded",
+		"event;sealing.SectorSeedReady",
+		"event;sealing.SectorCommitted",
+		"event;sealing.SectorCommitSubmitted",
+		"event;sealing.SectorProving",
+		"event;sealing.SectorFinalized",
+	)
+	logs[3].Trace = "pc1 failed\n  stack"
+	// not fsm events
+	logs = append(logs[:5], append([]*types.Log{{SectorNumber: 7, Timestamp: 1250, Kind: "import;OfflineDealImported"}}, logs[5:]...)...)
+
+	events := SectorEventsFromLogs(logs)
+	require.Len(t, events, 14)
+
+	var states []types.SectorState
+	for _, event := range events {
+		states = append(states, event.To)
+	}
+	require.Equal(t, []types.SectorState{types.Packing, types.GetTicket, types.PreCommit1, types.SealPreCommit1Failed,
+		types.PreCommit1, types.PreCommit2, types.PreCommitting, types.PreCommitWait, types.WaitSeed, types.Committing,
+		types.SubmitCommit, types.CommitWait, types.FinalizeSector, types.Proving}, states)
+
+	require.Equal(t, types.UndefinedSectorState, events[0].From)
+	require.Equal(t, time.Unix(1000, 0), events[0].Time)
+	require.Zero(t, events[0].Duration)
+	for i, event := range events[1:] {
+		require.Equal(t, events[i].To, event.From)
+		require.Equal(t, time.Minute, event.Duration)
+	}
+
+	require.Equal(t, "SectorSealPreCommit1Failed", events[3].Event)
+	require.Equal(t, "pc1", events[3].ErrorClass)
+	require.Equal(t, "pc1 failed", events[3].Error)
+}
+
+func TestSectorEventsFromLogsReturning(t *testing.T) {
+	logs := eventLogs(1, 0,
+		"event;sealing.SectorStartCC",
+		"event;sealing.SectorPacked",
+		"event;sealing.SectorTicket",
+		"event;sealing.SectorInvalidDealIDs",
+		"event;sealing.SectorUpdateDealIDs",
+		"event;sealing.SectorForceState",
+	)
+	logs[3].Message = `{"Return":"PreCommit1"}`
+	logs[5].Message = `{"State":"Removing"}`
+
+	events := SectorEventsFromLogs(logs)
+	require.Len(t, events, 6)
+	require.Equal(t, types.RecoverDealIDs, events[3].To)
+	require.Equal(t, types.PreCommit1, events[4].To)
+	require.Equal(t, types.Removing, events[5].To)
+
+	// forcing the state a sector is in already isn't a transition
+	logs[5].Message = `{"State":"PreCommit1"}`
+	require.Len(t, SectorEventsFromLogs(logs), 5)
+}
+
+func TestBackfillSectorEvents(t *testing.T) {
+	db := openTestDB(t)
+	require.NoError(t, db.AutoMigrate(&sectorLogRow{}, &sectorEventRow{}))
+
+	for _, l := range append(eventLogs(1, 100, "event;sealing.SectorStartCC", "event;sealing.SectorPacked"),
+		eventLogs(2, 200, "event;sealing.SectorStart", "event;sealing.SectorAddPiece", "event;sealing.SectorPieceAdded")...) {
+		require.NoError(t, db.Create(&sectorLogRow{SectorNumber: uint64(l.SectorNumber), Timestamp: l.Timestamp, Kind: l.Kind}).Error)
+	}
+
+	require.NoError(t, BackfillSectorEvents(db))
+	var rows []sectorEventRow
+	require.NoError(t, db.Order("id").Find(&rows).Error)
+	require.Len(t, rows, 5)
+	require.Equal(t, uint64(1), rows[0].SectorNumber)
+	require.Equal(t, string(types.GetTicket), rows[1].ToState)
+	require.Equal(t, time.Unix(200, 0).UnixNano(), rows[2].Time)
+	require.Equal(t, int64(time.Minute), rows[4].Duration)
+
+	// recorded events are kept as they are
+	require.NoError(t, BackfillSectorEvents(db))
+	var count int64
+	require.NoError(t, db.Model(&sectorEventRow{}).Count(&count).Error)
+	require.Equal(t, int64(5), count)
+}
@@ -3,6 +3,7 @@ package mysql
 import (
 	"fmt"
 	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/models/migration"
 	"github.com/filecoin-project/venus-sealer/models/repo"
 	"time"
 
@@ -16,37 +17,59 @@ type MysqlRepo struct {
 }
 
 func (d MysqlRepo) DealRefRepo() repo.DealRefRepo {
-	panic("implement me")
+	return newDealRefRepo(d.GetDb())
 }
 
 func (d MysqlRepo) LogRepo() repo.LogRepo {
-	panic("implement me")
+	return newLogRepo(d.GetDb())
+}
+
+func (d MysqlRepo) TokenRepo() repo.TokenRepo {
+	return newTokenRepo(d.GetDb())
+}
+
+func (d MysqlRepo) SectorEventRepo() repo.SectorEventRepo {
+	return newSectorEventRepo(d.GetDb())
+}
+
+func (d MysqlRepo) StorageIndexRepo() repo.StorageIndexRepo {
+	return newStorageIndexRepo(d.GetDb())
 }
 
 func (d MysqlRepo) WorkerCallRepo() repo.WorkerCallRepo {
-	panic("implement me")
+	return newWorkerCallRepo(d.GetDb())
 }
 
 func (d MysqlRepo) SectorInfoRepo() repo.SectorInfoRepo {
-	panic("implement me")
+	return newSectorInfoRepo(d.GetDb())
 }
 
 func (d MysqlRepo) WorkerStateRepo() repo.WorkerStateRepo {
-	panic("implement me")
+	return newWorkerStateRepo(d.GetDb())
 }
 
 func (d MysqlRepo) MetaDataRepo() repo.MetaDataRepo {
-	panic("implement me")
+	return newMetadataRepo(d.GetDb())
 }
 
+// AutoMigrate runs the numbered schema migrations, it fails if the database is newer than this binary
 func (d MysqlRepo) AutoMigrate() error {
-	return nil
-	/*	err := d.GetDb().AutoMigrate(mysqlMessage{})
-		if err != nil {
-			return err
-		}
+	return migration.Run(d.GetDb().Set("gorm:table_options", "CHARSET=utf8mb4"), migrations)
+}
 
-		return d.GetDb().AutoMigrate(mysqlWallet{})*/
+// SchemaVersion returns the schema version of the database and the latest version of this binary
+func (d MysqlRepo) SchemaVersion() (uint64, uint64, error) {
+	current, err := migration.Version(d.GetDb())
+	if err != nil {
+		return 0, 0, err
+	}
+	return current, migration.Latest(migrations), nil
+}
+
+func (d MysqlRepo) Transaction(fn func(txRepo repo.Repo) error) error {
+	return d.GetDb().Transaction(func(tx *gorm.DB) error {
+		return fn(&MysqlRepo{tx})
+	})
 }
 
 func (d MysqlRepo) GetDb() *gorm.DB {
@@ -54,7 +77,11 @@ func (d MysqlRepo) GetDb() *gorm.DB {
 }
 
 func (d MysqlRepo) DbClose() error {
-	return nil
+	sqlDB, err := d.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
 
 func OpenMysql(cfg *config.MySqlConfig) (repo.Repo, error) {
@@ -74,8 +101,6 @@ func OpenMysql(cfg *config.MySqlConfig) (repo.Repo, error) {
 		return nil, xerrors.Errorf("[db connection failed] Database name: %s %w", cfg.Name, err)
 	}
 
-	db.Set("gorm:table_options", "CHARSET=utf8mb4")
-
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, err
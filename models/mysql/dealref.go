@@ -0,0 +1,204 @@
+package mysql
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/v2/actors/builtin/market"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"gorm.io/gorm"
+)
+
+type dealRef struct {
+	Id         string `gorm:"column:id;type:varchar(36);primary_key;" json:"id"` // 主键
+	DealId     uint64 `gorm:"column:deal_id;type:bigint unsigned;" json:"deal_id"`
+	SectorId   uint64 `gorm:"column:sector_id;type:bigint unsigned;" json:"sector_id"`
+	PadOffset  uint64 `gorm:"column:offset_pad;type:bigint unsigned;" json:"offset_pad"`
+	UnPadSize  uint64 `gorm:"column:size_unpad;type:bigint unsigned;" json:"size_unpad"`
+	PieceCid   string `gorm:"column:piece_cid;type:varchar(256);index:deal_refs_piece_cid;" json:"piece_cid"`
+	PayloadCid string `gorm:"column:payload_cid;type:varchar(256);index:deal_refs_payload_cid;" json:"payload_cid"`
+}
+
+func (dealRef *dealRef) TableName() string {
+	return "deal_refs"
+}
+
+func (dealRef *dealRef) SealedRef() types.SealedRef {
+	return types.SealedRef{
+		SectorID: abi.SectorNumber(dealRef.SectorId),
+		Offset:   abi.PaddedPieceSize(dealRef.PadOffset),
+		Size:     abi.UnpaddedPieceSize(dealRef.UnPadSize),
+	}
+}
+
+func (dealRef *dealRef) PieceRef() (*types.PieceRef, error) {
+	pieceCid, err := parseOptionalCid(dealRef.PieceCid)
+	if err != nil {
+		return nil, err
+	}
+	payloadCid, err := parseOptionalCid(dealRef.PayloadCid)
+	if err != nil {
+		return nil, err
+	}
+	return &types.PieceRef{
+		DealID:     dealRef.DealId,
+		PieceCID:   pieceCid,
+		PayloadCID: payloadCid,
+		SealedRef:  dealRef.SealedRef(),
+	}, nil
+}
+
+func parseOptionalCid(str string) (*cid.Cid, error) {
+	if len(str) == 0 {
+		return nil, nil
+	}
+	c, err := cid.Decode(str)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func optionalCidString(c *cid.Cid) string {
+	if c == nil {
+		return ""
+	}
+	return c.String()
+}
+
+var _ repo.DealRefRepo = (*dealRefRepo)(nil)
+
+type dealRefRepo struct {
+	*gorm.DB
+}
+
+func newDealRefRepo(db *gorm.DB) *dealRefRepo {
+	return &dealRefRepo{DB: db}
+}
+
+func (d *dealRefRepo) Get(dealId uint64) (types.SealedRefs, error) {
+	var dealRefs []*dealRef
+	err := d.DB.Find(&dealRefs, "deal_id=?", dealId).Error
+	if err != nil {
+		return types.SealedRefs{}, err
+	}
+	refs := types.SealedRefs{}
+	refs.Refs = make([]types.SealedRef, len(dealRefs))
+	for index, ref := range dealRefs {
+		refs.Refs[index] = ref.SealedRef()
+	}
+	return refs, nil
+}
+
+// Save records ref, the piece cid and payload cid (from the deal label) are taken from dealProposal if given
+func (d *dealRefRepo) Save(dealId uint64, ref types.SealedRef, dealProposal *market.DealProposal) error {
+	pieceRef := &types.PieceRef{
+		DealID:    dealId,
+		SealedRef: ref,
+	}
+	if dealProposal != nil {
+		pieceCid := dealProposal.PieceCID
+		pieceRef.PieceCID = &pieceCid
+		if payloadCid, err := cid.Decode(dealProposal.Label); err == nil {
+			pieceRef.PayloadCID = &payloadCid
+		}
+	}
+	return d.SaveRef(pieceRef)
+}
+
+func (d *dealRefRepo) SaveRef(ref *types.PieceRef) error {
+	return d.DB.Save(&dealRef{
+		Id:         uuid.New().String(),
+		DealId:     ref.DealID,
+		SectorId:   uint64(ref.SectorID),
+		PadOffset:  uint64(ref.Offset),
+		UnPadSize:  uint64(ref.Size),
+		PieceCid:   optionalCidString(ref.PieceCID),
+		PayloadCid: optionalCidString(ref.PayloadCID),
+	}).Error
+}
+
+func (d *dealRefRepo) Has(dealId uint64) (bool, error) {
+	var count int64
+	err := d.DB.Table("deal_refs").Where("deal_id=?", dealId).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (d *dealRefRepo) List() (map[uint64][]types.SealedRef, error) {
+	var dealRefs []*dealRef
+	if err := d.DB.Find(&dealRefs).Error; err != nil {
+		return nil, err
+	}
+
+	results := make(map[uint64][]types.SealedRef)
+	for _, ref := range dealRefs {
+		results[ref.DealId] = append(results[ref.DealId], ref.SealedRef())
+	}
+	return results, nil
+}
+
+func toPieceRefs(dealRefs []*dealRef) ([]*types.PieceRef, error) {
+	refs := make([]*types.PieceRef, len(dealRefs))
+	for index, ref := range dealRefs {
+		pieceRef, err := ref.PieceRef()
+		if err != nil {
+			return nil, err
+		}
+		refs[index] = pieceRef
+	}
+	return refs, nil
+}
+
+func (d *dealRefRepo) ListAll() ([]*types.PieceRef, error) {
+	var dealRefs []*dealRef
+	if err := d.DB.Find(&dealRefs).Error; err != nil {
+		return nil, err
+	}
+	return toPieceRefs(dealRefs)
+}
+
+func (d *dealRefRepo) GetByPieceCid(pieceCid cid.Cid) ([]*types.PieceRef, error) {
+	var dealRefs []*dealRef
+	if err := d.DB.Find(&dealRefs, "piece_cid=?", pieceCid.String()).Error; err != nil {
+		return nil, err
+	}
+	return toPieceRefs(dealRefs)
+}
+
+func (d *dealRefRepo) GetByPayloadCid(payloadCid cid.Cid) ([]*types.PieceRef, error) {
+	var dealRefs []*dealRef
+	if err := d.DB.Find(&dealRefs, "payload_cid=?", payloadCid.String()).Error; err != nil {
+		return nil, err
+	}
+	return toPieceRefs(dealRefs)
+}
+
+func (d *dealRefRepo) listCids(column string) ([]cid.Cid, error) {
+	var strs []string
+	err := d.DB.Table("deal_refs").Distinct(column).Where(column+" <> ''").Order(column).Pluck(column, &strs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	cids := make([]cid.Cid, len(strs))
+	for index, str := range strs {
+		c, err := cid.Decode(str)
+		if err != nil {
+			return nil, err
+		}
+		cids[index] = c
+	}
+	return cids, nil
+}
+
+func (d *dealRefRepo) ListPieceCids() ([]cid.Cid, error) {
+	return d.listCids("piece_cid")
+}
+
+func (d *dealRefRepo) ListPayloadCids() ([]cid.Cid, error) {
+	return d.listCids("payload_cid")
+}
@@ -0,0 +1,93 @@
+package mysql
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	sqle "github.com/dolthub/go-mysql-server"
+	"github.com/dolthub/go-mysql-server/memory"
+	"github.com/dolthub/go-mysql-server/server"
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/information_schema"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/models/repotest"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// set VENUS_SEALER_TEST_MYSQL_DSN to a dsn of an empty database to run the tests against a mysql server, eg.
+// root:pass@tcp(127.0.0.1:3306)/sealer_test?parseTime=true, they run against an in-process server otherwise
+const testDsnEnv = "VENUS_SEALER_TEST_MYSQL_DSN"
+
+// every table of the schema, dropped before and after each test so no test sees the rows or the schema version of
+// another one
+var testTables = []interface{}{
+	"schema_version",
+	"deal_refs", "metadata", "logs", "sectors_infos", "worker_calls", "worker_states",
+	"deal_policies", "api_tokens", "sector_events", "sector_storages", "sector_storage_decls",
+}
+
+// newTestRepo returns an empty, migrated repo
+func newTestRepo(t *testing.T) *MysqlRepo {
+	dsn := os.Getenv(testDsnEnv)
+	if len(dsn) == 0 {
+		dsn = startTestServer(t)
+	}
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Migrator().DropTable(testTables...))
+
+	r := &MysqlRepo{db}
+	require.NoError(t, r.AutoMigrate())
+	t.Cleanup(func() {
+		_ = db.Migrator().DropTable(testTables...)
+		_ = r.DbClose()
+	})
+	return r
+}
+
+// startTestServer serves an empty in-memory database over the mysql protocol for the test and returns its dsn
+func startTestServer(t *testing.T) string {
+	engine := sqle.NewDefault(sql.NewDatabaseProvider(
+		memory.NewDatabase("sealer_test"),
+		information_schema.NewInformationSchemaDatabase(),
+	))
+	srv, err := server.NewDefaultServer(server.Config{Protocol: "tcp", Address: "127.0.0.1:0"}, engine)
+	require.NoError(t, err)
+	go srv.Start() // nolint
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	return fmt.Sprintf("root@tcp(%s)/sealer_test?parseTime=true", srv.Listener.Addr())
+}
+
+func TestMysqlRepo(t *testing.T) {
+	(&repotest.Suite{
+		NewRepo: func(t *testing.T) repo.Repo {
+			return newTestRepo(t)
+		},
+	}).RunTests(t, "")
+}
+
+// the live models must not have columns the frozen migrations never create
+func TestMigrationsCreateModels(t *testing.T) {
+	db := newTestRepo(t).GetDb()
+
+	models := []interface{}{&dealRef{}, &metadata{}, &Log{}, &sectorInfo{}, &workerCall{}, &workerState{}, &dealPolicy{},
+		&apiToken{}, &sectorEvent{}, &sectorStorage{}, &sectorStorageDecl{}}
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(model))
+		require.True(t, db.Migrator().HasTable(model), stmt.Schema.Table)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			require.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s", stmt.Schema.Table, field.DBName)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package mysql
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type sectorEvent struct {
+	Id           int64  `gorm:"column:id;type:bigint AUTO_INCREMENT;primary_key;" json:"id"`
+	SectorNumber uint64 `gorm:"column:sector_number;type:bigint unsigned;index:sector_event_sector_number" json:"sector_number"`
+	// unix nanoseconds
+	Time       int64  `gorm:"column:time;type:bigint;index:sector_event_time" json:"time"`
+	Event      string `gorm:"column:event;type:varchar(128);index:sector_event_event" json:"event"`
+	FromState  string `gorm:"column:from_state;type:varchar(64);index:sector_event_from_state" json:"from_state"`
+	ToState    string `gorm:"column:to_state;type:varchar(64);index:sector_event_to_state" json:"to_state"`
+	Worker     string `gorm:"column:worker;type:varchar(256);index:sector_event_worker" json:"worker"`
+	Duration   int64  `gorm:"column:duration;type:bigint;" json:"duration"`
+	Error      string `gorm:"column:error;type:text;" json:"error"`
+	ErrorClass string `gorm:"column:error_class;type:varchar(64);index:sector_event_error_class" json:"error_class"`
+}
+
+func (e *sectorEvent) TableName() string {
+	return "sector_events"
+}
+
+func (e *sectorEvent) SectorEvent() *types.SectorEvent {
+	return &types.SectorEvent{
+		ID:           e.Id,
+		SectorNumber: abi.SectorNumber(e.SectorNumber),
+		Time:         time.Unix(0, e.Time),
+		Event:        e.Event,
+		From:         types.SectorState(e.FromState),
+		To:           types.SectorState(e.ToState),
+		Worker:       e.Worker,
+		Duration:     time.Duration(e.Duration),
+		Error:        e.Error,
+		ErrorClass:   e.ErrorClass,
+	}
+}
+
+var _ repo.SectorEventRepo = (*sectorEventRepo)(nil)
+
+type sectorEventRepo struct {
+	*gorm.DB
+}
+
+func newSectorEventRepo(db *gorm.DB) *sectorEventRepo {
+	return &sectorEventRepo{DB: db}
+}
+
+func (r *sectorEventRepo) Append(event *types.SectorEvent) error {
+	at := event.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	return r.DB.Create(&sectorEvent{
+		SectorNumber: uint64(event.SectorNumber),
+		Time:         at.UnixNano(),
+		Event:        event.Event,
+		FromState:    string(event.From),
+		ToState:      string(event.To),
+		Worker:       event.Worker,
+		Duration:     int64(event.Duration),
+		Error:        event.Error,
+		ErrorClass:   event.ErrorClass,
+	}).Error
+}
+
+func (r *sectorEventRepo) Latest(sectorNumber abi.SectorNumber) (*types.SectorEvent, error) {
+	var row sectorEvent
+	if err := r.DB.Where("sector_number = ?", uint64(sectorNumber)).Order("id desc").Take(&row).Error; err != nil {
+		return nil, err
+	}
+	return row.SectorEvent(), nil
+}
+
+func (r *sectorEventRepo) Query(filter *types.SectorEventFilter) ([]*types.SectorEvent, error) {
+	db := r.DB.Model(&sectorEvent{})
+	if len(filter.Sectors) > 0 {
+		sectors := make([]uint64, len(filter.Sectors))
+		for i, s := range filter.Sectors {
+			sectors[i] = uint64(s)
+		}
+		db = db.Where("sector_number in ?", sectors)
+	}
+	if !filter.Since.IsZero() {
+		db = db.Where("time >= ?", filter.Since.UnixNano())
+	}
+	if !filter.Until.IsZero() {
+		db = db.Where("time < ?", filter.Until.UnixNano())
+	}
+	if filter.State != "" {
+		db = db.Where("from_state = ? or to_state = ?", string(filter.State), string(filter.State))
+	}
+	if filter.Event != "" {
+		db = db.Where("event = ?", filter.Event)
+	}
+	if filter.Worker != "" {
+		db = db.Where("worker = ?", filter.Worker)
+	}
+	if filter.ErrorClass != "" {
+		db = db.Where("error_class = ?", filter.ErrorClass)
+	}
+	if filter.OnlyErrors {
+		db = db.Where("error_class <> ''")
+	}
+
+	var rows []*sectorEvent
+	if filter.Limit > 0 {
+		// the latest Limit events
+		if err := db.Order("id desc").Limit(filter.Limit).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	} else if err := db.Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]*types.SectorEvent, len(rows))
+	for i, row := range rows {
+		out[i] = row.SectorEvent()
+	}
+	return out, nil
+}
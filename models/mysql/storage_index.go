@@ -0,0 +1,188 @@
+package mysql
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type sectorStorage struct {
+	Id         string `gorm:"column:id;type:varchar(128);primary_key;" json:"id"`
+	Urls       string `gorm:"column:urls;type:text;" json:"urls"`
+	Weight     uint64 `gorm:"column:weight;type:bigint unsigned;" json:"weight"`
+	MaxStorage uint64 `gorm:"column:max_storage;type:bigint unsigned;" json:"max_storage"`
+	CanSeal    bool   `gorm:"column:can_seal;type:tinyint(1);NOT NULL" json:"can_seal"`
+	CanStore   bool   `gorm:"column:can_store;type:tinyint(1);NOT NULL" json:"can_store"`
+	// json of the storagePlacement
+	Placement string `gorm:"column:placement;type:text;" json:"placement"`
+	// unix nanoseconds
+	LastHeartbeat int64  `gorm:"column:last_heartbeat;type:bigint;" json:"last_heartbeat"`
+	HeartbeatErr  string `gorm:"column:heartbeat_err;type:text;" json:"heartbeat_err"`
+}
+
+func (s *sectorStorage) TableName() string {
+	return "sector_storages"
+}
+
+type storagePlacement struct {
+	Groups     []string `json:",omitempty"`
+	AllowTo    []string `json:",omitempty"`
+	AllowTypes []string `json:",omitempty"`
+	DenyTypes  []string `json:",omitempty"`
+	AllowKinds []string `json:",omitempty"`
+}
+
+func (s *sectorStorage) StorageRecord() (*types.StorageRecord, error) {
+	var urls []string
+	if len(s.Urls) > 0 {
+		if err := json.Unmarshal([]byte(s.Urls), &urls); err != nil {
+			return nil, err
+		}
+	}
+	rec := &types.StorageRecord{
+		ID:           s.Id,
+		URLs:         urls,
+		Weight:       s.Weight,
+		MaxStorage:   s.MaxStorage,
+		CanSeal:      s.CanSeal,
+		CanStore:     s.CanStore,
+		HeartbeatErr: s.HeartbeatErr,
+	}
+	if len(s.Placement) > 0 {
+		var placement storagePlacement
+		if err := json.Unmarshal([]byte(s.Placement), &placement); err != nil {
+			return nil, err
+		}
+		rec.Groups, rec.AllowTo = placement.Groups, placement.AllowTo
+		rec.AllowTypes, rec.DenyTypes, rec.AllowKinds = placement.AllowTypes, placement.DenyTypes, placement.AllowKinds
+	}
+	if s.LastHeartbeat != 0 {
+		rec.LastHeartbeat = time.Unix(0, s.LastHeartbeat)
+	}
+	return rec, nil
+}
+
+func heartbeatNano(at time.Time) int64 {
+	if at.IsZero() {
+		return 0
+	}
+	return at.UnixNano()
+}
+
+type sectorStorageDecl struct {
+	StorageId string `gorm:"column:storage_id;type:varchar(128);primary_key;" json:"storage_id"`
+	Miner     uint64 `gorm:"column:miner;type:bigint unsigned;primary_key;" json:"miner"`
+	Number    uint64 `gorm:"column:number;type:bigint unsigned;primary_key;index:sector_storage_decl_number" json:"number"`
+	FileType  int    `gorm:"column:file_type;type:int;primary_key;" json:"file_type"`
+	IsPrimary bool   `gorm:"column:is_primary;type:tinyint(1);NOT NULL" json:"is_primary"`
+}
+
+func (d *sectorStorageDecl) TableName() string {
+	return "sector_storage_decls"
+}
+
+func (d *sectorStorageDecl) SectorDeclRecord() *types.SectorDeclRecord {
+	return &types.SectorDeclRecord{
+		StorageID: d.StorageId,
+		Miner:     abi.ActorID(d.Miner),
+		Number:    abi.SectorNumber(d.Number),
+		FileType:  d.FileType,
+		Primary:   d.IsPrimary,
+	}
+}
+
+var _ repo.StorageIndexRepo = (*storageIndexRepo)(nil)
+
+type storageIndexRepo struct {
+	*gorm.DB
+}
+
+func newStorageIndexRepo(db *gorm.DB) *storageIndexRepo {
+	return &storageIndexRepo{DB: db}
+}
+
+func (r *storageIndexRepo) SaveStorage(storage *types.StorageRecord) error {
+	urls, err := json.Marshal(storage.URLs)
+	if err != nil {
+		return err
+	}
+	placement, err := json.Marshal(storagePlacement{
+		Groups:     storage.Groups,
+		AllowTo:    storage.AllowTo,
+		AllowTypes: storage.AllowTypes,
+		DenyTypes:  storage.DenyTypes,
+		AllowKinds: storage.AllowKinds,
+	})
+	if err != nil {
+		return err
+	}
+	return r.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&sectorStorage{
+		Id:            storage.ID,
+		Urls:          string(urls),
+		Weight:        storage.Weight,
+		MaxStorage:    storage.MaxStorage,
+		CanSeal:       storage.CanSeal,
+		CanStore:      storage.CanStore,
+		Placement:     string(placement),
+		LastHeartbeat: heartbeatNano(storage.LastHeartbeat),
+		HeartbeatErr:  storage.HeartbeatErr,
+	}).Error
+}
+
+func (r *storageIndexRepo) UpdateHeartbeat(id string, at time.Time, heartbeatErr string) error {
+	return r.DB.Model(&sectorStorage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_heartbeat": heartbeatNano(at),
+		"heartbeat_err":  heartbeatErr,
+	}).Error
+}
+
+func (r *storageIndexRepo) ListStorages() ([]*types.StorageRecord, error) {
+	var rows []*sectorStorage
+	if err := r.DB.Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]*types.StorageRecord, len(rows))
+	for i, row := range rows {
+		rec, err := row.StorageRecord()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = rec
+	}
+	return out, nil
+}
+
+func (r *storageIndexRepo) DeclareSector(decl *types.SectorDeclRecord) error {
+	return r.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&sectorStorageDecl{
+		StorageId: decl.StorageID,
+		Miner:     uint64(decl.Miner),
+		Number:    uint64(decl.Number),
+		FileType:  decl.FileType,
+		IsPrimary: decl.Primary,
+	}).Error
+}
+
+func (r *storageIndexRepo) DropSector(decl *types.SectorDeclRecord) error {
+	return r.DB.Where("storage_id = ? and miner = ? and number = ? and file_type = ?",
+		decl.StorageID, uint64(decl.Miner), uint64(decl.Number), decl.FileType).Delete(&sectorStorageDecl{}).Error
+}
+
+func (r *storageIndexRepo) ListSectorDecls() ([]*types.SectorDeclRecord, error) {
+	var rows []*sectorStorageDecl
+	if err := r.DB.Order("miner, number, file_type, storage_id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]*types.SectorDeclRecord, len(rows))
+	for i, row := range rows {
+		out[i] = row.SectorDeclRecord()
+	}
+	return out, nil
+}
@@ -3,11 +3,19 @@ package repo
 import (
 	"github.com/filecoin-project/specs-actors/v2/actors/builtin/market"
 	"github.com/filecoin-project/venus-sealer/types"
+	"github.com/ipfs/go-cid"
 )
 
 type DealRefRepo interface {
 	Get(dealId uint64) (types.SealedRefs, error)
 	Save(dealId uint64, ref types.SealedRef, dealProposal *market.DealProposal) error
+	SaveRef(ref *types.PieceRef) error
 	Has(dealId uint64) (bool, error)
 	List() (map[uint64][]types.SealedRef, error)
+	ListAll() ([]*types.PieceRef, error)
+	GetByPieceCid(pieceCid cid.Cid) ([]*types.PieceRef, error)
+	GetByPayloadCid(payloadCid cid.Cid) ([]*types.PieceRef, error)
+	// ListPieceCids and ListPayloadCids return the distinct known cids
+	ListPieceCids() ([]cid.Cid, error)
+	ListPayloadCids() ([]cid.Cid, error)
 }
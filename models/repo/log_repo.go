@@ -10,6 +10,9 @@ type LogRepo interface {
 	Truncate(sectorNumber abi.SectorNumber) error
 	Append(log *types.Log) error
 	List(sectorNumber abi.SectorNumber) ([]*types.Log, error)
+	ListAll() ([]*types.Log, error)
+	// ListByKind returns the latest limit logs of kind, newest first
+	ListByKind(kind string, limit int) ([]*types.Log, error)
 	DelLogs(sectorNumber uint64) error
 	LatestLog(sectorNumber uint64) (*types.Log, error)
 }
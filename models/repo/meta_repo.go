@@ -3,11 +3,16 @@ package repo
 import (
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus-sealer/types"
 )
 
 type MetaDataRepo interface {
 	SaveMinerAddress(mAddr address.Address) error
 	GetMinerAddress() (address.Address, error)
+	GetStorageCounter() (uint64, error)
 	IncreaseStorageCounter() (abi.SectorNumber, error)
 	SetStorageCounter(counter uint64) error
+	// GetDealPolicy returns gorm.ErrRecordNotFound if the policy was never saved
+	GetDealPolicy() (*types.DealPolicy, error)
+	SaveDealPolicy(policy *types.DealPolicy) error
 }
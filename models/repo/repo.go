@@ -12,6 +12,13 @@ type Repo interface {
 	SectorInfoRepo() SectorInfoRepo
 	DealRefRepo() DealRefRepo
 	LogRepo() LogRepo
+	TokenRepo() TokenRepo
+	SectorEventRepo() SectorEventRepo
+	StorageIndexRepo() StorageIndexRepo
 	DbClose() error
 	AutoMigrate() error
+	// SchemaVersion returns the schema version of the database and the version AutoMigrate brings it to
+	SchemaVersion() (current uint64, latest uint64, err error)
+	// Transaction runs fn with a repo whose writes are committed together if fn returns nil, discarded otherwise
+	Transaction(fn func(txRepo Repo) error) error
 }
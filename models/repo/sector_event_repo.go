@@ -0,0 +1,14 @@
+package repo
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type SectorEventRepo interface {
+	Append(event *types.SectorEvent) error
+	// Latest returns gorm.ErrRecordNotFound if the sector has no events
+	Latest(sectorNumber abi.SectorNumber) (*types.SectorEvent, error)
+	// Query returns the events matching filter, oldest first
+	Query(filter *types.SectorEventFilter) ([]*types.SectorEvent, error)
+}
@@ -0,0 +1,18 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type StorageIndexRepo interface {
+	SaveStorage(storage *types.StorageRecord) error
+	UpdateHeartbeat(id string, at time.Time, heartbeatErr string) error
+	ListStorages() ([]*types.StorageRecord, error)
+
+	// DeclareSector adds or updates the sector file of a storage
+	DeclareSector(decl *types.SectorDeclRecord) error
+	DropSector(decl *types.SectorDeclRecord) error
+	ListSectorDecls() ([]*types.SectorDeclRecord, error)
+}
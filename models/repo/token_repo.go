@@ -0,0 +1,11 @@
+package repo
+
+import "github.com/filecoin-project/venus-sealer/types"
+
+type TokenRepo interface {
+	Save(token *types.TokenInfo) error
+	// Get returns gorm.ErrRecordNotFound for unknown tokens
+	Get(id string) (*types.TokenInfo, error)
+	List() ([]*types.TokenInfo, error)
+	Revoke(id string) error
+}
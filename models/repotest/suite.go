@@ -0,0 +1,543 @@
+package repotest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/v2/actors/builtin/market"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+)
+
+// Suite is a conformance suite every models/repo backend must pass, so that
+// sqlite and mysql keep the same behaviour.
+type Suite struct {
+	// NewRepo returns an empty, migrated repo. The backend is responsible for cleaning it up.
+	NewRepo func(t *testing.T) repo.Repo
+}
+
+func (s *Suite) RunTests(t *testing.T, prefix string) {
+	v := reflect.TypeOf(s)
+	f := func(t *testing.T) {
+		for i := 0; i < v.NumMethod(); i++ {
+			if m := v.Method(i); strings.HasPrefix(m.Name, "Test") {
+				f := m.Func.Interface().(func(*Suite, *testing.T))
+				t.Run(m.Name, func(t *testing.T) {
+					f(s, t)
+				})
+			}
+		}
+	}
+
+	if prefix == "" {
+		f(t)
+	} else {
+		t.Run(prefix, f)
+	}
+}
+
+func (s *Suite) TestMetaData(t *testing.T) {
+	r := s.NewRepo(t)
+	metaRepo := r.MetaDataRepo()
+
+	addr, err := address.NewFromString("t01000")
+	require.NoError(t, err)
+	require.NoError(t, metaRepo.SaveMinerAddress(addr))
+
+	got, err := metaRepo.GetMinerAddress()
+	require.NoError(t, err)
+	require.Equal(t, addr, got)
+
+	for i := 1; i <= 3; i++ {
+		num, err := metaRepo.IncreaseStorageCounter()
+		require.NoError(t, err)
+		require.Equal(t, abi.SectorNumber(i), num)
+	}
+
+	require.NoError(t, metaRepo.SetStorageCounter(100))
+	counter, err := metaRepo.GetStorageCounter()
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), counter)
+
+	num, err := metaRepo.IncreaseStorageCounter()
+	require.NoError(t, err)
+	require.Equal(t, abi.SectorNumber(101), num)
+}
+
+func (s *Suite) TestDealPolicy(t *testing.T) {
+	r := s.NewRepo(t)
+	metaRepo := r.MetaDataRepo()
+
+	_, err := metaRepo.GetDealPolicy()
+	require.True(t, xerrors.Is(err, gorm.ErrRecordNotFound))
+
+	pieceCid, err := cid.Decode("baga6ea4seaqao7s73y24kcutaosvacpdjgfe5pw76ooefnyqw4ynr3d2y6x2mpq")
+	require.NoError(t, err)
+
+	policy := types.DefaultDealPolicy()
+	policy.ConsiderVerifiedStorageDeals = false
+	policy.PieceCidBlocklist = []cid.Cid{pieceCid}
+	policy.ExpectedSealDuration = time.Hour
+	require.NoError(t, metaRepo.SaveDealPolicy(policy))
+
+	got, err := metaRepo.GetDealPolicy()
+	require.NoError(t, err)
+	require.Equal(t, policy, got)
+
+	policy.ConsiderVerifiedStorageDeals = true
+	policy.PieceCidBlocklist = []cid.Cid{}
+	require.NoError(t, metaRepo.SaveDealPolicy(policy))
+
+	got, err = metaRepo.GetDealPolicy()
+	require.NoError(t, err)
+	require.Equal(t, policy, got)
+}
+
+func (s *Suite) TestSectorInfo(t *testing.T) {
+	r := s.NewRepo(t)
+	sectorRepo := r.SectorInfoRepo()
+
+	commR, err := cid.Decode("bagboea4b5abcatlxechwbp7kjpjguna6r6q7ejrhe6mdp3lf34pmswn27pkkiekz")
+	require.NoError(t, err)
+
+	sector := &types.SectorInfo{
+		State:         types.PreCommit1,
+		SectorNumber:  10,
+		SectorType:    abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+		TicketValue:   abi.SealRandomness{1, 2, 3},
+		TicketEpoch:   100,
+		PreCommit1Out: []byte{4, 5, 6},
+		CommR:         &commR,
+	}
+	require.NoError(t, sectorRepo.Save(sector))
+
+	has, err := sectorRepo.HasSectorInfo(10)
+	require.NoError(t, err)
+	require.True(t, has)
+
+	has, err = sectorRepo.HasSectorInfo(11)
+	require.NoError(t, err)
+	require.False(t, has)
+
+	got, err := sectorRepo.GetSectorInfoByID(10)
+	require.NoError(t, err)
+	require.Equal(t, sector.State, got.State)
+	require.Equal(t, sector.SectorType, got.SectorType)
+	require.Equal(t, sector.TicketValue, got.TicketValue)
+	require.Equal(t, sector.TicketEpoch, got.TicketEpoch)
+	require.Equal(t, []byte(sector.PreCommit1Out), []byte(got.PreCommit1Out))
+	require.Equal(t, commR, *got.CommR)
+
+	sector.State = types.PreCommit2
+	sector.LastErr = "some error"
+	require.NoError(t, sectorRepo.UpdateSectorInfoBySectorId(sector, 10))
+
+	got, err = sectorRepo.GetSectorInfoByID(10)
+	require.NoError(t, err)
+	require.Equal(t, types.PreCommit2, got.State)
+	require.Equal(t, "some error", got.LastErr)
+
+	require.NoError(t, sectorRepo.Save(&types.SectorInfo{State: types.Packing, SectorNumber: 11}))
+	all, err := sectorRepo.GetAllSectorInfos()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+
+	require.NoError(t, sectorRepo.DeleteBySectorId(10))
+	has, err = sectorRepo.HasSectorInfo(10)
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+func (s *Suite) TestWorkerCall(t *testing.T) {
+	r := s.NewRepo(t)
+	callRepo := r.WorkerCallRepo()
+
+	callID := types.CallID{
+		Sector: abi.SectorID{Miner: 1000, Number: 1},
+		ID:     uuid.New(),
+	}
+	require.NoError(t, callRepo.Save("sealer", callID, &types.Call{
+		ID:      callID,
+		RetType: types.ReturnSealPreCommit1,
+		State:   types.CallStarted,
+		Result:  types.NewManyBytes([]byte{1, 2, 3}),
+	}))
+
+	has, err := callRepo.HasCall("sealer", callID)
+	require.NoError(t, err)
+	require.True(t, has)
+
+	has, err = callRepo.HasCall("worker", callID)
+	require.NoError(t, err)
+	require.False(t, has)
+
+	require.NoError(t, callRepo.UpdateCallByCallID("sealer", &types.Call{
+		ID:      callID,
+		RetType: types.ReturnSealPreCommit1,
+		State:   types.CallDone,
+		Result:  types.NewManyBytes([]byte{4, 5, 6}),
+	}, callID))
+
+	call, err := callRepo.GetCallByCallID("sealer", callID)
+	require.NoError(t, err)
+	require.Equal(t, callID, call.ID)
+	require.Equal(t, types.CallDone, call.State)
+	require.Equal(t, []byte{4, 5, 6}, call.Result.Bytes())
+
+	calls, err := callRepo.GetAllCall("sealer")
+	require.NoError(t, err)
+	require.Len(t, calls, 1)
+
+	require.NoError(t, callRepo.DeleteByCallID("sealer", callID))
+	calls, err = callRepo.GetAllCall("sealer")
+	require.NoError(t, err)
+	require.Len(t, calls, 0)
+}
+
+func (s *Suite) TestWorkerState(t *testing.T) {
+	r := s.NewRepo(t)
+	stateRepo := r.WorkerStateRepo()
+
+	workID, err := types.NewWorkID(types.TTPreCommit1, abi.SectorID{Miner: 1000, Number: 1})
+	require.NoError(t, err)
+	require.NoError(t, stateRepo.Save(workID, &types.WorkState{
+		ID:     workID,
+		Status: types.WsStarted,
+	}))
+
+	has, err := stateRepo.HasState(workID)
+	require.NoError(t, err)
+	require.True(t, has)
+
+	callID := types.CallID{
+		Sector: abi.SectorID{Miner: 1000, Number: 1},
+		ID:     uuid.New(),
+	}
+	require.NoError(t, stateRepo.UpdateStateByWorkID(&types.WorkState{
+		ID:             workID,
+		Status:         types.WsRunning,
+		WorkerCall:     callID,
+		WorkerHostname: "worker-1",
+		StartTime:      100,
+	}, workID))
+
+	state, err := stateRepo.GetWorkerStateByWorkID(workID)
+	require.NoError(t, err)
+	require.Equal(t, workID, state.ID)
+	require.Equal(t, types.WsRunning, state.Status)
+	require.Equal(t, callID, state.WorkerCall)
+	require.Equal(t, "worker-1", state.WorkerHostname)
+	require.Equal(t, int64(100), state.StartTime)
+
+	states, err := stateRepo.GetAllWorkState()
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+
+	require.NoError(t, stateRepo.DeleteByWorkID(workID))
+	has, err = stateRepo.HasState(workID)
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+func (s *Suite) TestDealRef(t *testing.T) {
+	r := s.NewRepo(t)
+	dealRepo := r.DealRefRepo()
+
+	require.NoError(t, dealRepo.Save(1, types.SealedRef{SectorID: 10, Offset: 0, Size: 127}, nil))
+	require.NoError(t, dealRepo.Save(1, types.SealedRef{SectorID: 11, Offset: 128, Size: 254}, nil))
+	require.NoError(t, dealRepo.Save(2, types.SealedRef{SectorID: 12, Offset: 0, Size: 508}, nil))
+
+	has, err := dealRepo.Has(1)
+	require.NoError(t, err)
+	require.True(t, has)
+
+	has, err = dealRepo.Has(3)
+	require.NoError(t, err)
+	require.False(t, has)
+
+	refs, err := dealRepo.Get(1)
+	require.NoError(t, err)
+	require.Len(t, refs.Refs, 2)
+
+	all, err := dealRepo.List()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	require.Equal(t, []types.SealedRef{{SectorID: 12, Offset: 0, Size: 508}}, all[2])
+
+	pieceCid, err := cid.Decode("baga6ea4seaqao7s73y24kcutaosvacpdjgfe5pw76ooefnyqw4ynr3d2y6x2mpq")
+	require.NoError(t, err)
+	payloadCid, err := cid.Decode("bafkqaaa")
+	require.NoError(t, err)
+	require.NoError(t, dealRepo.Save(4, types.SealedRef{SectorID: 13, Offset: 1024, Size: 1016}, &market.DealProposal{
+		PieceCID: pieceCid,
+		Label:    payloadCid.String(),
+	}))
+	require.NoError(t, dealRepo.Save(5, types.SealedRef{SectorID: 14, Offset: 0, Size: 1016}, &market.DealProposal{
+		PieceCID: pieceCid,
+		Label:    "not a cid",
+	}))
+
+	pieces, err := dealRepo.ListPieceCids()
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{pieceCid}, pieces)
+
+	payloads, err := dealRepo.ListPayloadCids()
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{payloadCid}, payloads)
+
+	pieceRefs, err := dealRepo.GetByPieceCid(pieceCid)
+	require.NoError(t, err)
+	require.Len(t, pieceRefs, 2)
+
+	pieceRefs, err = dealRepo.GetByPayloadCid(payloadCid)
+	require.NoError(t, err)
+	require.Len(t, pieceRefs, 1)
+	require.Equal(t, &types.PieceRef{
+		DealID:     4,
+		PieceCID:   &pieceCid,
+		PayloadCID: &payloadCid,
+		SealedRef:  types.SealedRef{SectorID: 13, Offset: 1024, Size: 1016},
+	}, pieceRefs[0])
+
+	pieceRefs, err = dealRepo.ListAll()
+	require.NoError(t, err)
+	require.Len(t, pieceRefs, 5)
+}
+
+func (s *Suite) TestLog(t *testing.T) {
+	r := s.NewRepo(t)
+	logRepo := r.LogRepo()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, logRepo.Append(&types.Log{
+			SectorNumber: 1,
+			Message:      "msg",
+			Kind:         "event;" + string(rune('a'+i)),
+		}))
+	}
+	require.NoError(t, logRepo.Append(&types.Log{SectorNumber: 2, Timestamp: 100, Kind: "event;x"}))
+
+	count, err := logRepo.Count(1)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+
+	logs, err := logRepo.List(1)
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+
+	latest, err := logRepo.LatestLog(1)
+	require.NoError(t, err)
+	require.Equal(t, "event;c", latest.Kind)
+
+	all, err := logRepo.ListAll()
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+	require.Equal(t, uint64(100), all[3].Timestamp)
+
+	byKind, err := logRepo.ListByKind("event;x", 10)
+	require.NoError(t, err)
+	require.Len(t, byKind, 1)
+	require.Equal(t, abi.SectorNumber(2), byKind[0].SectorNumber)
+
+	byKind, err = logRepo.ListByKind("event;", 10)
+	require.NoError(t, err)
+	require.Len(t, byKind, 0)
+
+	require.NoError(t, logRepo.Truncate(1))
+	require.NoError(t, logRepo.DelLogs(1))
+	count, err = logRepo.Count(1)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), count)
+
+	count, err = logRepo.Count(2)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+
+	// only the sector truncated loses logs, the 4000 logs after its first 2000 ones. The logs are inserted in
+	// batches, appending them one by one takes minutes
+	var rows []map[string]interface{}
+	for i := 0; i < 8100; i++ {
+		for _, sector := range []uint64{10, 11} {
+			rows = append(rows, map[string]interface{}{"sector_number": sector, "timestamp": uint64(i + 1), "kind": "event;x"})
+		}
+	}
+	require.NoError(t, r.GetDb().Table("logs").CreateInBatches(rows, 100).Error)
+	require.NoError(t, logRepo.Truncate(10))
+
+	count, err = logRepo.Count(10)
+	require.NoError(t, err)
+	require.Equal(t, int64(4100), count)
+	count, err = logRepo.Count(11)
+	require.NoError(t, err)
+	require.Equal(t, int64(8100), count)
+
+	logs, err = logRepo.List(10)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2000), logs[1999].Timestamp)
+	require.Equal(t, "truncate", logs[2000].Kind)
+	require.Equal(t, uint64(6002), logs[2001].Timestamp)
+}
+
+func (s *Suite) TestToken(t *testing.T) {
+	r := s.NewRepo(t)
+	tokenRepo := r.TokenRepo()
+
+	_, err := tokenRepo.Get("missing")
+	require.True(t, xerrors.Is(err, gorm.ErrRecordNotFound))
+	require.True(t, xerrors.Is(tokenRepo.Revoke("missing"), gorm.ErrRecordNotFound))
+
+	created := time.Unix(1600000000, 0)
+	worker := &types.TokenInfo{ID: uuid.New().String(), Name: "worker-1", Perms: []string{"worker"}, Created: created}
+	monitor := &types.TokenInfo{
+		ID:      uuid.New().String(),
+		Name:    "monitor",
+		Methods: []string{"SectorsSummary"},
+		Created: created.Add(time.Second),
+		Expires: created.Add(time.Hour),
+	}
+	require.NoError(t, tokenRepo.Save(worker))
+	require.NoError(t, tokenRepo.Save(monitor))
+
+	got, err := tokenRepo.Get(monitor.ID)
+	require.NoError(t, err)
+	require.Equal(t, monitor.Methods, got.Methods)
+	require.Equal(t, monitor.Expires.Unix(), got.Expires.Unix())
+	require.False(t, got.Revoked)
+
+	require.NoError(t, tokenRepo.Revoke(worker.ID))
+	all, err := tokenRepo.List()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	require.Equal(t, worker.ID, all[0].ID)
+	require.True(t, all[0].Revoked)
+	require.True(t, all[0].Expires.IsZero())
+}
+
+func (s *Suite) TestSectorEvent(t *testing.T) {
+	r := s.NewRepo(t)
+	eventRepo := r.SectorEventRepo()
+
+	_, err := eventRepo.Latest(1)
+	require.True(t, xerrors.Is(err, gorm.ErrRecordNotFound))
+
+	start := time.Unix(1600000000, 0)
+	events := []*types.SectorEvent{
+		{SectorNumber: 1, Time: start, Event: "SectorStart", From: types.UndefinedSectorState, To: types.Packing},
+		{SectorNumber: 1, Time: start.Add(time.Minute), Event: "SectorPacked", From: types.Packing, To: types.PreCommit1, Duration: time.Minute},
+		{SectorNumber: 2, Time: start.Add(2 * time.Minute), Event: "SectorSealPreCommit1Failed", From: types.PreCommit1, To: types.SealPreCommit1Failed, Worker: "worker-1", Error: "boom", ErrorClass: "pc1"},
+		{SectorNumber: 1, Time: start.Add(time.Hour), Event: "SectorPreCommit1", From: types.PreCommit1, To: types.PreCommit2, Worker: "worker-1", Duration: time.Hour},
+	}
+	for _, event := range events {
+		require.NoError(t, eventRepo.Append(event))
+	}
+
+	latest, err := eventRepo.Latest(1)
+	require.NoError(t, err)
+	require.Equal(t, "SectorPreCommit1", latest.Event)
+	require.Equal(t, time.Hour, latest.Duration)
+	require.Equal(t, start.Add(time.Hour).UnixNano(), latest.Time.UnixNano())
+
+	all, err := eventRepo.Query(&types.SectorEventFilter{})
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+	require.Equal(t, "SectorStart", all[0].Event)
+
+	got, err := eventRepo.Query(&types.SectorEventFilter{Sectors: []abi.SectorNumber{1}, Since: start.Add(time.Second)})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, types.Packing, got[0].From)
+
+	got, err = eventRepo.Query(&types.SectorEventFilter{State: types.PreCommit1})
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+
+	got, err = eventRepo.Query(&types.SectorEventFilter{OnlyErrors: true})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, "pc1", got[0].ErrorClass)
+	require.Equal(t, "boom", got[0].Error)
+
+	got, err = eventRepo.Query(&types.SectorEventFilter{Worker: "worker-1", Until: start.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+
+	got, err = eventRepo.Query(&types.SectorEventFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, abi.SectorNumber(2), got[0].SectorNumber)
+	require.Equal(t, "SectorPreCommit1", got[1].Event)
+}
+
+func (s *Suite) TestStorageIndex(t *testing.T) {
+	r := s.NewRepo(t)
+	indexRepo := r.StorageIndexRepo()
+
+	storages, err := indexRepo.ListStorages()
+	require.NoError(t, err)
+	require.Len(t, storages, 0)
+
+	require.NoError(t, indexRepo.SaveStorage(&types.StorageRecord{ID: "b", URLs: []string{"http://b/remote"}, Weight: 10, CanStore: true}))
+	require.NoError(t, indexRepo.SaveStorage(&types.StorageRecord{ID: "a", URLs: []string{"http://a1/remote", "http://a2/remote"}, MaxStorage: 1 << 40, CanSeal: true}))
+
+	storages, err = indexRepo.ListStorages()
+	require.NoError(t, err)
+	require.Len(t, storages, 2)
+	require.Equal(t, "a", storages[0].ID)
+	require.Equal(t, []string{"http://a1/remote", "http://a2/remote"}, storages[0].URLs)
+	require.Equal(t, uint64(1<<40), storages[0].MaxStorage)
+	require.True(t, storages[0].CanSeal)
+	require.True(t, storages[0].LastHeartbeat.IsZero())
+
+	at := time.Unix(1600000000, 0)
+	require.NoError(t, indexRepo.UpdateHeartbeat("b", at, "stat failed"))
+	// attaching again replaces the storage
+	require.NoError(t, indexRepo.SaveStorage(&types.StorageRecord{ID: "a", URLs: []string{"http://a1/remote"}, CanSeal: true, LastHeartbeat: at,
+		Groups: []string{"ssd"}, AllowTo: []string{"hdd"}, DenyTypes: []string{"unsealed"}, AllowKinds: []string{"deal"}}))
+
+	storages, err = indexRepo.ListStorages()
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://a1/remote"}, storages[0].URLs)
+	require.Equal(t, []string{"ssd"}, storages[0].Groups)
+	require.Equal(t, []string{"hdd"}, storages[0].AllowTo)
+	require.Nil(t, storages[0].AllowTypes)
+	require.Equal(t, []string{"unsealed"}, storages[0].DenyTypes)
+	require.Equal(t, []string{"deal"}, storages[0].AllowKinds)
+	require.Nil(t, storages[1].Groups)
+	require.Equal(t, at.UnixNano(), storages[0].LastHeartbeat.UnixNano())
+	require.Equal(t, at.UnixNano(), storages[1].LastHeartbeat.UnixNano())
+	require.Equal(t, "stat failed", storages[1].HeartbeatErr)
+	require.Equal(t, uint64(10), storages[1].Weight)
+
+	decls := []*types.SectorDeclRecord{
+		{StorageID: "a", Miner: 1000, Number: 2, FileType: 1, Primary: true},
+		{StorageID: "a", Miner: 1000, Number: 1, FileType: 2},
+		{StorageID: "b", Miner: 1000, Number: 1, FileType: 2, Primary: true},
+	}
+	for _, decl := range decls {
+		require.NoError(t, indexRepo.DeclareSector(decl))
+	}
+	// declaring again updates the primary flag
+	require.NoError(t, indexRepo.DeclareSector(&types.SectorDeclRecord{StorageID: "a", Miner: 1000, Number: 1, FileType: 2, Primary: true}))
+
+	got, err := indexRepo.ListSectorDecls()
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	require.Equal(t, &types.SectorDeclRecord{StorageID: "a", Miner: 1000, Number: 1, FileType: 2, Primary: true}, got[0])
+	require.Equal(t, "b", got[1].StorageID)
+	require.Equal(t, abi.SectorNumber(2), got[2].Number)
+
+	require.NoError(t, indexRepo.DropSector(&types.SectorDeclRecord{StorageID: "a", Miner: 1000, Number: 1, FileType: 2}))
+	got, err = indexRepo.ListSectorDecls()
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, "b", got[0].StorageID)
+}
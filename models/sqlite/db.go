@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/models/migration"
 	"github.com/filecoin-project/venus-sealer/models/repo"
 	"github.com/mitchellh/go-homedir"
 	"golang.org/x/xerrors"
@@ -21,6 +22,18 @@ func (d SqlLiteRepo) LogRepo() repo.LogRepo {
 	return newLogRepo(d.GetDb())
 }
 
+func (d SqlLiteRepo) TokenRepo() repo.TokenRepo {
+	return newTokenRepo(d.GetDb())
+}
+
+func (d SqlLiteRepo) SectorEventRepo() repo.SectorEventRepo {
+	return newSectorEventRepo(d.GetDb())
+}
+
+func (d SqlLiteRepo) StorageIndexRepo() repo.StorageIndexRepo {
+	return newStorageIndexRepo(d.GetDb())
+}
+
 func (d SqlLiteRepo) WorkerCallRepo() repo.WorkerCallRepo {
 	return newWorkerCallRepo(d.GetDb())
 }
@@ -37,38 +50,24 @@ func (d SqlLiteRepo) MetaDataRepo() repo.MetaDataRepo {
 	return newMetadataRepo(d.GetDb())
 }
 
+// AutoMigrate runs the numbered schema migrations, it fails if the database is newer than this binary
 func (d SqlLiteRepo) AutoMigrate() error {
-	err := d.GetDb().AutoMigrate(&dealRef{})
-	if err != nil {
-		return err
-	}
-
-	err = d.GetDb().AutoMigrate(&metadata{})
-	if err != nil {
-		return err
-	}
-
-	err = d.GetDb().AutoMigrate(&Log{})
-	if err != nil {
-		return err
-	}
-
-	err = d.GetDb().AutoMigrate(&sectorInfo{})
-	if err != nil {
-		return err
-	}
-
-	err = d.GetDb().AutoMigrate(&workerCall{})
-	if err != nil {
-		return err
-	}
+	return migration.Run(d.GetDb(), migrations)
+}
 
-	err = d.GetDb().AutoMigrate(&workerState{})
+// SchemaVersion returns the schema version of the database and the latest version of this binary
+func (d SqlLiteRepo) SchemaVersion() (uint64, uint64, error) {
+	current, err := migration.Version(d.GetDb())
 	if err != nil {
-		return err
+		return 0, 0, err
 	}
+	return current, migration.Latest(migrations), nil
+}
 
-	return nil
+func (d SqlLiteRepo) Transaction(fn func(txRepo repo.Repo) error) error {
+	return d.GetDb().Transaction(func(tx *gorm.DB) error {
+		return fn(&SqlLiteRepo{tx})
+	})
 }
 
 func (d SqlLiteRepo) GetDb() *gorm.DB {
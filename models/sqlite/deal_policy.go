@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"gorm.io/gorm/clause"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// the deal policy has a single row
+const dealPolicyID = 1
+
+type dealPolicy struct {
+	Id                             uint64 `gorm:"column:id;type:bigint;primary_key;" json:"id"`
+	ConsiderOnlineStorageDeals     bool   `gorm:"column:consider_online_storage_deals;type:boolean;NOT NULL" json:"consider_online_storage_deals"`
+	ConsiderOfflineStorageDeals    bool   `gorm:"column:consider_offline_storage_deals;type:boolean;NOT NULL" json:"consider_offline_storage_deals"`
+	ConsiderOnlineRetrievalDeals   bool   `gorm:"column:consider_online_retrieval_deals;type:boolean;NOT NULL" json:"consider_online_retrieval_deals"`
+	ConsiderOfflineRetrievalDeals  bool   `gorm:"column:consider_offline_retrieval_deals;type:boolean;NOT NULL" json:"consider_offline_retrieval_deals"`
+	ConsiderVerifiedStorageDeals   bool   `gorm:"column:consider_verified_storage_deals;type:boolean;NOT NULL" json:"consider_verified_storage_deals"`
+	ConsiderUnverifiedStorageDeals bool   `gorm:"column:consider_unverified_storage_deals;type:boolean;NOT NULL" json:"consider_unverified_storage_deals"`
+	PieceCidBlocklist              string `gorm:"column:piece_cid_blocklist;type:text;" json:"piece_cid_blocklist"`
+	ExpectedSealDuration           int64  `gorm:"column:expected_seal_duration;type:bigint;" json:"expected_seal_duration"`
+}
+
+func (p *dealPolicy) TableName() string {
+	return "deal_policies"
+}
+
+func fromDealPolicy(policy *types.DealPolicy) (*dealPolicy, error) {
+	blocklist, err := json.Marshal(policy.PieceCidBlocklist)
+	if err != nil {
+		return nil, err
+	}
+	return &dealPolicy{
+		Id:                             dealPolicyID,
+		ConsiderOnlineStorageDeals:     policy.ConsiderOnlineStorageDeals,
+		ConsiderOfflineStorageDeals:    policy.ConsiderOfflineStorageDeals,
+		ConsiderOnlineRetrievalDeals:   policy.ConsiderOnlineRetrievalDeals,
+		ConsiderOfflineRetrievalDeals:  policy.ConsiderOfflineRetrievalDeals,
+		ConsiderVerifiedStorageDeals:   policy.ConsiderVerifiedStorageDeals,
+		ConsiderUnverifiedStorageDeals: policy.ConsiderUnverifiedStorageDeals,
+		PieceCidBlocklist:              string(blocklist),
+		ExpectedSealDuration:           int64(policy.ExpectedSealDuration),
+	}, nil
+}
+
+func (p *dealPolicy) Policy() (*types.DealPolicy, error) {
+	blocklist := []cid.Cid{}
+	if len(p.PieceCidBlocklist) > 0 {
+		if err := json.Unmarshal([]byte(p.PieceCidBlocklist), &blocklist); err != nil {
+			return nil, err
+		}
+	}
+	return &types.DealPolicy{
+		ConsiderOnlineStorageDeals:     p.ConsiderOnlineStorageDeals,
+		ConsiderOfflineStorageDeals:    p.ConsiderOfflineStorageDeals,
+		ConsiderOnlineRetrievalDeals:   p.ConsiderOnlineRetrievalDeals,
+		ConsiderOfflineRetrievalDeals:  p.ConsiderOfflineRetrievalDeals,
+		ConsiderVerifiedStorageDeals:   p.ConsiderVerifiedStorageDeals,
+		ConsiderUnverifiedStorageDeals: p.ConsiderUnverifiedStorageDeals,
+		PieceCidBlocklist:              blocklist,
+		ExpectedSealDuration:           time.Duration(p.ExpectedSealDuration),
+	}, nil
+}
+
+func (m *metadataRepo) GetDealPolicy() (*types.DealPolicy, error) {
+	var policy dealPolicy
+	if err := m.DB.Take(&policy, "id = ?", dealPolicyID).Error; err != nil {
+		return nil, err
+	}
+	return policy.Policy()
+}
+
+func (m *metadataRepo) SaveDealPolicy(policy *types.DealPolicy) error {
+	row, err := fromDealPolicy(policy)
+	if err != nil {
+		return err
+	}
+	return m.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(row).Error
+}
@@ -64,7 +64,7 @@ func (s *logRepo) Count(sectorNumber abi.SectorNumber) (int64, error) {
 
 func (s *logRepo) Truncate(sectorNumber abi.SectorNumber) error {
 	var ids []int64
-	err := s.DB.Raw("SELECT id FROM logs WHERE id =?", sectorNumber).Scan(&ids).Error
+	err := s.DB.Raw("SELECT id FROM logs WHERE sector_number=?", sectorNumber).Scan(&ids).Error
 	if err != nil {
 		return err
 	}
@@ -72,9 +72,9 @@ func (s *logRepo) Truncate(sectorNumber abi.SectorNumber) error {
 		return ids[i] < ids[j]
 	})
 	if len(ids) > 8000 {
+		// keep the first 2000 logs and the ones from 6000 on, the first kept one after the gap marks the truncation
 		removeIds := ids[2000:6000]
-		leftIds := append(ids[:2000], ids[6000:]...)
-		modifyId := leftIds[2000]
+		modifyId := ids[6000]
 		err = s.DB.Delete(&Log{}, "id in ?", removeIds).Error
 		if err != nil {
 			return err
@@ -95,9 +95,13 @@ func (s *logRepo) Truncate(sectorNumber abi.SectorNumber) error {
 }
 
 func (s *logRepo) Append(log *types.Log) error {
+	timestamp := log.Timestamp
+	if timestamp == 0 {
+		timestamp = uint64(time.Now().Unix())
+	}
 	return s.DB.Save(&Log{
 		SectorNumber: uint64(log.SectorNumber),
-		Timestamp:    uint64(time.Now().Unix()),
+		Timestamp:    timestamp,
 		Trace:        log.Trace,
 		Message:      log.Message,
 		Kind:         log.Kind,
@@ -118,6 +122,34 @@ func (s *logRepo) List(sectorNumber abi.SectorNumber) ([]*types.Log, error) {
 	return tLogs, nil
 }
 
+func (s *logRepo) ListAll() ([]*types.Log, error) {
+	var logs []Log
+	err := s.DB.Table("logs").Order("id").Find(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	tLogs := make([]*types.Log, len(logs))
+	for index, log := range logs {
+		tLogs[index] = log.Log()
+	}
+	return tLogs, nil
+}
+
+func (s *logRepo) ListByKind(kind string, limit int) ([]*types.Log, error) {
+	var logs []Log
+	err := s.DB.Table("logs").Where("kind=?", kind).Order("id desc").Limit(limit).Find(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	tLogs := make([]*types.Log, len(logs))
+	for index, log := range logs {
+		tLogs[index] = log.Log()
+	}
+	return tLogs, nil
+}
+
 func (s *logRepo) DelLogs(sectorNumber uint64) error {
 	return s.DB.Table("logs").Delete(&Log{}, "sector_number=?", sectorNumber).Error
 }
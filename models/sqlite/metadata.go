@@ -57,6 +57,14 @@ func (m *metadataRepo) GetMinerAddress() (address.Address, error) {
 	return addr, nil
 }
 
+func (m *metadataRepo) GetStorageCounter() (uint64, error) {
+	var meta metadata
+	if err := m.DB.First(&meta).Error; err != nil {
+		return 0, err
+	}
+	return meta.SectorCount, nil
+}
+
 func (m *metadataRepo) IncreaseStorageCounter() (abi.SectorNumber, error) {
 	m.lk.Lock()
 	defer m.lk.Unlock()
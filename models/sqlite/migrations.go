@@ -0,0 +1,338 @@
+package sqlite
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/filecoin-project/venus-sealer/models/migration"
+)
+
+// migrations of the sqlite schema, append new steps here and never change a released one. Each step migrates
+// frozen copies of the models below, never the live models which change with later versions
+var migrations = []migration.Migration{
+	{
+		Version: 1,
+		Name:    "initial schema",
+		Migrate: func(tx *gorm.DB) error {
+			// idempotent, so databases created before schema versioning are adopted as version 1
+			return tx.AutoMigrate(&dealRefV1{}, &metadataV1{}, &logV1{}, &sectorInfoV1{}, &workerCallV1{}, &workerStateV1{})
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add deal policies",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&dealPolicyV2{})
+		},
+	},
+	{
+		Version: 3,
+		Name:    "add piece and payload cids to deal refs",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&dealRefV3{})
+		},
+	},
+	{
+		Version: 4,
+		Name:    "add api tokens",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&apiTokenV4{})
+		},
+	},
+	{
+		Version: 5,
+		Name:    "add sector events",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&sectorEventV5{}); err != nil {
+				return err
+			}
+			return migration.BackfillSectorEvents(tx)
+		},
+	},
+	{
+		Version: 6,
+		Name:    "add sector storage index",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&sectorStorageV6{}, &sectorStorageDeclV6{})
+		},
+	},
+	{
+		Version: 7,
+		Name:    "add placement rules to sector storages",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&sectorStorageV7{})
+		},
+	},
+}
+
+// the models as each migration created them
+
+// version 1
+
+type dealRefV1 struct {
+	Id        string `gorm:"column:id;type:varchar(36);primary_key;" json:"id"` // 主键
+	DealId    uint64 `gorm:"column:deal_id;type:unsigned bigint;" json:"deal_id"`
+	SectorId  uint64 `gorm:"column:sector_id;type:unsigned bigint;" json:"sector_id"`
+	PadOffset uint64 `gorm:"column:offset_pad;type:unsigned bigint;" json:"offset_pad"`
+	UnPadSize uint64 `gorm:"column:size_unpad;type:unsigned bigint;" json:"size_unpad"`
+}
+
+func (dealRefV1) TableName() string {
+	return "deal_refs"
+}
+
+type metadataV1 struct {
+	Id           string    `gorm:"column:id;type:varchar(36);primary_key;" json:"id"` // 主键
+	MinerAddress string    `gorm:"column:miner_address;type:varchar(256);NOT NULL" json:"miner_address"`
+	SectorCount  uint64    `gorm:"column:sector_count;type:bigint(20);NOT NULL" json:"sector_count"`
+	IsDeleted    int       `gorm:"column:is_deleted;default:-1;NOT NULL" json:"is_deleted"`               // 是否删除 1:是  -1:否
+	CreatedAt    time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL" json:"create_at"` // 创建时间
+	UpdatedAt    time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL" json:"update_at"` // 更新时间
+}
+
+func (metadataV1) TableName() string {
+	return "metadata"
+}
+
+type logV1 struct {
+	Id           int64  `gorm:"column:id;types:integer;primary_key;autoIncrement;" json:"id"` // 主键、
+	SectorNumber uint64 `gorm:"column:sector_number;type:unsigned bigint;index:log_sector_number" json:"sector_number"`
+	Timestamp    uint64 `gorm:"column:timestamp;type:unsigned bigint;" json:"timestamp"`
+	// for errors
+	Trace   string `gorm:"column:trace;type:text;" json:"trace"`
+	Message string `gorm:"column:message;type:text;" json:"message"`
+	// additional data (Event info)
+	Kind string `gorm:"column:kind;type:varchar(256);" json:"kind"`
+}
+
+func (logV1) TableName() string {
+	return "logs"
+}
+
+type sectorInfoV1 struct {
+	Id           string `gorm:"column:id;type:varchar(36);primary_key;" json:"id"` // 主键
+	SectorNumber uint64 `gorm:"uniqueIndex;column:sector_number;type:unsigned bigint;" json:"sector_number"`
+	State        string `gorm:"column:state;type:varchar(256);" json:"state"`
+	SectorType   int64  `gorm:"column:sector_type;type:bigint;" json:"sector_type"`
+
+	// Packing  []Piece
+	Pieces []byte `gorm:"column:pieces;type:blob;" json:"pieces"`
+
+	// PreCommit1
+	TicketValue   []byte `gorm:"column:ticket_value;type:blob;" json:"ticket_value"`
+	TicketEpoch   int64  `gorm:"column:ticket_epoch;type:bigint;" json:"ticket_epoch"`
+	PreCommit1Out []byte `gorm:"column:pre_commit1_out;type:blob;" json:"pre_commit1_out"`
+
+	// PreCommit2
+	CommD string `gorm:"column:commd;type:varchar(256);" json:"commd"`
+	CommR string `gorm:"column:commr;type:varchar(256);" json:"commr"`
+	Proof []byte `gorm:"column:proof;type:blob;" json:"proof"`
+
+	//*miner.SectorPreCommitInfo
+	PreCommitInfo    sectorPreCommitInfoV1 `gorm:"embedded;embeddedPrefix:precommit_"`
+	PreCommitDeposit string                `gorm:"column:pre_commit_deposit;type:varchar(256);" json:"pre_commit_deposit"`
+	PreCommitMessage string                `gorm:"column:pre_commit_message;type:varchar(256);" json:"pre_commit_message"`
+	PreCommitTipSet  []byte                `gorm:"column:pre_commit_tipset;type:blob;" json:"pre_commit_tipset"`
+
+	PreCommit2Fails uint64 `gorm:"column:pre_commit2_fails;type:unsigned bigint;" json:"pre_commit2_fails"`
+
+	// WaitSeed
+	SeedValue []byte `gorm:"column:seed_value;type:blob;" json:"seed_value"`
+	SeedEpoch int64  `gorm:"column:seed_epoch;type:bigint;" json:"seed_epoch"`
+
+	// Committing
+	CommitMessage string `gorm:"column:commit_message;type:text;" json:"commit_message"`
+	InvalidProofs uint64 `gorm:"column:invalid_proofs;type:unsigned bigint;" json:"invalid_proofs"`
+
+	// Faults
+	FaultReportMsg string `gorm:"column:fault_report_msg;type:text;" json:"fault_report_msg"`
+
+	// Recovery
+	Return string `gorm:"column:return;type:text;" json:"return"`
+
+	// Termination
+	TerminateMessage string `gorm:"column:terminate_message;type:text;" json:"terminate_message"`
+	TerminatedAt     int64  `gorm:"column:terminated_at;type:bigint;" json:"terminated_at"`
+
+	// Debug
+	LastErr string `gorm:"column:last_err;type:text;" json:"last_err"`
+}
+
+func (sectorInfoV1) TableName() string {
+	return "sectors_infos"
+}
+
+type sectorPreCommitInfoV1 struct {
+	SealProof     int64  `gorm:"column:seal_proof;type:bigint;" json:"seal_proof"`
+	SealedCID     string `gorm:"column:sealed_cid;type:varchar(256);" json:"sealed_cid"`
+	SealRandEpoch int64  `gorm:"column:seal_rand_epoch;type:bigint;" json:"seal_rand_epoch"`
+	// []uint64
+	DealIDs    string `gorm:"column:deal_ids;type:text;" json:"deal_ids"`
+	Expiration int64  `gorm:"column:expiration;type:bigint;" json:"expiration"`
+	//-1 false 1 true
+	ReplaceCapacity        int    `gorm:"column:replace_capacity;type:int;" json:"replace_capacity"`
+	ReplaceSectorDeadline  uint64 `gorm:"column:replace_sector_deadline;type:unsigned bigint;" json:"replace_sector_deadline"`
+	ReplaceSectorPartition uint64 `gorm:"column:replace_sector_partition;type:unsigned bigint;" json:"replace_sector_partition"`
+	ReplaceSectorNumber    uint64 `gorm:"column:replace_sector_number;type:unsigned bigint;" json:"replace_sector_number"`
+}
+
+type workerCallV1 struct {
+	Id string `gorm:"column:id;type:varchar(36);primary_key;" json:"id"` // 主键
+	//types.CallID
+	WorkId   string `gorm:"uniqueIndex:call_id;column:work_id;type:varchar(36);" json:"work_id"`
+	MinerID  uint64 `gorm:"uniqueIndex:call_id;column:miner_id;type:unsigned bigint;" json:"miner_id"`
+	SectorId uint64 `gorm:"uniqueIndex:call_id;column:sector_id;type:unsigned bigint;" json:"sector_id"`
+	Role     string `gorm:"uniqueIndex:call_id;column:role;type:unsigned bigint;" json:"role"`
+
+	RetType string `gorm:"column:ret_type;type:varchar(256);" json:"ret_type"`
+
+	State uint64 `gorm:"column:state;type:unsigned bigint;" json:"state"`
+
+	//json byte
+	Result []byte `gorm:"column:result;type:blob;" json:"result"`
+}
+
+func (workerCallV1) TableName() string {
+	return "worker_calls"
+}
+
+type workerStateV1 struct {
+	Id         string `gorm:"column:id;type:varchar(36);primary_key;" json:"id"` // 主键
+	Method     string `gorm:"uniqueIndex:method_params_hash;column:method;type:varchar(256);" json:"method"`
+	ParamsHash string `gorm:"uniqueIndex:method_params_hash;column:params_hash;type:varchar(64);" json:"params_hash"`
+	Params     string `gorm:"column:params;type:text;" json:"params"`
+	Status     string `gorm:"column:status;type:varchar(256);" json:"status"`
+	WorkId     string `gorm:"column:work_id;type:varchar(36);" json:"work_id"`
+	MinerID    uint64 `gorm:"column:miner_id;type:unsigned bigint;" json:"miner_id"`
+	SectorId   uint64 `gorm:"column:sector_id;type:unsigned bigint;" json:"sector_id"`
+
+	WorkError string `gorm:"column:work_error;type:varchar(256);" json:"work_error"`
+
+	WorkerHostname string `gorm:"column:worker_host_name;type:varchar(256);" json:"worker_host_name"`
+	StartTime      int64  `gorm:"column:start_time;type:bigint;" json:"start_time"`
+}
+
+func (workerStateV1) TableName() string {
+	return "worker_states"
+}
+
+// version 2
+
+type dealPolicyV2 struct {
+	Id                             uint64 `gorm:"column:id;type:bigint;primary_key;" json:"id"`
+	ConsiderOnlineStorageDeals     bool   `gorm:"column:consider_online_storage_deals;type:boolean;NOT NULL" json:"consider_online_storage_deals"`
+	ConsiderOfflineStorageDeals    bool   `gorm:"column:consider_offline_storage_deals;type:boolean;NOT NULL" json:"consider_offline_storage_deals"`
+	ConsiderOnlineRetrievalDeals   bool   `gorm:"column:consider_online_retrieval_deals;type:boolean;NOT NULL" json:"consider_online_retrieval_deals"`
+	ConsiderOfflineRetrievalDeals  bool   `gorm:"column:consider_offline_retrieval_deals;type:boolean;NOT NULL" json:"consider_offline_retrieval_deals"`
+	ConsiderVerifiedStorageDeals   bool   `gorm:"column:consider_verified_storage_deals;type:boolean;NOT NULL" json:"consider_verified_storage_deals"`
+	ConsiderUnverifiedStorageDeals bool   `gorm:"column:consider_unverified_storage_deals;type:boolean;NOT NULL" json:"consider_unverified_storage_deals"`
+	PieceCidBlocklist              string `gorm:"column:piece_cid_blocklist;type:text;" json:"piece_cid_blocklist"`
+	ExpectedSealDuration           int64  `gorm:"column:expected_seal_duration;type:bigint;" json:"expected_seal_duration"`
+}
+
+func (dealPolicyV2) TableName() string {
+	return "deal_policies"
+}
+
+// version 3
+
+type dealRefV3 struct {
+	Id         string `gorm:"column:id;type:varchar(36);primary_key;" json:"id"` // 主键
+	DealId     uint64 `gorm:"column:deal_id;type:unsigned bigint;" json:"deal_id"`
+	SectorId   uint64 `gorm:"column:sector_id;type:unsigned bigint;" json:"sector_id"`
+	PadOffset  uint64 `gorm:"column:offset_pad;type:unsigned bigint;" json:"offset_pad"`
+	UnPadSize  uint64 `gorm:"column:size_unpad;type:unsigned bigint;" json:"size_unpad"`
+	PieceCid   string `gorm:"column:piece_cid;type:varchar(256);index:deal_refs_piece_cid;" json:"piece_cid"`
+	PayloadCid string `gorm:"column:payload_cid;type:varchar(256);index:deal_refs_payload_cid;" json:"payload_cid"`
+}
+
+func (dealRefV3) TableName() string {
+	return "deal_refs"
+}
+
+// version 4
+
+type apiTokenV4 struct {
+	Id        string `gorm:"column:id;type:varchar(36);primary_key;" json:"id"`
+	Name      string `gorm:"column:name;type:varchar(256);" json:"name"`
+	Perms     string `gorm:"column:perms;type:text;" json:"perms"`
+	Methods   string `gorm:"column:methods;type:text;" json:"methods"`
+	CreatedAt int64  `gorm:"column:created_at;type:bigint;" json:"created_at"`
+	ExpiresAt int64  `gorm:"column:expires_at;type:bigint;" json:"expires_at"`
+	Revoked   bool   `gorm:"column:revoked;type:boolean;NOT NULL" json:"revoked"`
+}
+
+func (apiTokenV4) TableName() string {
+	return "api_tokens"
+}
+
+// version 5
+
+type sectorEventV5 struct {
+	Id           int64  `gorm:"column:id;type:integer;primary_key;autoIncrement;" json:"id"`
+	SectorNumber uint64 `gorm:"column:sector_number;type:unsigned bigint;index:sector_event_sector_number" json:"sector_number"`
+	// unix nanoseconds
+	Time       int64  `gorm:"column:time;type:bigint;index:sector_event_time" json:"time"`
+	Event      string `gorm:"column:event;type:varchar(128);index:sector_event_event" json:"event"`
+	FromState  string `gorm:"column:from_state;type:varchar(64);index:sector_event_from_state" json:"from_state"`
+	ToState    string `gorm:"column:to_state;type:varchar(64);index:sector_event_to_state" json:"to_state"`
+	Worker     string `gorm:"column:worker;type:varchar(256);index:sector_event_worker" json:"worker"`
+	Duration   int64  `gorm:"column:duration;type:bigint;" json:"duration"`
+	Error      string `gorm:"column:error;type:text;" json:"error"`
+	ErrorClass string `gorm:"column:error_class;type:varchar(64);index:sector_event_error_class" json:"error_class"`
+}
+
+func (sectorEventV5) TableName() string {
+	return "sector_events"
+}
+
+// version 6
+
+type sectorStorageV6 struct {
+	Id         string `gorm:"column:id;type:varchar(128);primary_key;" json:"id"`
+	Urls       string `gorm:"column:urls;type:text;" json:"urls"`
+	Weight     uint64 `gorm:"column:weight;type:unsigned bigint;" json:"weight"`
+	MaxStorage uint64 `gorm:"column:max_storage;type:unsigned bigint;" json:"max_storage"`
+	CanSeal    bool   `gorm:"column:can_seal;type:boolean;NOT NULL" json:"can_seal"`
+	CanStore   bool   `gorm:"column:can_store;type:boolean;NOT NULL" json:"can_store"`
+	// unix nanoseconds
+	LastHeartbeat int64  `gorm:"column:last_heartbeat;type:bigint;" json:"last_heartbeat"`
+	HeartbeatErr  string `gorm:"column:heartbeat_err;type:text;" json:"heartbeat_err"`
+}
+
+func (sectorStorageV6) TableName() string {
+	return "sector_storages"
+}
+
+type sectorStorageDeclV6 struct {
+	StorageId string `gorm:"column:storage_id;type:varchar(128);primary_key;" json:"storage_id"`
+	Miner     uint64 `gorm:"column:miner;type:unsigned bigint;primary_key;" json:"miner"`
+	Number    uint64 `gorm:"column:number;type:unsigned bigint;primary_key;index:sector_storage_decl_number" json:"number"`
+	FileType  int    `gorm:"column:file_type;type:integer;primary_key;" json:"file_type"`
+	IsPrimary bool   `gorm:"column:is_primary;type:boolean;NOT NULL" json:"is_primary"`
+}
+
+func (sectorStorageDeclV6) TableName() string {
+	return "sector_storage_decls"
+}
+
+// version 7
+
+type sectorStorageV7 struct {
+	Id         string `gorm:"column:id;type:varchar(128);primary_key;" json:"id"`
+	Urls       string `gorm:"column:urls;type:text;" json:"urls"`
+	Weight     uint64 `gorm:"column:weight;type:unsigned bigint;" json:"weight"`
+	MaxStorage uint64 `gorm:"column:max_storage;type:unsigned bigint;" json:"max_storage"`
+	CanSeal    bool   `gorm:"column:can_seal;type:boolean;NOT NULL" json:"can_seal"`
+	CanStore   bool   `gorm:"column:can_store;type:boolean;NOT NULL" json:"can_store"`
+	// json of the storagePlacement
+	Placement string `gorm:"column:placement;type:text;" json:"placement"`
+	// unix nanoseconds
+	LastHeartbeat int64  `gorm:"column:last_heartbeat;type:bigint;" json:"last_heartbeat"`
+	HeartbeatErr  string `gorm:"column:heartbeat_err;type:text;" json:"heartbeat_err"`
+}
+
+func (sectorStorageV7) TableName() string {
+	return "sector_storages"
+}
@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/models/migration"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/models/repotest"
+	"github.com/filecoin-project/venus-sealer/types"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestSqliteRepo(t *testing.T) {
+	(&repotest.Suite{
+		NewRepo: func(t *testing.T) repo.Repo {
+			r, err := OpenSqlite(&config.SqliteConfig{Path: filepath.Join(t.TempDir(), "sealer.db")})
+			require.NoError(t, err)
+			require.NoError(t, r.AutoMigrate())
+			return r
+		},
+	}).RunTests(t, "")
+}
+
+// the live models must not have columns the frozen migrations never create
+func TestMigrationsCreateModels(t *testing.T) {
+	r, err := OpenSqlite(&config.SqliteConfig{Path: filepath.Join(t.TempDir(), "sealer.db")})
+	require.NoError(t, err)
+	defer r.DbClose() // nolint
+	require.NoError(t, r.AutoMigrate())
+
+	db := r.(*SqlLiteRepo).GetDb()
+	models := []interface{}{&dealRef{}, &metadata{}, &Log{}, &sectorInfo{}, &workerCall{}, &workerState{}, &dealPolicy{},
+		&apiToken{}, &sectorEvent{}, &sectorStorage{}, &sectorStorageDecl{}}
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(model))
+		require.True(t, db.Migrator().HasTable(model), stmt.Schema.Table)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			require.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s", stmt.Schema.Table, field.DBName)
+		}
+	}
+}
+
+// sectors logged before sector events were recorded get their events from the log
+func TestMigrationsBackfillSectorEvents(t *testing.T) {
+	r, err := OpenSqlite(&config.SqliteConfig{Path: filepath.Join(t.TempDir(), "sealer.db")})
+	require.NoError(t, err)
+	defer r.DbClose() // nolint
+	require.NoError(t, migration.Run(r.(*SqlLiteRepo).GetDb(), migrations[:4]))
+
+	for i, kind := range []string{"event;sealing.SectorStartCC", "event;sealing.SectorPacked", "event;sealing.SectorTicket", "event;sealing.SectorPreCommit1"} {
+		require.NoError(t, r.LogRepo().Append(&types.Log{SectorNumber: 3, Timestamp: uint64(1000 + i*60), Kind: kind, Message: "{}"}))
+	}
+	require.NoError(t, r.AutoMigrate())
+
+	events, err := r.SectorEventRepo().Query(&types.SectorEventFilter{Sectors: []abi.SectorNumber{3}})
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	require.Equal(t, types.PreCommit1, events[3].From)
+	require.Equal(t, types.PreCommit2, events[3].To)
+	require.Equal(t, time.Minute, events[3].Duration)
+}
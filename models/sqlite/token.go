@@ -0,0 +1,120 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type apiToken struct {
+	Id        string `gorm:"column:id;type:varchar(36);primary_key;" json:"id"`
+	Name      string `gorm:"column:name;type:varchar(256);" json:"name"`
+	Perms     string `gorm:"column:perms;type:text;" json:"perms"`
+	Methods   string `gorm:"column:methods;type:text;" json:"methods"`
+	CreatedAt int64  `gorm:"column:created_at;type:bigint;" json:"created_at"`
+	ExpiresAt int64  `gorm:"column:expires_at;type:bigint;" json:"expires_at"`
+	Revoked   bool   `gorm:"column:revoked;type:boolean;NOT NULL" json:"revoked"`
+}
+
+func (t *apiToken) TableName() string {
+	return "api_tokens"
+}
+
+func fromTokenInfo(token *types.TokenInfo) (*apiToken, error) {
+	perms, err := json.Marshal(token.Perms)
+	if err != nil {
+		return nil, err
+	}
+	methods, err := json.Marshal(token.Methods)
+	if err != nil {
+		return nil, err
+	}
+	row := &apiToken{
+		Id:        token.ID,
+		Name:      token.Name,
+		Perms:     string(perms),
+		Methods:   string(methods),
+		CreatedAt: token.Created.Unix(),
+		Revoked:   token.Revoked,
+	}
+	if !token.Expires.IsZero() {
+		row.ExpiresAt = token.Expires.Unix()
+	}
+	return row, nil
+}
+
+func (t *apiToken) TokenInfo() (*types.TokenInfo, error) {
+	info := &types.TokenInfo{
+		ID:      t.Id,
+		Name:    t.Name,
+		Created: time.Unix(t.CreatedAt, 0),
+		Revoked: t.Revoked,
+	}
+	if t.ExpiresAt != 0 {
+		info.Expires = time.Unix(t.ExpiresAt, 0)
+	}
+	if err := json.Unmarshal([]byte(t.Perms), &info.Perms); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(t.Methods), &info.Methods); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+var _ repo.TokenRepo = (*tokenRepo)(nil)
+
+type tokenRepo struct {
+	*gorm.DB
+}
+
+func newTokenRepo(db *gorm.DB) *tokenRepo {
+	return &tokenRepo{DB: db}
+}
+
+func (r *tokenRepo) Save(token *types.TokenInfo) error {
+	row, err := fromTokenInfo(token)
+	if err != nil {
+		return err
+	}
+	return r.DB.Save(row).Error
+}
+
+func (r *tokenRepo) Get(id string) (*types.TokenInfo, error) {
+	var row apiToken
+	if err := r.DB.Take(&row, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return row.TokenInfo()
+}
+
+func (r *tokenRepo) List() ([]*types.TokenInfo, error) {
+	var rows []*apiToken
+	if err := r.DB.Order("created_at").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*types.TokenInfo, 0, len(rows))
+	for _, row := range rows {
+		info, err := row.TokenInfo()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+func (r *tokenRepo) Revoke(id string) error {
+	res := r.DB.Model(&apiToken{}).Where("id = ?", id).Update("revoked", true)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -3,7 +3,6 @@ package venus_sealer
 import (
 	"context"
 	"encoding/hex"
-	"errors"
 	"net/http"
 	"time"
 
@@ -11,7 +10,6 @@ import (
 	"github.com/ipfs/go-datastore"
 	"github.com/mitchellh/go-homedir"
 	"go.uber.org/fx"
-	"go.uber.org/multierr"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
@@ -27,6 +25,7 @@ import (
 	sectorstorage "github.com/filecoin-project/venus-sealer/sector-storage"
 	"github.com/filecoin-project/venus-sealer/sector-storage/ffiwrapper"
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
 	"github.com/filecoin-project/venus-sealer/service"
 	"github.com/filecoin-project/venus-sealer/storage"
 	"github.com/filecoin-project/venus-sealer/storage-sealing/sealiface"
@@ -145,13 +144,42 @@ func SectorIDCounter(metaDataService *service.MetadataService) types2.SectorIDCo
 var WorkerCallsPrefix = datastore.NewKey("/worker/calls")
 var ManagerWorkPrefix = datastore.NewKey("/stmgr/calls")
 
+// SectorIndex loads the sector storage index persisted in the database, the sectors holding deals are
+// marked from their pieces so that the placement rules follow them across restarts
+func SectorIndex(storageIndexService *service.StorageIndexService, sectorInfoService *service.SectorInfoService, minerID types2.MinerID) (*stores.Index, error) {
+	index, err := stores.NewPersistedIndex(storageIndexService)
+	if err != nil {
+		return nil, err
+	}
+
+	var sectors []types2.SectorInfo
+	if err := sectorInfoService.List(&sectors); err != nil {
+		return nil, xerrors.Errorf("listing sectors: %w", err)
+	}
+	for _, sector := range sectors {
+		if !sector.HasDeals() {
+			continue
+		}
+
+		sid := abi.SectorID{Miner: abi.ActorID(minerID), Number: sector.SectorNumber}
+		if err := index.StorageSetSectorKind(context.TODO(), sid, storiface.KindDeal); err != nil {
+			return nil, xerrors.Errorf("marking deal sector %d: %w", sector.SectorNumber, err)
+		}
+	}
+
+	return index, nil
+}
+
 func LocalStorage(mctx MetricsCtx, lc fx.Lifecycle, ls stores.LocalStorage, si stores.SectorIndex, urls sectorstorage.URLs) (*stores.Local, error) {
 	ctx := LifecycleCtx(mctx, lc)
 	return stores.NewLocal(ctx, ls, si, urls)
 }
 
 func RemoteStorage(lstor *stores.Local, si stores.SectorIndex, sa sectorstorage.StorageAuth, sc sectorstorage.SealerConfig) *stores.Remote {
-	return stores.NewRemote(lstor, si, http.Header(sa), sc.ParallelFetchLimit, &stores.DefaultPartialFileHandler{})
+	remote := stores.NewRemote(lstor, si, http.Header(sa), sc.ParallelFetchLimit, &stores.DefaultPartialFileHandler{})
+	remote.SetBandwidthLimits(sc.FetchHostBandwidth, sc.FetchPathBandwidth)
+	remote.SetCompression(sc.CompressFetch)
+	return remote
 }
 
 func SectorStorage(mctx MetricsCtx, lc fx.Lifecycle, lstor *stores.Local, stor *stores.Remote, ls stores.LocalStorage, si stores.SectorIndex, sc sectorstorage.SealerConfig, repo repo.Repo) (*sectorstorage.Manager, error) {
@@ -208,26 +236,46 @@ func AddressSelector(addrConf *config.MinerAddressConfig) func() (*storage.Addre
 			return as, nil
 		}
 
-		for _, s := range addrConf.PreCommitControl {
-			addr, err := address.NewFromString(s)
-			if err != nil {
-				return nil, xerrors.Errorf("parsing precommit control address: %w", err)
-			}
+		ac, err := parseAddressConfig(addrConf)
+		if err != nil {
+			return nil, err
+		}
+		as.SetConfig(ac)
+
+		return as, nil
+	}
+}
 
-			as.PreCommitControl = append(as.PreCommitControl, addr)
+func parseAddressConfig(addrConf *config.MinerAddressConfig) (api.AddressConfig, error) {
+	var ac api.AddressConfig
+	for _, s := range addrConf.PreCommitControl {
+		addr, err := address.NewFromString(s)
+		if err != nil {
+			return api.AddressConfig{}, xerrors.Errorf("parsing precommit control address: %w", err)
 		}
 
-		for _, s := range addrConf.CommitControl {
-			addr, err := address.NewFromString(s)
-			if err != nil {
-				return nil, xerrors.Errorf("parsing commit control address: %w", err)
-			}
+		ac.PreCommitControl = append(ac.PreCommitControl, addr)
+	}
 
-			as.CommitControl = append(as.CommitControl, addr)
+	for _, s := range addrConf.CommitControl {
+		addr, err := address.NewFromString(s)
+		if err != nil {
+			return api.AddressConfig{}, xerrors.Errorf("parsing commit control address: %w", err)
 		}
 
-		return as, nil
+		ac.CommitControl = append(ac.CommitControl, addr)
+	}
+
+	for _, s := range addrConf.TerminateControl {
+		addr, err := address.NewFromString(s)
+		if err != nil {
+			return api.AddressConfig{}, xerrors.Errorf("parsing terminate control address: %w", err)
+		}
+
+		ac.TerminateControl = append(ac.TerminateControl, addr)
 	}
+
+	return ac, nil
 }
 
 type StorageMinerParams struct {
@@ -239,23 +287,27 @@ type StorageMinerParams struct {
 	Messager           api.IMessager
 	MetadataService    *service.MetadataService
 	LogService         *service.LogService
+	SectorEventService *service.SectorEventService
 	SectorInfoService  *service.SectorInfoService
 	Sealer             sectorstorage.SectorManager
 	SectorIDCounter    types2.SectorIDCounter
 	Verifier           ffiwrapper.Verifier
 	Prover             ffiwrapper.Prover
 	GetSealingConfigFn types2.GetSealingConfigFunc
+	GetFeeConfigFn     config.GetFeeConfigFunc
 	Journal            journal.Journal
 	AddrSel            *storage.AddressSelector
 	NetworkParams      *config.NetParamsConfig
 }
 
-func StorageMiner(fc config.MinerFeeConfig) func(params StorageMinerParams) (*storage.Miner, error) {
+func StorageMiner() func(params StorageMinerParams) (*storage.Miner, error) {
 	return func(params StorageMinerParams) (*storage.Miner, error) {
 		var (
+			fc                = params.GetFeeConfigFn
 			metadataService   = params.MetadataService
 			sectorinfoService = params.SectorInfoService
 			logService        = params.LogService
+			eventService      = params.SectorEventService
 			mctx              = params.MetricsCtx
 			lc                = params.Lifecycle
 			api               = params.API
@@ -282,7 +334,7 @@ func StorageMiner(fc config.MinerFeeConfig) func(params StorageMinerParams) (*st
 			return nil, err
 		}
 
-		sm, err := storage.NewMiner(api, messager, maddr, metadataService, sectorinfoService, logService, sealer, sc, verif, prover, gsd, fc, j, as, np)
+		sm, err := storage.NewMiner(api, messager, maddr, metadataService, sectorinfoService, logService, eventService, sealer, sc, verif, prover, gsd, fc, j, as, np)
 		if err != nil {
 			return nil, err
 		}
@@ -299,9 +351,9 @@ func StorageMiner(fc config.MinerFeeConfig) func(params StorageMinerParams) (*st
 	}
 }
 
-func NewSetSealConfigFunc(r *config.StorageMiner) (types2.SetSealingConfigFunc, error) {
+func NewSetSealConfigFunc(m *config.Manager) (types2.SetSealingConfigFunc, error) {
 	return func(cfg sealiface.Config) (err error) {
-		err = mutateCfg(r, func(c *config.StorageMiner) {
+		_, err = m.Update(func(c *config.StorageMiner) error {
 			c.Sealing = config.SealingConfig{
 				MaxWaitDealsSectors:       cfg.MaxWaitDealsSectors,
 				MaxSealingSectors:         cfg.MaxSealingSectors,
@@ -325,62 +377,88 @@ func NewSetSealConfigFunc(r *config.StorageMiner) (types2.SetSealingConfigFunc,
 				TerminateBatchMax:  cfg.TerminateBatchMax,
 				TerminateBatchMin:  cfg.TerminateBatchMin,
 				TerminateBatchWait: config.Duration(cfg.TerminateBatchWait),
+
+				TargetSealingSectors: cfg.TargetSealingSectors,
+				TargetSectors:        cfg.TargetSectors,
 			}
+			return nil
 		})
 		return
 	}, nil
 }
 
-func NewGetSealConfigFunc(r *config.StorageMiner) (types2.GetSealingConfigFunc, error) {
+func NewGetSealConfigFunc(m *config.Manager) (types2.GetSealingConfigFunc, error) {
 	return func() (out sealiface.Config, err error) {
-		err = readCfg(r, func(cfg *config.StorageMiner) {
-			out = sealiface.Config{
-				MaxWaitDealsSectors:       cfg.Sealing.MaxWaitDealsSectors,
-				MaxSealingSectors:         cfg.Sealing.MaxSealingSectors,
-				MaxSealingSectorsForDeals: cfg.Sealing.MaxSealingSectorsForDeals,
-				WaitDealsDelay:            time.Duration(cfg.Sealing.WaitDealsDelay),
-				AlwaysKeepUnsealedCopy:    cfg.Sealing.AlwaysKeepUnsealedCopy,
-				FinalizeEarly:             cfg.Sealing.FinalizeEarly,
-
-				BatchPreCommits:     cfg.Sealing.BatchPreCommits,
-				MaxPreCommitBatch:   cfg.Sealing.MaxPreCommitBatch,
-				PreCommitBatchWait:  time.Duration(cfg.Sealing.PreCommitBatchWait),
-				PreCommitBatchSlack: time.Duration(cfg.Sealing.PreCommitBatchSlack),
-
-				AggregateCommits:      cfg.Sealing.AggregateCommits,
-				MinCommitBatch:        cfg.Sealing.MinCommitBatch,
-				MaxCommitBatch:        cfg.Sealing.MaxCommitBatch,
-				CommitBatchWait:       time.Duration(cfg.Sealing.CommitBatchWait),
-				CommitBatchSlack:      time.Duration(cfg.Sealing.CommitBatchSlack),
-				AggregateAboveBaseFee: types.BigInt(cfg.Sealing.AggregateAboveBaseFee),
-
-				TerminateBatchMax:  cfg.Sealing.TerminateBatchMax,
-				TerminateBatchMin:  cfg.Sealing.TerminateBatchMin,
-				TerminateBatchWait: time.Duration(cfg.Sealing.TerminateBatchWait),
-			}
-		})
+		cfg := m.Get()
+		out = sealiface.Config{
+			MaxWaitDealsSectors:       cfg.Sealing.MaxWaitDealsSectors,
+			MaxSealingSectors:         cfg.Sealing.MaxSealingSectors,
+			MaxSealingSectorsForDeals: cfg.Sealing.MaxSealingSectorsForDeals,
+			WaitDealsDelay:            time.Duration(cfg.Sealing.WaitDealsDelay),
+			AlwaysKeepUnsealedCopy:    cfg.Sealing.AlwaysKeepUnsealedCopy,
+			FinalizeEarly:             cfg.Sealing.FinalizeEarly,
+
+			BatchPreCommits:     cfg.Sealing.BatchPreCommits,
+			MaxPreCommitBatch:   cfg.Sealing.MaxPreCommitBatch,
+			PreCommitBatchWait:  time.Duration(cfg.Sealing.PreCommitBatchWait),
+			PreCommitBatchSlack: time.Duration(cfg.Sealing.PreCommitBatchSlack),
+
+			AggregateCommits:      cfg.Sealing.AggregateCommits,
+			MinCommitBatch:        cfg.Sealing.MinCommitBatch,
+			MaxCommitBatch:        cfg.Sealing.MaxCommitBatch,
+			CommitBatchWait:       time.Duration(cfg.Sealing.CommitBatchWait),
+			CommitBatchSlack:      time.Duration(cfg.Sealing.CommitBatchSlack),
+			AggregateAboveBaseFee: types.BigInt(cfg.Sealing.AggregateAboveBaseFee),
+
+			TerminateBatchMax:  cfg.Sealing.TerminateBatchMax,
+			TerminateBatchMin:  cfg.Sealing.TerminateBatchMin,
+			TerminateBatchWait: time.Duration(cfg.Sealing.TerminateBatchWait),
+
+			TargetSealingSectors: cfg.Sealing.TargetSealingSectors,
+			TargetSectors:        cfg.Sealing.TargetSectors,
+		}
 		return
 	}, nil
 }
 
-func readCfg(cfg *config.StorageMiner, accessor func(*config.StorageMiner)) error {
-	accessor(cfg)
-	return nil
+func NewGetFeeConfigFunc(m *config.Manager) config.GetFeeConfigFunc {
+	return m.Fees
 }
 
-func mutateCfg(cfg *config.StorageMiner, mutator func(*config.StorageMiner)) error {
-	var typeErr error
-	setConfigErr := cfg.LocalStorage().SetConfig(func(raw interface{}) {
-		cfg, ok := raw.(*config.StorageMiner)
-		if !ok {
-			typeErr = errors.New("expected miner config")
-			return
+// StartConfigManager applies the reloadable config sections to the running components and reloads the
+// config when the config file changes
+func StartConfigManager(mctx MetricsCtx, lc fx.Lifecycle, m *config.Manager, as *storage.AddressSelector, remote *stores.Remote, sm *sectorstorage.Manager) error {
+	m.Subscribe("addresses", func(cfg *config.StorageMiner) error {
+		ac, err := parseAddressConfig(&cfg.Addresses)
+		if err != nil {
+			return err
 		}
-
-		mutator(cfg)
+		as.SetConfig(ac)
+		return nil
+	})
+	m.Subscribe("fetch", func(cfg *config.StorageMiner) error {
+		remote.SetFetchLimit(cfg.Storage.ParallelFetchLimit)
+		remote.SetBandwidthLimits(cfg.Storage.FetchHostBandwidth, cfg.Storage.FetchPathBandwidth)
+		remote.SetCompression(cfg.Storage.CompressFetch)
+		return nil
+	})
+	m.Subscribe("checks", func(cfg *config.StorageMiner) error {
+		sm.SetParallelCheckLimit(cfg.Storage.ParallelCheckLimit)
+		return nil
+	})
+	m.Subscribe("scheduler", func(cfg *config.StorageMiner) error {
+		sm.SetWinningPoStTimeout(cfg.Storage.WinningPoStTimeout)
+		sm.SetWindowPoStTimeout(cfg.Storage.WindowPoStTimeout)
+		return sm.SetSchedulerPolicy(cfg.Storage.Scheduler)
 	})
 
-	return multierr.Combine(typeErr, setConfigErr)
+	ctx := LifecycleCtx(mctx, lc)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return m.Watch(ctx)
+		},
+	})
+	return nil
 }
 
 // MetricsCtx is a context wrapper with metrics
@@ -0,0 +1,205 @@
+package proof_client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/metrics"
+	"github.com/filecoin-project/venus-sealer/storage"
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+const (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// nextBackoff doubles the backoff up to maxBackoff
+func nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// gateway holds the connection state of one gateway
+type gateway struct {
+	url string
+
+	lk     sync.Mutex
+	status types2.ProofGatewayStatus
+}
+
+func (gw *gateway) update(cb func(status *types2.ProofGatewayStatus)) {
+	gw.lk.Lock()
+	defer gw.lk.Unlock()
+	cb(&gw.status)
+}
+
+func (gw *gateway) proofDone(ctx context.Context, start time.Time, err error) {
+	latency := time.Since(start)
+	gw.update(func(status *types2.ProofGatewayStatus) {
+		if err != nil {
+			status.ProofsFailed++
+		} else {
+			status.ProofsServed++
+		}
+		status.LastProofLatency = latency
+	})
+
+	ctx, _ = tag.New(ctx, tag.Upsert(metrics.Gateway, gw.url))
+	if err != nil {
+		stats.Record(ctx, metrics.WinningPoStFailed.M(1))
+	} else {
+		stats.Record(ctx, metrics.WinningPoStServed.M(1))
+	}
+	stats.Record(ctx, metrics.WinningPoStDuration.M(metrics.SinceInMilliseconds(start)))
+}
+
+// ProofEventManager keeps a connection to every gateway in config, broken connections are retried with exponential backoff
+type ProofEventManager struct {
+	prover storage.WinningPoStProver
+	mAddr  types2.MinerAddress
+	token  string
+
+	gateways []*gateway
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewProofEventManager(prover storage.WinningPoStProver, cfg *config.RegisterProofConfig, mAddr types2.MinerAddress) *ProofEventManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &ProofEventManager{
+		prover: prover,
+		mAddr:  mAddr,
+		token:  cfg.Token,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for _, url := range cfg.Urls {
+		m.gateways = append(m.gateways, &gateway{
+			url: url,
+			status: types2.ProofGatewayStatus{
+				URL:   url,
+				State: types2.GatewayConnecting,
+			},
+		})
+	}
+	return m
+}
+
+func (m *ProofEventManager) Start() {
+	for _, gw := range m.gateways {
+		m.wg.Add(1)
+		go m.run(gw)
+	}
+}
+
+func (m *ProofEventManager) Stop(ctx context.Context) error {
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Status returns the state of every gateway
+func (m *ProofEventManager) Status() []types2.ProofGatewayStatus {
+	out := make([]types2.ProofGatewayStatus, 0, len(m.gateways))
+	for _, gw := range m.gateways {
+		gw.lk.Lock()
+		out = append(out, gw.status)
+		gw.lk.Unlock()
+	}
+	return out
+}
+
+func (m *ProofEventManager) run(gw *gateway) {
+	defer m.wg.Done()
+
+	backoff := minBackoff
+	for {
+		connected, err := m.connectOnce(gw)
+		if m.ctx.Err() != nil {
+			gw.update(func(status *types2.ProofGatewayStatus) {
+				status.State = types2.GatewayStopped
+			})
+			return
+		}
+		if connected {
+			backoff = minBackoff
+		}
+
+		log.Errorf("connection to gateway %s broken, retry in %s: %s", gw.url, backoff, err)
+		now := time.Now()
+		gw.update(func(status *types2.ProofGatewayStatus) {
+			status.State = types2.GatewayBackoff
+			status.LastError = err.Error()
+			status.LastErrorAt = now
+			status.NextRetry = now.Add(backoff)
+		})
+
+		select {
+		case <-time.After(backoff):
+		case <-m.ctx.Done():
+			gw.update(func(status *types2.ProofGatewayStatus) {
+				status.State = types2.GatewayStopped
+				status.NextRetry = time.Time{}
+			})
+			return
+		}
+		backoff = nextBackoff(backoff)
+
+		gw.update(func(status *types2.ProofGatewayStatus) {
+			status.State = types2.GatewayConnecting
+			status.NextRetry = time.Time{}
+			status.Reconnects++
+		})
+	}
+}
+
+// connectOnce serves proof requests of gw until the connection breaks, it reports whether the connection was up
+func (m *ProofEventManager) connectOnce(gw *gateway) (bool, error) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	defer cancel()
+
+	client, closer, err := NewProofEventClient(ctx, gw.url, m.token)
+	if err != nil {
+		return false, err
+	}
+	defer closer()
+
+	connected := false
+	proofEvent := ProofEvent{
+		prover: m.prover,
+		client: client,
+		mAddr:  m.mAddr,
+		gw:     gw,
+	}
+	err = proofEvent.listenProofRequestOnce(ctx, func() {
+		connected = true
+		log.Infof("listening proof events of gateway %s", gw.url)
+		gw.update(func(status *types2.ProofGatewayStatus) {
+			status.State = types2.GatewayConnected
+			status.ConnectedAt = time.Now()
+		})
+	})
+	return connected, err
+}
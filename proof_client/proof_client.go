@@ -6,7 +6,6 @@ import (
 	"github.com/ipfs-force-community/venus-common-utils/apiinfo"
 	"github.com/ipfs-force-community/venus-gateway/proofevent"
 	"github.com/ipfs-force-community/venus-gateway/types"
-	"go.uber.org/fx"
 )
 
 type ProofEventClient struct {
@@ -14,7 +13,7 @@ type ProofEventClient struct {
 	ListenProofEvent   func(ctx context.Context, policy *proofevent.ProofRegisterPolicy) (chan *types.RequestEvent, error)
 }
 
-func NewProofEventClient(lc fx.Lifecycle, url, token string) (*ProofEventClient, error) {
+func NewProofEventClient(ctx context.Context, url, token string) (*ProofEventClient, jsonrpc.ClientCloser, error) {
 	pvc := &ProofEventClient{}
 	apiInfo := apiinfo.APIInfo{
 		Addr:  url,
@@ -22,17 +21,11 @@ func NewProofEventClient(lc fx.Lifecycle, url, token string) (*ProofEventClient,
 	}
 	addr, err := apiInfo.DialArgs("v0")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	closer, err := jsonrpc.NewMergeClient(context.Background(), addr, "Gateway", []interface{}{pvc}, apiInfo.AuthHeader())
+	closer, err := jsonrpc.NewMergeClient(ctx, addr, "Gateway", []interface{}{pvc}, apiInfo.AuthHeader())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	lc.Append(fx.Hook{
-		OnStop: func(_ context.Context) error {
-			closer()
-			return nil
-		},
-	})
-	return pvc, nil
+	return pvc, closer, nil
 }
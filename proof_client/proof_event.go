@@ -20,27 +20,11 @@ type ProofEvent struct {
 	prover storage.WinningPoStProver
 	client *ProofEventClient
 	mAddr  types2.MinerAddress
+	gw     *gateway
 }
 
-func (e *ProofEvent) listenProofRequest(ctx context.Context) {
-	for {
-		if err := e.listenProofRequestOnce(ctx); err != nil {
-			log.Errorf("listen head changes errored: %s", err)
-		} else {
-			log.Warn("listenHeadChanges quit")
-		}
-		select {
-		case <-time.After(time.Second):
-		case <-ctx.Done():
-			log.Warnf("not restarting listenHeadChanges: context error: %s", ctx.Err())
-			return
-		}
-
-		log.Info("restarting listenHeadChanges")
-	}
-}
-
-func (e *ProofEvent) listenProofRequestOnce(ctx context.Context) error {
+// listenProofRequestOnce serves proof requests until the connection breaks, onConnected is called once listening
+func (e *ProofEvent) listenProofRequestOnce(ctx context.Context, onConnected func()) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	policy := &proofevent.ProofRegisterPolicy{
@@ -49,8 +33,9 @@ func (e *ProofEvent) listenProofRequestOnce(ctx context.Context) error {
 	proofEventCh, err := e.client.ListenProofEvent(ctx, policy)
 	if err != nil {
 		// Retry is handled by caller
-		return xerrors.Errorf("listenHeadChanges ChainNotify call failed: %w", err)
+		return xerrors.Errorf("listen proof event call failed: %w", err)
 	}
+	onConnected()
 
 	for proofEvent := range proofEventCh {
 		switch proofEvent.Method {
@@ -78,23 +63,15 @@ func (e *ProofEvent) listenProofRequestOnce(ctx context.Context) error {
 		}
 	}
 
-	return nil
+	return xerrors.Errorf("proof event channel closed")
 }
 
 func (e *ProofEvent) processComputeProof(ctx context.Context, reqId uuid.UUID, req types.ComputeProofRequest) {
-
-	proof, err := e.prover.ComputeProof(ctx, req.SectorInfos, req.Rand)
-	if err != nil {
-		_ = e.client.ResponseProofEvent(ctx, &types.ResponseEvent{
-			Id:      reqId,
-			Payload: nil,
-			Error:   err.Error(),
-		})
-		return
-	}
-
-	proofBytes, err := json.Marshal(proof)
+	start := time.Now()
+	proofBytes, err := e.computeProof(ctx, req)
+	e.gw.proofDone(ctx, start, err)
 	if err != nil {
+		log.Errorf("compute winning PoSt for request %s from %s failed: %s", reqId, e.gw.url, err)
 		_ = e.client.ResponseProofEvent(ctx, &types.ResponseEvent{
 			Id:      reqId,
 			Payload: nil,
@@ -112,3 +89,12 @@ func (e *ProofEvent) processComputeProof(ctx context.Context, reqId uuid.UUID, r
 		log.Errorf("response proof event %s failed", reqId)
 	}
 }
+
+func (e *ProofEvent) computeProof(ctx context.Context, req types.ComputeProofRequest) ([]byte, error) {
+	proof, err := e.prover.ComputeProof(ctx, req.SectorInfos, req.Rand)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(proof)
+}
@@ -17,6 +17,7 @@ import (
 
 	"github.com/filecoin-project/venus-sealer/api"
 	"github.com/filecoin-project/venus-sealer/api/impl"
+	"github.com/filecoin-project/venus-sealer/metrics"
 )
 
 // ServeRPC serves an HTTP handler over the supplied listen multiaddr.
@@ -56,23 +57,26 @@ func MinerHandler(mapi api.StorageMiner, permissioned bool) (http.Handler, error
 	m := mux.NewRouter()
 
 	rpcServer := jsonrpc.NewServer()
-	rpcServer.Register("Filecoin", mapi)
+	if permissioned {
+		rpcServer.Register("Filecoin", api.PermissionedStorMinerAPI(mapi))
+	} else {
+		rpcServer.Register("Filecoin", mapi)
+	}
 
-	mux := mux.NewRouter()
-	mux.Handle("/rpc/v0", rpcServer)
-	mux.PathPrefix("/remote").HandlerFunc(mapi.(*impl.StorageMinerAPI).ServeRemote)
+	m.Handle("/rpc/v0", rpcServer)
+	m.PathPrefix("/remote").HandlerFunc(mapi.(*impl.StorageMinerAPI).ServeRemote)
 
 	// debugging
-	// m.Handle("/debug/metrics", metrics.Exporter())
+	m.Handle("/debug/metrics", metrics.Exporter())
 	m.PathPrefix("/").Handler(http.DefaultServeMux) // pprof
 
 	if !permissioned {
-		return rpcServer, nil
+		return m, nil
 	}
 
 	ah := &auth.Handler{
 		Verify: mapi.AuthVerify,
-		Next:   mux.ServeHTTP,
+		Next:   m.ServeHTTP,
 	}
 
 	return ah, nil
@@ -6,139 +6,231 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-
-	"golang.org/x/xerrors"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	ffi "github.com/filecoin-project/filecoin-ffi"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/specs-actors/actors/runtime/proof"
 	"github.com/filecoin-project/specs-storage/storage"
 
+	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
 	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
 )
 
 // FaultTracker TODO: Track things more actively
 type FaultTracker interface {
-	CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, rg storiface.RGetter) (map[abi.SectorID]string, error)
+	CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, rg storiface.RGetter) ([]storiface.SectorCheck, error)
 }
 
-// CheckProvable returns unprovable sectors
-func (m *Manager) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, rg storiface.RGetter) (map[abi.SectorID]string, error) {
-	var bad = make(map[abi.SectorID]string)
+// DefaultParallelCheckLimit is the number of sectors CheckProvable checks at once
+const DefaultParallelCheckLimit = 32
 
-	ssize, err := pp.SectorSize()
-	if err != nil {
+// CheckProvable checks sectors in parallel, the sectors the sealer can't read are checked by the storage holding
+// them. There is a result for every sector, in the order of sectors
+func (m *Manager) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, rg storiface.RGetter) ([]storiface.SectorCheck, error) {
+	if _, err := pp.SectorSize(); err != nil {
 		return nil, err
 	}
+	ctx = types.WithPriority(ctx, types.WindowPoStPriority)
+
+	out := make([]storiface.SectorCheck, len(sectors))
+	throttle := make(chan struct{}, m.ParallelCheckLimit())
+
+	var wg sync.WaitGroup
+	for i, sector := range sectors {
+		select {
+		case throttle <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
 
-	// TODO: More better checks
-	for _, sector := range sectors {
-		err := func() error {
-			ctx, cancel := context.WithCancel(ctx)
-			defer cancel()
+		wg.Add(1)
+		go func(i int, sector storage.SectorRef) {
+			defer wg.Done()
+			defer func() {
+				<-throttle
+			}()
 
-			locked, err := m.index.StorageTryLock(ctx, sector.ID, storiface.FTSealed|storiface.FTCache, storiface.FTNone)
-			if err != nil {
-				return xerrors.Errorf("acquiring sector lock: %w", err)
-			}
+			start := time.Now()
+			res := m.checkSector(ctx, pp, sector, rg)
+			res.Sector = sector.ID
+			res.Took = time.Since(start)
 
-			if !locked {
-				log.Warnw("CheckProvable Sector FAULT: can't acquire read lock", "sector", sector)
-				bad[sector.ID] = "can't acquire read lock"
-				return nil
+			if res.Faulty() {
+				log.Warnw("CheckProvable Sector FAULT", "sector", sector, "code", res.Code, "path", res.Path, "remote", res.Remote, "error", res.Error)
 			}
+			out[i] = res
+		}(i, sector)
+	}
+	wg.Wait()
 
-			lp, _, err := m.localStore.AcquireSector(ctx, sector, storiface.FTSealed|storiface.FTCache, storiface.FTNone, storiface.PathStorage, storiface.AcquireMove)
-			if err != nil {
-				log.Warnw("CheckProvable Sector FAULT: acquire sector in checkProvable", "sector", sector, "error", err)
-				bad[sector.ID] = fmt.Sprintf("acquire sector failed: %s", err)
-				return nil
-			}
+	return out, nil
+}
 
-			if lp.Sealed == "" || lp.Cache == "" {
-				log.Warnw("CheckProvable Sector FAULT: cache and/or sealed paths not found", "sector", sector, "sealed", lp.Sealed, "cache", lp.Cache)
-				bad[sector.ID] = fmt.Sprintf("cache and/or sealed paths not found, cache %q, sealed %q", lp.Cache, lp.Sealed)
-				return nil
-			}
+// ParallelCheckLimit is the number of sectors CheckProvable checks at once
+func (m *Manager) ParallelCheckLimit() int {
+	if limit := atomic.LoadInt64(&m.parallelCheckLimit); limit > 0 {
+		return int(limit)
+	}
+	return DefaultParallelCheckLimit
+}
 
-			toCheck := map[string]int64{
-				lp.Sealed:                        1,
-				filepath.Join(lp.Cache, "t_aux"): 0,
-				filepath.Join(lp.Cache, "p_aux"): 0,
-			}
+// SetParallelCheckLimit sets the number of sectors CheckProvable checks at once, 0 for the default
+func (m *Manager) SetParallelCheckLimit(limit int) {
+	atomic.StoreInt64(&m.parallelCheckLimit, int64(limit))
+}
 
-			addCachePathsForSectorSize(toCheck, lp.Cache, ssize)
-
-			for p, sz := range toCheck {
-				st, err := os.Stat(p)
-				if err != nil {
-					log.Warnw("CheckProvable Sector FAULT: sector file stat error", "sector", sector, "sealed", lp.Sealed, "cache", lp.Cache, "file", p, "err", err)
-					bad[sector.ID] = fmt.Sprintf("%s", err)
-					return nil
-				}
-
-				if sz != 0 {
-					if st.Size() != int64(ssize)*sz {
-						log.Warnw("CheckProvable Sector FAULT: sector file is wrong size", "sector", sector, "sealed", lp.Sealed, "cache", lp.Cache, "file", p, "size", st.Size(), "expectSize", int64(ssize)*sz)
-						bad[sector.ID] = fmt.Sprintf("%s is wrong size (got %d, expect %d)", p, st.Size(), int64(ssize)*sz)
-						return nil
-					}
-				}
-			}
+// checkSector never fails, failures of the sealer itself (eg. the index) are reported as the result of the sector
+func (m *Manager) checkSector(ctx context.Context, pp abi.RegisteredPoStProof, sector storage.SectorRef, rg storiface.RGetter) storiface.SectorCheck {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-			if rg != nil {
-				wpp, err := sector.ProofType.RegisteredWindowPoStProof()
-				if err != nil {
-					return err
-				}
-
-				var pr abi.PoStRandomness = make([]byte, abi.RandomnessLength)
-				_, _ = rand.Read(pr)
-				pr[31] &= 0x3f
-
-				ch, err := ffi.GeneratePoStFallbackSectorChallenges(wpp, sector.ID.Miner, pr, []abi.SectorNumber{
-					sector.ID.Number,
-				})
-				if err != nil {
-					log.Warnw("CheckProvable Sector FAULT: generating challenges", "sector", sector, "sealed", lp.Sealed, "cache", lp.Cache, "err", err)
-					bad[sector.ID] = fmt.Sprintf("generating fallback challenges: %s", err)
-					return nil
-				}
-
-				commr, err := rg(ctx, sector.ID)
-				if err != nil {
-					log.Warnw("CheckProvable Sector FAULT: getting commR", "sector", sector, "sealed", lp.Sealed, "cache", lp.Cache, "err", err)
-					bad[sector.ID] = fmt.Sprintf("getting commR: %s", err)
-					return nil
-				}
-
-				_, err = ffi.GenerateSingleVanillaProof(ffi.PrivateSectorInfo{
-					SectorInfo: proof.SectorInfo{
-						SealProof:    sector.ProofType,
-						SectorNumber: sector.ID.Number,
-						SealedCID:    commr,
-					},
-					CacheDirPath:     lp.Cache,
-					PoStProofType:    wpp,
-					SealedSectorPath: lp.Sealed,
-				}, ch.Challenges[sector.ID.Number])
-				if err != nil {
-					log.Warnw("CheckProvable Sector FAULT: generating vanilla proof", "sector", sector, "sealed", lp.Sealed, "cache", lp.Cache, "err", err)
-					bad[sector.ID] = fmt.Sprintf("generating vanilla proof: %s", err)
-					return nil
-				}
-			}
+	locked, err := m.index.StorageTryLock(ctx, sector.ID, storiface.FTSealed|storiface.FTCache, storiface.FTNone)
+	if err != nil {
+		return storiface.SectorCheck{Code: storiface.CheckUnreachable, Error: fmt.Sprintf("acquiring sector lock: %s", err)}
+	}
+	if !locked {
+		return storiface.SectorCheck{Code: storiface.CheckLocked, Error: "can't acquire read lock"}
+	}
+
+	req := storiface.CheckRequest{Sector: sector, PoStProof: pp}
+	if rg != nil {
+		commr, err := rg(ctx, sector.ID)
+		if err != nil {
+			return storiface.SectorCheck{Code: storiface.CheckCommR, Error: fmt.Sprintf("getting commR: %s", err)}
+		}
+		req.SealedCID = &commr
+	}
+
+	lp, _, err := m.localStore.AcquireSector(ctx, sector, storiface.FTSealed|storiface.FTCache, storiface.FTNone, storiface.PathStorage, storiface.AcquireMove)
+	if err != nil {
+		return storiface.SectorCheck{Code: storiface.CheckNotFound, Error: fmt.Sprintf("acquire sector failed: %s", err)}
+	}
+	if lp.Sealed != "" && lp.Cache != "" {
+		return CheckSector(ctx, req, lp)
+	}
+
+	return m.checkRemote(ctx, req)
+}
+
+// checkRemote delegates the check to the FetchHandler of a storage holding both the sealed and cache files
+func (m *Manager) checkRemote(ctx context.Context, req storiface.CheckRequest) storiface.SectorCheck {
+	sealed, err := m.index.StorageFindSector(ctx, req.Sector.ID, storiface.FTSealed, 0, false)
+	if err != nil {
+		return storiface.SectorCheck{Code: storiface.CheckUnreachable, Error: fmt.Sprintf("finding sealed file: %s", err)}
+	}
+	cache, err := m.index.StorageFindSector(ctx, req.Sector.ID, storiface.FTCache, 0, false)
+	if err != nil {
+		return storiface.SectorCheck{Code: storiface.CheckUnreachable, Error: fmt.Sprintf("finding cache: %s", err)}
+	}
+
+	hasCache := make(map[stores.ID]struct{}, len(cache))
+	for _, info := range cache {
+		hasCache[info.ID] = struct{}{}
+	}
+	var urls []string
+	for _, info := range sealed {
+		if _, ok := hasCache[info.ID]; ok {
+			urls = append(urls, info.URLs...)
+		}
+	}
+	if len(urls) == 0 {
+		return storiface.SectorCheck{Code: storiface.CheckNotFound, Error: "no storage holds both the sealed and cache files"}
+	}
 
-			return nil
-		}()
+	var lastErr error
+	for _, url := range urls {
+		res, err := m.storage.CheckSector(ctx, url, req)
 		if err != nil {
-			return nil, err
+			log.Warnw("delegating sector check", "sector", req.Sector.ID, "url", url, "error", err)
+			lastErr = err
+			continue
 		}
+		res.Remote = url
+		return res
 	}
+	return storiface.SectorCheck{Code: storiface.CheckUnreachable, Error: fmt.Sprintf("checking on %d storage urls: %s", len(urls), lastErr)}
+}
 
-	return bad, nil
+// CheckSector checks the sealed and cache files of a sector in local paths, a vanilla proof is generated when the
+// request has the commR of the sector
+func CheckSector(ctx context.Context, req storiface.CheckRequest, lp storiface.SectorPaths) storiface.SectorCheck {
+	sector := req.Sector
+	res := storiface.SectorCheck{Sector: sector.ID, Code: storiface.CheckOK, Path: lp.Sealed}
+	fail := func(code storiface.CheckCode, path, msg string) storiface.SectorCheck {
+		res.Code, res.Path, res.Error = code, path, msg
+		return res
+	}
+
+	ssize, err := req.PoStProof.SectorSize()
+	if err != nil {
+		return fail(storiface.CheckWrongSize, lp.Sealed, err.Error())
+	}
+
+	toCheck := map[string]int64{
+		lp.Sealed:                        1,
+		filepath.Join(lp.Cache, "t_aux"): 0,
+		filepath.Join(lp.Cache, "p_aux"): 0,
+	}
+
+	addCachePathsForSectorSize(toCheck, lp.Cache, ssize)
+
+	for p, sz := range toCheck {
+		st, err := os.Stat(p)
+		if err != nil {
+			return fail(storiface.CheckMissingFile, p, err.Error())
+		}
+
+		if sz != 0 {
+			if st.Size() != int64(ssize)*sz {
+				return fail(storiface.CheckWrongSize, p, fmt.Sprintf("%s is wrong size (got %d, expect %d)", p, st.Size(), int64(ssize)*sz))
+			}
+		}
+	}
+
+	if req.SealedCID == nil {
+		return res
+	}
+
+	wpp, err := sector.ProofType.RegisteredWindowPoStProof()
+	if err != nil {
+		return fail(storiface.CheckVanillaProof, lp.Sealed, err.Error())
+	}
+
+	var pr abi.PoStRandomness = make([]byte, abi.RandomnessLength)
+	_, _ = rand.Read(pr)
+	pr[31] &= 0x3f
+
+	ch, err := ffi.GeneratePoStFallbackSectorChallenges(wpp, sector.ID.Miner, pr, []abi.SectorNumber{
+		sector.ID.Number,
+	})
+	if err != nil {
+		return fail(storiface.CheckVanillaProof, lp.Sealed, fmt.Sprintf("generating fallback challenges: %s", err))
+	}
+
+	_, err = ffi.GenerateSingleVanillaProof(ffi.PrivateSectorInfo{
+		SectorInfo: proof.SectorInfo{
+			SealProof:    sector.ProofType,
+			SectorNumber: sector.ID.Number,
+			SealedCID:    *req.SealedCID,
+		},
+		CacheDirPath:     lp.Cache,
+		PoStProofType:    wpp,
+		SealedSectorPath: lp.Sealed,
+	}, ch.Challenges[sector.ID.Number])
+	if err != nil {
+		return fail(storiface.CheckVanillaProof, lp.Sealed, fmt.Sprintf("generating vanilla proof: %s", err))
+	}
+
+	return res
 }
 
+var _ stores.CheckFunc = CheckSector
+
 func addCachePathsForSectorSize(chk map[string]int64, cacheDir string, ssize abi.SectorSize) {
 	switch ssize {
 	case 2 << 10:
@@ -0,0 +1,57 @@
+package sectorstorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+)
+
+type failingLockIndex struct {
+	*stores.Index
+	fail abi.SectorNumber
+}
+
+func (i *failingLockIndex) StorageTryLock(ctx context.Context, sector abi.SectorID, read storiface.SectorFileType, write storiface.SectorFileType) (bool, error) {
+	if sector.Number == i.fail {
+		return false, xerrors.New("index unavailable")
+	}
+	return i.Index.StorageTryLock(ctx, sector, read, write)
+}
+
+func TestCheckProvableSectorErrors(t *testing.T) {
+	ctx := context.Background()
+	m, _, _, si, cleanup := newTestMgr(ctx, t, datastore.NewMapDatastore())
+	defer cleanup()
+
+	m.index = &failingLockIndex{Index: si, fail: 1}
+
+	var sectors []storage.SectorRef
+	for n := abi.SectorNumber(1); n <= 3; n++ {
+		sectors = append(sectors, storage.SectorRef{
+			ID:        abi.SectorID{Miner: 1000, Number: n},
+			ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+		})
+	}
+
+	// a failing sector doesn't fail the others
+	res, err := m.CheckProvable(ctx, abi.RegisteredPoStProof_StackedDrgWindow2KiBV1, sectors, nil)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+
+	require.Equal(t, sectors[0].ID, res[0].Sector)
+	require.Equal(t, storiface.CheckUnreachable, res[0].Code)
+	require.Contains(t, res[0].Error, "index unavailable")
+
+	for _, r := range res[1:] {
+		require.Equal(t, storiface.CheckNotFound, r.Code)
+	}
+}
@@ -49,6 +49,15 @@ type Prover interface {
 	AggregateSealProofs(aggregateInfo proof5.AggregateSealVerifyProofAndInfos, proofs [][]byte) ([]byte, error)
 }
 
+// VanillaProver splits a window post, the vanilla proofs of the sectors are generated where the sectors are stored and
+// the post is computed from all of them
+type VanillaProver interface {
+	// GenerateWindowPoStVanilla returns the vanilla proofs of the sectors, ordered by sector number
+	GenerateWindowPoStVanilla(ctx context.Context, minerID abi.ActorID, sectorInfo []proof5.SectorInfo, randomness abi.PoStRandomness) (storiface.WindowPoStVanillaResult, error)
+	// GenerateWindowPoStWithVanilla computes the window post from the vanilla proofs of every sector, ordered by sector number
+	GenerateWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, vanilla [][]byte) ([]proof5.PoStProof, error)
+}
+
 type SectorProvider interface {
 	// * returns storiface.ErrSectorNotFound if a requested existing sector doesn't exist
 	// * returns an error when allocate is set, and existing isn't, and the sector exists
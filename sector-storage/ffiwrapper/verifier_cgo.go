@@ -55,6 +55,50 @@ func (sb *Sealer) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, s
 	return proof, faultyIDs, err
 }
 
+var _ VanillaProver = &Sealer{}
+
+func (sb *Sealer) GenerateWindowPoStVanilla(ctx context.Context, minerID abi.ActorID, sectorInfo []proof5.SectorInfo, randomness abi.PoStRandomness) (storiface.WindowPoStVanillaResult, error) {
+	randomness[31] &= 0x3f
+	privsectors, skipped, done, err := sb.pubSectorToPriv(ctx, minerID, sectorInfo, nil, abi.RegisteredSealProof.RegisteredWindowPoStProof)
+	if err != nil {
+		return storiface.WindowPoStVanillaResult{}, xerrors.Errorf("gathering sector info: %w", err)
+	}
+	defer done()
+
+	if len(skipped) > 0 {
+		return storiface.WindowPoStVanillaResult{Skipped: skipped}, xerrors.Errorf("pubSectorToPriv skipped some sectors")
+	}
+
+	sectors := privsectors.Values()
+	if len(sectors) == 0 {
+		return storiface.WindowPoStVanillaResult{}, nil
+	}
+
+	nums := make([]abi.SectorNumber, len(sectors))
+	for i, s := range sectors {
+		nums[i] = s.SectorNumber
+	}
+	ch, err := ffi.GeneratePoStFallbackSectorChallenges(sectors[0].PoStProofType, minerID, randomness, nums)
+	if err != nil {
+		return storiface.WindowPoStVanillaResult{}, xerrors.Errorf("generating fallback challenges: %w", err)
+	}
+
+	res := storiface.WindowPoStVanillaResult{Sectors: nums, Proofs: make([][]byte, len(sectors))}
+	for i, s := range sectors {
+		res.Proofs[i], err = ffi.GenerateSingleVanillaProof(s, ch.Challenges[s.SectorNumber])
+		if err != nil {
+			return storiface.WindowPoStVanillaResult{}, xerrors.Errorf("generating vanilla proof of sector %d: %w", s.SectorNumber, err)
+		}
+	}
+
+	return res, nil
+}
+
+func (sb *Sealer) GenerateWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, vanilla [][]byte) ([]proof5.PoStProof, error) {
+	randomness[31] &= 0x3f
+	return ffi.GenerateWindowPoStWithVanilla(proofType, minerID, randomness, vanilla)
+}
+
 func (sb *Sealer) pubSectorToPriv(ctx context.Context, mid abi.ActorID, sectorInfo []proof5.SectorInfo, faults []abi.SectorNumber, rpt func(abi.RegisteredSealProof) (abi.RegisteredPoStProof, error)) (ffi.SortedPrivateSectorInfo, []abi.SectorID, func(), error) {
 	fmap := map[abi.SectorNumber]struct{}{}
 	for _, fault := range faults {
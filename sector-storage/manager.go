@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/go-multierror"
@@ -39,6 +40,9 @@ type Worker interface {
 	// Returns paths accessible to the worker
 	Paths(context.Context) ([]stores.StoragePath, error)
 
+	// Returns the progress of sector files being fetched by the worker
+	Transfers(context.Context) ([]storiface.TransferProgress, error)
+
 	Info(context.Context) (storiface.WorkerInfo, error)
 
 	Session(context.Context) (uuid.UUID, error)
@@ -51,6 +55,9 @@ type SectorManager interface {
 	storage.Prover
 	storiface.WorkerReturn
 	FaultTracker
+
+	SealingIdle(ctx context.Context, spt abi.RegisteredSealProof) (bool, error)
+	SectorWorker(sid abi.SectorID) string
 }
 
 type WorkerID uuid.UUID // worker session UUID
@@ -70,6 +77,13 @@ type Manager struct {
 	sched *scheduler
 
 	storage.Prover
+	// serializes the window posts computed by the sealer itself
+	localPoStLk sync.Mutex
+	// nanoseconds, accessed atomically
+	winningPoStTimeout int64
+	windowPoStTimeout  int64
+	// accessed atomically
+	parallelCheckLimit int64
 
 	workLk sync.Mutex
 	work   statestore.StateStore
@@ -103,6 +117,15 @@ const (
 
 type SealerConfig struct {
 	ParallelFetchLimit int
+	// FetchHostBandwidth caps the bytes per second fetched from one remote host, 0 for no cap
+	FetchHostBandwidth int64
+	// FetchPathBandwidth caps the bytes per second fetched into one local storage path, 0 for no cap
+	FetchPathBandwidth int64
+	// ServeFetchLimit is the number of sector transfers served to workers at once, further
+	// requests are asked to back off, 0 for no limit
+	ServeFetchLimit int
+	// CompressFetch asks remotes to compress cache directory transfers with zstd
+	CompressFetch bool
 
 	// Local worker config
 	AllowAddPiece   bool
@@ -115,6 +138,21 @@ type SealerConfig struct {
 	// to use when evaluating tasks against this worker. An empty value defaults
 	// to "hardware".
 	ResourceFiltering ResourceFilteringStrategy
+
+	// Scheduler configures worker groups, task pinning and deal/CC fair share of the scheduler
+	Scheduler SchedulerPolicy
+
+	// ParallelCheckLimit is the number of sectors checked at once by the provable checks, 0 for
+	// DefaultParallelCheckLimit
+	ParallelCheckLimit int
+
+	// WinningPoStTimeout is how long a winning post worker has to return the proof before the sealer
+	// computes it itself, in nanoseconds, 0 for DefaultWinningPoStTimeout
+	WinningPoStTimeout time.Duration
+
+	// WindowPoStTimeout is how long the window post workers have to return the proof or the vanilla proofs of their
+	// sectors before the sealer computes it itself, in nanoseconds, 0 for DefaultWindowPoStTimeout
+	WindowPoStTimeout time.Duration
 }
 
 type StorageAuth http.Header
@@ -128,11 +166,16 @@ func New(ctx context.Context, lstor *stores.Local, stor *stores.Remote, ls store
 		return nil, xerrors.Errorf("creating prover instance: %w", err)
 	}
 
+	policy, err := newSchedPolicy(sc.Scheduler)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid scheduler policy: %w", err)
+	}
+
 	m := &Manager{
 		ls:         ls,
 		storage:    stor,
 		localStore: lstor,
-		remoteHnd:  &stores.FetchHandler{Local: lstor, PfHandler: &stores.DefaultPartialFileHandler{}},
+		remoteHnd:  &stores.FetchHandler{Local: lstor, PfHandler: &stores.DefaultPartialFileHandler{}, Check: CheckSector, MaxServing: sc.ServeFetchLimit},
 		index:      si,
 
 		sched: newScheduler(),
@@ -146,6 +189,11 @@ func New(ctx context.Context, lstor *stores.Local, stor *stores.Remote, ls store
 		waitRes:    map[types.WorkID]chan struct{}{},
 	}
 
+	policy.index = si
+	m.sched.policy = policy
+	m.SetWinningPoStTimeout(sc.WinningPoStTimeout)
+	m.SetWindowPoStTimeout(sc.WindowPoStTimeout)
+	m.SetParallelCheckLimit(sc.ParallelCheckLimit)
 	m.setupWorkTracker()
 
 	go m.sched.runSched()
@@ -286,6 +334,8 @@ func (m *Manager) AddPiece(ctx context.Context, sector storage.SectorRef, existi
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	m.setSectorKind(ctx, sector)
+
 	if err := m.index.StorageLock(ctx, sector.ID, storiface.FTNone, storiface.FTUnsealed); err != nil {
 		return abi.PieceInfo{}, xerrors.Errorf("acquiring sector lock: %w", err)
 	}
@@ -293,7 +343,7 @@ func (m *Manager) AddPiece(ctx context.Context, sector storage.SectorRef, existi
 	var selector WorkerSelector
 	var err error
 	if len(existingPieces) == 0 { // new
-		selector = newAllocSelector(m.index, storiface.FTUnsealed, storiface.PathSealing)
+		selector = newAllocSelector(m.index, sector.ID, storiface.FTUnsealed, storiface.PathSealing)
 	} else { // use existing
 		selector = newExistingSelector(m.index, sector.ID, storiface.FTUnsealed, false)
 	}
@@ -317,6 +367,8 @@ func (m *Manager) SealPreCommit1(ctx context.Context, sector storage.SectorRef,
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	m.setSectorKind(ctx, sector)
+
 	wk, wait, cancel, err := m.getWork(ctx, types.TTPreCommit1, sector, ticket, pieces)
 	if err != nil {
 		return nil, xerrors.Errorf("getWork: %w", err)
@@ -346,7 +398,7 @@ func (m *Manager) SealPreCommit1(ctx context.Context, sector storage.SectorRef,
 
 	// TODO: also consider where the unsealed data sits
 
-	selector := newAllocSelector(m.index, storiface.FTCache|storiface.FTSealed, storiface.PathSealing)
+	selector := newAllocSelector(m.index, sector.ID, storiface.FTCache|storiface.FTSealed, storiface.PathSealing)
 
 	err = m.sched.Schedule(ctx, sector, types.TTPreCommit1, selector, m.schedFetch(sector, storiface.FTUnsealed, storiface.PathSealing, storiface.AcquireMove), func(ctx context.Context, w Worker) error {
 		err := m.startWork(ctx, w, wk)(w.SealPreCommit1(ctx, sector, ticket, pieces))
@@ -368,6 +420,8 @@ func (m *Manager) SealPreCommit2(ctx context.Context, sector storage.SectorRef,
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	m.setSectorKind(ctx, sector)
+
 	wk, wait, cancel, err := m.getWork(ctx, types.TTPreCommit2, sector, phase1Out)
 	if err != nil {
 		return storage.SectorCids{}, xerrors.Errorf("getWork: %w", err)
@@ -512,6 +566,8 @@ func (m *Manager) FinalizeSector(ctx context.Context, sector storage.SectorRef,
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	m.setSectorKind(ctx, sector)
+
 	if err := m.index.StorageLock(ctx, sector.ID, storiface.FTNone, storiface.FTSealed|storiface.FTUnsealed|storiface.FTCache); err != nil {
 		return xerrors.Errorf("acquiring sector lock: %w", err)
 	}
@@ -555,7 +611,7 @@ func (m *Manager) FinalizeSector(ctx context.Context, sector storage.SectorRef,
 		return err
 	}
 
-	fetchSel := newAllocSelector(m.index, storiface.FTCache|storiface.FTSealed, storiface.PathStorage)
+	fetchSel := newAllocSelector(m.index, sector.ID, storiface.FTCache|storiface.FTSealed, storiface.PathStorage)
 	moveUnsealed := unsealed
 	{
 		if len(keepUnsealed) == 0 {
@@ -601,9 +657,27 @@ func (m *Manager) Remove(ctx context.Context, sector storage.SectorRef) error {
 		err = multierror.Append(err, xerrors.Errorf("removing sector (unsealed): %w", rerr))
 	}
 
+	if rerr := m.index.StorageSetSectorKind(ctx, sector.ID, storiface.KindCC); rerr != nil {
+		err = multierror.Append(err, xerrors.Errorf("forgetting sector kind: %w", rerr))
+	}
+	m.sched.policy.forget(sector.ID)
+
 	return err
 }
 
+// setSectorKind tells the index the sector holds deals if marked so in ctx, so that the placement
+// rules of the storages apply to the files allocated for the call. Calls without the mark (e.g. from
+// the cli) don't know the sector, so they never turn a deal sector back into a cc one
+func (m *Manager) setSectorKind(ctx context.Context, sector storage.SectorRef) {
+	if !types.HasDeals(ctx) {
+		return
+	}
+
+	if err := m.index.StorageSetSectorKind(ctx, sector.ID, storiface.KindDeal); err != nil {
+		log.Warnf("setting kind of sector %v: %+v", sector.ID, err)
+	}
+}
+
 func (m *Manager) ReturnAddPiece(ctx context.Context, callID types.CallID, pi abi.PieceInfo, err *storiface.CallError) error {
 	return m.returnResult(ctx, callID, pi, err)
 }
@@ -719,6 +793,11 @@ func (m *Manager) SchedDiag(ctx context.Context, doSched bool) (interface{}, err
 	return i, nil
 }
 
+// SetSchedulerPolicy replaces the scheduler policy, tasks already assigned are not moved
+func (m *Manager) SetSchedulerPolicy(cfg SchedulerPolicy) error {
+	return m.sched.policy.update(cfg)
+}
+
 func (m *Manager) Close(ctx context.Context) error {
 	return m.sched.Close(ctx)
 }
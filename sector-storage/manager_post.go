@@ -0,0 +1,472 @@
+package sectorstorage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/actors/runtime/proof"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/ffiwrapper"
+	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// DefaultWinningPoStTimeout leaves the sealer enough of the block time to compute the proof itself
+const DefaultWinningPoStTimeout = 10 * time.Second
+
+// DefaultWindowPoStTimeout leaves the sealer enough of the challenge window to compute the proof itself
+const DefaultWindowPoStTimeout = 10 * time.Minute
+
+// GenerateWindowPoSt computes the proof on a worker accepting TTGenerateWindowPoSt which has every sector in its own
+// storage. When the sectors are spread over several workers, each of them generates the vanilla proofs of the sectors
+// it holds and the sealer computes the proof from all of them. The sealer proves the sectors itself when there is no
+// such worker or no worker answers within the window post timeout
+func (m *Manager) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) ([]proof.PoStProof, []abi.SectorID, error) {
+	ctx = types.WithPriority(ctx, types.WindowPoStPriority)
+
+	if len(sectorInfo) == 0 {
+		return m.localWindowPoSt(ctx, minerID, sectorInfo, randomness)
+	}
+
+	holders, err := m.postHolders(ctx, types.TTGenerateWindowPoSt, minerID, sectorInfo)
+	if err != nil {
+		log.Warnw("finding window post workers, proving locally", "error", err)
+		return m.localWindowPoSt(ctx, minerID, sectorInfo, randomness)
+	}
+
+	// scheduling and proving on the workers must leave time for the sealer to prove the sectors itself
+	wctx, cancel := context.WithTimeout(ctx, m.WindowPoStTimeout())
+	defer cancel()
+
+	if workers := holdingAll(holders); len(workers) > 0 {
+		res, err := m.workerWindowPoSt(wctx, workers, minerID, sectorInfo, randomness)
+		if err == nil || len(res.Skipped) > 0 || ctx.Err() != nil {
+			return res.Proofs, res.Skipped, err
+		}
+		log.Warnw("window post on worker failed, proving locally", "sectors", len(sectorInfo), "error", err)
+		return m.localWindowPoSt(ctx, minerID, sectorInfo, randomness)
+	}
+
+	vp, ok := m.Prover.(ffiwrapper.VanillaProver)
+	if !ok || !holdingAny(holders) {
+		return m.localWindowPoSt(ctx, minerID, sectorInfo, randomness)
+	}
+	return m.splitWindowPoSt(ctx, wctx, vp, holders, minerID, sectorInfo, randomness)
+}
+
+func (m *Manager) workerWindowPoSt(ctx context.Context, workers map[*workerHandle]struct{}, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (storiface.WindowPoStResult, error) {
+	sector := storage.SectorRef{
+		ID:        abi.SectorID{Miner: minerID, Number: sectorInfo[0].SectorNumber},
+		ProofType: sectorInfo[0].SealProof,
+	}
+
+	var res storiface.WindowPoStResult
+	err := m.sched.Schedule(ctx, sector, types.TTGenerateWindowPoSt, newPoStSelector(workers), schedNop, func(ctx context.Context, w Worker) error {
+		// the request may have waited in the queue past its deadline
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r, err := m.waitSimpleCall(ctx)(w.GenerateWindowPoSt(ctx, minerID, sectorInfo, randomness))
+		if r != nil {
+			res = r.(storiface.WindowPoStResult)
+		}
+		return err
+	})
+	return res, err
+}
+
+// splitWindowPoSt gathers the vanilla proofs of the sectors from the workers holding them, wctx bounds the workers,
+// the sectors no worker proved in time are proven by the sealer within ctx
+func (m *Manager) splitWindowPoSt(ctx, wctx context.Context, vp ffiwrapper.VanillaProver, holders []map[*workerHandle]struct{}, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) ([]proof.PoStProof, []abi.SectorID, error) {
+	ppt, err := sectorInfo[0].SealProof.RegisteredWindowPoStProof()
+	if err != nil {
+		return nil, nil, xerrors.Errorf("getting window post proof type: %w", err)
+	}
+
+	groups, local := groupByHolder(holders, sectorInfo)
+
+	var lk sync.Mutex
+	vanilla := map[abi.SectorNumber][]byte{}
+	var skipped []abi.SectorID
+	add := func(res storiface.WindowPoStVanillaResult) {
+		lk.Lock()
+		defer lk.Unlock()
+		for i, num := range res.Sectors {
+			if i < len(res.Proofs) {
+				vanilla[num] = res.Proofs[i]
+			}
+		}
+		skipped = append(skipped, res.Skipped...)
+	}
+
+	var wg sync.WaitGroup
+	for handle, sis := range groups {
+		wg.Add(1)
+		go func(handle *workerHandle, sis []proof.SectorInfo) {
+			defer wg.Done()
+
+			res, err := m.workerWindowPoStVanilla(wctx, handle, minerID, sis, randomness)
+			if err == nil || len(res.Skipped) > 0 {
+				add(res)
+				return
+			}
+			log.Warnw("window post vanilla proofs on worker failed, proving locally", "worker", handle.info.Hostname, "sectors", len(sis), "error", err)
+
+			lk.Lock()
+			local = append(local, sis...)
+			lk.Unlock()
+		}(handle, sis)
+	}
+	wg.Wait()
+
+	if len(skipped) > 0 {
+		return nil, skipped, xerrors.Errorf("%d sectors skipped by the workers", len(skipped))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(local) > 0 {
+		res, err := vp.GenerateWindowPoStVanilla(ctx, minerID, local, append(abi.PoStRandomness{}, randomness...))
+		if err != nil {
+			return nil, res.Skipped, xerrors.Errorf("generating vanilla proofs locally: %w", err)
+		}
+		add(res)
+	}
+
+	sorted := make([]proof.SectorInfo, len(sectorInfo))
+	copy(sorted, sectorInfo)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].SectorNumber < sorted[j].SectorNumber
+	})
+	proofs := make([][]byte, 0, len(sorted))
+	for _, si := range sorted {
+		p, ok := vanilla[si.SectorNumber]
+		if !ok {
+			return nil, nil, xerrors.Errorf("missing vanilla proof of sector %d", si.SectorNumber)
+		}
+		proofs = append(proofs, p)
+	}
+
+	m.localPoStLk.Lock()
+	defer m.localPoStLk.Unlock()
+
+	out, err := vp.GenerateWindowPoStWithVanilla(ctx, ppt, minerID, append(abi.PoStRandomness{}, randomness...), proofs)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("computing window post from vanilla proofs: %w", err)
+	}
+	return out, nil, nil
+}
+
+func (m *Manager) workerWindowPoStVanilla(ctx context.Context, handle *workerHandle, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (storiface.WindowPoStVanillaResult, error) {
+	sector := storage.SectorRef{
+		ID:        abi.SectorID{Miner: minerID, Number: sectorInfo[0].SectorNumber},
+		ProofType: sectorInfo[0].SealProof,
+	}
+
+	var res storiface.WindowPoStVanillaResult
+	workers := map[*workerHandle]struct{}{handle: {}}
+	err := m.sched.Schedule(ctx, sector, types.TTGenerateWindowPoSt, newPoStSelector(workers), schedNop, func(ctx context.Context, w Worker) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r, err := m.waitSimpleCall(ctx)(w.GenerateWindowPoStVanilla(ctx, minerID, sectorInfo, randomness))
+		if r != nil {
+			res = r.(storiface.WindowPoStVanillaResult)
+		}
+		return err
+	})
+	return res, err
+}
+
+// groupByHolder assigns each sector to one of the workers holding it, preferring the workers holding the most
+// sectors, and returns the sectors no worker holds
+func groupByHolder(holders []map[*workerHandle]struct{}, sectorInfo []proof.SectorInfo) (map[*workerHandle][]proof.SectorInfo, []proof.SectorInfo) {
+	groups := map[*workerHandle][]proof.SectorInfo{}
+	left := make([]int, len(sectorInfo))
+	for i := range left {
+		left[i] = i
+	}
+
+	for len(left) > 0 {
+		count := map[*workerHandle]int{}
+		for _, i := range left {
+			for handle := range holders[i] {
+				count[handle]++
+			}
+		}
+
+		var best *workerHandle
+		for handle, n := range count {
+			if best == nil || n > count[best] || (n == count[best] && handle.info.Hostname < best.info.Hostname) {
+				best = handle
+			}
+		}
+		if best == nil {
+			break
+		}
+
+		var rest []int
+		for _, i := range left {
+			if _, ok := holders[i][best]; ok {
+				groups[best] = append(groups[best], sectorInfo[i])
+			} else {
+				rest = append(rest, i)
+			}
+		}
+		left = rest
+	}
+
+	var local []proof.SectorInfo
+	for _, i := range left {
+		local = append(local, sectorInfo[i])
+	}
+	return groups, local
+}
+
+// localWindowPoSt computes the proof on the sealer, one at a time as the batches of a deadline are proven in parallel
+func (m *Manager) localWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) ([]proof.PoStProof, []abi.SectorID, error) {
+	m.localPoStLk.Lock()
+	defer m.localPoStLk.Unlock()
+
+	return m.Prover.GenerateWindowPoSt(ctx, minerID, sectorInfo, randomness)
+}
+
+// GenerateWinningPoSt computes the proof on a worker accepting TTGenerateWinningPoSt which has the sectors in its own
+// storage, ahead of every other task. The sealer computes it itself when there is no such worker or no worker answers
+// within the winning post timeout
+func (m *Manager) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) ([]proof.PoStProof, error) {
+	ctx = types.WithPriority(ctx, types.WinningPoStPriority)
+
+	if len(sectorInfo) == 0 {
+		return m.Prover.GenerateWinningPoSt(ctx, minerID, sectorInfo, randomness)
+	}
+
+	workers, err := m.postWorkers(ctx, types.TTGenerateWinningPoSt, minerID, sectorInfo)
+	if err != nil {
+		log.Warnw("finding winning post workers, proving locally", "error", err)
+	}
+	if len(workers) == 0 {
+		return m.Prover.GenerateWinningPoSt(ctx, minerID, sectorInfo, randomness)
+	}
+
+	sector := storage.SectorRef{
+		ID:        abi.SectorID{Miner: minerID, Number: sectorInfo[0].SectorNumber},
+		ProofType: sectorInfo[0].SealProof,
+	}
+
+	wctx, cancel := context.WithTimeout(ctx, m.WinningPoStTimeout())
+	defer cancel()
+
+	var proofs []proof.PoStProof
+	err = m.sched.Schedule(wctx, sector, types.TTGenerateWinningPoSt, newPoStSelector(workers), schedNop, func(ctx context.Context, w Worker) error {
+		// the request may have waited in the queue past its deadline
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r, err := m.waitSimpleCall(ctx)(w.GenerateWinningPoSt(ctx, minerID, sectorInfo, randomness))
+		if r != nil {
+			proofs = r.([]proof.PoStProof)
+		}
+		return err
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		log.Warnw("winning post on worker failed, proving locally", "sector", sector.ID.Number, "error", err)
+		return m.Prover.GenerateWinningPoSt(ctx, minerID, sectorInfo, randomness)
+	}
+
+	return proofs, nil
+}
+
+// WinningPoStTimeout is how long a worker has to return a winning post before the sealer computes it itself
+func (m *Manager) WinningPoStTimeout() time.Duration {
+	if timeout := time.Duration(atomic.LoadInt64(&m.winningPoStTimeout)); timeout > 0 {
+		return timeout
+	}
+	return DefaultWinningPoStTimeout
+}
+
+// SetWinningPoStTimeout sets the winning post timeout, 0 for the default
+func (m *Manager) SetWinningPoStTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&m.winningPoStTimeout, int64(timeout))
+}
+
+// WindowPoStTimeout is how long the workers have to return a window post before the sealer computes it itself
+func (m *Manager) WindowPoStTimeout() time.Duration {
+	if timeout := time.Duration(atomic.LoadInt64(&m.windowPoStTimeout)); timeout > 0 {
+		return timeout
+	}
+	return DefaultWindowPoStTimeout
+}
+
+// SetWindowPoStTimeout sets the window post timeout, 0 for the default
+func (m *Manager) SetWindowPoStTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&m.windowPoStTimeout, int64(timeout))
+}
+
+// postWorkers returns the enabled workers accepting task which have the sealed and cache files of every sector in
+// their own paths
+func (m *Manager) postWorkers(ctx context.Context, task types.TaskType, minerID abi.ActorID, sectorInfo []proof.SectorInfo) (map[*workerHandle]struct{}, error) {
+	holders, err := m.postHolders(ctx, task, minerID, sectorInfo)
+	if err != nil {
+		return nil, err
+	}
+	return holdingAll(holders), nil
+}
+
+// postHolders returns, for each sector, the enabled workers accepting task which have its sealed and cache files in
+// their own paths
+func (m *Manager) postHolders(ctx context.Context, task types.TaskType, minerID abi.ActorID, sectorInfo []proof.SectorInfo) ([]map[*workerHandle]struct{}, error) {
+	m.sched.workersLk.RLock()
+	var handles []*workerHandle
+	for _, handle := range m.sched.workers {
+		if handle.enabled {
+			handles = append(handles, handle)
+		}
+	}
+	m.sched.workersLk.RUnlock()
+
+	var candidates []*workerHandle
+	for _, handle := range handles {
+		tasks, err := handle.workerRpc.TaskTypes(ctx)
+		if err != nil {
+			log.Warnw("getting worker task types", "worker", handle.info.Hostname, "error", err)
+			continue
+		}
+		if _, ok := tasks[task]; ok {
+			candidates = append(candidates, handle)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// storages holding each file of the sectors
+	find := func(sid abi.SectorID, ft storiface.SectorFileType) (map[stores.ID]struct{}, error) {
+		infos, err := m.index.StorageFindSector(ctx, sid, ft, 0, false)
+		if err != nil {
+			return nil, xerrors.Errorf("finding sector %d: %w", sid.Number, err)
+		}
+		out := make(map[stores.ID]struct{}, len(infos))
+		for _, info := range infos {
+			out[info.ID] = struct{}{}
+		}
+		return out, nil
+	}
+	sealed := make([]map[stores.ID]struct{}, len(sectorInfo))
+	cache := make([]map[stores.ID]struct{}, len(sectorInfo))
+	for i, si := range sectorInfo {
+		sid := abi.SectorID{Miner: minerID, Number: si.SectorNumber}
+		var err error
+		if sealed[i], err = find(sid, storiface.FTSealed); err != nil {
+			return nil, err
+		}
+		if cache[i], err = find(sid, storiface.FTCache); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]map[*workerHandle]struct{}, len(sectorInfo))
+	for i := range out {
+		out[i] = map[*workerHandle]struct{}{}
+	}
+	for _, handle := range candidates {
+		paths, err := handle.workerRpc.Paths(ctx)
+		if err != nil {
+			log.Warnw("getting worker paths", "worker", handle.info.Hostname, "error", err)
+			continue
+		}
+		have := make(map[stores.ID]struct{}, len(paths))
+		for _, path := range paths {
+			have[path.ID] = struct{}{}
+		}
+
+		for i := range sectorInfo {
+			if hasAny(have, sealed[i]) && hasAny(have, cache[i]) {
+				out[i][handle] = struct{}{}
+			}
+		}
+	}
+	return out, nil
+}
+
+// hasAny reports whether one of the storages is in have
+func hasAny(have map[stores.ID]struct{}, storages map[stores.ID]struct{}) bool {
+	for id := range storages {
+		if _, ok := have[id]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// holdingAll returns the workers holding every sector
+func holdingAll(holders []map[*workerHandle]struct{}) map[*workerHandle]struct{} {
+	if len(holders) == 0 {
+		return nil
+	}
+	out := map[*workerHandle]struct{}{}
+	for handle := range holders[0] {
+		out[handle] = struct{}{}
+	}
+	for _, hs := range holders[1:] {
+		for handle := range out {
+			if _, ok := hs[handle]; !ok {
+				delete(out, handle)
+			}
+		}
+	}
+	return out
+}
+
+// holdingAny reports whether a worker holds one of the sectors
+func holdingAny(holders []map[*workerHandle]struct{}) bool {
+	for _, hs := range holders {
+		if len(hs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) ReturnGenerateWindowPoSt(ctx context.Context, callID types.CallID, res storiface.WindowPoStResult, err *storiface.CallError) error {
+	return m.returnResult(ctx, callID, res, err)
+}
+
+func (m *Manager) ReturnGenerateWindowPoStVanilla(ctx context.Context, callID types.CallID, res storiface.WindowPoStVanillaResult, err *storiface.CallError) error {
+	return m.returnResult(ctx, callID, res, err)
+}
+
+func (m *Manager) ReturnGenerateWinningPoSt(ctx context.Context, callID types.CallID, proofs []proof.PoStProof, err *storiface.CallError) error {
+	return m.returnResult(ctx, callID, proofs, err)
+}
+
+type postSelector struct {
+	workers map[*workerHandle]struct{}
+}
+
+func newPoStSelector(workers map[*workerHandle]struct{}) *postSelector {
+	return &postSelector{workers: workers}
+}
+
+func (s *postSelector) Ok(ctx context.Context, task types.TaskType, spt abi.RegisteredSealProof, whnd *workerHandle) (bool, error) {
+	_, ok := s.workers[whnd]
+	return ok, nil
+}
+
+func (s *postSelector) Cmp(ctx context.Context, task types.TaskType, a, b *workerHandle) (bool, error) {
+	return a.utilization() < b.utilization(), nil
+}
+
+var _ WorkerSelector = &postSelector{}
@@ -0,0 +1,564 @@
+package sectorstorage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/specs-actors/actors/runtime/proof"
+	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
+	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func TestPoStWorkers(t *testing.T) {
+	ctx := context.Background()
+
+	sched := newScheduler()
+	go sched.runSched()
+	defer sched.Close(ctx) // nolint
+
+	index := stores.NewIndex()
+	m := &Manager{sched: sched, index: index}
+
+	post := map[types.TaskType]struct{}{types.TTGenerateWindowPoSt: {}}
+	winning := map[types.TaskType]struct{}{types.TTGenerateWinningPoSt: {}}
+	add := func(name string, tasks map[types.TaskType]struct{}, paths ...stores.ID) {
+		w := &schedTestWorker{
+			name:      name,
+			taskTypes: tasks,
+			session:   uuid.New(),
+			resources: decentWorkerResources,
+		}
+		for _, id := range paths {
+			w.paths = append(w.paths, stores.StoragePath{ID: id, Weight: 1, CanStore: true})
+			require.NoError(t, index.StorageAttach(ctx, stores.StorageInfo{ID: id, Weight: 1, CanStore: true}, fsutil.FsStat{
+				Capacity:    1 << 40,
+				Available:   1 << 40,
+				FSAvailable: 1 << 40,
+			}))
+		}
+		require.NoError(t, sched.runWorker(ctx, w))
+	}
+
+	add("all", post, "a", "b")
+	add("half", post, "b")
+	add("nopost", nil, "c")
+	add("winning", winning, "c")
+
+	declare := func(id stores.ID, num abi.SectorNumber, ft storiface.SectorFileType) {
+		require.NoError(t, index.StorageDeclareSector(ctx, id, abi.SectorID{Miner: 1000, Number: num}, ft, true))
+	}
+	declare("a", 1, storiface.FTSealed)
+	declare("b", 1, storiface.FTCache)
+	declare("b", 2, storiface.FTSealed|storiface.FTCache)
+	declare("c", 1, storiface.FTSealed|storiface.FTCache)
+	declare("c", 2, storiface.FTSealed|storiface.FTCache)
+
+	hosts := func(task types.TaskType, sectors ...abi.SectorNumber) []string {
+		var sis []proof.SectorInfo
+		for _, num := range sectors {
+			sis = append(sis, proof.SectorInfo{SealProof: abi.RegisteredSealProof_StackedDrg2KiBV1, SectorNumber: num})
+		}
+		workers, err := m.postWorkers(ctx, task, 1000, sis)
+		require.NoError(t, err)
+
+		var out []string
+		for handle := range workers {
+			out = append(out, handle.info.Hostname)
+		}
+		return out
+	}
+
+	require.ElementsMatch(t, []string{"all"}, hosts(types.TTGenerateWindowPoSt, 1, 2))
+	require.ElementsMatch(t, []string{"all", "half"}, hosts(types.TTGenerateWindowPoSt, 2))
+	require.ElementsMatch(t, []string{"winning"}, hosts(types.TTGenerateWinningPoSt, 2))
+}
+
+// priorityProver records the priority of the calls of the local prover
+type priorityProver struct {
+	lk    sync.Mutex
+	prios []int
+}
+
+func (p *priorityProver) record(ctx context.Context) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	p.prios = append(p.prios, types.GetPriority(ctx))
+}
+
+func (p *priorityProver) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) ([]proof.PoStProof, error) {
+	p.record(ctx)
+	return nil, nil
+}
+
+func (p *priorityProver) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) ([]proof.PoStProof, []abi.SectorID, error) {
+	p.record(ctx)
+	return nil, nil, nil
+}
+
+func TestPoStFetchPriority(t *testing.T) {
+	ctx := context.Background()
+	m, _, _, si, cleanup := newTestMgr(ctx, t, datastore.NewMapDatastore())
+	defer cleanup()
+
+	prover := &priorityProver{}
+	m.Prover = prover
+
+	sis := []proof.SectorInfo{{SealProof: abi.RegisteredSealProof_StackedDrg2KiBV1_1, SectorNumber: 1}}
+	_, _, err := m.GenerateWindowPoSt(ctx, 1000, sis, abi.PoStRandomness{1})
+	require.NoError(t, err)
+	_, err = m.GenerateWinningPoSt(ctx, 1000, sis, abi.PoStRandomness{1})
+	require.NoError(t, err)
+
+	require.Len(t, prover.prios, 2)
+	require.GreaterOrEqual(t, prover.prios[0], stores.PoStFetchPriority)
+	require.GreaterOrEqual(t, prover.prios[1], stores.PoStFetchPriority)
+	require.Greater(t, prover.prios[1], prover.prios[0])
+
+	// the checks of sectors in other storages pass saturated handlers
+	var checkPrio int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prio, err := strconv.Atoi(r.Header.Get(stores.FetchPriorityHeader))
+		require.NoError(t, err)
+		atomic.StoreInt64(&checkPrio, int64(prio))
+		require.NoError(t, json.NewEncoder(w).Encode(storiface.SectorCheck{Code: storiface.CheckOK}))
+	}))
+	defer srv.Close()
+
+	require.NoError(t, si.StorageAttach(ctx, stores.StorageInfo{ID: "remote", URLs: []string{srv.URL + "/remote"}, Weight: 1, CanStore: true}, fsutil.FsStat{
+		Capacity:  1 << 40,
+		Available: 1 << 40,
+	}))
+	sector := storage.SectorRef{ID: abi.SectorID{Miner: 1000, Number: 1}, ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1_1}
+	require.NoError(t, si.StorageDeclareSector(ctx, "remote", sector.ID, storiface.FTSealed|storiface.FTCache, true))
+
+	res, err := m.CheckProvable(ctx, abi.RegisteredPoStProof_StackedDrgWindow2KiBV1, []storage.SectorRef{sector}, nil)
+	require.NoError(t, err)
+	require.Equal(t, storiface.CheckOK, res[0].Code)
+	require.GreaterOrEqual(t, int(atomic.LoadInt64(&checkPrio)), stores.PoStFetchPriority)
+}
+
+// vanillaWorker returns one byte vanilla proofs, or never answers when stalled
+type vanillaWorker struct {
+	*schedTestWorker
+	ret   storiface.WorkerReturn
+	stall bool
+
+	lk      sync.Mutex
+	sectors []abi.SectorNumber
+}
+
+func (w *vanillaWorker) call(minerID abi.ActorID, sectorInfo []proof.SectorInfo, respond func(types.CallID)) (types.CallID, error) {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	for _, si := range sectorInfo {
+		w.sectors = append(w.sectors, si.SectorNumber)
+	}
+
+	ci := types.CallID{Sector: abi.SectorID{Miner: minerID, Number: sectorInfo[0].SectorNumber}, ID: uuid.New()}
+	if !w.stall {
+		go respond(ci)
+	}
+	return ci, nil
+}
+
+func (w *vanillaWorker) proved() []abi.SectorNumber {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	return append([]abi.SectorNumber{}, w.sectors...)
+}
+
+func (w *vanillaWorker) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	return w.call(minerID, sectorInfo, func(ci types.CallID) {
+		res := storiface.WindowPoStResult{Proofs: []proof.PoStProof{{ProofBytes: []byte("worker")}}}
+		if err := w.ret.ReturnGenerateWindowPoSt(ctx, ci, res, nil); err != nil {
+			log.Error(err)
+		}
+	})
+}
+
+func (w *vanillaWorker) GenerateWindowPoStVanilla(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	return w.call(minerID, sectorInfo, func(ci types.CallID) {
+		if err := w.ret.ReturnGenerateWindowPoStVanilla(ctx, ci, vanillaOf(sectorInfo, 'w'), nil); err != nil {
+			log.Error(err)
+		}
+	})
+}
+
+func vanillaOf(sectorInfo []proof.SectorInfo, by byte) storiface.WindowPoStVanillaResult {
+	var res storiface.WindowPoStVanillaResult
+	for _, si := range sectorInfo {
+		res.Sectors = append(res.Sectors, si.SectorNumber)
+		res.Proofs = append(res.Proofs, []byte{byte(si.SectorNumber), by})
+	}
+	return res
+}
+
+// vanillaProver proves the sectors on the sealer, the window post carries the vanilla proofs it was computed from
+type vanillaProver struct {
+	priorityProver
+
+	lk      sync.Mutex
+	whole   int
+	sectors []abi.SectorNumber
+}
+
+func (p *vanillaProver) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) ([]proof.PoStProof, []abi.SectorID, error) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	p.whole++
+	return []proof.PoStProof{{ProofBytes: []byte("local")}}, nil, nil
+}
+
+func (p *vanillaProver) GenerateWindowPoStVanilla(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (storiface.WindowPoStVanillaResult, error) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	for _, si := range sectorInfo {
+		p.sectors = append(p.sectors, si.SectorNumber)
+	}
+	return vanillaOf(sectorInfo, 'l'), nil
+}
+
+func (p *vanillaProver) GenerateWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, vanilla [][]byte) ([]proof.PoStProof, error) {
+	var out []byte
+	for _, v := range vanilla {
+		out = append(out, v...)
+	}
+	return []proof.PoStProof{{PoStProof: proofType, ProofBytes: out}}, nil
+}
+
+func newVanillaTest(t *testing.T, stall ...bool) (*Manager, *vanillaProver, []*vanillaWorker, func()) {
+	ctx := context.Background()
+	m, _, _, si, cleanup := newTestMgr(ctx, t, datastore.NewMapDatastore())
+
+	prover := &vanillaProver{}
+	m.Prover = prover
+
+	var workers []*vanillaWorker
+	for i, stalled := range stall {
+		id := stores.ID("w" + strconv.Itoa(i))
+		require.NoError(t, si.StorageAttach(ctx, stores.StorageInfo{ID: id, Weight: 1, CanStore: true}, fsutil.FsStat{
+			Capacity:  1 << 40,
+			Available: 1 << 40,
+		}))
+		w := &vanillaWorker{
+			schedTestWorker: &schedTestWorker{
+				name:      string(id),
+				taskTypes: map[types.TaskType]struct{}{types.TTGenerateWindowPoSt: {}},
+				paths:     []stores.StoragePath{{ID: id, Weight: 1, CanStore: true}},
+				session:   uuid.New(),
+				resources: decentWorkerResources,
+			},
+			ret:   m,
+			stall: stalled,
+		}
+		require.NoError(t, m.AddWorker(ctx, w))
+		workers = append(workers, w)
+	}
+	return m, prover, workers, cleanup
+}
+
+func declarePoSt(t *testing.T, m *Manager, id stores.ID, nums ...abi.SectorNumber) []proof.SectorInfo {
+	var sis []proof.SectorInfo
+	for _, num := range nums {
+		require.NoError(t, m.index.StorageDeclareSector(context.Background(), id, abi.SectorID{Miner: 1000, Number: num}, storiface.FTSealed|storiface.FTCache, true))
+		sis = append(sis, proof.SectorInfo{SealProof: abi.RegisteredSealProof_StackedDrg2KiBV1_1, SectorNumber: num})
+	}
+	return sis
+}
+
+func TestWindowPoStSplit(t *testing.T) {
+	ctx := context.Background()
+	m, prover, workers, cleanup := newVanillaTest(t, false, false)
+	defer cleanup()
+
+	sis := append(declarePoSt(t, m, "w1", 3), declarePoSt(t, m, "w0", 2, 1)...)
+	sis = append(sis, proof.SectorInfo{SealProof: abi.RegisteredSealProof_StackedDrg2KiBV1_1, SectorNumber: 4})
+
+	proofs, skipped, err := m.GenerateWindowPoSt(ctx, 1000, sis, abi.PoStRandomness{1})
+	require.NoError(t, err)
+	require.Empty(t, skipped)
+	require.Len(t, proofs, 1)
+	require.Equal(t, []byte{1, 'w', 2, 'w', 3, 'w', 4, 'l'}, proofs[0].ProofBytes)
+
+	require.ElementsMatch(t, []abi.SectorNumber{1, 2}, workers[0].proved())
+	require.Equal(t, []abi.SectorNumber{3}, workers[1].proved())
+	require.Equal(t, []abi.SectorNumber{4}, prover.sectors)
+	require.Zero(t, prover.whole)
+
+	// a worker holding every sector computes the whole proof
+	proofs, _, err = m.GenerateWindowPoSt(ctx, 1000, sis[1:3], abi.PoStRandomness{1})
+	require.NoError(t, err)
+	require.Equal(t, []byte("worker"), proofs[0].ProofBytes)
+}
+
+func TestWindowPoStTimeout(t *testing.T) {
+	ctx := context.Background()
+	m, prover, workers, cleanup := newVanillaTest(t, false, true)
+	defer cleanup()
+	m.SetWindowPoStTimeout(100 * time.Millisecond)
+
+	sis := append(declarePoSt(t, m, "w0", 1), declarePoSt(t, m, "w1", 2)...)
+
+	// the stalled worker's sectors are proven by the sealer once the timeout expires
+	start := time.Now()
+	proofs, _, err := m.GenerateWindowPoSt(ctx, 1000, sis, abi.PoStRandomness{1})
+	require.NoError(t, err)
+	require.Less(t, int64(time.Since(start)), int64(5*time.Second))
+	require.Equal(t, []byte{1, 'w', 2, 'l'}, proofs[0].ProofBytes)
+	require.Equal(t, []abi.SectorNumber{2}, workers[1].proved())
+	require.Equal(t, []abi.SectorNumber{2}, prover.sectors)
+
+	// the whole proof too when the stalled worker holds every sector
+	proofs, _, err = m.GenerateWindowPoSt(ctx, 1000, sis[1:], abi.PoStRandomness{1})
+	require.NoError(t, err)
+	require.Equal(t, []byte("local"), proofs[0].ProofBytes)
+	require.Equal(t, 1, prover.whole)
+}
+
+// winningWorker records the order its tasks start in, its winning posts never answer when stalled
+type winningWorker struct {
+	*schedTestWorker
+	ret   storiface.WorkerReturn
+	stall bool
+
+	lk        sync.Mutex
+	started   []string
+	deadlines []time.Time
+}
+
+func (w *winningWorker) start(name string) {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	w.started = append(w.started, name)
+}
+
+func (w *winningWorker) starts() []string {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	return append([]string{}, w.started...)
+}
+
+func (w *winningWorker) setStall(stall bool) {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	w.stall = stall
+}
+
+func (w *winningWorker) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	w.start("winning")
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	deadline, _ := ctx.Deadline()
+	w.deadlines = append(w.deadlines, deadline)
+
+	ci := types.CallID{Sector: abi.SectorID{Miner: minerID, Number: sectorInfo[0].SectorNumber}, ID: uuid.New()}
+	if !w.stall {
+		go func() {
+			if err := w.ret.ReturnGenerateWinningPoSt(ctx, ci, []proof.PoStProof{{ProofBytes: []byte("worker")}}, nil); err != nil {
+				log.Error(err)
+			}
+		}()
+	}
+	return ci, nil
+}
+
+// winningProver records when the sealer computes a winning post itself
+type winningProver struct {
+	priorityProver
+
+	lk     sync.Mutex
+	calls  []time.Time
+	ctxErr []error
+}
+
+func (p *winningProver) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) ([]proof.PoStProof, error) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	p.calls = append(p.calls, time.Now())
+	p.ctxErr = append(p.ctxErr, ctx.Err())
+	return []proof.PoStProof{{ProofBytes: []byte("local")}}, nil
+}
+
+func newWinningTest(t *testing.T, stall bool, resources storiface.WorkerResources) (*Manager, *winningProver, *winningWorker, []proof.SectorInfo, func()) {
+	ctx := context.Background()
+	m, _, _, si, cleanup := newTestMgr(ctx, t, datastore.NewMapDatastore())
+
+	prover := &winningProver{}
+	m.Prover = prover
+
+	require.NoError(t, si.StorageAttach(ctx, stores.StorageInfo{ID: "winning", Weight: 1, CanStore: true}, fsutil.FsStat{
+		Capacity:  1 << 40,
+		Available: 1 << 40,
+	}))
+	w := &winningWorker{
+		schedTestWorker: &schedTestWorker{
+			name: "winning",
+			taskTypes: map[types.TaskType]struct{}{
+				types.TTGenerateWinningPoSt: {},
+				types.TTPreCommit1:          {},
+				types.TTFinalize:            {},
+			},
+			paths:     []stores.StoragePath{{ID: "winning", Weight: 1, CanStore: true}},
+			session:   uuid.New(),
+			resources: resources,
+		},
+		ret:   m,
+		stall: stall,
+	}
+	require.NoError(t, m.AddWorker(ctx, w))
+
+	return m, prover, w, declarePoSt(t, m, "winning", 1), cleanup
+}
+
+func TestWinningPoStDefaultTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the default winning post timeout")
+	}
+
+	m, prover, w, sis, cleanup := newWinningTest(t, true, decentWorkerResources)
+	defer cleanup()
+	require.Equal(t, DefaultWinningPoStTimeout, m.WinningPoStTimeout())
+
+	// the block deadline leaves the sealer time to prove after the workers
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultWinningPoStTimeout+5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	proofs, err := m.GenerateWinningPoSt(ctx, 1000, sis, abi.PoStRandomness{1})
+	require.NoError(t, err)
+	require.Equal(t, []byte("local"), proofs[0].ProofBytes)
+
+	// the stalled worker got the request bounded by the default timeout
+	require.Equal(t, []string{"winning"}, w.starts())
+	w.lk.Lock()
+	require.WithinDuration(t, start.Add(DefaultWinningPoStTimeout), w.deadlines[0], time.Second)
+	w.lk.Unlock()
+
+	// and the sealer proved it as soon as the timeout expired, within the block deadline
+	require.Len(t, prover.calls, 1)
+	require.WithinDuration(t, start.Add(DefaultWinningPoStTimeout), prover.calls[0], time.Second)
+	require.NoError(t, prover.ctxErr[0])
+}
+
+func TestWinningPoStFallback(t *testing.T) {
+	ctx := context.Background()
+	m, prover, w, sis, cleanup := newWinningTest(t, true, decentWorkerResources)
+	defer cleanup()
+
+	timeout := 200 * time.Millisecond
+	m.SetWinningPoStTimeout(timeout)
+
+	start := time.Now()
+	proofs, err := m.GenerateWinningPoSt(ctx, 1000, sis, abi.PoStRandomness{1})
+	require.NoError(t, err)
+	require.Equal(t, []byte("local"), proofs[0].ProofBytes)
+	require.Equal(t, []string{"winning"}, w.starts())
+
+	require.Len(t, prover.calls, 1)
+	require.GreaterOrEqual(t, int64(prover.calls[0].Sub(start)), int64(timeout))
+	require.Less(t, int64(time.Since(start)), int64(timeout+time.Second))
+	require.NoError(t, prover.ctxErr[0])
+
+	// a worker answering in time proves it
+	w.setStall(false)
+	proofs, err = m.GenerateWinningPoSt(ctx, 1000, sis, abi.PoStRandomness{1})
+	require.NoError(t, err)
+	require.Equal(t, []byte("worker"), proofs[0].ProofBytes)
+	require.Len(t, prover.calls, 1)
+}
+
+func TestWinningPoStPriority(t *testing.T) {
+	ctx := context.Background()
+
+	// a single cpu runs one precommit or winning post at a time
+	m, prover, w, sis, cleanup := newWinningTest(t, false, storiface.WorkerResources{
+		MemPhysical: 128 << 30,
+		MemSwap:     200 << 30,
+		CPUs:        1,
+	})
+	defer cleanup()
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	schedule := func(ctx context.Context, num abi.SectorNumber, task types.TaskType, name string, block bool) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sector := storage.SectorRef{ID: abi.SectorID{Miner: 1000, Number: num}, ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1_1}
+			err := m.sched.Schedule(ctx, sector, task, newTaskSelector(), schedNop, func(ctx context.Context, _ Worker) error {
+				w.start(name)
+				if block {
+					<-release
+				}
+				return nil
+			})
+			require.NoError(t, err)
+		}()
+	}
+	diag := func() SchedDiagInfo {
+		info, err := m.sched.Info(ctx)
+		require.NoError(t, err)
+		return info.(SchedDiagInfo)
+	}
+	queued := func(requests int) func() bool {
+		return func() bool {
+			return len(diag().Requests) == requests
+		}
+	}
+
+	// a running precommit and one waiting in every open window of the worker
+	schedule(ctx, 10, types.TTPreCommit1, "pc1", true)
+	require.Eventually(t, func() bool {
+		return len(w.starts()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	for num := abi.SectorNumber(11); len(diag().OpenWindows) > 0; num++ {
+		require.Less(t, int(num), 20)
+		schedule(ctx, num, types.TTPreCommit1, "pc1", false)
+		require.Eventually(t, queued(0), 5*time.Second, 10*time.Millisecond)
+	}
+
+	// sealing work with a raised priority queued first is still behind the winning post
+	schedule(types.WithPriority(ctx, 1<<20), 20, types.TTFinalize, "finalize", false)
+	schedule(types.WithPriority(ctx, 1<<20), 21, types.TTPreCommit1, "priority pc1", false)
+	require.Eventually(t, queued(2), 5*time.Second, 10*time.Millisecond)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		proofs, err := m.GenerateWinningPoSt(ctx, 1000, sis, abi.PoStRandomness{1})
+		require.NoError(t, err)
+		require.Equal(t, []byte("worker"), proofs[0].ProofBytes)
+	}()
+	require.Eventually(t, queued(3), 5*time.Second, 10*time.Millisecond)
+
+	requests := diag().Requests
+	require.Equal(t, types.TTGenerateWinningPoSt, requests[0].TaskType)
+	require.Equal(t, types.TTFinalize, requests[1].TaskType)
+
+	close(release)
+	wg.Wait()
+
+	// the finalize needs no resources and may start as soon as it is assigned, the precommit waits for the cpu
+	started := map[string]int{}
+	for i, name := range w.starts() {
+		started[name] = i
+	}
+	require.Less(t, started["winning"], started["priority pc1"])
+	require.Empty(t, prover.calls)
+}
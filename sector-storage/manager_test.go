@@ -26,6 +26,7 @@ import (
 	"github.com/filecoin-project/venus-sealer/sector-storage/ffiwrapper"
 	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
 	"github.com/filecoin-project/venus-sealer/types"
 )
 
@@ -452,3 +453,29 @@ func TestReenableWorker(t *testing.T) {
 	i, _ = m.sched.Info(ctx)
 	require.Len(t, i.(SchedDiagInfo).OpenWindows, 2)
 }
+
+func TestSectorKindKeepsDeals(t *testing.T) {
+	ctx := context.Background()
+	index := stores.NewIndex()
+	m := &Manager{index: index}
+
+	stat := fsutil.FsStat{Capacity: 1 << 40, Available: 1 << 40}
+	require.NoError(t, index.StorageAttach(ctx, stores.StorageInfo{ID: "deals", URLs: []string{"http://deals/remote"}, Weight: 10, CanStore: true,
+		PlacementRules: stores.PlacementRules{AllowKinds: []storiface.SectorKind{storiface.KindDeal}}}, stat))
+
+	sector := storage.SectorRef{ID: abi.SectorID{Miner: 1000, Number: 1}, ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1}
+	best := func() error {
+		_, err := index.StorageBestAlloc(ctx, storiface.FTSealed, 2048, storiface.PathStorage, sector.ID)
+		return err
+	}
+
+	m.setSectorKind(ctx, sector)
+	require.Error(t, best())
+
+	m.setSectorKind(types.WithDeals(ctx), sector)
+	require.NoError(t, best())
+
+	// e.g. a finalize or move started from the cli
+	m.setSectorKind(ctx, sector)
+	require.NoError(t, best())
+}
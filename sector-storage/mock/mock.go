@@ -437,18 +437,21 @@ func (mgr *SectorMgr) Remove(ctx context.Context, sector storage.SectorRef) erro
 	return nil
 }
 
-func (mgr *SectorMgr) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, ids []storage.SectorRef, rg storiface.RGetter) (map[abi.SectorID]string, error) {
-	bad := map[abi.SectorID]string{}
+func (mgr *SectorMgr) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, ids []storage.SectorRef, rg storiface.RGetter) ([]storiface.SectorCheck, error) {
+	checks := make([]storiface.SectorCheck, len(ids))
+
+	for i, sid := range ids {
+		checks[i] = storiface.SectorCheck{Sector: sid.ID, Code: storiface.CheckOK}
 
-	for _, sid := range ids {
 		_, found := mgr.sectors[sid.ID]
 
 		if !found || mgr.sectors[sid.ID].failed {
-			bad[sid.ID] = "mock fail"
+			checks[i].Code = storiface.CheckMissingFile
+			checks[i].Error = "mock fail"
 		}
 	}
 
-	return bad, nil
+	return checks, nil
 }
 
 func (mgr *SectorMgr) ReturnAddPiece(ctx context.Context, callID types.CallID, pi abi.PieceInfo, err *storiface.CallError) error {
@@ -495,6 +498,18 @@ func (mgr *SectorMgr) ReturnFetch(ctx context.Context, callID types.CallID, err
 	panic("not supported")
 }
 
+func (mgr *SectorMgr) ReturnGenerateWindowPoSt(ctx context.Context, callID types.CallID, res storiface.WindowPoStResult, err *storiface.CallError) error {
+	panic("not supported")
+}
+
+func (mgr *SectorMgr) ReturnGenerateWindowPoStVanilla(ctx context.Context, callID types.CallID, res storiface.WindowPoStVanillaResult, err *storiface.CallError) error {
+	panic("not supported")
+}
+
+func (mgr *SectorMgr) ReturnGenerateWinningPoSt(ctx context.Context, callID types.CallID, proofs []proof5.PoStProof, err *storiface.CallError) error {
+	panic("not supported")
+}
+
 func (mgr *SectorMgr) SectorsUnsealPiece(ctx context.Context, sector storage.SectorRef, offset storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize, randomness abi.SealRandomness, commd *cid.Cid) error {
 	return nil
 }
@@ -261,6 +261,100 @@ var ResourceTable = map[types.TaskType]map[abi.RegisteredSealProof]Resources{
 			BaseMinMemory: 8 << 20,
 		},
 	},
+	types.TTGenerateWindowPoSt: {
+		abi.RegisteredSealProof_StackedDrg64GiBV1: Resources{
+			MaxMemory: 120 << 30, // TODO: Confirm
+			MinMemory: 60 << 30,
+
+			MaxParallelism: -1,
+			CanGPU:         true,
+
+			BaseMinMemory: 64 << 30, // params
+		},
+		abi.RegisteredSealProof_StackedDrg32GiBV1: Resources{
+			MaxMemory: 96 << 30,
+			MinMemory: 30 << 30,
+
+			MaxParallelism: -1,
+			CanGPU:         true,
+
+			BaseMinMemory: 32 << 30, // params
+		},
+		abi.RegisteredSealProof_StackedDrg512MiBV1: Resources{
+			MaxMemory: 3 << 29, // 1.5G
+			MinMemory: 1 << 30,
+
+			MaxParallelism: -1,
+			CanGPU:         true,
+
+			BaseMinMemory: 10 << 30,
+		},
+		abi.RegisteredSealProof_StackedDrg2KiBV1: Resources{
+			MaxMemory: 2 << 10,
+			MinMemory: 2 << 10,
+
+			MaxParallelism: -1,
+			CanGPU:         true,
+
+			BaseMinMemory: 2 << 10,
+		},
+		abi.RegisteredSealProof_StackedDrg8MiBV1: Resources{
+			MaxMemory: 8 << 20,
+			MinMemory: 8 << 20,
+
+			MaxParallelism: -1,
+			CanGPU:         true,
+
+			BaseMinMemory: 8 << 20,
+		},
+	},
+	types.TTGenerateWinningPoSt: {
+		abi.RegisteredSealProof_StackedDrg64GiBV1: Resources{
+			MaxMemory: 2 << 30,
+			MinMemory: 2 << 30,
+
+			MaxParallelism: -1,
+			CanGPU:         true,
+
+			BaseMinMemory: 1 << 30,
+		},
+		abi.RegisteredSealProof_StackedDrg32GiBV1: Resources{
+			MaxMemory: 1 << 30,
+			MinMemory: 1 << 30,
+
+			MaxParallelism: -1,
+			CanGPU:         true,
+
+			BaseMinMemory: 1 << 30,
+		},
+		abi.RegisteredSealProof_StackedDrg512MiBV1: Resources{
+			MaxMemory: 1 << 30,
+			MinMemory: 1 << 30,
+
+			MaxParallelism: -1,
+			CanGPU:         true,
+
+			BaseMinMemory: 1 << 30,
+		},
+		abi.RegisteredSealProof_StackedDrg2KiBV1: Resources{
+			MaxMemory: 2 << 10,
+			MinMemory: 2 << 10,
+
+			MaxParallelism: -1,
+			CanGPU:         true,
+
+			BaseMinMemory: 2 << 10,
+		},
+		abi.RegisteredSealProof_StackedDrg8MiBV1: Resources{
+			MaxMemory: 8 << 20,
+			MinMemory: 8 << 20,
+
+			MaxParallelism: -1,
+			CanGPU:         true,
+
+			BaseMinMemory: 8 << 20,
+		},
+	},
 	types.TTFetch: {
 		abi.RegisteredSealProof_StackedDrg64GiBV1: Resources{
 			MaxMemory: 1 << 20,
@@ -13,6 +13,7 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/specs-storage/storage"
 
+	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
 	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
 	"github.com/filecoin-project/venus-sealer/types"
 )
@@ -67,6 +68,8 @@ type scheduler struct {
 
 	workTracker *workTracker
 
+	policy *schedPolicy
+
 	info chan func(interface{})
 
 	closing  chan struct{}
@@ -157,8 +160,11 @@ func newScheduler() *scheduler {
 		workTracker: &workTracker{
 			done:    map[types.CallID]struct{}{},
 			running: map[types.CallID]trackedWork{},
+			last:    map[abi.SectorID]string{},
 		},
 
+		policy: defaultSchedPolicy(),
+
 		info: make(chan func(interface{})),
 
 		closing: make(chan struct{}),
@@ -174,7 +180,7 @@ func (sh *scheduler) Schedule(ctx context.Context, sector storage.SectorRef, tas
 		sector:   sector,
 		taskType: taskType,
 		priority: types.GetPriority(ctx),
-		sel:      sel,
+		sel:      newPolicySelector(sh.policy, sector.ID, sel),
 
 		prepare: prepare,
 		work:    work,
@@ -182,7 +188,7 @@ func (sh *scheduler) Schedule(ctx context.Context, sector storage.SectorRef, tas
 		start: time.Now(),
 
 		ret: ret,
-		ctx: ctx,
+		ctx: stores.WithFetchTask(ctx, taskType),
 	}:
 	case <-sh.closing:
 		return xerrors.New("closing")
@@ -217,6 +223,8 @@ type SchedDiagRequestInfo struct {
 type SchedDiagInfo struct {
 	Requests    []SchedDiagRequestInfo
 	OpenWindows []string
+	// most recent placements first, with the policy rules which placed them
+	Placements []SchedDiagPlacementInfo
 }
 
 func (sh *scheduler) runSched() {
@@ -326,13 +334,17 @@ func (sh *scheduler) diag() SchedDiagInfo {
 		out.OpenWindows = append(out.OpenWindows, uuid.UUID(window.worker).String())
 	}
 
+	out.Placements = sh.policy.diag()
+
 	return out
 }
 
 func (sh *scheduler) trySched() {
 	/*
 		This assigns tasks to workers based on:
-		- Task priority (achieved by handling sh.schedQueue in order, since it's already sorted by priority)
+		- Task priority (achieved by handling sh.schedQueue in order, since it's already sorted by priority),
+		  deal and CC sector tasks are interleaved when the policy sets a deal share
+		- Scheduler policy rules (checked by the policySelector wrapping every task selector)
 		- Worker resource availability
 		- Task-specified worker preference (acceptableWindows array below sorted by this preference)
 		- Window request age
@@ -454,7 +466,7 @@ func (sh *scheduler) trySched() {
 	scheduled := 0
 	rmQueue := make([]int, 0, queueLen)
 
-	for sqi := 0; sqi < queueLen; sqi++ {
+	for _, sqi := range sh.policy.order(*sh.schedQueue) {
 		task := (*sh.schedQueue)[sqi]
 		needRes := ResourceTable[task.taskType][task.sector.ProofType]
 
@@ -488,12 +500,15 @@ func (sh *scheduler) trySched() {
 		}
 
 		windows[selectedWindow].todo = append(windows[selectedWindow].todo, task)
+		sh.policy.placed(task, sh.workers[sh.openWindows[selectedWindow].worker].info.Hostname)
 
 		rmQueue = append(rmQueue, sqi)
 		scheduled++
 	}
 
 	if len(rmQueue) > 0 {
+		// the policy may have reordered the queue, remove from the end
+		sort.Ints(rmQueue)
 		for i := len(rmQueue) - 1; i >= 0; i-- {
 			sh.schedQueue.Remove(rmQueue[i])
 		}
@@ -0,0 +1,477 @@
+package sectorstorage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// SchedulerPolicy configures the placement and ordering rules of the scheduler on top of the built-in selectors
+type SchedulerPolicy struct {
+	// Groups maps a group name to the hostnames of its workers
+	Groups map[string][]string
+	// TaskGroups pins a task type (full name or short name, eg. "PC2") to the workers of a group
+	TaskGroups map[string]string
+	// GroupAffinity keeps the tasks of a sector in the group of the worker which ran its previous task,
+	// pinned task types are not affected
+	GroupAffinity bool
+	// KeepPC2WithPC1 runs PC2 of a sector on the host which ran its PC1
+	KeepPC2WithPC1 bool
+	// AffinityTimeout is how long a task waits for the host or group GroupAffinity and KeepPC2WithPC1 keep it on
+	// before any worker may take it, in nanoseconds, 0 for DefaultAffinityTimeout. Tasks of sectors whose host
+	// isn't connected are not kept waiting
+	AffinityTimeout time.Duration
+	// DealShare is the share (0-1) of deal sector tasks when deal and CC sector tasks are both queued,
+	// 0 keeps the default ordering where deal sectors always go first
+	DealShare float64
+}
+
+// rule names reported by sched-diag
+const (
+	ruleDefault   = "default"
+	rulePin       = "pin"
+	ruleAffinity  = "affinity"
+	ruleSameHost  = "same-host"
+	ruleFairShare = "fair-share"
+)
+
+// number of placements kept for sched-diag
+const schedDiagPlacements = 100
+
+// DefaultAffinityTimeout is the AffinityTimeout used when the policy doesn't set it
+const DefaultAffinityTimeout = 30 * time.Minute
+
+type SchedDiagPlacementInfo struct {
+	Sector   abi.SectorID
+	TaskType types.TaskType
+	Worker   string
+	Rules    []string
+	At       time.Time
+}
+
+type schedPolicy struct {
+	cfg        SchedulerPolicy
+	hostGroup  map[string]string
+	taskGroups map[types.TaskType]string
+
+	// index is used to find the hosts of sectors placed before the sealer restarted
+	index stores.SectorIndex
+
+	lk sync.Mutex
+	// host of the last placed task and of PC1 of sectors being sealed
+	lastHost map[abi.SectorID]string
+	pc1Host  map[abi.SectorID]string
+	// sectors whose hosts were looked up in the index
+	restored map[abi.SectorID]struct{}
+
+	// connected workers with their storage paths
+	workers map[WorkerID]policyWorker
+
+	placements []SchedDiagPlacementInfo
+}
+
+type policyWorker struct {
+	host  string
+	paths map[stores.ID]struct{}
+}
+
+func parseTaskType(s string) (types.TaskType, error) {
+	for _, tt := range []types.TaskType{
+		types.TTAddPiece, types.TTPreCommit1, types.TTPreCommit2, types.TTCommit1, types.TTCommit2,
+		types.TTFinalize, types.TTFetch, types.TTUnseal, types.TTGenerateWindowPoSt, types.TTGenerateWinningPoSt,
+	} {
+		if string(tt) == s || strings.EqualFold(tt.Short(), s) {
+			return tt, nil
+		}
+	}
+	return "", xerrors.Errorf("unknown task type %s", s)
+}
+
+func defaultSchedPolicy() *schedPolicy {
+	return &schedPolicy{
+		hostGroup:  map[string]string{},
+		taskGroups: map[types.TaskType]string{},
+		lastHost:   map[abi.SectorID]string{},
+		pc1Host:    map[abi.SectorID]string{},
+		restored:   map[abi.SectorID]struct{}{},
+		workers:    map[WorkerID]policyWorker{},
+	}
+}
+
+// Validate checks the groups and task types of the policy
+func (cfg SchedulerPolicy) Validate() error {
+	_, err := newSchedPolicy(cfg)
+	return err
+}
+
+func newSchedPolicy(cfg SchedulerPolicy) (*schedPolicy, error) {
+	if cfg.DealShare < 0 || cfg.DealShare > 1 {
+		return nil, xerrors.Errorf("deal share %f out of range [0, 1]", cfg.DealShare)
+	}
+	if cfg.AffinityTimeout < 0 {
+		return nil, xerrors.Errorf("negative affinity timeout %s", cfg.AffinityTimeout)
+	}
+
+	p := defaultSchedPolicy()
+	p.cfg = cfg
+
+	for group, hosts := range cfg.Groups {
+		for _, host := range hosts {
+			if other, ok := p.hostGroup[host]; ok {
+				return nil, xerrors.Errorf("host %s is in groups %s and %s", host, other, group)
+			}
+			p.hostGroup[host] = group
+		}
+	}
+
+	for task, group := range cfg.TaskGroups {
+		tt, err := parseTaskType(task)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := cfg.Groups[group]; !ok {
+			return nil, xerrors.Errorf("task %s is pinned to unknown group %s", task, group)
+		}
+		p.taskGroups[tt] = group
+	}
+
+	return p, nil
+}
+
+// update replaces the config of the policy, the placement history is kept
+func (p *schedPolicy) update(cfg SchedulerPolicy) error {
+	np, err := newSchedPolicy(cfg)
+	if err != nil {
+		return err
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	p.cfg = np.cfg
+	p.hostGroup = np.hostGroup
+	p.taskGroups = np.taskGroups
+	return nil
+}
+
+// rules returns the rules applying to a task of sector which waited for waited, placed on host. false if the
+// policy rejects host
+func (p *schedPolicy) rules(sector abi.SectorID, task types.TaskType, host string, waited time.Duration) ([]string, bool) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	var rules []string
+
+	group, pinned := p.taskGroups[task]
+	if pinned {
+		if p.hostGroup[host] != group {
+			return nil, false
+		}
+		rules = append(rules, fmt.Sprintf("%s:%s", rulePin, group))
+	}
+
+	// the task isn't held back for hosts which are gone or too busy
+	keep := waited < p.affinityTimeout()
+
+	if p.cfg.KeepPC2WithPC1 && task == types.TTPreCommit2 && keep {
+		if pc1Host, ok := p.pc1Host[sector]; ok && p.connected(pc1Host) {
+			if pc1Host != host {
+				return nil, false
+			}
+			rules = append(rules, fmt.Sprintf("%s:%s", ruleSameHost, pc1Host))
+		}
+	}
+
+	if p.cfg.GroupAffinity && !pinned && keep {
+		if group, ok := p.hostGroup[p.lastHost[sector]]; ok && p.groupConnected(group) {
+			if p.hostGroup[host] != group {
+				return nil, false
+			}
+			rules = append(rules, fmt.Sprintf("%s:%s", ruleAffinity, group))
+		}
+	}
+
+	return rules, true
+}
+
+// affinityTimeout must be called with p.lk held
+func (p *schedPolicy) affinityTimeout() time.Duration {
+	if p.cfg.AffinityTimeout > 0 {
+		return p.cfg.AffinityTimeout
+	}
+	return DefaultAffinityTimeout
+}
+
+// connected must be called with p.lk held
+func (p *schedPolicy) connected(host string) bool {
+	for _, w := range p.workers {
+		if w.host == host {
+			return true
+		}
+	}
+	return false
+}
+
+// groupConnected must be called with p.lk held
+func (p *schedPolicy) groupConnected(group string) bool {
+	for _, w := range p.workers {
+		if p.hostGroup[w.host] == group {
+			return true
+		}
+	}
+	return false
+}
+
+// workerUp registers a connected worker with the storage paths it has
+func (p *schedPolicy) workerUp(wid WorkerID, host string, paths []stores.StoragePath) {
+	w := policyWorker{host: host, paths: make(map[stores.ID]struct{}, len(paths))}
+	for _, path := range paths {
+		w.paths[path.ID] = struct{}{}
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	p.workers[wid] = w
+}
+
+func (p *schedPolicy) workerDown(wid WorkerID) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	delete(p.workers, wid)
+}
+
+// forget drops the hosts of a sector which is finalized or removed
+func (p *schedPolicy) forget(sector abi.SectorID) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	delete(p.lastHost, sector)
+	delete(p.pc1Host, sector)
+	delete(p.restored, sector)
+}
+
+// restore looks up the hosts of a sector the policy doesn't know in the index, the sector may have been placed
+// before the sealer restarted. The host of the cache is taken as the host of PC1, files in storages shared by
+// several hosts are skipped
+func (p *schedPolicy) restore(ctx context.Context, sector abi.SectorID) {
+	p.lk.Lock()
+	_, known := p.lastHost[sector]
+	_, restored := p.restored[sector]
+	if known || restored || p.index == nil || !(p.cfg.GroupAffinity || p.cfg.KeepPC2WithPC1) {
+		p.lk.Unlock()
+		return
+	}
+	p.restored[sector] = struct{}{}
+	p.lk.Unlock()
+
+	hosts := map[storiface.SectorFileType]string{}
+	for _, ft := range []storiface.SectorFileType{storiface.FTCache, storiface.FTUnsealed, storiface.FTSealed} {
+		infos, err := p.index.StorageFindSector(ctx, sector, ft, 0, false)
+		if err != nil {
+			log.Warnw("finding sector files for the scheduler policy", "sector", sector, "type", ft, "error", err)
+			continue
+		}
+
+		p.lk.Lock()
+		for _, info := range infos {
+			if host, ok := p.storageHost(info.ID); ok {
+				hosts[ft] = host
+				break
+			}
+		}
+		p.lk.Unlock()
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	if _, ok := p.lastHost[sector]; ok {
+		return // placed in the meantime
+	}
+	if host, ok := hosts[storiface.FTCache]; ok {
+		p.pc1Host[sector] = host
+	}
+	for _, ft := range []storiface.SectorFileType{storiface.FTCache, storiface.FTUnsealed, storiface.FTSealed} {
+		if host, ok := hosts[ft]; ok {
+			p.lastHost[sector] = host
+			break
+		}
+	}
+}
+
+// storageHost returns the only host having the storage, must be called with p.lk held
+func (p *schedPolicy) storageHost(id stores.ID) (string, bool) {
+	var host string
+	for _, w := range p.workers {
+		if _, ok := w.paths[id]; !ok {
+			continue
+		}
+		if host != "" && host != w.host {
+			return "", false
+		}
+		host = w.host
+	}
+	return host, host != ""
+}
+
+// prefer reports whether the policy prefers host a over host b for a task of sector, and whether it has a preference
+func (p *schedPolicy) prefer(sector abi.SectorID, a, b string) (bool, bool) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	if !p.cfg.GroupAffinity {
+		return false, false
+	}
+	last, ok := p.lastHost[sector]
+	if !ok || (a == last) == (b == last) {
+		return false, false
+	}
+	return a == last, true
+}
+
+func (p *schedPolicy) isDeal(req *workerRequest) bool {
+	return req.priority >= types.DealSectorPriority
+}
+
+// order returns the indexes of the queue in the order tasks should be assigned,
+// deal and CC sector tasks are interleaved according to DealShare
+func (p *schedPolicy) order(q requestQueue) []int {
+	p.lk.Lock()
+	share := p.cfg.DealShare
+	p.lk.Unlock()
+
+	out := make([]int, 0, len(q))
+	if share == 0 {
+		for sqi := range q {
+			out = append(out, sqi)
+		}
+		return out
+	}
+
+	var deals, ccs []int
+	for sqi, req := range q {
+		// fetch and finalize are not shared, they always go first
+		if urgent, less := req.taskType.MuchLess(types.TTUnseal); urgent && less {
+			out = append(out, sqi)
+			continue
+		}
+		if p.isDeal(req) {
+			deals = append(deals, sqi)
+		} else {
+			ccs = append(ccs, sqi)
+		}
+	}
+
+	var dealTaken, ccTaken int
+	for len(deals) > 0 || len(ccs) > 0 {
+		takeDeal := len(ccs) == 0 || (len(deals) > 0 && float64(dealTaken) < share*float64(dealTaken+ccTaken+1))
+		if takeDeal {
+			out = append(out, deals[0])
+			deals = deals[1:]
+			dealTaken++
+		} else {
+			out = append(out, ccs[0])
+			ccs = ccs[1:]
+			ccTaken++
+		}
+	}
+	return out
+}
+
+// placed records the placement of a task on host
+func (p *schedPolicy) placed(req *workerRequest, host string) {
+	rules, _ := p.rules(req.sector.ID, req.taskType, host, time.Since(req.start))
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	if p.cfg.DealShare > 0 {
+		kind := "cc"
+		if p.isDeal(req) {
+			kind = "deal"
+		}
+		rules = append(rules, fmt.Sprintf("%s:%s", ruleFairShare, kind))
+	}
+	if len(rules) == 0 {
+		rules = []string{ruleDefault}
+	}
+
+	switch req.taskType {
+	case types.TTPreCommit1:
+		p.pc1Host[req.sector.ID] = host
+	case types.TTPreCommit2:
+		delete(p.pc1Host, req.sector.ID)
+	}
+	if req.taskType == types.TTFinalize {
+		delete(p.lastHost, req.sector.ID)
+		delete(p.pc1Host, req.sector.ID)
+		delete(p.restored, req.sector.ID)
+	} else if req.taskType != types.TTGenerateWindowPoSt && req.taskType != types.TTGenerateWinningPoSt {
+		p.lastHost[req.sector.ID] = host
+	}
+
+	p.placements = append(p.placements, SchedDiagPlacementInfo{
+		Sector:   req.sector.ID,
+		TaskType: req.taskType,
+		Worker:   host,
+		Rules:    rules,
+		At:       time.Now(),
+	})
+	if len(p.placements) > schedDiagPlacements {
+		p.placements = p.placements[len(p.placements)-schedDiagPlacements:]
+	}
+}
+
+func (p *schedPolicy) diag() []SchedDiagPlacementInfo {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	out := make([]SchedDiagPlacementInfo, len(p.placements))
+	copy(out, p.placements)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].At.After(out[j].At)
+	})
+	return out
+}
+
+// policySelector applies the scheduler policy on top of the selector of a task
+type policySelector struct {
+	policy *schedPolicy
+	sector abi.SectorID
+	inner  WorkerSelector
+	start  time.Time
+}
+
+func newPolicySelector(policy *schedPolicy, sector abi.SectorID, inner WorkerSelector) *policySelector {
+	return &policySelector{
+		policy: policy,
+		sector: sector,
+		inner:  inner,
+		start:  time.Now(),
+	}
+}
+
+func (s *policySelector) Ok(ctx context.Context, task types.TaskType, spt abi.RegisteredSealProof, whnd *workerHandle) (bool, error) {
+	s.policy.restore(ctx, s.sector)
+	if _, ok := s.policy.rules(s.sector, task, whnd.info.Hostname, time.Since(s.start)); !ok {
+		return false, nil
+	}
+	return s.inner.Ok(ctx, task, spt, whnd)
+}
+
+func (s *policySelector) Cmp(ctx context.Context, task types.TaskType, a, b *workerHandle) (bool, error) {
+	if r, ok := s.policy.prefer(s.sector, a.info.Hostname, b.info.Hostname); ok {
+		return r, nil
+	}
+	return s.inner.Cmp(ctx, task, a, b)
+}
+
+var _ WorkerSelector = &policySelector{}
@@ -0,0 +1,243 @@
+package sectorstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
+	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func TestSchedPolicyConfig(t *testing.T) {
+	_, err := newSchedPolicy(SchedulerPolicy{DealShare: 1.5})
+	require.Error(t, err)
+
+	_, err = newSchedPolicy(SchedulerPolicy{TaskGroups: map[string]string{"PC2": "gpu"}})
+	require.Error(t, err, "unknown group")
+
+	_, err = newSchedPolicy(SchedulerPolicy{
+		Groups:     map[string][]string{"gpu": {"a"}},
+		TaskGroups: map[string]string{"PC3": "gpu"},
+	})
+	require.Error(t, err, "unknown task type")
+
+	_, err = newSchedPolicy(SchedulerPolicy{Groups: map[string][]string{"gpu": {"a"}, "cpu": {"a"}}})
+	require.Error(t, err, "host in two groups")
+
+	p, err := newSchedPolicy(SchedulerPolicy{
+		Groups:     map[string][]string{"gpu": {"a"}},
+		TaskGroups: map[string]string{"PC2": "gpu", string(types.TTCommit2): "gpu"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[types.TaskType]string{types.TTPreCommit2: "gpu", types.TTCommit2: "gpu"}, p.taskGroups)
+}
+
+func TestSchedPolicyRules(t *testing.T) {
+	p, err := newSchedPolicy(SchedulerPolicy{
+		Groups: map[string][]string{
+			"gpu": {"g1"},
+			"a":   {"a1", "a2"},
+			"b":   {"b1"},
+		},
+		TaskGroups:     map[string]string{"C2": "gpu"},
+		GroupAffinity:  true,
+		KeepPC2WithPC1: true,
+	})
+	require.NoError(t, err)
+	for _, host := range []string{"g1", "a1", "a2", "b1"} {
+		p.workerUp(WorkerID(uuid.New()), host, nil)
+	}
+
+	sector := abi.SectorID{Miner: 1000, Number: 1}
+	req := func(task types.TaskType) *workerRequest {
+		return &workerRequest{sector: storage.SectorRef{ID: sector}, taskType: task, start: time.Now()}
+	}
+
+	// nothing placed yet, any host
+	rules, ok := p.rules(sector, types.TTAddPiece, "b1", 0)
+	require.True(t, ok)
+	require.Empty(t, rules)
+
+	p.placed(req(types.TTAddPiece), "a1")
+
+	// affinity to group a
+	_, ok = p.rules(sector, types.TTPreCommit1, "b1", 0)
+	require.False(t, ok)
+	rules, ok = p.rules(sector, types.TTPreCommit1, "a2", 0)
+	require.True(t, ok)
+	require.Equal(t, []string{"affinity:a"}, rules)
+
+	p.placed(req(types.TTPreCommit1), "a2")
+
+	// same host as PC1
+	_, ok = p.rules(sector, types.TTPreCommit2, "a1", 0)
+	require.False(t, ok)
+	rules, ok = p.rules(sector, types.TTPreCommit2, "a2", 0)
+	require.True(t, ok)
+	require.Equal(t, []string{"same-host:a2", "affinity:a"}, rules)
+
+	p.placed(req(types.TTPreCommit2), "a2")
+
+	// pinned task ignores affinity
+	_, ok = p.rules(sector, types.TTCommit2, "a2", 0)
+	require.False(t, ok)
+	rules, ok = p.rules(sector, types.TTCommit2, "g1", 0)
+	require.True(t, ok)
+	require.Equal(t, []string{"pin:gpu"}, rules)
+
+	p.placed(req(types.TTCommit2), "g1")
+	p.placed(req(types.TTFinalize), "g1")
+
+	placements := p.diag()
+	require.Len(t, placements, 5)
+	require.Equal(t, types.TTFinalize, placements[0].TaskType)
+	require.Equal(t, []string{"default"}, placements[len(placements)-1].Rules)
+
+	// finalize forgets the sector
+	rules, ok = p.rules(sector, types.TTAddPiece, "b1", 0)
+	require.True(t, ok)
+	require.Empty(t, rules)
+}
+
+func TestSchedPolicyOrder(t *testing.T) {
+	var q requestQueue
+	for i := 0; i < 4; i++ {
+		q.Push(&workerRequest{taskType: types.TTPreCommit1, priority: types.DealSectorPriority, sector: storage.SectorRef{ID: abi.SectorID{Number: abi.SectorNumber(i)}}})
+		q.Push(&workerRequest{taskType: types.TTPreCommit1, sector: storage.SectorRef{ID: abi.SectorID{Number: abi.SectorNumber(10 + i)}}})
+	}
+	q.Push(&workerRequest{taskType: types.TTFinalize, sector: storage.SectorRef{ID: abi.SectorID{Number: 20}}})
+
+	sectors := func(order []int) []abi.SectorNumber {
+		var out []abi.SectorNumber
+		for _, sqi := range order {
+			out = append(out, q[sqi].sector.ID.Number)
+		}
+		return out
+	}
+
+	p := defaultSchedPolicy()
+	require.Equal(t, []abi.SectorNumber{20, 0, 1, 2, 3, 10, 11, 12, 13}, sectors(p.order(q)))
+
+	p.cfg.DealShare = 0.5
+	require.Equal(t, []abi.SectorNumber{20, 0, 10, 1, 11, 2, 12, 3, 13}, sectors(p.order(q)))
+
+	p.cfg.DealShare = 0.75
+	require.Equal(t, []abi.SectorNumber{20, 0, 1, 2, 10, 3, 11, 12, 13}, sectors(p.order(q)))
+}
+
+func TestSchedPolicyUpdate(t *testing.T) {
+	p := defaultSchedPolicy()
+	sector := abi.SectorID{Miner: 1000, Number: 1}
+	p.workerUp(WorkerID(uuid.New()), "a1", nil)
+
+	p.placed(&workerRequest{sector: storage.SectorRef{ID: sector}, taskType: types.TTAddPiece}, "a1")
+
+	require.Error(t, p.update(SchedulerPolicy{DealShare: 2}))
+	require.NoError(t, p.update(SchedulerPolicy{
+		Groups:        map[string][]string{"a": {"a1"}, "b": {"b1"}},
+		GroupAffinity: true,
+	}))
+
+	// placements made before the update are used by the new rules
+	_, ok := p.rules(sector, types.TTPreCommit1, "b1", 0)
+	require.False(t, ok)
+	rules, ok := p.rules(sector, types.TTPreCommit1, "a1", 0)
+	require.True(t, ok)
+	require.Equal(t, []string{"affinity:a"}, rules)
+	require.Len(t, p.diag(), 1)
+}
+
+func TestSchedPolicyFallback(t *testing.T) {
+	p, err := newSchedPolicy(SchedulerPolicy{
+		Groups:          map[string][]string{"a": {"a1", "a2"}, "b": {"b1"}},
+		GroupAffinity:   true,
+		KeepPC2WithPC1:  true,
+		AffinityTimeout: time.Minute,
+	})
+	require.NoError(t, err)
+
+	a1, a2, b1 := WorkerID(uuid.New()), WorkerID(uuid.New()), WorkerID(uuid.New())
+	p.workerUp(a1, "a1", nil)
+	p.workerUp(a2, "a2", nil)
+	p.workerUp(b1, "b1", nil)
+
+	sector := abi.SectorID{Miner: 1000, Number: 1}
+	p.placed(&workerRequest{sector: storage.SectorRef{ID: sector}, taskType: types.TTPreCommit1, start: time.Now()}, "a1")
+
+	_, ok := p.rules(sector, types.TTPreCommit2, "a2", 0)
+	require.False(t, ok)
+
+	// waited too long for a1
+	rules, ok := p.rules(sector, types.TTPreCommit2, "b1", time.Minute)
+	require.True(t, ok)
+	require.Empty(t, rules)
+
+	// a1 is gone, the rest of group a is still up
+	p.workerDown(a1)
+	rules, ok = p.rules(sector, types.TTPreCommit2, "a2", 0)
+	require.True(t, ok)
+	require.Equal(t, []string{"affinity:a"}, rules)
+	_, ok = p.rules(sector, types.TTPreCommit2, "b1", 0)
+	require.False(t, ok)
+
+	// the whole group is gone
+	p.workerDown(a2)
+	_, ok = p.rules(sector, types.TTPreCommit2, "b1", 0)
+	require.True(t, ok)
+
+	// removed sectors are forgotten
+	p.workerUp(a1, "a1", nil)
+	p.forget(sector)
+	rules, ok = p.rules(sector, types.TTPreCommit2, "b1", 0)
+	require.True(t, ok)
+	require.Empty(t, rules)
+}
+
+func TestSchedPolicyRestore(t *testing.T) {
+	ctx := context.Background()
+
+	p, err := newSchedPolicy(SchedulerPolicy{
+		Groups:         map[string][]string{"a": {"a1"}, "b": {"b1"}},
+		GroupAffinity:  true,
+		KeepPC2WithPC1: true,
+	})
+	require.NoError(t, err)
+
+	index := stores.NewIndex()
+	p.index = index
+	stat := fsutil.FsStat{Capacity: 1 << 40, Available: 1 << 40}
+	for _, id := range []stores.ID{"a1-seal", "b1-seal", "shared"} {
+		require.NoError(t, index.StorageAttach(ctx, stores.StorageInfo{ID: id, URLs: []string{"http://" + string(id) + "/remote"}, Weight: 1, CanSeal: true}, stat))
+	}
+	p.workerUp(WorkerID(uuid.New()), "a1", []stores.StoragePath{{ID: "a1-seal"}, {ID: "shared"}})
+	p.workerUp(WorkerID(uuid.New()), "b1", []stores.StoragePath{{ID: "b1-seal"}, {ID: "shared"}})
+
+	// PC1 ran on a1 before the restart
+	sector := abi.SectorID{Miner: 1000, Number: 1}
+	require.NoError(t, index.StorageDeclareSector(ctx, "a1-seal", sector, storiface.FTCache, true))
+	require.NoError(t, index.StorageDeclareSector(ctx, "shared", sector, storiface.FTUnsealed, true))
+
+	p.restore(ctx, sector)
+	_, ok := p.rules(sector, types.TTPreCommit2, "b1", 0)
+	require.False(t, ok)
+	rules, ok := p.rules(sector, types.TTPreCommit2, "a1", 0)
+	require.True(t, ok)
+	require.Equal(t, []string{"same-host:a1", "affinity:a"}, rules)
+
+	// files only in shared storages don't tie the sector to a host
+	other := abi.SectorID{Miner: 1000, Number: 2}
+	require.NoError(t, index.StorageDeclareSector(ctx, "shared", other, storiface.FTUnsealed, true))
+	p.restore(ctx, other)
+	rules, ok = p.rules(other, types.TTPreCommit1, "b1", 0)
+	require.True(t, ok)
+	require.Empty(t, rules)
+}
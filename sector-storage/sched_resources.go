@@ -114,3 +114,26 @@ func (wh *workerHandle) utilization() float64 {
 
 	return u
 }
+
+// committed returns the resources of the running, preparing and assigned tasks of the worker
+func (wh *workerHandle) committed() *activeResources {
+	var out activeResources
+	sum := func(a *activeResources) {
+		out.memUsedMin += a.memUsedMin
+		out.memUsedMax += a.memUsedMax
+		out.cpuUse += a.cpuUse
+		out.gpuUsed = out.gpuUsed || a.gpuUsed
+	}
+
+	wh.lk.Lock()
+	sum(wh.active)
+	sum(wh.preparing)
+	wh.lk.Unlock()
+	wh.wndLk.Lock()
+	for _, window := range wh.activeWindows {
+		sum(&window.allocated)
+	}
+	wh.wndLk.Unlock()
+
+	return &out
+}
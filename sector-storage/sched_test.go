@@ -17,6 +17,7 @@ import (
 
 	"github.com/filecoin-project/go-state-types/abi"
 
+	"github.com/filecoin-project/specs-actors/actors/runtime/proof"
 	"github.com/filecoin-project/specs-storage/storage"
 	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
@@ -108,6 +109,18 @@ func (s *schedTestWorker) Fetch(ctx context.Context, id storage.SectorRef, ft st
 	panic("implement me")
 }
 
+func (s *schedTestWorker) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	panic("implement me")
+}
+
+func (s *schedTestWorker) GenerateWindowPoStVanilla(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	panic("implement me")
+}
+
+func (s *schedTestWorker) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	panic("implement me")
+}
+
 func (s *schedTestWorker) UnsealPiece(ctx context.Context, id storage.SectorRef, index storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize, randomness abi.SealRandomness, cid cid.Cid) (types.CallID, error) {
 	panic("implement me")
 }
@@ -124,6 +137,10 @@ func (s *schedTestWorker) Paths(ctx context.Context) ([]stores.StoragePath, erro
 	return s.paths, nil
 }
 
+func (s *schedTestWorker) Transfers(ctx context.Context) ([]storiface.TransferProgress, error) {
+	return nil, nil
+}
+
 func (s *schedTestWorker) Info(ctx context.Context) (storiface.WorkerInfo, error) {
 	return storiface.WorkerInfo{
 		Hostname:        s.name,
@@ -221,7 +238,7 @@ func TestSched(t *testing.T) {
 			done := make(chan struct{})
 			rm.done[taskName] = done
 
-			sel := newAllocSelector(index, storiface.FTCache, storiface.PathSealing)
+			sel := newAllocSelector(index, abi.SectorID{Miner: 8, Number: sid}, storiface.FTCache, storiface.PathSealing)
 
 			rm.wg.Add(1)
 			go func() {
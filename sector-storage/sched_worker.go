@@ -64,6 +64,12 @@ func (sh *scheduler) runWorker(ctx context.Context, w Worker) error {
 	sh.workers[wid] = worker
 	sh.workersLk.Unlock()
 
+	paths, err := w.Paths(ctx)
+	if err != nil {
+		log.Warnw("getting worker paths for the scheduler policy", "worker", wid, "error", err)
+	}
+	sh.policy.workerUp(wid, info.Hostname, paths)
+
 	sw := &schedWorker{
 		sched:  sh,
 		worker: worker,
@@ -100,6 +106,7 @@ func (sw *schedWorker) handleWorker() {
 		sched.workersLk.Lock()
 		delete(sched.workers, sw.wid)
 		sched.workersLk.Unlock()
+		sched.policy.workerDown(sw.wid)
 	}()
 
 	defer sw.heartbeatTimer.Stop()
@@ -13,16 +13,18 @@ import (
 )
 
 type allocSelector struct {
-	index stores.SectorIndex
-	alloc storiface.SectorFileType
-	ptype storiface.PathType
+	index  stores.SectorIndex
+	sector abi.SectorID
+	alloc  storiface.SectorFileType
+	ptype  storiface.PathType
 }
 
-func newAllocSelector(index stores.SectorIndex, alloc storiface.SectorFileType, ptype storiface.PathType) *allocSelector {
+func newAllocSelector(index stores.SectorIndex, sector abi.SectorID, alloc storiface.SectorFileType, ptype storiface.PathType) *allocSelector {
 	return &allocSelector{
-		index: index,
-		alloc: alloc,
-		ptype: ptype,
+		index:  index,
+		sector: sector,
+		alloc:  alloc,
+		ptype:  ptype,
 	}
 }
 
@@ -50,7 +52,7 @@ func (s *allocSelector) Ok(ctx context.Context, task types.TaskType, spt abi.Reg
 		return false, xerrors.Errorf("getting sector size: %w", err)
 	}
 
-	best, err := s.index.StorageBestAlloc(ctx, s.alloc, ssize, s.ptype)
+	best, err := s.index.StorageBestAlloc(ctx, s.alloc, ssize, s.ptype, s.sector)
 	if err != nil {
 		return false, xerrors.Errorf("finding best alloc storage: %w", err)
 	}
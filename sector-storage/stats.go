@@ -1,9 +1,14 @@
 package sectorstorage
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
 
 	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
 	"github.com/filecoin-project/venus-sealer/types"
@@ -39,6 +44,30 @@ func (m *Manager) WorkerJobs() map[uuid.UUID][]storiface.WorkerJob {
 		calls[t.job.ID] = struct{}{}
 	}
 
+	for wid, transfers := range m.workerTransfers() {
+		for i := range transfers {
+			transfer := transfers[i]
+			attached := false
+			for ji, job := range out[uuid.UUID(wid)] {
+				if job.Sector == transfer.Sector {
+					out[uuid.UUID(wid)][ji].Transfer = &transfer
+					attached = true
+					break
+				}
+			}
+			if !attached {
+				out[uuid.UUID(wid)] = append(out[uuid.UUID(wid)], storiface.WorkerJob{
+					ID:       types.UndefCall,
+					Sector:   transfer.Sector,
+					Task:     types.TTFetch,
+					RunWait:  0,
+					Start:    transfer.Start,
+					Transfer: &transfer,
+				})
+			}
+		}
+	}
+
 	m.sched.workersLk.RLock()
 
 	for id, handle := range m.sched.workers {
@@ -93,3 +122,93 @@ func (m *Manager) WorkerJobs() map[uuid.UUID][]storiface.WorkerJob {
 
 	return out
 }
+
+// workerTransfers collects the running transfers of all workers, workers which fail to answer are skipped
+func (m *Manager) workerTransfers() map[WorkerID][]storiface.TransferProgress {
+	m.sched.workersLk.RLock()
+	handles := make(map[WorkerID]*workerHandle, len(m.sched.workers))
+	for id, handle := range m.sched.workers {
+		handles[id] = handle
+	}
+	m.sched.workersLk.RUnlock()
+
+	var lk sync.Mutex
+	var wg sync.WaitGroup
+	out := map[WorkerID][]storiface.TransferProgress{}
+	for id, handle := range handles {
+		wg.Add(1)
+		go func(id WorkerID, handle *workerHandle) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.TODO(), SelectorTimeout)
+			defer cancel()
+
+			transfers, err := handle.workerRpc.Transfers(ctx)
+			if err != nil {
+				log.Debugf("getting transfers of worker %s: %+v", id, err)
+				return
+			}
+			if len(transfers) == 0 {
+				return
+			}
+
+			lk.Lock()
+			out[id] = transfers
+			lk.Unlock()
+		}(id, handle)
+	}
+	wg.Wait()
+
+	return out
+}
+
+// SectorWorker returns the hostname of the worker which got the latest sealing task of the sector,
+// empty if no task of the sector was scheduled since the manager started
+func (m *Manager) SectorWorker(sid abi.SectorID) string {
+	return m.sched.workTracker.lastWorker(sid)
+}
+
+// SealingIdle reports whether a new sector would start sealing right away: no AddPiece or PreCommit1 task
+// waits for a worker and an enabled worker has the resources to run a PreCommit1 of spt
+func (m *Manager) SealingIdle(ctx context.Context, spt abi.RegisteredSealProof) (bool, error) {
+	si, err := m.sched.Info(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, req := range si.(SchedDiagInfo).Requests {
+		if req.TaskType == types.TTAddPiece || req.TaskType == types.TTPreCommit1 {
+			return false, nil
+		}
+	}
+
+	needRes, ok := ResourceTable[types.TTPreCommit1][spt]
+	if !ok {
+		return false, xerrors.Errorf("no resources for seal proof %d", spt)
+	}
+
+	m.sched.workersLk.RLock()
+	handles := map[WorkerID]*workerHandle{}
+	for id, handle := range m.sched.workers {
+		if handle.enabled {
+			handles[id] = handle
+		}
+	}
+	m.sched.workersLk.RUnlock()
+
+	for id, handle := range handles {
+		tasks, err := handle.workerRpc.TaskTypes(ctx)
+		if err != nil {
+			log.Warnf("getting task types of worker %s: %+v", id, err)
+			continue
+		}
+		if _, supported := tasks[types.TTPreCommit1]; !supported {
+			continue
+		}
+
+		if handle.committed().canHandleRequest(needRes, id, "sealingIdle", handle.info) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,69 @@
+package stores
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/xerrors"
+)
+
+// EncodingZstd is the content encoding of zstd compressed transfers. Fetchers with compression enabled send it
+// in Accept-Encoding, FetchHandler compresses cache directories and their files only for fetchers asking for it.
+const EncodingZstd = "zstd"
+
+// cache files are large, favor speed over ratio
+const zstdLevel = zstd.SpeedFastest
+
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		if i := strings.Index(accepted, ";"); i >= 0 {
+			accepted = accepted[:i]
+		}
+		if strings.TrimSpace(accepted) == encoding {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeBody returns the decoded body of resp
+func decodeBody(resp *http.Response, body io.Reader) (io.ReadCloser, error) {
+	switch encoding := resp.Header.Get("Content-Encoding"); encoding {
+	case "", "identity":
+		return ioutil.NopCloser(body), nil
+	case EncodingZstd:
+		dec, err := zstd.NewReader(body)
+		if err != nil {
+			return nil, xerrors.Errorf("creating zstd decoder: %w", err)
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return nil, xerrors.Errorf("unknown content encoding: '%s'", encoding)
+	}
+}
+
+// encodeWriter returns a writer compressing to w with the zstd encoding
+func encodeWriter(w io.Writer) (io.WriteCloser, error) {
+	enc, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstdLevel))
+	if err != nil {
+		return nil, xerrors.Errorf("creating zstd encoder: %w", err)
+	}
+	return enc, nil
+}
+
+// parseRangeStart parses the open ended ranges sent by Remote, 'bytes=N-'. N may be the size of the file, Remote
+// asks for the checksum of a file it has completely that way
+func parseRangeStart(rng string, size int64) (int64, bool) {
+	if !strings.HasPrefix(rng, "bytes=") || !strings.HasSuffix(rng, "-") {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"), 10, 64)
+	if err != nil || start < 0 || start > size {
+		return 0, false
+	}
+	return start, true
+}
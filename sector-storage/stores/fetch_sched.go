@@ -0,0 +1,305 @@
+package stores
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// FetchPriorityHeader carries the priority of a fetch, the serving FetchHandler lets PoSt priority requests
+// through when it is saturated
+const FetchPriorityHeader = "X-Fetch-Priority"
+
+// PoStFetchPriority is the lowest priority of the reads done for proving, window post, winning post and the
+// provable checks read at or above it
+var PoStFetchPriority = types.WindowPoStPriority
+
+// FetchBusyRetry is how long a saturated FetchHandler asks fetchers to back off
+var FetchBusyRetry = 10 * time.Second
+
+// bandwidthBurst is how much unused bandwidth a limiter accumulates
+const bandwidthBurst = time.Second
+
+type fetchTaskKey struct{}
+
+// WithFetchTask tags the fetches done with ctx with the sealing task they are done for,
+// fetches of more urgent tasks go first
+func WithFetchTask(ctx context.Context, task types.TaskType) context.Context {
+	return context.WithValue(ctx, fetchTaskKey{}, task)
+}
+
+func fetchTask(ctx context.Context) types.TaskType {
+	task, _ := ctx.Value(fetchTaskKey{}).(types.TaskType)
+	return task
+}
+
+// errRemoteBusy is returned when the remote FetchHandler is saturated
+type errRemoteBusy struct {
+	retryAfter time.Duration
+}
+
+func (e *errRemoteBusy) Error() string {
+	return "remote is busy, retry after " + e.retryAfter.String()
+}
+
+func remoteBusy(retryAfter string) error {
+	d := FetchBusyRetry
+	if s, err := strconv.Atoi(retryAfter); err == nil && s > 0 {
+		d = time.Duration(s) * time.Second
+	}
+	return &errRemoteBusy{retryAfter: d}
+}
+
+func urlHost(u string) string {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	return pu.Host
+}
+
+type fetchWaiter struct {
+	priority int
+	task     types.TaskType
+	seq      uint64
+	host     string
+
+	ready chan struct{}
+}
+
+func (a *fetchWaiter) before(b *fetchWaiter) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	if a.task != b.task {
+		return a.task.Less(b.task)
+	}
+	return a.seq < b.seq
+}
+
+// fetchScheduler limits the number of running fetches, waiting fetches start by priority, then task type,
+// fetches from hosts which asked to back off wait until the backoff ends
+type fetchScheduler struct {
+	lk      sync.Mutex
+	limit   int
+	running int
+	seq     uint64
+	waiting []*fetchWaiter
+
+	busyUntil map[string]time.Time
+}
+
+func newFetchScheduler(limit int) *fetchScheduler {
+	return &fetchScheduler{
+		limit:     limit,
+		busyUntil: map[string]time.Time{},
+	}
+}
+
+func (fs *fetchScheduler) setLimit(limit int) {
+	fs.lk.Lock()
+	defer fs.lk.Unlock()
+
+	fs.limit = limit
+	fs.dispatch()
+}
+
+// acquire waits for a fetch slot, the returned func releases it
+func (fs *fetchScheduler) acquire(ctx context.Context, host string) (func(), error) {
+	fs.lk.Lock()
+	fs.seq++
+	w := &fetchWaiter{
+		priority: types.GetPriority(ctx),
+		task:     fetchTask(ctx),
+		seq:      fs.seq,
+		host:     host,
+		ready:    make(chan struct{}),
+	}
+	fs.waiting = append(fs.waiting, w)
+	fs.dispatch()
+
+	select {
+	case <-w.ready:
+	default:
+		log.Infof("Throttling fetch from %s, %d running, %d waiting", host, fs.running, len(fs.waiting))
+	}
+	fs.lk.Unlock()
+
+	select {
+	case <-w.ready:
+		return fs.release, nil
+	case <-ctx.Done():
+		fs.lk.Lock()
+		defer fs.lk.Unlock()
+
+		select {
+		case <-w.ready:
+			// got the slot while cancelling
+			fs.running--
+			fs.dispatch()
+		default:
+			for i, ww := range fs.waiting {
+				if ww == w {
+					fs.waiting = append(fs.waiting[:i], fs.waiting[i+1:]...)
+					break
+				}
+			}
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (fs *fetchScheduler) release() {
+	fs.lk.Lock()
+	defer fs.lk.Unlock()
+
+	fs.running--
+	fs.dispatch()
+}
+
+// backoff holds the fetches from host for d, PoSt priority fetches are not held
+func (fs *fetchScheduler) backoff(host string, d time.Duration) {
+	fs.lk.Lock()
+	defer fs.lk.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(fs.busyUntil[host]) {
+		fs.busyUntil[host] = until
+	}
+	time.AfterFunc(d, func() {
+		fs.lk.Lock()
+		defer fs.lk.Unlock()
+		fs.dispatch()
+	})
+}
+
+// dispatch starts waiting fetches while there are free slots, fs.lk must be held
+func (fs *fetchScheduler) dispatch() {
+	sort.SliceStable(fs.waiting, func(i, j int) bool {
+		return fs.waiting[i].before(fs.waiting[j])
+	})
+
+	now := time.Now()
+	for host, until := range fs.busyUntil {
+		if !until.After(now) {
+			delete(fs.busyUntil, host)
+		}
+	}
+
+	rest := fs.waiting[:0]
+	for _, w := range fs.waiting {
+		_, busy := fs.busyUntil[w.host]
+		if fs.running >= fs.limit || (busy && w.priority < PoStFetchPriority) {
+			rest = append(rest, w)
+			continue
+		}
+		fs.running++
+		close(w.ready)
+	}
+	fs.waiting = rest
+}
+
+// bandwidth limits the rate of the data going through it
+type bandwidth struct {
+	rate int64 // bytes per second
+
+	lk   sync.Mutex
+	next time.Time
+}
+
+// wait blocks until n more bytes fit in the rate
+func (b *bandwidth) wait(ctx context.Context, n int) error {
+	b.lk.Lock()
+	now := time.Now()
+	if b.next.Before(now.Add(-bandwidthBurst)) {
+		b.next = now.Add(-bandwidthBurst)
+	}
+	b.next = b.next.Add(time.Duration(int64(n) * int64(time.Second) / b.rate))
+	d := b.next.Sub(now)
+	b.lk.Unlock()
+
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// bandwidthLimits holds the bandwidth limiters of remote hosts and local paths
+type bandwidthLimits struct {
+	lk       sync.Mutex
+	hostRate int64
+	pathRate int64
+	hosts    map[string]*bandwidth
+	paths    map[ID]*bandwidth
+}
+
+func (bl *bandwidthLimits) set(hostRate, pathRate int64) {
+	bl.lk.Lock()
+	defer bl.lk.Unlock()
+
+	bl.hostRate, bl.pathRate = hostRate, pathRate
+	bl.hosts = map[string]*bandwidth{}
+	bl.paths = map[ID]*bandwidth{}
+}
+
+// limiters returns the limiters of a fetch from host into the local path, path may be empty
+func (bl *bandwidthLimits) limiters(host string, path ID) []*bandwidth {
+	bl.lk.Lock()
+	defer bl.lk.Unlock()
+
+	var out []*bandwidth
+	if bl.hostRate > 0 {
+		b, ok := bl.hosts[host]
+		if !ok {
+			b = &bandwidth{rate: bl.hostRate}
+			bl.hosts[host] = b
+		}
+		out = append(out, b)
+	}
+	if bl.pathRate > 0 && path != "" {
+		b, ok := bl.paths[path]
+		if !ok {
+			b = &bandwidth{rate: bl.pathRate}
+			bl.paths[path] = b
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+type throttledReader struct {
+	ctx      context.Context
+	r        io.Reader
+	limiters []*bandwidth
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	for _, l := range t.limiters {
+		if werr := l.wait(t.ctx, n); werr != nil {
+			return n, xerrors.Errorf("waiting for bandwidth: %w", werr)
+		}
+	}
+	return n, err
+}
+
+// throttle limits reads from r to the rate of limiters
+func throttle(ctx context.Context, r io.Reader, limiters []*bandwidth) io.Reader {
+	if len(limiters) == 0 {
+		return r
+	}
+	return &throttledReader{ctx: ctx, r: r, limiters: limiters}
+}
@@ -0,0 +1,128 @@
+package stores
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func TestFetchSchedulerOrder(t *testing.T) {
+	fs := newFetchScheduler(1)
+	ctx := context.Background()
+
+	release, err := fs.acquire(ctx, "a")
+	require.NoError(t, err)
+
+	started := make(chan string, 3)
+	wait := func(ctx context.Context, name string) {
+		go func() {
+			release, err := fs.acquire(ctx, "a")
+			if err != nil {
+				return
+			}
+			started <- name
+			release()
+		}()
+	}
+
+	wait(WithFetchTask(ctx, types.TTPreCommit1), "pc1")
+	wait(WithFetchTask(ctx, types.TTFinalize), "finalize")
+	wait(types.WithPriority(WithFetchTask(ctx, types.TTPreCommit1), types.WindowPoStPriority), "post")
+
+	require.Eventually(t, func() bool {
+		fs.lk.Lock()
+		defer fs.lk.Unlock()
+		return len(fs.waiting) == 3
+	}, time.Second, time.Millisecond)
+
+	release()
+	require.Equal(t, "post", <-started)
+	require.Equal(t, "finalize", <-started)
+	require.Equal(t, "pc1", <-started)
+}
+
+func TestFetchSchedulerBackoff(t *testing.T) {
+	fs := newFetchScheduler(2)
+	ctx := context.Background()
+
+	fs.backoff("a", 100*time.Millisecond)
+
+	// other hosts are not held
+	release, err := fs.acquire(ctx, "b")
+	require.NoError(t, err)
+	release()
+
+	start := time.Now()
+	release, err = fs.acquire(ctx, "a")
+	require.NoError(t, err)
+	release()
+	require.True(t, time.Since(start) >= 90*time.Millisecond)
+
+	// cancelled waiters leave the queue
+	fs.backoff("a", time.Minute)
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = fs.acquire(cctx, "a")
+	require.Error(t, err)
+	require.Empty(t, fs.waiting)
+	require.Equal(t, 0, fs.running)
+}
+
+func TestFetchBusyRemote(t *testing.T) {
+	defer func(d time.Duration) { FetchBusyRetry = d }(FetchBusyRetry)
+	FetchBusyRetry = 50 * time.Millisecond
+
+	src, err := ioutil.TempDir("", "TestFetchBusyRemote-src")
+	require.NoError(t, err)
+	defer os.RemoveAll(src) // nolint
+
+	data := bytes.Repeat([]byte("a"), 1000)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src, "p_aux"), data, 0644))
+
+	handler := &FetchHandler{Local: &dirStore{dir: src}, MaxServing: 1}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	sid := abi.SectorID{Miner: 1000, Number: 1}
+	url := fmt.Sprintf("%s/remote/%s/%s", ts.URL, storiface.FTCache.String(), storiface.SectorName(sid))
+
+	dest, err := ioutil.TempDir("", "TestFetchBusyRemote-dest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest) // nolint
+
+	// the handler is saturated until the busy transfer finishes
+	atomic.StoreInt64(&handler.serving, 1)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		atomic.StoreInt64(&handler.serving, 0)
+	}()
+
+	r := NewRemote(nil, nil, nil, 1, nil)
+	start := time.Now()
+	require.NoError(t, r.fetch(context.TODO(), sid, storiface.FTCache, url, filepath.Join(dest, "cache"), ""))
+	require.True(t, time.Since(start) >= 200*time.Millisecond)
+
+	b, err := ioutil.ReadFile(filepath.Join(dest, "cache", "p_aux"))
+	require.NoError(t, err)
+	require.Equal(t, data, b)
+
+	// PoSt reads go through a saturated handler
+	atomic.StoreInt64(&handler.serving, 1)
+	require.NoError(t, os.RemoveAll(dest))
+	ctx := types.WithPriority(context.TODO(), types.WinningPoStPriority)
+	require.NoError(t, r.fetch(ctx, sid, storiface.FTCache, url, filepath.Join(dest, "cache"), ""))
+}
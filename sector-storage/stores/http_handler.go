@@ -1,11 +1,18 @@
 package stores
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/mux"
 	logging "github.com/ipfs/go-log/v2"
@@ -45,15 +52,47 @@ func (d *DefaultPartialFileHandler) Close(pf *partialfile.PartialFile) error {
 	return pf.Close()
 }
 
+// CheckFunc checks the sealed and cache files of a sector in local paths
+type CheckFunc func(ctx context.Context, req storiface.CheckRequest, paths storiface.SectorPaths) storiface.SectorCheck
+
 type FetchHandler struct {
 	Local     Store
 	PfHandler partialFileHandler
+
+	// Check serves the provable checks of the sealer, checks aren't served when nil
+	Check CheckFunc
+
+	// MaxServing is the number of sector transfers served at once, further requests are asked to back off
+	// unless they have PoSt priority, 0 for no limit
+	MaxServing int
+
+	serving int64
+}
+
+// acquireServing takes a serving slot, it writes the busy response when the handler is saturated
+func (handler *FetchHandler) acquireServing(w http.ResponseWriter, r *http.Request) (func(), bool) {
+	n := atomic.AddInt64(&handler.serving, 1)
+	release := func() { atomic.AddInt64(&handler.serving, -1) }
+
+	if handler.MaxServing > 0 && n > int64(handler.MaxServing) {
+		if prio, err := strconv.Atoi(r.Header.Get(FetchPriorityHeader)); err != nil || prio < PoStFetchPriority {
+			release()
+			log.Infof("busy serving %d transfers, asking %s to back off", n-1, r.RemoteAddr)
+			w.Header().Set("Retry-After", strconv.Itoa(int(FetchBusyRetry/time.Second)))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return nil, false
+		}
+	}
+
+	return release, true
 }
 
 func (handler *FetchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) { // /remote/
 	mux := mux.NewRouter()
 
 	mux.HandleFunc("/remote/stat/{id}", handler.remoteStatFs).Methods("GET")
+	mux.HandleFunc("/remote/check/{id}", handler.remoteCheckSector).Methods("POST")
+	mux.HandleFunc("/remote/{type}/{id}/manifest", handler.remoteGetManifest).Methods("GET")
 	mux.HandleFunc("/remote/{type}/{id}", handler.remoteGetSector).Methods("GET")
 	mux.HandleFunc("/remote/{type}/{id}", handler.remoteDeleteSector).Methods("DELETE")
 
@@ -84,24 +123,63 @@ func (handler *FetchHandler) remoteStatFs(w http.ResponseWriter, r *http.Request
 	}
 }
 
-// remoteGetSector returns the sector file/tared directory byte stream for the sectorID and sector file type sent in the request.
-// returns an error if it does NOT have the required sector file/dir.
-func (handler *FetchHandler) remoteGetSector(w http.ResponseWriter, r *http.Request) {
-	log.Infof("SERVE GET %s", r.URL)
+// remoteCheckSector checks the files of a sector in the local storages, the sealer delegates the provable checks of
+// the sectors it can't read to the holder of the files
+func (handler *FetchHandler) remoteCheckSector(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("SERVE check %s", r.URL)
+
+	if handler.Check == nil {
+		w.WriteHeader(404)
+		return
+	}
+
+	var req storiface.CheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Errorf("decoding check request: %+v", err)
+		w.WriteHeader(400)
+		return
+	}
+
+	id, err := storiface.ParseSectorID(mux.Vars(r)["id"])
+	if err != nil || id != req.Sector.ID {
+		log.Errorf("check request of %s doesn't match %s: %+v", req.Sector.ID, mux.Vars(r)["id"], err)
+		w.WriteHeader(400)
+		return
+	}
+
+	res := storiface.SectorCheck{Sector: req.Sector.ID, Code: storiface.CheckNotFound}
+	paths, _, err := handler.Local.AcquireSector(r.Context(), req.Sector, storiface.FTSealed|storiface.FTCache, storiface.FTNone, storiface.PathStorage, storiface.AcquireMove)
+	switch {
+	case err != nil:
+		res.Error = fmt.Sprintf("acquire sector failed: %s", err)
+	case paths.Sealed == "" || paths.Cache == "":
+		res.Error = fmt.Sprintf("cache and/or sealed paths not found, cache %q, sealed %q", paths.Cache, paths.Sealed)
+	default:
+		res = handler.Check(r.Context(), req, paths)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&res); err != nil {
+		log.Warnf("error writing check response: %+v", err)
+	}
+}
+
+// sectorPath returns the local path of the sector file/dir requested by r, it writes the error response on failure
+func (handler *FetchHandler) sectorPath(w http.ResponseWriter, r *http.Request) (string, bool) {
 	vars := mux.Vars(r)
 
 	id, err := storiface.ParseSectorID(vars["id"])
 	if err != nil {
 		log.Errorf("%+v", err)
 		w.WriteHeader(500)
-		return
+		return "", false
 	}
 
 	ft, err := ftFromString(vars["type"])
 	if err != nil {
 		log.Errorf("%+v", err)
 		w.WriteHeader(500)
-		return
+		return "", false
 	}
 
 	// The caller has a lock on this sector already, no need to get one here
@@ -116,7 +194,7 @@ func (handler *FetchHandler) remoteGetSector(w http.ResponseWriter, r *http.Requ
 	if err != nil {
 		log.Errorf("AcquireSector: %+v", err)
 		w.WriteHeader(500)
-		return
+		return "", false
 	}
 
 	// TODO: reserve local storage here
@@ -125,9 +203,62 @@ func (handler *FetchHandler) remoteGetSector(w http.ResponseWriter, r *http.Requ
 	if path == "" {
 		log.Error("acquired path was empty")
 		w.WriteHeader(500)
+		return "", false
+	}
+
+	return path, true
+}
+
+// remoteGetManifest returns the files of the sector file/dir with their sizes and checksums,
+// fetchers use it to resume interrupted transfers and verify the data
+func (handler *FetchHandler) remoteGetManifest(w http.ResponseWriter, r *http.Request) {
+	log.Infof("SERVE GET manifest %s", r.URL)
+
+	path, ok := handler.sectorPath(w, r)
+	if !ok {
 		return
 	}
 
+	manifest, err := buildManifest(path)
+	if err != nil {
+		log.Errorf("building manifest of %s: %+v", path, err)
+		w.WriteHeader(500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(manifest); err != nil {
+		log.Warnf("error writing manifest response: %+v", err)
+	}
+}
+
+// remoteGetSector returns the sector file/tared directory byte stream for the sectorID and sector file type sent in the request.
+// the `file` query parameter selects one file of a directory, files are served with range support.
+// returns an error if it does NOT have the required sector file/dir.
+func (handler *FetchHandler) remoteGetSector(w http.ResponseWriter, r *http.Request) {
+	log.Infof("SERVE GET %s", r.URL)
+
+	release, ok := handler.acquireServing(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	path, ok := handler.sectorPath(w, r)
+	if !ok {
+		return
+	}
+
+	name := r.URL.Query().Get("file")
+	if name != "" {
+		if filepath.Base(name) != name || name == ".." || name == "." {
+			log.Errorf("invalid file name %s", name)
+			w.WriteHeader(400)
+			return
+		}
+		path = filepath.Join(path, name)
+	}
+
 	stat, err := os.Stat(path)
 	if err != nil {
 		log.Errorf("os.Stat: %+v", err)
@@ -149,19 +280,120 @@ func (handler *FetchHandler) remoteGetSector(w http.ResponseWriter, r *http.Requ
 			return
 		}
 
+		compress := acceptsEncoding(r, EncodingZstd)
+
 		w.Header().Set("Content-Type", "application/x-tar")
+		w.Header().Set("Trailer", ChecksumHeader)
+		if compress {
+			w.Header().Set("Content-Encoding", EncodingZstd)
+		}
 		w.WriteHeader(200)
-		if _, err := io.CopyBuffer(w, rd, make([]byte, CopyBuf)); err != nil {
+
+		var out io.WriteCloser = nopWriteCloser{w}
+		if compress {
+			if out, err = encodeWriter(w); err != nil {
+				log.Errorf("%+v", err)
+				return
+			}
+		}
+
+		// the checksum is of the tar stream before compression
+		h := sha256.New()
+		if _, err := io.CopyBuffer(io.MultiWriter(out, h), rd, make([]byte, CopyBuf)); err != nil {
 			log.Errorf("%+v", err)
 			return
 		}
+		if err := out.Close(); err != nil {
+			log.Errorf("closing compressed stream: %+v", err)
+			return
+		}
+		w.Header().Set(ChecksumHeader, hex.EncodeToString(h.Sum(nil)))
+	} else {
+		// files of cache directories are compressed too
+		serveFile(w, r, path, stat, name != "" && acceptsEncoding(r, EncodingZstd))
+	}
+
+	log.Debugf("served sector file/dir, url=%s, path=%s", r.URL, path)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// serveFile serves the file at path with the sha256 of the whole file in the checksum trailer, the part of the
+// file before the start of a 'bytes=N-' range is hashed without being sent. Other ranges are served without a
+// checksum, compressed files only support 'bytes=N-' ranges
+func serveFile(w http.ResponseWriter, r *http.Request, path string, stat os.FileInfo, compress bool) {
+	var start int64
+	if rng := r.Header.Get("Range"); rng != "" {
+		var ok bool
+		if start, ok = parseRangeStart(rng, stat.Size()); !ok {
+			if !compress {
+				w.Header().Set("Content-Type", "application/octet-stream")
+				http.ServeFile(w, r, path)
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", stat.Size()))
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Errorf("opening %s: %+v", path, err)
+		w.WriteHeader(500)
+		return
+	}
+	defer f.Close() // nolint
+
+	h := sha256.New()
+	if _, err := io.CopyBuffer(h, io.LimitReader(f, start), make([]byte, CopyBuf)); err != nil {
+		log.Errorf("hashing %s: %+v", path, err)
+		w.WriteHeader(500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Trailer", ChecksumHeader)
+	if compress {
+		w.Header().Set("Content-Encoding", EncodingZstd)
+	}
+	if start > 0 {
+		// a fetcher holding the whole file only asks for the checksum
+		if start < stat.Size() {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, stat.Size()-1, stat.Size()))
+		} else {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", stat.Size()))
+		}
+		w.WriteHeader(http.StatusPartialContent)
 	} else {
-		w.Header().Set("Content-Type", "application/octet-stream")
-		// will do a ranged read over the file at the given path if the caller has asked for a ranged read in the request headers.
-		http.ServeFile(w, r, path)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	var out io.WriteCloser = nopWriteCloser{w}
+	if compress {
+		if out, err = encodeWriter(w); err != nil {
+			log.Errorf("serving %s: %+v", path, err)
+			return
+		}
+	}
+	if _, err := io.CopyBuffer(io.MultiWriter(out, h), f, make([]byte, CopyBuf)); err != nil {
+		log.Errorf("serving %s: %+v", path, err)
+		return
+	}
+	if err := out.Close(); err != nil {
+		log.Errorf("closing compressed stream: %+v", err)
+		return
 	}
 
-	log.Debugf("served sector file/dir, sectorID=%+v, fileType=%s, path=%s", id, ft, path)
+	sum := hex.EncodeToString(h.Sum(nil))
+	storeChecksum(path, stat, sum)
+	w.Header().Set(ChecksumHeader, sum)
 }
 
 func (handler *FetchHandler) remoteDeleteSector(w http.ResponseWriter, r *http.Request) {
@@ -207,8 +207,8 @@ func TestRemoteGetAllocated(t *testing.T) {
 			pfhandler := mocks.NewMockpartialFileHandler(mockCtrl)
 
 			handler := &stores.FetchHandler{
-				lstore,
-				pfhandler,
+				Local:     lstore,
+				PfHandler: pfhandler,
 			}
 
 			// run http server
@@ -411,8 +411,8 @@ func TestRemoteGetSector(t *testing.T) {
 			}
 
 			handler := &stores.FetchHandler{
-				lstore,
-				pfhandler,
+				Local:     lstore,
+				PfHandler: pfhandler,
 			}
 
 			// run http server
@@ -34,6 +34,8 @@ type StorageInfo struct {
 
 	CanSeal  bool
 	CanStore bool
+
+	PlacementRules
 }
 
 type HealthReport struct {
@@ -61,7 +63,11 @@ type SectorIndex interface { // part of storage-miner api
 	StorageDropSector(ctx context.Context, storageID ID, s abi.SectorID, ft storiface.SectorFileType) error
 	StorageFindSector(ctx context.Context, sector abi.SectorID, ft storiface.SectorFileType, ssize abi.SectorSize, allowFetch bool) ([]SectorStorageInfo, error)
 
-	StorageBestAlloc(ctx context.Context, allocate storiface.SectorFileType, ssize abi.SectorSize, pathType storiface.PathType) ([]StorageInfo, error)
+	// StorageBestAlloc returns the storages the files of the sector can be allocated in, best first, following
+	// their placement rules and the AllowTo rules of the storages already holding the sector
+	StorageBestAlloc(ctx context.Context, allocate storiface.SectorFileType, ssize abi.SectorSize, pathType storiface.PathType, sector abi.SectorID) ([]StorageInfo, error)
+	// StorageSetSectorKind sets the kind placement rules apply to for the sector, sectors are cc by default
+	StorageSetSectorKind(ctx context.Context, sector abi.SectorID, kind storiface.SectorKind) error
 
 	// atomically acquire locks on all sector file types. close ctx to unlock
 	StorageLock(ctx context.Context, sector abi.SectorID, read storiface.SectorFileType, write storiface.SectorFileType) error
@@ -76,6 +82,9 @@ type Decl struct {
 type declMeta struct {
 	storage ID
 	primary bool
+
+	// restored is set for declarations loaded from the IndexStore until the storage declares them again
+	restored bool
 }
 
 type storageEntry struct {
@@ -84,6 +93,16 @@ type storageEntry struct {
 
 	lastHeartbeat time.Time
 	heartbeatErr  error
+
+	// restored is set for storages loaded from the IndexStore until they attach or report health
+	restored bool
+	// restoredDecls is set while the storage has restored declarations, sweepDecls once it attached again,
+	// the restored declarations it didn't declare again are dropped on its next health report
+	restoredDecls bool
+	sweepDecls    bool
+
+	persistedHeartbeat time.Time
+	persistedErr       string
 }
 
 type Index struct {
@@ -92,6 +111,14 @@ type Index struct {
 
 	sectors map[Decl][]*declMeta
 	stores  map[ID]*storageEntry
+	kinds   map[abi.SectorID]storiface.SectorKind
+
+	// store is nil for an in-memory index
+	store IndexStore
+	// writes to store are queued with lk held and written in order by flushStore once lk is released
+	storeLk   sync.Mutex
+	pendingLk sync.Mutex
+	pending   []func()
 }
 
 func NewIndex() *Index {
@@ -101,6 +128,7 @@ func NewIndex() *Index {
 		},
 		sectors: map[Decl][]*declMeta{},
 		stores:  map[ID]*storageEntry{},
+		kinds:   map[abi.SectorID]storiface.SectorKind{},
 	}
 }
 
@@ -134,6 +162,7 @@ func (i *Index) StorageList(ctx context.Context) (map[ID][]Decl, error) {
 }
 
 func (i *Index) StorageAttach(ctx context.Context, si StorageInfo, st fsutil.FsStat) error {
+	defer i.flushStore()
 	i.lk.Lock()
 	defer i.lk.Unlock()
 
@@ -161,7 +190,20 @@ func (i *Index) StorageAttach(ctx context.Context, si StorageInfo, st fsutil.FsS
 		i.stores[si.ID].info.MaxStorage = si.MaxStorage
 		i.stores[si.ID].info.CanSeal = si.CanSeal
 		i.stores[si.ID].info.CanStore = si.CanStore
+		i.stores[si.ID].info.PlacementRules = si.PlacementRules
+
+		if ent := i.stores[si.ID]; ent.restored {
+			log.Infof("Restored sector storage %s is back", si.ID)
+			ent.fsi = st
+			ent.lastHeartbeat = time.Now()
+			ent.heartbeatErr = nil
+			ent.restored = false
+		}
+		if ent := i.stores[si.ID]; ent.restoredDecls {
+			ent.sweepDecls = true
+		}
 
+		i.persistStorage(i.stores[si.ID])
 		return nil
 	}
 	i.stores[si.ID] = &storageEntry{
@@ -170,10 +212,12 @@ func (i *Index) StorageAttach(ctx context.Context, si StorageInfo, st fsutil.FsS
 
 		lastHeartbeat: time.Now(),
 	}
+	i.persistStorage(i.stores[si.ID])
 	return nil
 }
 
 func (i *Index) StorageReportHealth(ctx context.Context, id ID, report HealthReport) error {
+	defer i.flushStore()
 	i.lk.Lock()
 	defer i.lk.Unlock()
 
@@ -189,11 +233,41 @@ func (i *Index) StorageReportHealth(ctx context.Context, id ID, report HealthRep
 		ent.heartbeatErr = nil
 	}
 	ent.lastHeartbeat = time.Now()
+	ent.restored = false
+
+	if ent.sweepDecls {
+		// the storage declared its sectors since it attached again
+		i.dropRestoredDecls(id)
+		ent.restoredDecls, ent.sweepDecls = false, false
+	}
 
+	i.persistHeartbeat(ent)
 	return nil
 }
 
+// dropRestoredDecls drops the restored declarations of the storage which it didn't declare again, must be
+// called with i.lk held
+func (i *Index) dropRestoredDecls(storageID ID) {
+	for d, metas := range i.sectors {
+		rewritten := make([]*declMeta, 0, len(metas))
+		for _, meta := range metas {
+			if meta.storage == storageID && meta.restored {
+				log.Infof("dropping sector %v (%s) from storage %s, not declared since it was restored", d.SectorID, d.SectorFileType, storageID)
+				i.persistDrop(storageID, d)
+				continue
+			}
+			rewritten = append(rewritten, meta)
+		}
+		if len(rewritten) == 0 {
+			delete(i.sectors, d)
+			continue
+		}
+		i.sectors[d] = rewritten
+	}
+}
+
 func (i *Index) StorageDeclareSector(ctx context.Context, storageID ID, s abi.SectorID, ft storiface.SectorFileType, primary bool) error {
+	defer i.flushStore()
 	i.lk.Lock()
 	defer i.lk.Unlock()
 
@@ -209,9 +283,11 @@ loop:
 			if sid.storage == storageID {
 				if !sid.primary && primary {
 					sid.primary = true
-				} else {
+					i.persistDecl(storageID, d, true)
+				} else if !sid.restored {
 					log.Warnf("sector %v redeclared in %s", s, storageID)
 				}
+				sid.restored = false
 				continue loop
 			}
 		}
@@ -220,12 +296,14 @@ loop:
 			storage: storageID,
 			primary: primary,
 		})
+		i.persistDecl(storageID, d, primary)
 	}
 
 	return nil
 }
 
 func (i *Index) StorageDropSector(ctx context.Context, storageID ID, s abi.SectorID, ft storiface.SectorFileType) error {
+	defer i.flushStore()
 	i.lk.Lock()
 	defer i.lk.Unlock()
 
@@ -243,6 +321,7 @@ func (i *Index) StorageDropSector(ctx context.Context, storageID ID, s abi.Secto
 		rewritten := make([]*declMeta, 0, len(i.sectors[d])-1)
 		for _, sid := range i.sectors[d] {
 			if sid.storage == storageID {
+				i.persistDrop(storageID, d)
 				continue
 			}
 
@@ -272,7 +351,13 @@ func (i *Index) StorageFindSector(ctx context.Context, s abi.SectorID, ft storif
 		}
 
 		for _, id := range i.sectors[Decl{s, pathType}] {
-			storageIDs[id.storage]++
+			if _, ok := storageIDs[id.storage]; !ok {
+				storageIDs[id.storage] = 0
+			}
+			if !id.restored {
+				// restored declarations not confirmed by the storage yet don't add weight
+				storageIDs[id.storage]++
+			}
 			isprimary[id.storage] = isprimary[id.storage] || id.primary
 		}
 	}
@@ -297,10 +382,16 @@ func (i *Index) StorageFindSector(ctx context.Context, s abi.SectorID, ft storif
 			urls[k] = rl.String()
 		}
 
+		weight := st.info.Weight * n // storage with more sector types is better
+		if !st.healthy() {
+			// still listed, the sector may be there, but the storages known to be up are tried first
+			weight = 0
+		}
+
 		out = append(out, SectorStorageInfo{
 			ID:     id,
 			URLs:   urls,
-			Weight: st.info.Weight * n, // storage with more sector types is better
+			Weight: weight,
 
 			CanSeal:  st.info.CanSeal,
 			CanStore: st.info.CanStore,
@@ -308,6 +399,9 @@ func (i *Index) StorageFindSector(ctx context.Context, s abi.SectorID, ft storif
 			Primary: isprimary[id],
 		})
 	}
+	sort.SliceStable(out, func(a, b int) bool {
+		return out[a].Weight > out[b].Weight
+	})
 
 	if allowFetch {
 		spaceReq, err := ft.SealSpaceUse(ssize)
@@ -315,11 +409,18 @@ func (i *Index) StorageFindSector(ctx context.Context, s abi.SectorID, ft storif
 			return nil, xerrors.Errorf("estimating required space: %w", err)
 		}
 
+		kind, holders := i.sectorPlacement(s)
+
 		for id, st := range i.stores {
 			if !st.info.CanSeal {
 				continue
 			}
 
+			if !i.placementOk(st, ft, kind, holders) {
+				log.Debugf("not selecting on %s, denied by placement rules", st.info.ID)
+				continue
+			}
+
 			if spaceReq > uint64(st.fsi.Available) {
 				log.Debugf("not selecting on %s, out of space (available: %d, need: %d)", st.info.ID, st.fsi.Available, spaceReq)
 				continue
@@ -366,6 +467,11 @@ func (i *Index) StorageFindSector(ctx context.Context, s abi.SectorID, ft storif
 	return out, nil
 }
 
+// healthy returns true if the storage is back since the restart and sends heartbeats without errors
+func (st *storageEntry) healthy() bool {
+	return !st.restored && st.heartbeatErr == nil && time.Since(st.lastHeartbeat) <= SkippedHeartbeatThresh
+}
+
 func (i *Index) StorageInfo(ctx context.Context, id ID) (StorageInfo, error) {
 	i.lk.RLock()
 	defer i.lk.RUnlock()
@@ -378,7 +484,7 @@ func (i *Index) StorageInfo(ctx context.Context, id ID) (StorageInfo, error) {
 	return *si.info, nil
 }
 
-func (i *Index) StorageBestAlloc(ctx context.Context, allocate storiface.SectorFileType, ssize abi.SectorSize, pathType storiface.PathType) ([]StorageInfo, error) {
+func (i *Index) StorageBestAlloc(ctx context.Context, allocate storiface.SectorFileType, ssize abi.SectorSize, pathType storiface.PathType, sector abi.SectorID) ([]StorageInfo, error) {
 	i.lk.RLock()
 	defer i.lk.RUnlock()
 
@@ -398,6 +504,8 @@ func (i *Index) StorageBestAlloc(ctx context.Context, allocate storiface.SectorF
 		return nil, xerrors.Errorf("estimating required space: %w", err)
 	}
 
+	kind, holders := i.sectorPlacement(sector)
+
 	for _, p := range i.stores {
 		if (pathType == storiface.PathSealing) && !p.info.CanSeal {
 			continue
@@ -406,6 +514,11 @@ func (i *Index) StorageBestAlloc(ctx context.Context, allocate storiface.SectorF
 			continue
 		}
 
+		if !i.placementOk(p, allocate, kind, holders) {
+			log.Debugf("not allocating on %s, denied by placement rules", p.info.ID)
+			continue
+		}
+
 		if spaceReq > uint64(p.fsi.Available) {
 			log.Debugf("not allocating on %s, out of space (available: %d, need: %d)", p.info.ID, p.fsi.Available, spaceReq)
 			continue
@@ -443,6 +556,59 @@ func (i *Index) StorageBestAlloc(ctx context.Context, allocate storiface.SectorF
 	return out, nil
 }
 
+func (i *Index) StorageSetSectorKind(ctx context.Context, sector abi.SectorID, kind storiface.SectorKind) error {
+	i.lk.Lock()
+	defer i.lk.Unlock()
+
+	if kind == storiface.KindCC {
+		delete(i.kinds, sector)
+		return nil
+	}
+
+	i.kinds[sector] = kind
+	return nil
+}
+
+// sectorPlacement returns the kind of the sector and the storages holding any of its files, must be called with i.lk held
+func (i *Index) sectorPlacement(sector abi.SectorID) (storiface.SectorKind, map[ID]struct{}) {
+	kind, ok := i.kinds[sector]
+	if !ok {
+		kind = storiface.KindCC
+	}
+
+	holders := map[ID]struct{}{}
+	for _, fileType := range storiface.PathTypes {
+		for _, meta := range i.sectors[Decl{sector, fileType}] {
+			holders[meta.storage] = struct{}{}
+		}
+	}
+
+	return kind, holders
+}
+
+// placementOk checks the rules of the storage and the AllowTo rules of the storages holding the sector
+func (i *Index) placementOk(st *storageEntry, ft storiface.SectorFileType, kind storiface.SectorKind, holders map[ID]struct{}) bool {
+	if !st.info.Accepts(ft, kind) {
+		return false
+	}
+
+	for id := range holders {
+		if id == st.info.ID {
+			continue
+		}
+
+		holder, ok := i.stores[id]
+		if !ok {
+			continue
+		}
+		if !holder.info.AllowsTo(st.info.PlacementRules) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (i *Index) FindSector(id abi.SectorID, typ storiface.SectorFileType) ([]ID, error) {
 	i.lk.RLock()
 	defer i.lk.RUnlock()
@@ -0,0 +1,206 @@
+package stores
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// HeartbeatPersistInterval bounds how often the heartbeat of a healthy storage is written to the IndexStore
+var HeartbeatPersistInterval = time.Minute
+
+// IndexStore persists the storages and sector declarations of the Index so that a restarted
+// sealer knows where every sector lives before the storages attach again
+type IndexStore interface {
+	SaveStorage(PersistedStorage) error
+	UpdateHeartbeat(id ID, at time.Time, heartbeatErr string) error
+	ListStorages() ([]PersistedStorage, error)
+
+	DeclareSector(PersistedDecl) error
+	DropSector(PersistedDecl) error
+	ListSectorDecls() ([]PersistedDecl, error)
+}
+
+type PersistedStorage struct {
+	Info StorageInfo
+
+	LastHeartbeat time.Time
+	HeartbeatErr  string
+}
+
+// PersistedDecl is a single sector file type declared in a storage
+type PersistedDecl struct {
+	Decl
+	Storage ID
+	Primary bool
+}
+
+// StorageHealth is the heartbeat state of a storage in the index
+type StorageHealth struct {
+	LastHeartbeat time.Time
+	Err           string
+
+	// Restored is set while a storage loaded from the IndexStore hasn't attached or reported its health
+	// since the sealer started
+	Restored bool
+}
+
+// NewPersistedIndex returns an Index backed by store, loaded with the storages and sectors it holds.
+// Loaded storages keep their last heartbeat, so they aren't used for allocations until they report again.
+// The loaded declarations a storage doesn't declare again once it is back are dropped
+func NewPersistedIndex(store IndexStore) (*Index, error) {
+	i := NewIndex()
+	i.store = store
+
+	storages, err := store.ListStorages()
+	if err != nil {
+		return nil, xerrors.Errorf("loading storages: %w", err)
+	}
+	for _, st := range storages {
+		info := st.Info
+		ent := &storageEntry{
+			info:          &info,
+			lastHeartbeat: st.LastHeartbeat,
+			restored:      true,
+
+			persistedHeartbeat: st.LastHeartbeat,
+			persistedErr:       st.HeartbeatErr,
+		}
+		if st.HeartbeatErr != "" {
+			ent.heartbeatErr = xerrors.New(st.HeartbeatErr)
+		}
+		i.stores[info.ID] = ent
+	}
+
+	decls, err := store.ListSectorDecls()
+	if err != nil {
+		return nil, xerrors.Errorf("loading sector declarations: %w", err)
+	}
+	for _, decl := range decls {
+		i.sectors[decl.Decl] = append(i.sectors[decl.Decl], &declMeta{
+			storage:  decl.Storage,
+			primary:  decl.Primary,
+			restored: true,
+		})
+		if ent, ok := i.stores[decl.Storage]; ok {
+			ent.restoredDecls = true
+		}
+	}
+
+	log.Infof("loaded sector index: %d storages, %d sector files", len(storages), len(decls))
+	return i, nil
+}
+
+// StorageHealth returns the heartbeat state of all storages in the index
+func (i *Index) StorageHealth(ctx context.Context) (map[ID]StorageHealth, error) {
+	i.lk.RLock()
+	defer i.lk.RUnlock()
+
+	out := make(map[ID]StorageHealth, len(i.stores))
+	for id, ent := range i.stores {
+		h := StorageHealth{
+			LastHeartbeat: ent.lastHeartbeat,
+			Restored:      ent.restored,
+		}
+		if ent.heartbeatErr != nil {
+			h.Err = ent.heartbeatErr.Error()
+		}
+		out[id] = h
+	}
+
+	return out, nil
+}
+
+// the persist* helpers must be called with i.lk held, they queue the writes for flushStore. Failures only lose
+// the update so they are logged
+
+func (i *Index) persistStorage(ent *storageEntry) {
+	if i.store == nil {
+		return
+	}
+
+	var herr string
+	if ent.heartbeatErr != nil {
+		herr = ent.heartbeatErr.Error()
+	}
+	st := PersistedStorage{
+		Info:          *ent.info,
+		LastHeartbeat: ent.lastHeartbeat,
+		HeartbeatErr:  herr,
+	}
+	ent.persistedHeartbeat, ent.persistedErr = ent.lastHeartbeat, herr
+
+	i.queueStore(func() {
+		if err := i.store.SaveStorage(st); err != nil {
+			log.Warnf("persisting storage %s: %+v", st.Info.ID, err)
+		}
+	})
+}
+
+func (i *Index) persistHeartbeat(ent *storageEntry) {
+	if i.store == nil {
+		return
+	}
+
+	var herr string
+	if ent.heartbeatErr != nil {
+		herr = ent.heartbeatErr.Error()
+	}
+	if herr == ent.persistedErr && ent.lastHeartbeat.Sub(ent.persistedHeartbeat) < HeartbeatPersistInterval {
+		return
+	}
+	id, at := ent.info.ID, ent.lastHeartbeat
+	ent.persistedHeartbeat, ent.persistedErr = at, herr
+
+	i.queueStore(func() {
+		if err := i.store.UpdateHeartbeat(id, at, herr); err != nil {
+			log.Warnf("persisting heartbeat of storage %s: %+v", id, err)
+		}
+	})
+}
+
+func (i *Index) persistDecl(storageID ID, d Decl, primary bool) {
+	i.queueStore(func() {
+		if err := i.store.DeclareSector(PersistedDecl{Decl: d, Storage: storageID, Primary: primary}); err != nil {
+			log.Warnf("persisting sector %v (%s) in storage %s: %+v", d.SectorID, d.SectorFileType, storageID, err)
+		}
+	})
+}
+
+func (i *Index) persistDrop(storageID ID, d Decl) {
+	i.queueStore(func() {
+		if err := i.store.DropSector(PersistedDecl{Decl: d, Storage: storageID}); err != nil {
+			log.Warnf("persisting drop of sector %v (%s) from storage %s: %+v", d.SectorID, d.SectorFileType, storageID, err)
+		}
+	})
+}
+
+func (i *Index) queueStore(write func()) {
+	if i.store == nil {
+		return
+	}
+
+	i.pendingLk.Lock()
+	i.pending = append(i.pending, write)
+	i.pendingLk.Unlock()
+}
+
+// flushStore writes the queued updates in order, it must be called without i.lk held
+func (i *Index) flushStore() {
+	if i.store == nil {
+		return
+	}
+
+	i.storeLk.Lock()
+	defer i.storeLk.Unlock()
+
+	i.pendingLk.Lock()
+	pending := i.pending
+	i.pending = nil
+	i.pendingLk.Unlock()
+
+	for _, write := range pending {
+		write()
+	}
+}
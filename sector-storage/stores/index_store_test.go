@@ -0,0 +1,161 @@
+package stores
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+)
+
+type memIndexStore struct {
+	storages   map[ID]PersistedStorage
+	decls      map[Decl]map[ID]bool
+	heartbeats int
+}
+
+func newMemIndexStore() *memIndexStore {
+	return &memIndexStore{
+		storages: map[ID]PersistedStorage{},
+		decls:    map[Decl]map[ID]bool{},
+	}
+}
+
+func (m *memIndexStore) SaveStorage(st PersistedStorage) error {
+	m.storages[st.Info.ID] = st
+	return nil
+}
+
+func (m *memIndexStore) UpdateHeartbeat(id ID, at time.Time, heartbeatErr string) error {
+	st := m.storages[id]
+	st.LastHeartbeat, st.HeartbeatErr = at, heartbeatErr
+	m.storages[id] = st
+	m.heartbeats++
+	return nil
+}
+
+func (m *memIndexStore) ListStorages() ([]PersistedStorage, error) {
+	var out []PersistedStorage
+	for _, st := range m.storages {
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Info.ID < out[j].Info.ID
+	})
+	return out, nil
+}
+
+func (m *memIndexStore) DeclareSector(decl PersistedDecl) error {
+	if m.decls[decl.Decl] == nil {
+		m.decls[decl.Decl] = map[ID]bool{}
+	}
+	m.decls[decl.Decl][decl.Storage] = decl.Primary
+	return nil
+}
+
+func (m *memIndexStore) DropSector(decl PersistedDecl) error {
+	delete(m.decls[decl.Decl], decl.Storage)
+	return nil
+}
+
+func (m *memIndexStore) ListSectorDecls() ([]PersistedDecl, error) {
+	var out []PersistedDecl
+	for d, ids := range m.decls {
+		for id, primary := range ids {
+			out = append(out, PersistedDecl{Decl: d, Storage: id, Primary: primary})
+		}
+	}
+	return out, nil
+}
+
+var _ IndexStore = &memIndexStore{}
+
+func TestPersistedIndex(t *testing.T) {
+	ctx := context.TODO()
+	store := newMemIndexStore()
+
+	index, err := NewPersistedIndex(store)
+	require.NoError(t, err)
+
+	stat := fsutil.FsStat{Capacity: 1 << 40, Available: 1 << 40}
+	require.NoError(t, index.StorageAttach(ctx, StorageInfo{ID: "seal", URLs: []string{"http://seal/remote"}, Weight: 10, CanSeal: true}, stat))
+	require.NoError(t, index.StorageAttach(ctx, StorageInfo{ID: "store", URLs: []string{"http://store/remote"}, Weight: 10, CanStore: true}, stat))
+
+	require.NoError(t, index.StorageDeclareSector(ctx, "seal", aSector, storiface.FTUnsealed, true))
+	require.NoError(t, index.StorageDeclareSector(ctx, "store", aSector, storiface.FTSealed|storiface.FTCache, false))
+	require.NoError(t, index.StorageDeclareSector(ctx, "store", aSector, storiface.FTSealed|storiface.FTCache, true))
+	require.NoError(t, index.StorageDropSector(ctx, "seal", aSector, storiface.FTUnsealed))
+
+	// heartbeats are written at most once per HeartbeatPersistInterval unless the error changes
+	require.NoError(t, index.StorageReportHealth(ctx, "seal", HealthReport{Stat: stat}))
+	require.Equal(t, 0, store.heartbeats)
+	require.NoError(t, index.StorageReportHealth(ctx, "seal", HealthReport{Stat: stat, Err: "stat failed"}))
+	require.Equal(t, 1, store.heartbeats)
+
+	// restart
+	restarted, err := NewPersistedIndex(store)
+	require.NoError(t, err)
+
+	found, err := restarted.StorageFindSector(ctx, aSector, storiface.FTSealed, 0, false)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, ID("store"), found[0].ID)
+	require.True(t, found[0].Primary)
+
+	found, err = restarted.StorageFindSector(ctx, aSector, storiface.FTUnsealed, 0, false)
+	require.NoError(t, err)
+	require.Len(t, found, 0)
+
+	health, err := restarted.StorageHealth(ctx)
+	require.NoError(t, err)
+	require.Len(t, health, 2)
+	require.True(t, health["seal"].Restored)
+	require.Equal(t, "stat failed", health["seal"].Err)
+	require.True(t, health["store"].Restored)
+
+	// restored storages aren't allocated on until they come back
+	_, err = restarted.StorageBestAlloc(ctx, storiface.FTSealed, 2048, storiface.PathStorage, aSector)
+	require.Error(t, err)
+
+	// a storage which is up is tried before the restored ones
+	require.NoError(t, restarted.StorageAttach(ctx, StorageInfo{ID: "other", URLs: []string{"http://other/remote"}, Weight: 1, CanStore: true}, stat))
+	require.NoError(t, restarted.StorageDeclareSector(ctx, "other", aSector, storiface.FTSealed, false))
+	found, err = restarted.StorageFindSector(ctx, aSector, storiface.FTSealed, 0, false)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	require.Equal(t, ID("other"), found[0].ID)
+	require.Equal(t, ID("store"), found[1].ID)
+	require.Equal(t, uint64(0), found[1].Weight)
+
+	require.NoError(t, restarted.StorageAttach(ctx, StorageInfo{ID: "store", URLs: []string{"http://store/remote"}, Weight: 10, CanStore: true}, stat))
+	best, err := restarted.StorageBestAlloc(ctx, storiface.FTSealed, 2048, storiface.PathStorage, aSector)
+	require.NoError(t, err)
+	require.Equal(t, ID("store"), best[0].ID)
+
+	health, err = restarted.StorageHealth(ctx)
+	require.NoError(t, err)
+	require.False(t, health["store"].Restored)
+	require.True(t, health["seal"].Restored)
+
+	// the cache is gone from the storage, the restored declaration is dropped once the storage declared its sectors
+	require.NoError(t, restarted.StorageDeclareSector(ctx, "store", aSector, storiface.FTSealed, true))
+	found, err = restarted.StorageFindSector(ctx, aSector, storiface.FTCache, 0, false)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+
+	require.NoError(t, restarted.StorageReportHealth(ctx, "store", HealthReport{Stat: stat}))
+	found, err = restarted.StorageFindSector(ctx, aSector, storiface.FTCache, 0, false)
+	require.NoError(t, err)
+	require.Len(t, found, 0)
+	found, err = restarted.StorageFindSector(ctx, aSector, storiface.FTSealed, 0, false)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	require.Equal(t, ID("store"), found[0].ID)
+
+	require.Empty(t, store.decls[Decl{aSector, storiface.FTCache}])
+	require.Len(t, store.decls[Decl{aSector, storiface.FTSealed}], 2)
+}
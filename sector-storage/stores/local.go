@@ -46,6 +46,9 @@ type LocalStorageMeta struct {
 	// MaxStorage specifies the maximum number of bytes to use for sector storage
 	// (0 = unlimited)
 	MaxStorage uint64
+
+	// Groups, AllowTo, AllowTypes, DenyTypes and AllowKinds restrict which sector files are placed here
+	PlacementRules
 }
 
 // StorageConfig .lotusstorage/storage.json
@@ -183,6 +186,10 @@ func (st *Local) OpenPath(ctx context.Context, p string) error {
 		return xerrors.Errorf("unmarshalling storage metadata for %s: %w", p, err)
 	}
 
+	if err := meta.PlacementRules.Validate(); err != nil {
+		return xerrors.Errorf("placement rules of %s: %w", p, err)
+	}
+
 	// TODO: Check existing / dedupe
 
 	out := &path{
@@ -205,6 +212,8 @@ func (st *Local) OpenPath(ctx context.Context, p string) error {
 		MaxStorage: meta.MaxStorage,
 		CanSeal:    meta.CanSeal,
 		CanStore:   meta.CanStore,
+
+		PlacementRules: meta.PlacementRules,
 	}, fst)
 	if err != nil {
 		return xerrors.Errorf("declaring storage in index: %w", err)
@@ -269,6 +278,8 @@ func (st *Local) Redeclare(ctx context.Context) error {
 			MaxStorage: meta.MaxStorage,
 			CanSeal:    meta.CanSeal,
 			CanStore:   meta.CanStore,
+
+			PlacementRules: meta.PlacementRules,
 		}, fst)
 		if err != nil {
 			return xerrors.Errorf("redeclaring storage in index: %w", err)
@@ -465,7 +476,7 @@ func (st *Local) AcquireSector(ctx context.Context, sid storage.SectorRef, exist
 			continue
 		}
 
-		sis, err := st.index.StorageBestAlloc(ctx, fileType, ssize, pathType)
+		sis, err := st.index.StorageBestAlloc(ctx, fileType, ssize, pathType, sid.ID)
 		if err != nil {
 			return storiface.SectorPaths{}, storiface.SectorPaths{}, xerrors.Errorf("finding best storage for allocating : %w", err)
 		}
@@ -658,6 +669,10 @@ func (st *Local) MoveStorage(ctx context.Context, s storage.SectorRef, types sto
 			continue
 		}
 
+		if !sst.AllowsTo(dst.PlacementRules) {
+			return xerrors.Errorf("moving %v(%d) from %s to %s: not allowed by the placement rules of the source", s, fileType, sst.ID, dst.ID)
+		}
+
 		log.Debugf("moving %v(%d) to storage: %s(se:%t; st:%t) -> %s(se:%t; st:%t)", s, fileType, sst.ID, sst.CanSeal, sst.CanStore, dst.ID, dst.CanSeal, dst.CanStore)
 
 		if err := st.index.StorageDropSector(ctx, ID(storiface.PathByType(srcIds, fileType)), s.ID, fileType); err != nil {
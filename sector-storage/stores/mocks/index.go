@@ -53,18 +53,18 @@ func (mr *MockSectorIndexMockRecorder) StorageAttach(arg0, arg1, arg2 interface{
 }
 
 // StorageBestAlloc mocks base method.
-func (m *MockSectorIndex) StorageBestAlloc(ctx context.Context, allocate storiface.SectorFileType, ssize abi.SectorSize, pathType storiface.PathType) ([]stores.StorageInfo, error) {
+func (m *MockSectorIndex) StorageBestAlloc(ctx context.Context, allocate storiface.SectorFileType, ssize abi.SectorSize, pathType storiface.PathType, sector abi.SectorID) ([]stores.StorageInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StorageBestAlloc", ctx, allocate, ssize, pathType)
+	ret := m.ctrl.Call(m, "StorageBestAlloc", ctx, allocate, ssize, pathType, sector)
 	ret0, _ := ret[0].([]stores.StorageInfo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // StorageBestAlloc indicates an expected call of StorageBestAlloc.
-func (mr *MockSectorIndexMockRecorder) StorageBestAlloc(ctx, allocate, ssize, pathType interface{}) *gomock.Call {
+func (mr *MockSectorIndexMockRecorder) StorageBestAlloc(ctx, allocate, ssize, pathType, sector interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageBestAlloc", reflect.TypeOf((*MockSectorIndex)(nil).StorageBestAlloc), ctx, allocate, ssize, pathType)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageBestAlloc", reflect.TypeOf((*MockSectorIndex)(nil).StorageBestAlloc), ctx, allocate, ssize, pathType, sector)
 }
 
 // StorageDeclareSector mocks base method.
@@ -153,6 +153,20 @@ func (mr *MockSectorIndexMockRecorder) StorageReportHealth(arg0, arg1, arg2 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageReportHealth", reflect.TypeOf((*MockSectorIndex)(nil).StorageReportHealth), arg0, arg1, arg2)
 }
 
+// StorageSetSectorKind mocks base method.
+func (m *MockSectorIndex) StorageSetSectorKind(ctx context.Context, sector abi.SectorID, kind storiface.SectorKind) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StorageSetSectorKind", ctx, sector, kind)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StorageSetSectorKind indicates an expected call of StorageSetSectorKind.
+func (mr *MockSectorIndexMockRecorder) StorageSetSectorKind(ctx, sector, kind interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageSetSectorKind", reflect.TypeOf((*MockSectorIndex)(nil).StorageSetSectorKind), ctx, sector, kind)
+}
+
 // StorageTryLock mocks base method.
 func (m *MockSectorIndex) StorageTryLock(ctx context.Context, sector abi.SectorID, read, write storiface.SectorFileType) (bool, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,106 @@
+package stores
+
+import (
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+)
+
+// PlacementRules restrict the sector files a storage accepts and where its files may go, they are
+// set in the sectorstore.json of the path
+type PlacementRules struct {
+	// Groups the storage belongs to, referenced by the AllowTo rules of other storages
+	Groups []string `json:",omitempty"`
+
+	// AllowTo lists the groups the sector files in this storage may be moved to, empty allows any storage
+	AllowTo []string `json:",omitempty"`
+
+	// AllowTypes lists the file types (unsealed, sealed, cache) allocated in the storage, empty allows all
+	AllowTypes []string `json:",omitempty"`
+	// DenyTypes lists the file types never allocated in the storage
+	DenyTypes []string `json:",omitempty"`
+
+	// AllowKinds lists the kinds of sectors (cc, deal) allocated in the storage, empty allows all
+	AllowKinds []storiface.SectorKind `json:",omitempty"`
+}
+
+func (r PlacementRules) Validate() error {
+	for _, list := range [][]string{r.AllowTypes, r.DenyTypes} {
+	typeLoop:
+		for _, name := range list {
+			for _, ft := range storiface.PathTypes {
+				if ft.String() == name {
+					continue typeLoop
+				}
+			}
+			return xerrors.Errorf("unknown sector file type %q", name)
+		}
+	}
+
+kindLoop:
+	for _, kind := range r.AllowKinds {
+		for _, known := range storiface.SectorKinds {
+			if kind == known {
+				continue kindLoop
+			}
+		}
+		return xerrors.Errorf("unknown sector kind %q", kind)
+	}
+
+	return nil
+}
+
+// Accepts returns true if all file types in ft of a sector of the given kind may be allocated in the storage
+func (r PlacementRules) Accepts(ft storiface.SectorFileType, kind storiface.SectorKind) bool {
+	if len(r.AllowKinds) > 0 && !containsKind(r.AllowKinds, kind) {
+		return false
+	}
+
+	for _, fileType := range storiface.PathTypes {
+		if fileType&ft == 0 {
+			continue
+		}
+
+		if len(r.AllowTypes) > 0 && !contains(r.AllowTypes, fileType.String()) {
+			return false
+		}
+		if contains(r.DenyTypes, fileType.String()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AllowsTo returns true if the sector files in the storage may be moved to a storage with the dst rules
+func (r PlacementRules) AllowsTo(dst PlacementRules) bool {
+	if len(r.AllowTo) == 0 {
+		return true
+	}
+
+	for _, group := range dst.Groups {
+		if contains(r.AllowTo, group) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsKind(list []storiface.SectorKind, kind storiface.SectorKind) bool {
+	for _, e := range list {
+		if e == kind {
+			return true
+		}
+	}
+	return false
+}
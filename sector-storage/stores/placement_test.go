@@ -0,0 +1,83 @@
+package stores
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+)
+
+func TestPlacementRules(t *testing.T) {
+	require.NoError(t, PlacementRules{AllowTypes: []string{"sealed", "cache"}, AllowKinds: []storiface.SectorKind{storiface.KindDeal}}.Validate())
+	require.Error(t, PlacementRules{DenyTypes: []string{"sealed", "unseal"}}.Validate())
+	require.Error(t, PlacementRules{AllowKinds: []storiface.SectorKind{"deals"}}.Validate())
+
+	unrestricted := PlacementRules{}
+	require.True(t, unrestricted.Accepts(storiface.FTUnsealed|storiface.FTSealed|storiface.FTCache, storiface.KindCC))
+	require.True(t, unrestricted.AllowsTo(PlacementRules{}))
+
+	sealedOnly := PlacementRules{AllowTypes: []string{"sealed", "cache"}}
+	require.True(t, sealedOnly.Accepts(storiface.FTSealed|storiface.FTCache, storiface.KindCC))
+	require.False(t, sealedOnly.Accepts(storiface.FTSealed|storiface.FTUnsealed, storiface.KindCC))
+
+	noUnsealed := PlacementRules{DenyTypes: []string{"unsealed"}}
+	require.True(t, noUnsealed.Accepts(storiface.FTCache, storiface.KindDeal))
+	require.False(t, noUnsealed.Accepts(storiface.FTUnsealed, storiface.KindDeal))
+
+	deals := PlacementRules{AllowKinds: []storiface.SectorKind{storiface.KindDeal}}
+	require.True(t, deals.Accepts(storiface.FTSealed, storiface.KindDeal))
+	require.False(t, deals.Accepts(storiface.FTSealed, storiface.KindCC))
+
+	groupA := PlacementRules{Groups: []string{"a"}, AllowTo: []string{"b", "c"}}
+	require.True(t, groupA.AllowsTo(PlacementRules{Groups: []string{"x", "c"}}))
+	require.False(t, groupA.AllowsTo(PlacementRules{Groups: []string{"a"}}))
+	require.False(t, groupA.AllowsTo(PlacementRules{}))
+}
+
+func TestBestAllocPlacement(t *testing.T) {
+	ctx := context.TODO()
+	index := NewIndex()
+
+	stat := fsutil.FsStat{Capacity: 1 << 40, Available: 1 << 40}
+	attach := func(id ID, canSeal, canStore bool, rules PlacementRules) {
+		require.NoError(t, index.StorageAttach(ctx, StorageInfo{ID: id, URLs: []string{"http://" + string(id) + "/remote"}, Weight: 10, CanSeal: canSeal, CanStore: canStore, PlacementRules: rules}, stat))
+	}
+	attach("seal-a", true, false, PlacementRules{Groups: []string{"a"}, AllowTo: []string{"b", "c"}})
+	attach("store-b", false, true, PlacementRules{Groups: []string{"b"}})
+	attach("store-d", false, true, PlacementRules{Groups: []string{"d"}})
+	attach("store-ssd", false, true, PlacementRules{Groups: []string{"c"}, AllowKinds: []storiface.SectorKind{storiface.KindDeal}})
+
+	ids := func(infos []StorageInfo) []ID {
+		var out []ID
+		for _, info := range infos {
+			out = append(out, info.ID)
+		}
+		return out
+	}
+
+	// not sealed anywhere yet, any store accepting cc sectors
+	best, err := index.StorageBestAlloc(ctx, storiface.FTSealed, 2048, storiface.PathStorage, aSector)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []ID{"store-b", "store-d"}, ids(best))
+
+	// sealed in group a, which may only move to b and c
+	require.NoError(t, index.StorageDeclareSector(ctx, "seal-a", aSector, storiface.FTSealed|storiface.FTCache, false))
+	best, err = index.StorageBestAlloc(ctx, storiface.FTSealed|storiface.FTCache, 2048, storiface.PathStorage, aSector)
+	require.NoError(t, err)
+	require.Equal(t, []ID{"store-b"}, ids(best))
+
+	require.NoError(t, index.StorageSetSectorKind(ctx, aSector, storiface.KindDeal))
+	best, err = index.StorageBestAlloc(ctx, storiface.FTSealed|storiface.FTCache, 2048, storiface.PathStorage, aSector)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []ID{"store-b", "store-ssd"}, ids(best))
+
+	// back to cc
+	require.NoError(t, index.StorageSetSectorKind(ctx, aSector, storiface.KindCC))
+	require.NoError(t, index.StorageDropSector(ctx, "seal-a", aSector, storiface.FTSealed|storiface.FTCache))
+	best, err = index.StorageBestAlloc(ctx, storiface.FTSealed, 2048, storiface.PathStorage, aSector)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []ID{"store-b", "store-d"}, ids(best))
+}
@@ -1,7 +1,10 @@
 package stores
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,11 +17,15 @@ import (
 	gopath "path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
 	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
 	"github.com/filecoin-project/venus-sealer/sector-storage/tarutil"
+	"github.com/filecoin-project/venus-sealer/types"
 
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/specs-storage/storage"
@@ -36,12 +43,40 @@ type Remote struct {
 	index SectorIndex
 	auth  http.Header
 
-	limit chan struct{}
+	limit     *fetchScheduler
+	bandwidth bandwidthLimits
+	compress  int32
 
 	fetchLk  sync.Mutex
 	fetching map[abi.SectorID]chan struct{}
 
 	pfHandler partialFileHandler
+
+	transfers transferTracker
+}
+
+// Transfers returns the progress of running fetches
+func (r *Remote) Transfers() []storiface.TransferProgress {
+	return r.transfers.list()
+}
+
+// SetBandwidthLimits caps the bytes per second fetched from one remote host and into one local path, 0 for no cap
+func (r *Remote) SetBandwidthLimits(host, path int64) {
+	r.bandwidth.set(host, path)
+}
+
+// SetCompression asks remotes to compress cache transfers, remotes not supporting it send them uncompressed
+func (r *Remote) SetCompression(compress bool) {
+	var v int32
+	if compress {
+		v = 1
+	}
+	atomic.StoreInt32(&r.compress, v)
+}
+
+// SetFetchLimit sets the number of fetches running at once
+func (r *Remote) SetFetchLimit(limit int) {
+	r.limit.setLimit(limit)
 }
 
 func (r *Remote) RemoveCopies(ctx context.Context, s abi.SectorID, types storiface.SectorFileType) error {
@@ -58,7 +93,7 @@ func NewRemote(local Store, index SectorIndex, auth http.Header, fetchLimit int,
 		index: index,
 		auth:  auth,
 
-		limit: make(chan struct{}, fetchLimit),
+		limit: newFetchScheduler(fetchLimit),
 
 		fetching:  map[abi.SectorID]chan struct{}{},
 		pfHandler: pfHandler,
@@ -141,7 +176,7 @@ func (r *Remote) AcquireSector(ctx context.Context, s storage.SectorRef, existin
 		dest := storiface.PathByType(apaths, fileType)
 		storageID := storiface.PathByType(ids, fileType)
 
-		url, err := r.acquireFromRemote(ctx, s.ID, fileType, dest)
+		url, err := r.acquireFromRemote(ctx, s.ID, fileType, dest, ID(storageID))
 		if err != nil {
 			return storiface.SectorPaths{}, storiface.SectorPaths{}, err
 		}
@@ -176,7 +211,7 @@ func tempFetchDest(spath string, create bool) (string, error) {
 	return filepath.Join(tempdir, b), nil
 }
 
-func (r *Remote) acquireFromRemote(ctx context.Context, s abi.SectorID, fileType storiface.SectorFileType, dest string) (string, error) {
+func (r *Remote) acquireFromRemote(ctx context.Context, s abi.SectorID, fileType storiface.SectorFileType, dest string, pathID ID) (string, error) {
 	si, err := r.index.StorageFindSector(ctx, s, fileType, 0, false)
 	if err != nil {
 		return "", err
@@ -187,7 +222,7 @@ func (r *Remote) acquireFromRemote(ctx context.Context, s abi.SectorID, fileType
 	}
 
 	sort.Slice(si, func(i, j int) bool {
-		return si[i].Weight < si[j].Weight
+		return si[i].Weight > si[j].Weight
 	})
 
 	var merr error
@@ -204,7 +239,8 @@ func (r *Remote) acquireFromRemote(ctx context.Context, s abi.SectorID, fileType
 				return "", xerrors.Errorf("removing dest: %w", err)
 			}
 
-			err = r.fetch(ctx, url, tempDest)
+			// data of an interrupted fetch is kept in tempDest and resumed
+			err = r.fetch(ctx, s, fileType, url, tempDest, pathID)
 			if err != nil {
 				merr = multierror.Append(merr, xerrors.Errorf("fetch error %s (storage %s) -> %s: %w", url, info.ID, tempDest, err))
 				continue
@@ -224,50 +260,319 @@ func (r *Remote) acquireFromRemote(ctx context.Context, s abi.SectorID, fileType
 	return "", xerrors.Errorf("failed to acquire sector %v from remote (tried %v): %w", s, si, merr)
 }
 
-func (r *Remote) fetch(ctx context.Context, url, outname string) error {
+// throttled runs cb in a fetch slot, it is retried after the backoff asked by the remote when the remote is busy
+func (r *Remote) throttled(ctx context.Context, url string, cb func() error) error {
+	host := urlHost(url)
+	for {
+		release, err := r.limit.acquire(ctx, host)
+		if err != nil {
+			return xerrors.Errorf("context error while waiting for fetch limiter: %w", err)
+		}
+
+		err = cb()
+		release()
+
+		var busy *errRemoteBusy
+		if !xerrors.As(err, &busy) {
+			return err
+		}
+
+		log.Infof("Remote %s is busy, backing off for %s", host, busy.retryAfter)
+		r.limit.backoff(host, busy.retryAfter)
+	}
+}
+
+// request creates a request to the FetchHandler at url
+func (r *Remote) request(ctx context.Context, method, url string) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("request: %w", err)
+	}
+	if r.auth != nil {
+		req.Header = r.auth.Clone()
+	}
+	req.Header.Set(FetchPriorityHeader, strconv.Itoa(types.GetPriority(ctx)))
+	return req.WithContext(ctx), nil
+}
+
+func (r *Remote) fetch(ctx context.Context, s abi.SectorID, fileType storiface.SectorFileType, url, outname string, pathID ID) error {
 	log.Infof("Fetch %s -> %s", url, outname)
 
-	if len(r.limit) >= cap(r.limit) {
-		log.Infof("Throttling fetch, %d already running", len(r.limit))
+	return r.throttled(ctx, url, func() error {
+		return r.fetchOnce(ctx, s, fileType, url, outname, pathID)
+	})
+}
+
+func (r *Remote) fetchOnce(ctx context.Context, s abi.SectorID, fileType storiface.SectorFileType, url, outname string, pathID ID) error {
+	limiters := r.bandwidth.limiters(urlHost(url), pathID)
+
+	manifest, err := r.fetchManifest(ctx, url)
+	if err != nil {
+		return xerrors.Errorf("fetching manifest: %w", err)
 	}
 
-	// TODO: Smarter throttling
-	//  * Priority (just going sequentially is still pretty good)
-	//  * Per interface
-	//  * Aware of remote load
-	select {
-	case r.limit <- struct{}{}:
-		defer func() { <-r.limit }()
-	case <-ctx.Done():
-		return xerrors.Errorf("context error while waiting for fetch limiter: %w", ctx.Err())
+	progress := storiface.TransferProgress{
+		Sector:   s,
+		FileType: fileType,
+		URL:      url,
+		Start:    time.Now(),
 	}
+	if manifest == nil {
+		// remote doesn't serve manifests, fetch the whole file/dir
+		t := r.transfers.start(progress, limiters)
+		defer r.transfers.finish(t)
 
-	req, err := http.NewRequest("GET", url, nil)
+		return r.fetchStream(ctx, url, outname, t)
+	}
+
+	progress.Size = manifest.Size()
+	t := r.transfers.start(progress, limiters)
+	defer r.transfers.finish(t)
+
+	if !manifest.Dir {
+		if st, err := os.Stat(outname); err == nil && st.IsDir() {
+			if err := os.RemoveAll(outname); err != nil {
+				return xerrors.Errorf("removing dest: %w", err)
+			}
+		}
+		return r.fetchFile(ctx, url, outname, manifest.Files[0], t)
+	}
+
+	if st, err := os.Stat(outname); err == nil && !st.IsDir() {
+		if err := os.RemoveAll(outname); err != nil {
+			return xerrors.Errorf("removing dest: %w", err)
+		}
+	}
+	if err := os.MkdirAll(outname, 0755); err != nil { // nolint
+		return xerrors.Errorf("mkdir: %w", err)
+	}
+
+	// remove files which are not in the manifest anymore
+	want := map[string]struct{}{}
+	for _, file := range manifest.Files {
+		want[file.Name] = struct{}{}
+	}
+	have, err := ioutil.ReadDir(outname)
 	if err != nil {
-		return xerrors.Errorf("request: %w", err)
+		return err
+	}
+	for _, file := range have {
+		if _, ok := want[file.Name()]; !ok {
+			if err := os.RemoveAll(filepath.Join(outname, file.Name())); err != nil {
+				return xerrors.Errorf("removing stale file: %w", err)
+			}
+		}
+	}
+
+	for _, file := range manifest.Files {
+		if err := r.fetchFile(ctx, dirFileURL(url, file.Name), filepath.Join(outname, file.Name), file, t); err != nil {
+			return xerrors.Errorf("fetching %s: %w", file.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// dirFileURL is the url of one file of the sector dir at dirURL
+func dirFileURL(dirURL, name string) string {
+	return dirURL + "?file=" + url.QueryEscape(name)
+}
+
+// acceptEncoding asks for compressed data when compression is enabled
+func (r *Remote) acceptEncoding(req *http.Request) {
+	if atomic.LoadInt32(&r.compress) == 1 {
+		req.Header.Set("Accept-Encoding", EncodingZstd)
+	}
+}
+
+// fetchManifest returns the manifest of url, nil if the remote doesn't serve manifests
+func (r *Remote) fetchManifest(ctx context.Context, url string) (*TransferManifest, error) {
+	req, err := r.request(ctx, "GET", url+"/manifest")
+	if err != nil {
+		return nil, err
 	}
-	req.Header = r.auth
-	req = req.WithContext(ctx)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return xerrors.Errorf("do request: %w", err)
+		return nil, xerrors.Errorf("do request: %w", err)
 	}
 	defer resp.Body.Close() // nolint
 
-	if resp.StatusCode != 200 {
-		return xerrors.Errorf("non-200 code: %d", resp.StatusCode)
+	switch resp.StatusCode {
+	case 200:
+	case 404, 405:
+		return nil, nil
+	default:
+		return nil, xerrors.Errorf("non-200 code: %d", resp.StatusCode)
+	}
+
+	var manifest TransferManifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, xerrors.Errorf("decoding manifest: %w", err)
+	}
+	if !manifest.Dir && len(manifest.Files) != 1 {
+		return nil, xerrors.Errorf("manifest of a file has %d entries", len(manifest.Files))
+	}
+	return &manifest, nil
+}
+
+// fetchFile downloads url into outname, resuming from the data already in outname, and verifies its checksum.
+// a file failing verification is removed so that the next attempt starts over.
+func (r *Remote) fetchFile(ctx context.Context, url, outname string, file TransferFile, t *transfer) error {
+	f, err := os.OpenFile(outname, os.O_RDWR|os.O_CREATE, 0644) // nolint
+	if err != nil {
+		return err
+	}
+
+	err = r.fetchFileInto(ctx, url, f, file, t)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil && xerrors.Is(err, errChecksumMismatch) {
+		if rerr := os.Remove(outname); rerr != nil {
+			log.Warnf("removing corrupted file %s: %+v", outname, rerr)
+		}
+	}
+	return err
+}
+
+var errChecksumMismatch = xerrors.New("checksum mismatch")
+
+func (r *Remote) fetchFileInto(ctx context.Context, url string, f *os.File, file TransferFile, t *transfer) error {
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	have := st.Size()
+	if have > file.Size {
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		have = 0
+	}
+
+	// hash the data we already have, the rest is hashed while downloading
+	h := sha256.New()
+	if _, err := io.CopyBuffer(h, io.LimitReader(f, have), make([]byte, CopyBuf)); err != nil {
+		return xerrors.Errorf("reading resumed data: %w", err)
+	}
+	t.resumed(have)
+
+	expect := file.Checksum
+	// without a checksum in the manifest the remote is asked for the trailer even when the file is complete
+	if have < file.Size || expect == "" {
+		if have > 0 {
+			log.Infof("Resuming fetch of %s at %d/%d", url, have, file.Size)
+		}
+
+		req, err := r.request(ctx, "GET", url)
+		if err != nil {
+			return err
+		}
+		if have > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", have))
+		}
+		r.acceptEncoding(req)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return xerrors.Errorf("do request: %w", err)
+		}
+		defer resp.Body.Close() // nolint
+
+		switch resp.StatusCode {
+		case 206:
+		case 503:
+			return remoteBusy(resp.Header.Get("Retry-After"))
+		case 200:
+			// the remote ignored the range, start over
+			if have > 0 {
+				if err := f.Truncate(0); err != nil {
+					return err
+				}
+				h.Reset()
+				t.resumed(-have)
+				have = 0
+			}
+		default:
+			return xerrors.Errorf("non-200 code: %d", resp.StatusCode)
+		}
+
+		if _, err := f.Seek(have, io.SeekStart); err != nil {
+			return err
+		}
+		body, err := decodeBody(resp, throttle(ctx, resp.Body, t.limiters))
+		if err != nil {
+			return err
+		}
+		defer body.Close() // nolint
+
+		if _, err := io.CopyBuffer(io.MultiWriter(f, h, t), body, make([]byte, CopyBuf)); err != nil {
+			return err
+		}
+
+		trailer, advertised, err := streamChecksum(resp)
+		if err != nil {
+			return xerrors.Errorf("%s: %w", url, err)
+		}
+		switch {
+		case expect == "":
+			if !advertised {
+				return xerrors.Errorf("%s: remote sent no checksum for the file", url)
+			}
+			expect = trailer
+		case advertised && trailer != expect:
+			return xerrors.Errorf("%s: manifest checksum %s, trailer %s: %w", url, expect, trailer, errChecksumMismatch)
+		}
 	}
 
-	/*bar := pb.New64(w.sizeForType(typ))
-	bar.ShowPercent = true
-	bar.ShowSpeed = true
-	bar.Units = pb.U_BYTES
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != expect {
+		return xerrors.Errorf("%s: expected %s, got %s: %w", url, expect, sum, errChecksumMismatch)
+	}
+	return nil
+}
 
-	barreader := bar.NewProxyReader(resp.Body)
+var errMissingChecksum = xerrors.New("checksum trailer missing")
 
-	bar.Start()
-	defer bar.Finish()*/
+// streamChecksum reads resp to the end and returns its checksum trailer. A trailer the remote advertised but didn't
+// send means the stream was cut short
+func streamChecksum(resp *http.Response) (string, bool, error) {
+	// the decoder may stop at the end of the compressed frame
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		return "", false, err
+	}
+
+	if _, advertised := resp.Trailer[http.CanonicalHeaderKey(ChecksumHeader)]; !advertised {
+		return "", false, nil
+	}
+	sum := resp.Trailer.Get(ChecksumHeader)
+	if sum == "" {
+		return "", true, errMissingChecksum
+	}
+	return sum, true, nil
+}
+
+// fetchStream downloads the whole file/tared dir of url into outname, verified with the checksum trailer. Only remotes
+// which don't advertise the trailer are trusted without it
+func (r *Remote) fetchStream(ctx context.Context, url, outname string, t *transfer) error {
+	req, err := r.request(ctx, "GET", url)
+	if err != nil {
+		return err
+	}
+	r.acceptEncoding(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return xerrors.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close() // nolint
+
+	if resp.StatusCode == 503 {
+		return remoteBusy(resp.Header.Get("Retry-After"))
+	}
+	if resp.StatusCode != 200 {
+		return xerrors.Errorf("non-200 code: %d", resp.StatusCode)
+	}
 
 	mediatype, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
 	if err != nil {
@@ -278,23 +583,57 @@ func (r *Remote) fetch(ctx context.Context, url, outname string) error {
 		return xerrors.Errorf("removing dest: %w", err)
 	}
 
+	decoded, err := decodeBody(resp, throttle(ctx, resp.Body, t.limiters))
+	if err != nil {
+		return err
+	}
+	defer decoded.Close() // nolint
+
+	h := sha256.New()
+	body := io.TeeReader(decoded, io.MultiWriter(t, h))
+
 	switch mediatype {
 	case "application/x-tar":
-		return tarutil.ExtractTar(resp.Body, outname)
+		if err := tarutil.ExtractTar(body, outname); err != nil {
+			return err
+		}
+		// the tar reader may stop before the end of the stream
+		if _, err := io.Copy(ioutil.Discard, body); err != nil {
+			return err
+		}
 	case "application/octet-stream":
 		f, err := os.Create(outname)
 		if err != nil {
 			return err
 		}
-		_, err = io.CopyBuffer(f, resp.Body, make([]byte, CopyBuf))
+		_, err = io.CopyBuffer(f, body, make([]byte, CopyBuf))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
 		if err != nil {
-			f.Close() // nolint
 			return err
 		}
-		return f.Close()
 	default:
 		return xerrors.Errorf("unknown content type: '%s'", mediatype)
 	}
+
+	expect, advertised, err := streamChecksum(resp)
+	if err == nil && !advertised {
+		log.Warnf("remote didn't send a checksum for %s", url)
+		return nil
+	}
+	if err == nil {
+		if sum := hex.EncodeToString(h.Sum(nil)); sum != expect {
+			err = xerrors.Errorf("expected %s, got %s: %w", expect, sum, errChecksumMismatch)
+		}
+	}
+	if err != nil {
+		if rerr := os.RemoveAll(outname); rerr != nil {
+			log.Warnf("removing unverified %s: %+v", outname, rerr)
+		}
+		return xerrors.Errorf("%s: %w", url, err)
+	}
+	return nil
 }
 
 func (r *Remote) MoveStorage(ctx context.Context, s storage.SectorRef, types storiface.SectorFileType) error {
@@ -419,6 +758,38 @@ func (r *Remote) FsStat(ctx context.Context, id ID) (fsutil.FsStat, error) {
 	return out, nil
 }
 
+// CheckSector asks the FetchHandler at url, the URL of a storage holding the sector, to check the sector files
+func (r *Remote) CheckSector(ctx context.Context, url string, creq storiface.CheckRequest) (storiface.SectorCheck, error) {
+	body, err := json.Marshal(&creq)
+	if err != nil {
+		return storiface.SectorCheck{}, xerrors.Errorf("marshaling check request: %w", err)
+	}
+
+	req, err := r.request(ctx, "POST", url+"/check/"+storiface.SectorName(creq.Sector.ID))
+	if err != nil {
+		return storiface.SectorCheck{}, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return storiface.SectorCheck{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close() // nolint
+
+	if resp.StatusCode != http.StatusOK {
+		return storiface.SectorCheck{}, xerrors.Errorf("non-200 code: %d", resp.StatusCode)
+	}
+
+	var out storiface.SectorCheck
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return storiface.SectorCheck{}, xerrors.Errorf("decoding check response: %w", err)
+	}
+	return out, nil
+}
+
 func (r *Remote) checkAllocated(ctx context.Context, url string, spt abi.RegisteredSealProof, offset, size abi.PaddedPieceSize) (bool, error) {
 	url = fmt.Sprintf("%s/%d/allocated/%d/%d", url, spt, offset.Unpadded(), size.Unpadded())
 	req, err := http.NewRequest("GET", url, nil)
@@ -445,43 +816,35 @@ func (r *Remote) checkAllocated(ctx context.Context, url string, spt abi.Registe
 }
 
 func (r *Remote) readRemote(ctx context.Context, url string, offset, size abi.PaddedPieceSize) (io.ReadCloser, error) {
-	if len(r.limit) >= cap(r.limit) {
-		log.Infof("Throttling remote read, %d already running", len(r.limit))
-	}
-
-	// TODO: Smarter throttling
-	//  * Priority (just going sequentially is still pretty good)
-	//  * Per interface
-	//  * Aware of remote load
-	select {
-	case r.limit <- struct{}{}:
-		defer func() { <-r.limit }()
-	case <-ctx.Done():
-		return nil, xerrors.Errorf("context error while waiting for fetch limiter: %w", ctx.Err())
-	}
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, xerrors.Errorf("request: %w", err)
-	}
-
-	if r.auth != nil {
-		req.Header = r.auth.Clone()
-	}
-	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+size-1))
-	req = req.WithContext(ctx)
+	var rd io.ReadCloser
+	err := r.throttled(ctx, url, func() error {
+		req, err := r.request(ctx, "GET", url)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+size-1))
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, xerrors.Errorf("do request: %w", err)
-	}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return xerrors.Errorf("do request: %w", err)
+		}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
-		resp.Body.Close() // nolint
-		return nil, xerrors.Errorf("non-200 code: %d", resp.StatusCode)
-	}
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			resp.Body.Close() // nolint
+			return remoteBusy(resp.Header.Get("Retry-After"))
+		}
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+			resp.Body.Close() // nolint
+			return xerrors.Errorf("non-200 code: %d", resp.StatusCode)
+		}
 
-	return resp.Body, nil
+		rd = struct {
+			io.Reader
+			io.Closer
+		}{throttle(ctx, resp.Body, r.bandwidth.limiters(urlHost(url), "")), resp.Body}
+		return nil
+	})
+	return rd, err
 }
 
 // CheckIsUnsealed checks if we have an unsealed piece at the given offset in an already unsealed sector file for the given piece
@@ -740,3 +740,46 @@ func (m *mockHttpServer) getSector(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(m.getSectorReturnCode)
 	_, _ = w.Write(m.getSectorBytes)
 }
+
+func TestCheckSector(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	sectorRef := storage.SectorRef{
+		ID:        abi.SectorID{Miner: 123, Number: 123},
+		ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1,
+	}
+	req := storiface.CheckRequest{Sector: sectorRef, PoStProof: abi.RegisteredPoStProof_StackedDrgWindow2KiBV1}
+
+	local := mocks.NewMockStore(ctrl)
+	local.EXPECT().AcquireSector(gomock.Any(), sectorRef, storiface.FTSealed|storiface.FTCache, storiface.FTNone,
+		storiface.PathStorage, storiface.AcquireMove).Return(storiface.SectorPaths{ID: sectorRef.ID, Sealed: "sealed", Cache: "cache"},
+		storiface.SectorPaths{}, nil).Times(1)
+
+	var got storiface.CheckRequest
+	handler := &stores.FetchHandler{
+		Local: local,
+		Check: func(ctx context.Context, creq storiface.CheckRequest, paths storiface.SectorPaths) storiface.SectorCheck {
+			got = creq
+			return storiface.SectorCheck{Sector: creq.Sector.ID, Code: storiface.CheckWrongSize, Path: paths.Sealed}
+		},
+	}
+	mux := mux.NewRouter()
+	mux.PathPrefix("/remote").HandlerFunc(handler.ServeHTTP)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	remote := stores.NewRemote(mocks.NewMockStore(ctrl), mocks.NewMockSectorIndex(ctrl), nil, 1, &stores.DefaultPartialFileHandler{})
+
+	res, err := remote.CheckSector(ctx, ts.URL+"/remote", req)
+	require.NoError(t, err)
+	require.Equal(t, req, got)
+	require.Equal(t, storiface.CheckWrongSize, res.Code)
+	require.Equal(t, "sealed", res.Path)
+
+	// sectors are only checked by storages serving checks
+	handler.Check = nil
+	_, err = remote.CheckSector(ctx, ts.URL+"/remote", req)
+	require.Error(t, err)
+}
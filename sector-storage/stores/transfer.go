@@ -0,0 +1,150 @@
+package stores
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+)
+
+// ChecksumHeader is the http trailer holding the sha256 of a tar stream or of the whole file served
+const ChecksumHeader = "X-Sector-Checksum"
+
+// TransferManifest lists the files of a sector file or cache directory with their checksums
+type TransferManifest struct {
+	Dir   bool
+	Files []TransferFile
+}
+
+// TransferFile is a file of a transfer, Name is empty for a single file
+type TransferFile struct {
+	Name string
+	Size int64
+	// Checksum is empty when the serving side hasn't hashed the file yet
+	Checksum string
+}
+
+func (m *TransferManifest) Size() int64 {
+	var size int64
+	for _, f := range m.Files {
+		size += f.Size
+	}
+	return size
+}
+
+type checksumKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// checksums of the files served whole, sector files don't change once sealed so manifests list them without
+// reading the files again
+var checksumCache = struct {
+	lk   sync.Mutex
+	sums map[checksumKey]string
+}{sums: map[checksumKey]string{}}
+
+const maxChecksumCache = 10000
+
+func cachedChecksum(path string, st os.FileInfo) string {
+	checksumCache.lk.Lock()
+	defer checksumCache.lk.Unlock()
+	return checksumCache.sums[checksumKey{path: path, size: st.Size(), modTime: st.ModTime()}]
+}
+
+func storeChecksum(path string, st os.FileInfo, sum string) {
+	checksumCache.lk.Lock()
+	defer checksumCache.lk.Unlock()
+	if len(checksumCache.sums) >= maxChecksumCache {
+		checksumCache.sums = map[checksumKey]string{}
+	}
+	checksumCache.sums[checksumKey{path: path, size: st.Size(), modTime: st.ModTime()}] = sum
+}
+
+// buildManifest lists the files at path, the checksums are only known for files served before. Files are hashed
+// while they are served, the fetchers verify the ones without a checksum with the checksum trailer
+func buildManifest(path string) (*TransferManifest, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !st.IsDir() {
+		return &TransferManifest{Files: []TransferFile{{Size: st.Size(), Checksum: cachedChecksum(path, st)}}}, nil
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &TransferManifest{Dir: true}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		sum := cachedChecksum(filepath.Join(path, file.Name()), file)
+		manifest.Files = append(manifest.Files, TransferFile{Name: file.Name(), Size: file.Size(), Checksum: sum})
+	}
+	return manifest, nil
+}
+
+// transfer tracks the progress of one fetch
+type transfer struct {
+	progress storiface.TransferProgress
+	limiters []*bandwidth
+	done     int64
+	resume   int64
+}
+
+func (t *transfer) Write(p []byte) (int, error) {
+	atomic.AddInt64(&t.done, int64(len(p)))
+	return len(p), nil
+}
+
+func (t *transfer) resumed(n int64) {
+	atomic.AddInt64(&t.done, n)
+	atomic.AddInt64(&t.resume, n)
+}
+
+type transferTracker struct {
+	lk      sync.Mutex
+	running map[*transfer]struct{}
+}
+
+func (tt *transferTracker) start(progress storiface.TransferProgress, limiters []*bandwidth) *transfer {
+	t := &transfer{progress: progress, limiters: limiters}
+
+	tt.lk.Lock()
+	defer tt.lk.Unlock()
+	if tt.running == nil {
+		tt.running = map[*transfer]struct{}{}
+	}
+	tt.running[t] = struct{}{}
+	return t
+}
+
+func (tt *transferTracker) finish(t *transfer) {
+	tt.lk.Lock()
+	defer tt.lk.Unlock()
+	delete(tt.running, t)
+}
+
+func (tt *transferTracker) list() []storiface.TransferProgress {
+	tt.lk.Lock()
+	defer tt.lk.Unlock()
+
+	out := make([]storiface.TransferProgress, 0, len(tt.running))
+	for t := range tt.running {
+		progress := t.progress
+		progress.Done = atomic.LoadInt64(&t.done)
+		progress.Resumed = atomic.LoadInt64(&t.resume)
+		out = append(out, progress)
+	}
+	return out
+}
@@ -0,0 +1,242 @@
+package stores
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+)
+
+// dirStore serves one cache directory
+type dirStore struct {
+	dir string
+}
+
+func (d *dirStore) AcquireSector(ctx context.Context, s storage.SectorRef, existing storiface.SectorFileType, allocate storiface.SectorFileType, sealing storiface.PathType, op storiface.AcquireMode) (storiface.SectorPaths, storiface.SectorPaths, error) {
+	return storiface.SectorPaths{ID: s.ID, Cache: d.dir}, storiface.SectorPaths{}, nil
+}
+
+func (d *dirStore) Remove(ctx context.Context, s abi.SectorID, types storiface.SectorFileType, force bool) error {
+	return nil
+}
+
+func (d *dirStore) RemoveCopies(ctx context.Context, s abi.SectorID, types storiface.SectorFileType) error {
+	return nil
+}
+
+func (d *dirStore) MoveStorage(ctx context.Context, s storage.SectorRef, types storiface.SectorFileType) error {
+	return nil
+}
+
+func (d *dirStore) FsStat(ctx context.Context, id ID) (fsutil.FsStat, error) {
+	return fsutil.FsStat{}, nil
+}
+
+func (d *dirStore) Reserve(ctx context.Context, sid storage.SectorRef, ft storiface.SectorFileType, storageIDs storiface.SectorPaths, overheadTab map[storiface.SectorFileType]int) (func(), error) {
+	return func() {}, nil
+}
+
+var _ Store = &dirStore{}
+
+func TestFetchResume(t *testing.T) {
+	src, err := ioutil.TempDir("", "TestFetchResume-src")
+	require.NoError(t, err)
+	defer os.RemoveAll(src) // nolint
+
+	files := map[string][]byte{
+		"p_aux":      bytes.Repeat([]byte("a"), 1000),
+		"t_aux":      bytes.Repeat([]byte("b"), 3000),
+		"sc-02-data": bytes.Repeat([]byte("c"), 5000),
+	}
+	for name, data := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(src, name), data, 0644))
+	}
+
+	ts := httptest.NewServer(&FetchHandler{Local: &dirStore{dir: src}})
+	defer ts.Close()
+
+	sid := abi.SectorID{Miner: 1000, Number: 1}
+	url := fmt.Sprintf("%s/remote/%s/%s", ts.URL, storiface.FTCache.String(), storiface.SectorName(sid))
+
+	dest, err := ioutil.TempDir("", "TestFetchResume-dest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest) // nolint
+	out := filepath.Join(dest, "cache")
+
+	// an interrupted transfer left part of a file and a stale file
+	require.NoError(t, os.MkdirAll(out, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(out, "sc-02-data"), files["sc-02-data"][:2000], 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(out, "p_aux"), files["p_aux"], 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(out, "stale"), []byte("x"), 0644))
+
+	// files are only hashed while they are served
+	manifest, err := buildManifest(src)
+	require.NoError(t, err)
+	for _, file := range manifest.Files {
+		require.Empty(t, file.Checksum, file.Name)
+	}
+
+	r := NewRemote(nil, nil, nil, 1, nil)
+	require.NoError(t, r.fetch(context.TODO(), sid, storiface.FTCache, url, out, ""))
+
+	manifest, err = buildManifest(src)
+	require.NoError(t, err)
+	for _, file := range manifest.Files {
+		require.NotEmpty(t, file.Checksum, file.Name)
+	}
+
+	got, err := ioutil.ReadDir(out)
+	require.NoError(t, err)
+	require.Len(t, got, len(files))
+	for name, data := range files {
+		b, err := ioutil.ReadFile(filepath.Join(out, name))
+		require.NoError(t, err)
+		require.Equal(t, data, b, name)
+	}
+	require.Empty(t, r.Transfers())
+
+	// corrupted resumed data fails the checksum and is removed
+	require.NoError(t, ioutil.WriteFile(filepath.Join(out, "t_aux"), bytes.Repeat([]byte("x"), 1000), 0644))
+	err = r.fetch(context.TODO(), sid, storiface.FTCache, url, out, "")
+	require.Error(t, err)
+	require.True(t, os.IsNotExist(statErr(filepath.Join(out, "t_aux"))))
+
+	// next attempt starts over
+	require.NoError(t, r.fetch(context.TODO(), sid, storiface.FTCache, url, out, ""))
+	b, err := ioutil.ReadFile(filepath.Join(out, "t_aux"))
+	require.NoError(t, err)
+	require.Equal(t, files["t_aux"], b)
+}
+
+func statErr(path string) error {
+	_, err := os.Stat(path)
+	return err
+}
+
+func TestTransferProgress(t *testing.T) {
+	var tt transferTracker
+
+	tr := tt.start(storiface.TransferProgress{Size: 100}, nil)
+	tr.resumed(40)
+	_, err := tr.Write(make([]byte, 10))
+	require.NoError(t, err)
+
+	list := tt.list()
+	require.Len(t, list, 1)
+	require.Equal(t, int64(50), list[0].Done)
+	require.Equal(t, int64(40), list[0].Resumed)
+
+	tt.finish(tr)
+	require.Empty(t, tt.list())
+}
+
+func TestFetchCompressed(t *testing.T) {
+	src, err := ioutil.TempDir("", "TestFetchCompressed-src")
+	require.NoError(t, err)
+	defer os.RemoveAll(src) // nolint
+
+	files := map[string][]byte{
+		"p_aux":      bytes.Repeat([]byte("a"), 1000),
+		"sc-02-data": bytes.Repeat([]byte("c"), 50000),
+	}
+	for name, data := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(src, name), data, 0644))
+	}
+
+	ts := httptest.NewServer(&FetchHandler{Local: &dirStore{dir: src}})
+	defer ts.Close()
+
+	sid := abi.SectorID{Miner: 1000, Number: 1}
+	url := fmt.Sprintf("%s/remote/%s/%s", ts.URL, storiface.FTCache.String(), storiface.SectorName(sid))
+
+	dest, err := ioutil.TempDir("", "TestFetchCompressed-dest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest) // nolint
+
+	r := NewRemote(nil, nil, nil, 1, nil)
+	r.SetCompression(true)
+
+	check := func(out string) {
+		for name, data := range files {
+			b, err := ioutil.ReadFile(filepath.Join(out, name))
+			require.NoError(t, err)
+			require.Equal(t, data, b, name)
+		}
+	}
+
+	// resumed file fetch, the range applies to the uncompressed data
+	out := filepath.Join(dest, "files")
+	require.NoError(t, os.MkdirAll(out, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(out, "sc-02-data"), files["sc-02-data"][:20000], 0644))
+	require.NoError(t, r.fetch(context.TODO(), sid, storiface.FTCache, url, out, ""))
+	check(out)
+
+	// compressed tar stream with checksum trailer
+	out = filepath.Join(dest, "tar")
+	var tt transferTracker
+	tr := tt.start(storiface.TransferProgress{}, nil)
+	require.NoError(t, r.fetchStream(context.TODO(), url, out, tr))
+	check(out)
+}
+
+func TestFetchStreamChecksum(t *testing.T) {
+	data := bytes.Repeat([]byte("s"), 4000)
+	sum := sha256.Sum256(data)
+
+	var trailer string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Trailer", ChecksumHeader)
+		w.WriteHeader(200)
+		_, _ = w.Write(data)
+		if trailer != "" {
+			w.Header().Set(ChecksumHeader, trailer)
+		}
+	}))
+	defer ts.Close()
+
+	dest, err := ioutil.TempDir("", "TestFetchStreamChecksum")
+	require.NoError(t, err)
+	defer os.RemoveAll(dest) // nolint
+	out := filepath.Join(dest, "sealed")
+
+	r := NewRemote(nil, nil, nil, 1, nil)
+	var tt transferTracker
+	fetch := func() error {
+		tr := tt.start(storiface.TransferProgress{}, nil)
+		defer tt.finish(tr)
+		return r.fetchStream(context.TODO(), ts.URL, out, tr)
+	}
+
+	// advertised but not sent
+	err = fetch()
+	require.True(t, xerrors.Is(err, errMissingChecksum), err)
+	require.True(t, os.IsNotExist(statErr(out)))
+
+	trailer = hex.EncodeToString(bytes.Repeat([]byte{1}, sha256.Size))
+	err = fetch()
+	require.True(t, xerrors.Is(err, errChecksumMismatch), err)
+	require.True(t, os.IsNotExist(statErr(out)))
+
+	trailer = hex.EncodeToString(sum[:])
+	require.NoError(t, fetch())
+	b, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, data, b)
+}
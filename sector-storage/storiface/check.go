@@ -0,0 +1,55 @@
+package storiface
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+	"github.com/ipfs/go-cid"
+)
+
+// CheckCode is the outcome of the provable check of a sector
+type CheckCode string
+
+const (
+	CheckOK CheckCode = "ok"
+
+	// CheckLocked the sector files are locked for writing
+	CheckLocked CheckCode = "locked"
+	// CheckNotFound no storage holds both the sealed and cache files
+	CheckNotFound CheckCode = "not-found"
+	// CheckUnreachable the sector index or the storages holding the sector couldn't be asked to check it
+	CheckUnreachable CheckCode = "unreachable"
+	// CheckMissingFile a sealed or cache file can't be read
+	CheckMissingFile CheckCode = "missing-file"
+	// CheckWrongSize a sealed or cache file has an unexpected size
+	CheckWrongSize CheckCode = "wrong-size"
+	// CheckCommR the commR of the sector is unknown
+	CheckCommR CheckCode = "commr"
+	// CheckVanillaProof no vanilla proof could be generated from the files
+	CheckVanillaProof CheckCode = "vanilla-proof"
+)
+
+// SectorCheck is the result of the provable check of a sector
+type SectorCheck struct {
+	Sector abi.SectorID
+	Code   CheckCode
+	// Error details a failed check
+	Error string
+	// Path is the file which failed the check, the sealed file of a provable sector
+	Path string
+	// Remote is the storage URL the check was delegated to, empty when checked by the sealer
+	Remote string
+	Took   time.Duration
+}
+
+func (c SectorCheck) Faulty() bool {
+	return c.Code != CheckOK
+}
+
+// CheckRequest asks the holder of a sector to check its files, a vanilla proof is generated when SealedCID is set
+type CheckRequest struct {
+	Sector    storage.SectorRef
+	PoStProof abi.RegisteredPoStProof
+	SealedCID *cid.Cid
+}
@@ -0,0 +1,11 @@
+package storiface
+
+// SectorKind tells sectors holding deals from committed capacity ones, storages can be restricted to a kind
+type SectorKind string
+
+const (
+	KindCC   SectorKind = "cc"
+	KindDeal SectorKind = "deal"
+)
+
+var SectorKinds = []SectorKind{KindCC, KindDeal}
@@ -0,0 +1,31 @@
+package storiface
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// TransferProgress is the progress of fetching a sector file from a remote store
+type TransferProgress struct {
+	Sector   abi.SectorID
+	FileType SectorFileType
+	URL      string
+
+	// Size is the total size of the transfer, 0 when the remote doesn't send a manifest
+	Size int64
+	// Done counts bytes on disk, including Resumed
+	Done int64
+	// Resumed is the size of the data kept from an interrupted transfer
+	Resumed int64
+	Start   time.Time
+}
+
+// Rate returns the transfer rate in bytes per second, resumed data is not counted
+func (p TransferProgress) Rate() float64 {
+	elapsed := time.Since(p.Start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Done-p.Resumed) / elapsed
+}
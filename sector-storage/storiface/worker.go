@@ -10,6 +10,7 @@ import (
 	"github.com/ipfs/go-cid"
 
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/actors/runtime/proof"
 	"github.com/filecoin-project/specs-storage/storage"
 )
 
@@ -64,6 +65,8 @@ type WorkerJob struct {
 	Start   time.Time
 
 	Hostname string `json:",omitempty"` // optional, set for ret-wait jobs
+
+	Transfer *TransferProgress `json:",omitempty"` // set while the worker fetches sector files for the job
 }
 
 type WorkerCalls interface {
@@ -77,6 +80,26 @@ type WorkerCalls interface {
 	MoveStorage(ctx context.Context, sector storage.SectorRef, types SectorFileType) (types.CallID, error)
 	UnsealPiece(context.Context, storage.SectorRef, UnpaddedByteIndex, abi.UnpaddedPieceSize, abi.SealRandomness, cid.Cid) (types.CallID, error)
 	Fetch(context.Context, storage.SectorRef, SectorFileType, PathType, AcquireMode) (types.CallID, error)
+
+	GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error)
+	// GenerateWindowPoStVanilla generates the vanilla proofs of the sectors, the window post is computed from the
+	// vanilla proofs of all sectors by the sealer
+	GenerateWindowPoStVanilla(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error)
+	GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error)
+}
+
+// WindowPoStResult is the outcome of a window post computed by a worker, Skipped are the sectors the worker
+// couldn't read or found faulty
+type WindowPoStResult struct {
+	Proofs  []proof.PoStProof
+	Skipped []abi.SectorID
+}
+
+// WindowPoStVanillaResult holds the vanilla proofs of Sectors generated by a worker, in the same order
+type WindowPoStVanillaResult struct {
+	Sectors []abi.SectorNumber
+	Proofs  [][]byte
+	Skipped []abi.SectorID
 }
 
 type ErrorCode int
@@ -131,4 +154,7 @@ type WorkerReturn interface {
 	ReturnUnsealPiece(ctx context.Context, callID types.CallID, err *CallError) error
 	ReturnReadPiece(ctx context.Context, callID types.CallID, ok bool, err *CallError) error
 	ReturnFetch(ctx context.Context, callID types.CallID, err *CallError) error
+	ReturnGenerateWindowPoSt(ctx context.Context, callID types.CallID, res WindowPoStResult, err *CallError) error
+	ReturnGenerateWindowPoStVanilla(ctx context.Context, callID types.CallID, res WindowPoStVanillaResult, err *CallError) error
+	ReturnGenerateWinningPoSt(ctx context.Context, callID types.CallID, proofs []proof.PoStProof, err *CallError) error
 }
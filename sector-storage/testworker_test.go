@@ -102,6 +102,10 @@ func (t *testWorker) Paths(ctx context.Context) ([]stores.StoragePath, error) {
 	return t.lstor.Local(ctx)
 }
 
+func (t *testWorker) Transfers(ctx context.Context) ([]storiface.TransferProgress, error) {
+	return nil, nil
+}
+
 func (t *testWorker) Info(ctx context.Context) (storiface.WorkerInfo, error) {
 	res := ResourceTable[types.TTPreCommit2][abi.RegisteredSealProof_StackedDrg2KiBV1]
 
@@ -20,6 +20,7 @@ import (
 	ffi "github.com/filecoin-project/filecoin-ffi"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-statestore"
+	"github.com/filecoin-project/specs-actors/actors/runtime/proof"
 	"github.com/filecoin-project/specs-storage/storage"
 
 	"github.com/filecoin-project/venus-sealer/sector-storage/ffiwrapper"
@@ -157,6 +158,11 @@ func (l *LocalWorker) ffiExec() (ffiwrapper.Storage, error) {
 	return ffiwrapper.New(&localWorkerPathProvider{w: l})
 }
 
+// postExec proves sectors from the local stores only, sectors are never fetched for a post
+func (l *LocalWorker) postExec() (*ffiwrapper.Sealer, error) {
+	return ffiwrapper.New(&readonlyProvider{index: l.sindex, stor: l.localStore})
+}
+
 // in: func(WorkerReturn, context.Context, CallID, err string)
 // in: func(WorkerReturn, context.Context, CallID, ret T, err string)
 func rfunc(in interface{}) func(context.Context, types.CallID, storiface.WorkerReturn, interface{}, *storiface.CallError) error {
@@ -202,6 +208,10 @@ var returnFunc = map[types.ReturnType]func(context.Context, types.CallID, storif
 	types.ReturnMoveStorage:     rfunc(storiface.WorkerReturn.ReturnMoveStorage),
 	types.ReturnUnsealPiece:     rfunc(storiface.WorkerReturn.ReturnUnsealPiece),
 	types.ReturnFetch:           rfunc(storiface.WorkerReturn.ReturnFetch),
+
+	types.ReturnGenerateWindowPoSt:        rfunc(storiface.WorkerReturn.ReturnGenerateWindowPoSt),
+	types.ReturnGenerateWindowPoStVanilla: rfunc(storiface.WorkerReturn.ReturnGenerateWindowPoStVanilla),
+	types.ReturnGenerateWinningPoSt:       rfunc(storiface.WorkerReturn.ReturnGenerateWinningPoSt),
 }
 
 func (l *LocalWorker) asyncCall(ctx context.Context, sector storage.SectorRef, rt types.ReturnType, work func(ctx context.Context, ci types.CallID) (interface{}, error)) (types.CallID, error) {
@@ -388,6 +398,64 @@ func (l *LocalWorker) FinalizeSector(ctx context.Context, sector storage.SectorR
 	})
 }
 
+func (l *LocalWorker) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	if len(sectorInfo) == 0 {
+		return types.UndefCall, xerrors.New("no sectors to prove")
+	}
+
+	sb, err := l.postExec()
+	if err != nil {
+		return types.UndefCall, err
+	}
+
+	sector := storage.SectorRef{
+		ID:        abi.SectorID{Miner: minerID, Number: sectorInfo[0].SectorNumber},
+		ProofType: sectorInfo[0].SealProof,
+	}
+	return l.asyncCall(ctx, sector, types.ReturnGenerateWindowPoSt, func(ctx context.Context, ci types.CallID) (interface{}, error) {
+		proofs, skipped, err := sb.GenerateWindowPoSt(types.WithPriority(ctx, types.WindowPoStPriority), minerID, sectorInfo, append(abi.PoStRandomness{}, randomness...))
+		return storiface.WindowPoStResult{Proofs: proofs, Skipped: skipped}, err
+	})
+}
+
+func (l *LocalWorker) GenerateWindowPoStVanilla(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	if len(sectorInfo) == 0 {
+		return types.UndefCall, xerrors.New("no sectors to prove")
+	}
+
+	sb, err := l.postExec()
+	if err != nil {
+		return types.UndefCall, err
+	}
+
+	sector := storage.SectorRef{
+		ID:        abi.SectorID{Miner: minerID, Number: sectorInfo[0].SectorNumber},
+		ProofType: sectorInfo[0].SealProof,
+	}
+	return l.asyncCall(ctx, sector, types.ReturnGenerateWindowPoStVanilla, func(ctx context.Context, ci types.CallID) (interface{}, error) {
+		return sb.GenerateWindowPoStVanilla(types.WithPriority(ctx, types.WindowPoStPriority), minerID, sectorInfo, append(abi.PoStRandomness{}, randomness...))
+	})
+}
+
+func (l *LocalWorker) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	if len(sectorInfo) == 0 {
+		return types.UndefCall, xerrors.New("no sectors to prove")
+	}
+
+	sb, err := l.postExec()
+	if err != nil {
+		return types.UndefCall, err
+	}
+
+	sector := storage.SectorRef{
+		ID:        abi.SectorID{Miner: minerID, Number: sectorInfo[0].SectorNumber},
+		ProofType: sectorInfo[0].SealProof,
+	}
+	return l.asyncCall(ctx, sector, types.ReturnGenerateWinningPoSt, func(ctx context.Context, ci types.CallID) (interface{}, error) {
+		return sb.GenerateWinningPoSt(types.WithPriority(ctx, types.WinningPoStPriority), minerID, sectorInfo, append(abi.PoStRandomness{}, randomness...))
+	})
+}
+
 func (l *LocalWorker) ReleaseUnsealed(ctx context.Context, sector storage.SectorRef, safeToFree []storage.Range) (types.CallID, error) {
 	return types.UndefCall, xerrors.Errorf("implement me")
 }
@@ -467,6 +535,13 @@ func (l *LocalWorker) Paths(ctx context.Context) ([]stores.StoragePath, error) {
 	return l.localStore.Local(ctx)
 }
 
+func (l *LocalWorker) Transfers(context.Context) ([]storiface.TransferProgress, error) {
+	if r, ok := l.storage.(*stores.Remote); ok {
+		return r.Transfers(), nil
+	}
+	return nil, nil
+}
+
 func (l *LocalWorker) Info(context.Context) (storiface.WorkerInfo, error) {
 	hostname, err := os.Hostname() // TODO: allow overriding from config
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 	"github.com/ipfs/go-cid"
 
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/actors/runtime/proof"
 	"github.com/filecoin-project/specs-storage/storage"
 
 	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
@@ -25,6 +26,8 @@ type workTracker struct {
 
 	done    map[types.CallID]struct{}
 	running map[types.CallID]trackedWork
+	// hostname of the worker which got the latest sealing task of a sector
+	last map[abi.SectorID]string
 
 	// TODO: done, aggregate stats, queue stats, scheduler feedback
 }
@@ -51,6 +54,10 @@ func (wt *workTracker) track(ctx context.Context, wid WorkerID, wi storiface.Wor
 		wt.lk.Lock()
 		defer wt.lk.Unlock()
 
+		if task != types.TTFetch && task != types.TTGenerateWindowPoSt && task != types.TTGenerateWinningPoSt {
+			wt.last[sid.ID] = wi.Hostname
+		}
+
 		_, done := wt.done[callID]
 		if done {
 			delete(wt.done, callID)
@@ -94,6 +101,14 @@ func (wt *workTracker) Running() []trackedWork {
 	return out
 }
 
+// lastWorker returns the hostname of the worker which got the latest sealing task of the sector
+func (wt *workTracker) lastWorker(sid abi.SectorID) string {
+	wt.lk.Lock()
+	defer wt.lk.Unlock()
+
+	return wt.last[sid]
+}
+
 type trackedWorker struct {
 	Worker
 	wid        WorkerID
@@ -134,4 +149,31 @@ func (t *trackedWorker) UnsealPiece(ctx context.Context, id storage.SectorRef, i
 	return t.tracker.track(ctx, t.wid, t.workerInfo, id, types.TTUnseal)(t.Worker.UnsealPiece(ctx, id, index, size, randomness, cid))
 }
 
+func (t *trackedWorker) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	sector := storage.SectorRef{ID: abi.SectorID{Miner: minerID}}
+	if len(sectorInfo) > 0 {
+		sector.ID.Number = sectorInfo[0].SectorNumber
+		sector.ProofType = sectorInfo[0].SealProof
+	}
+	return t.tracker.track(ctx, t.wid, t.workerInfo, sector, types.TTGenerateWindowPoSt)(t.Worker.GenerateWindowPoSt(ctx, minerID, sectorInfo, randomness))
+}
+
+func (t *trackedWorker) GenerateWindowPoStVanilla(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	sector := storage.SectorRef{ID: abi.SectorID{Miner: minerID}}
+	if len(sectorInfo) > 0 {
+		sector.ID.Number = sectorInfo[0].SectorNumber
+		sector.ProofType = sectorInfo[0].SealProof
+	}
+	return t.tracker.track(ctx, t.wid, t.workerInfo, sector, types.TTGenerateWindowPoSt)(t.Worker.GenerateWindowPoStVanilla(ctx, minerID, sectorInfo, randomness))
+}
+
+func (t *trackedWorker) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.SectorInfo, randomness abi.PoStRandomness) (types.CallID, error) {
+	sector := storage.SectorRef{ID: abi.SectorID{Miner: minerID}}
+	if len(sectorInfo) > 0 {
+		sector.ID.Number = sectorInfo[0].SectorNumber
+		sector.ProofType = sectorInfo[0].SealProof
+	}
+	return t.tracker.track(ctx, t.wid, t.workerInfo, sector, types.TTGenerateWinningPoSt)(t.Worker.GenerateWinningPoSt(ctx, minerID, sectorInfo, randomness))
+}
+
 var _ Worker = &trackedWorker{}
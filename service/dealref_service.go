@@ -1,6 +1,12 @@
 package service
 
 import (
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/piecestore"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/specs-actors/v2/actors/builtin/market"
 	"github.com/filecoin-project/venus-sealer/models/repo"
 	"github.com/filecoin-project/venus-sealer/types"
@@ -31,3 +37,58 @@ func (d *DealRefService) Has(dealId uint64) (bool, error) {
 func (d *DealRefService) List() (map[uint64][]types.SealedRef, error) {
 	return d.DealRefRepo.List()
 }
+
+// GetPieceInfo returns the deals and sector locations of a piece
+func (d *DealRefService) GetPieceInfo(pieceCid cid.Cid) (*piecestore.PieceInfo, error) {
+	refs, err := d.DealRefRepo.GetByPieceCid(pieceCid)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, xerrors.Errorf("piece with CID %s: %w", pieceCid, retrievalmarket.ErrNotFound)
+	}
+
+	pi := &piecestore.PieceInfo{PieceCID: pieceCid}
+	for _, ref := range refs {
+		pi.Deals = append(pi.Deals, piecestore.DealInfo{
+			DealID:   abi.DealID(ref.DealID),
+			SectorID: ref.SectorID,
+			Offset:   ref.Offset,
+			Length:   ref.Size.Padded(),
+		})
+	}
+	return pi, nil
+}
+
+// GetCIDInfo returns the pieces containing a payload. the payload cid is the deal label root, blocks are not
+// indexed so every location covers the whole piece
+func (d *DealRefService) GetCIDInfo(payloadCid cid.Cid) (*piecestore.CIDInfo, error) {
+	refs, err := d.DealRefRepo.GetByPayloadCid(payloadCid)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, xerrors.Errorf("payload CID %s: %w", payloadCid, retrievalmarket.ErrNotFound)
+	}
+
+	ci := &piecestore.CIDInfo{CID: payloadCid}
+	seen := map[cid.Cid]struct{}{}
+	for _, ref := range refs {
+		if ref.PieceCID == nil {
+			continue
+		}
+		if _, ok := seen[*ref.PieceCID]; ok {
+			continue
+		}
+		seen[*ref.PieceCID] = struct{}{}
+
+		ci.PieceBlockLocations = append(ci.PieceBlockLocations, piecestore.PieceBlockLocation{
+			BlockLocation: piecestore.BlockLocation{
+				RelOffset: 0,
+				BlockSize: uint64(ref.Size),
+			},
+			PieceCID: *ref.PieceCID,
+		})
+	}
+	return ci, nil
+}
@@ -1,6 +1,13 @@
 package service
 
-import "github.com/filecoin-project/venus-sealer/models/repo"
+import (
+	"sort"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/models/repo"
+)
 
 type LogService struct {
 	repo.LogRepo
@@ -9,3 +16,43 @@ type LogService struct {
 func NewLogService(repo repo.Repo) *LogService {
 	return &LogService{LogRepo: repo.LogRepo()}
 }
+
+// MeasureSealDuration returns the median time the latest sectors took from their first log to a log of doneKind,
+// samples is the max number of sectors measured. returns 0 if no sector has a log of doneKind yet
+func (logService *LogService) MeasureSealDuration(doneKind string, samples int) (time.Duration, error) {
+	doneLogs, err := logService.ListByKind(doneKind, samples)
+	if err != nil {
+		return 0, err
+	}
+
+	var durations []time.Duration
+	measured := map[abi.SectorNumber]struct{}{}
+	for _, done := range doneLogs {
+		if _, ok := measured[done.SectorNumber]; ok {
+			continue
+		}
+		measured[done.SectorNumber] = struct{}{}
+
+		logs, err := logService.List(done.SectorNumber)
+		if err != nil {
+			return 0, err
+		}
+
+		start := done.Timestamp
+		for _, l := range logs {
+			if l.Timestamp < start {
+				start = l.Timestamp
+			}
+		}
+		durations = append(durations, time.Duration(done.Timestamp-start)*time.Second)
+	}
+
+	if len(durations) == 0 {
+		return 0, nil
+	}
+
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+	return durations[len(durations)/2], nil
+}
@@ -1,6 +1,11 @@
 package service
 
 import (
+	"sync"
+
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/venus-sealer/models/repo"
@@ -11,6 +16,8 @@ var _ types.SectorIDCounter = (*MetadataService)(nil)
 
 type MetadataService struct {
 	repo.MetaDataRepo
+
+	policyLk sync.Mutex
 }
 
 func NewMetadataService(repo repo.Repo) *MetadataService {
@@ -32,3 +39,25 @@ func (metadataService *MetadataService) SaveMinerAddress(mAddr address.Address)
 func (metadataService *MetadataService) Next() (abi.SectorNumber, error) {
 	return metadataService.MetaDataRepo.IncreaseStorageCounter()
 }
+
+// GetDealPolicy returns the saved deal policy, or the default policy if none was saved
+func (metadataService *MetadataService) GetDealPolicy() (*types.DealPolicy, error) {
+	policy, err := metadataService.MetaDataRepo.GetDealPolicy()
+	if xerrors.Is(err, gorm.ErrRecordNotFound) {
+		return types.DefaultDealPolicy(), nil
+	}
+	return policy, err
+}
+
+// UpdateDealPolicy applies update to the current deal policy and saves it
+func (metadataService *MetadataService) UpdateDealPolicy(update func(policy *types.DealPolicy)) error {
+	metadataService.policyLk.Lock()
+	defer metadataService.policyLk.Unlock()
+
+	policy, err := metadataService.GetDealPolicy()
+	if err != nil {
+		return err
+	}
+	update(policy)
+	return metadataService.MetaDataRepo.SaveDealPolicy(policy)
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// chainStages don't run on workers, their stats are grouped under an empty worker
+var chainStages = map[string]bool{
+	types.StagePreCommitLanding: true,
+	types.StageCommitLanding:    true,
+}
+
+// stageOf returns the sealing stage a sector in state is in, afterCommit1 tells whether Commit1 of a Committing
+// sector is done
+func stageOf(state types.SectorState, afterCommit1 bool) string {
+	switch state {
+	case types.AddPiece, types.Packing:
+		return types.StageAddPiece
+	case types.PreCommit1:
+		return types.StagePreCommit1
+	case types.PreCommit2:
+		return types.StagePreCommit2
+	case types.PreCommitting, types.SubmitPreCommitBatch, types.PreCommitBatchWait, types.PreCommitWait:
+		return types.StagePreCommitLanding
+	case types.Committing:
+		if afterCommit1 {
+			return types.StageCommit2
+		}
+		return types.StageCommit1
+	case types.SubmitCommit, types.CommitWait, types.SubmitCommitAggregate, types.CommitAggregateWait:
+		return types.StageCommitLanding
+	case types.FinalizeSector:
+		return types.StageFinalize
+	}
+	return ""
+}
+
+type statsKey struct {
+	worker string
+	size   abi.SectorSize
+}
+
+type stageRuns struct {
+	durations []time.Duration
+	failures  int
+}
+
+func (r *stageRuns) stats(stage string) types.StageStats {
+	sort.Slice(r.durations, func(i, j int) bool {
+		return r.durations[i] < r.durations[j]
+	})
+
+	out := types.StageStats{
+		Stage:    stage,
+		Count:    len(r.durations),
+		Failures: r.failures,
+	}
+	if total := out.Count + out.Failures; total > 0 {
+		out.FailureRate = float64(out.Failures) / float64(total)
+	}
+	if out.Count > 0 {
+		out.P50 = percentile(r.durations, 50)
+		out.P90 = percentile(r.durations, 90)
+		out.P99 = percentile(r.durations, 99)
+		out.Max = r.durations[out.Count-1]
+	}
+	return out
+}
+
+// percentile of sorted durations, nearest rank
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (len(sorted)*p + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// running is a stage a sector is in, spanning one or more events
+type running struct {
+	stage  string
+	took   time.Duration
+	worker string
+}
+
+// SealingStats computes the stage durations, throughput and failure rates from the sector events recorded in
+// [since, until), sizes are the sector sizes by number. A zero since starts at the first recorded event
+func (sectorEventService *SectorEventService) SealingStats(since, until time.Time, sizes map[abi.SectorNumber]abi.SectorSize) (*types.SealingStats, error) {
+	if until.IsZero() {
+		until = time.Now()
+	}
+	events, err := sectorEventService.Query(&types.SectorEventFilter{Since: since, Until: until})
+	if err != nil {
+		return nil, err
+	}
+	if since.IsZero() && len(events) > 0 {
+		since = events[0].Time
+	}
+	return sealingStats(events, sizes, since, until), nil
+}
+
+func sealingStats(events []*types.SectorEvent, sizes map[abi.SectorNumber]abi.SectorSize, since, until time.Time) *types.SealingStats {
+	out := &types.SealingStats{Since: since, Until: until}
+
+	runs := map[statsKey]map[string]*stageRuns{}
+	completed := map[statsKey]int{}
+	add := func(key statsKey, stage string) *stageRuns {
+		if runs[key] == nil {
+			runs[key] = map[string]*stageRuns{}
+		}
+		if runs[key][stage] == nil {
+			runs[key][stage] = &stageRuns{}
+		}
+		return runs[key][stage]
+	}
+
+	current := map[abi.SectorNumber]*running{}
+	afterCommit1 := map[abi.SectorNumber]bool{}
+	for _, event := range events {
+		size := sizes[event.SectorNumber]
+		if event.To == types.Proving && event.From != types.Proving {
+			out.Completed++
+			completed[statsKey{worker: event.Worker, size: size}]++
+		}
+
+		stage := stageOf(event.From, afterCommit1[event.SectorNumber])
+		if event.Event == types.SectorEventCommit1Done {
+			afterCommit1[event.SectorNumber] = true
+		} else if event.To != types.Committing {
+			delete(afterCommit1, event.SectorNumber)
+		}
+		if stage == "" {
+			delete(current, event.SectorNumber)
+			continue
+		}
+
+		run := current[event.SectorNumber]
+		if run == nil || run.stage != stage {
+			run = &running{stage: stage}
+			current[event.SectorNumber] = run
+		}
+		run.took += event.Duration
+		if !chainStages[stage] {
+			run.worker = event.Worker
+		}
+
+		next := stageOf(event.To, afterCommit1[event.SectorNumber])
+		switch {
+		case event.ErrorClass != "":
+			add(statsKey{worker: run.worker, size: size}, stage).failures++
+		case next != stage:
+			r := add(statsKey{worker: run.worker, size: size}, stage)
+			r.durations = append(r.durations, run.took)
+		default:
+			continue
+		}
+		delete(current, event.SectorNumber)
+	}
+
+	if hours := until.Sub(since).Hours(); hours > 0 {
+		out.PerHour = float64(out.Completed) / hours
+		out.PerDay = out.PerHour * 24
+	}
+
+	keys := make([]statsKey, 0, len(runs))
+	for key := range runs {
+		keys = append(keys, key)
+	}
+	for key := range completed {
+		if runs[key] == nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].worker != keys[j].worker {
+			return keys[i].worker < keys[j].worker
+		}
+		return keys[i].size < keys[j].size
+	})
+
+	all := map[string]*stageRuns{}
+	for _, key := range keys {
+		group := types.SealingStatsGroup{
+			Worker:     key.worker,
+			SectorSize: key.size,
+			Completed:  completed[key],
+		}
+		for _, stage := range types.SealingStages {
+			r, ok := runs[key][stage]
+			if !ok {
+				continue
+			}
+			group.Stages = append(group.Stages, r.stats(stage))
+
+			if all[stage] == nil {
+				all[stage] = &stageRuns{}
+			}
+			all[stage].durations = append(all[stage].durations, r.durations...)
+			all[stage].failures += r.failures
+		}
+		out.Groups = append(out.Groups, group)
+	}
+	for _, stage := range types.SealingStages {
+		if r, ok := all[stage]; ok {
+			out.Stages = append(out.Stages, r.stats(stage))
+		}
+	}
+
+	return out
+}
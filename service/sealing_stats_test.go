@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func TestSealingStats(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	ev := func(sector abi.SectorNumber, event string, from, to types.SectorState, worker string, took time.Duration) *types.SectorEvent {
+		return &types.SectorEvent{SectorNumber: sector, Event: event, From: from, To: to, Worker: worker, Duration: took}
+	}
+
+	events := []*types.SectorEvent{
+		ev(1, "SectorStartCC", types.UndefinedSectorState, types.Packing, "", 0),
+		ev(1, "SectorPacked", types.Packing, types.GetTicket, "w1", time.Minute),
+		ev(1, "SectorTicket", types.GetTicket, types.PreCommit1, "w1", time.Second),
+		ev(1, "SectorPreCommit1", types.PreCommit1, types.PreCommit2, "w1", 3*time.Hour),
+		ev(1, "SectorPreCommit2", types.PreCommit2, types.PreCommitting, "w1", 20*time.Minute),
+		ev(1, "SectorPreCommitted", types.PreCommitting, types.PreCommitWait, "w1", time.Minute),
+		ev(1, "SectorPreCommitLanded", types.PreCommitWait, types.WaitSeed, "w1", 2*time.Minute),
+		ev(1, "SectorSeedReady", types.WaitSeed, types.Committing, "w1", 75*time.Minute),
+		ev(1, types.SectorEventCommit1Done, types.Committing, types.Committing, "w1", time.Minute),
+		ev(1, "SectorCommitted", types.Committing, types.CommitWait, "w2", 10*time.Minute),
+		ev(1, "SectorProving", types.CommitWait, types.FinalizeSector, "w2", 3*time.Minute),
+		ev(1, "SectorFinalized", types.FinalizeSector, types.Proving, "w1", 2*time.Minute),
+
+		ev(2, "SectorPreCommit1", types.PreCommit1, types.PreCommit2, "w1", time.Hour),
+		ev(2, "SectorSealPreCommit2Failed", types.PreCommit2, types.SealPreCommit2Failed, "w1", time.Minute),
+		ev(2, "SectorRetrySealPreCommit2", types.SealPreCommit2Failed, types.PreCommit2, "w1", time.Minute),
+		ev(2, "SectorPreCommit2", types.PreCommit2, types.PreCommitting, "w1", 30*time.Minute),
+	}
+	events[13].ErrorClass = "pc2"
+
+	sizes := map[abi.SectorNumber]abi.SectorSize{1: 32 << 30, 2: 32 << 30}
+	stats := sealingStats(events, sizes, start, start.Add(2*time.Hour))
+
+	require.Equal(t, 1, stats.Completed)
+	require.Equal(t, 0.5, stats.PerHour)
+	require.Equal(t, 12.0, stats.PerDay)
+
+	byStage := map[string]types.StageStats{}
+	for _, st := range stats.Stages {
+		byStage[st.Stage] = st
+	}
+	require.Equal(t, time.Minute, byStage[types.StageAddPiece].P50)
+	require.Equal(t, 2, byStage[types.StagePreCommit1].Count)
+	require.Equal(t, time.Hour, byStage[types.StagePreCommit1].P50)
+	require.Equal(t, 3*time.Hour, byStage[types.StagePreCommit1].Max)
+	require.Equal(t, 2, byStage[types.StagePreCommit2].Count)
+	require.Equal(t, 1, byStage[types.StagePreCommit2].Failures)
+	require.InDelta(t, 1.0/3, byStage[types.StagePreCommit2].FailureRate, 1e-9)
+	require.Equal(t, 3*time.Minute, byStage[types.StagePreCommitLanding].P99)
+	require.Equal(t, time.Minute, byStage[types.StageCommit1].P50)
+	require.Equal(t, 10*time.Minute, byStage[types.StageCommit2].P50)
+	require.Equal(t, 3*time.Minute, byStage[types.StageCommitLanding].P50)
+	require.Equal(t, 2*time.Minute, byStage[types.StageFinalize].P50)
+
+	// chain stages are grouped under the empty worker, commit2 ran on w2
+	require.Len(t, stats.Groups, 3)
+	require.Equal(t, "", stats.Groups[0].Worker)
+	require.Len(t, stats.Groups[0].Stages, 2)
+	require.Equal(t, "w1", stats.Groups[1].Worker)
+	require.Equal(t, 1, stats.Groups[1].Completed)
+	require.Equal(t, "w2", stats.Groups[2].Worker)
+	require.Equal(t, types.StageCommit2, stats.Groups[2].Stages[0].Stage)
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i + 1)
+	}
+	require.Equal(t, time.Duration(50), percentile(sorted, 50))
+	require.Equal(t, time.Duration(99), percentile(sorted, 99))
+	require.Equal(t, time.Duration(1), percentile(sorted[:1], 99))
+}
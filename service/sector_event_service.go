@@ -0,0 +1,38 @@
+package service
+
+import (
+	"time"
+
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// SectorEventService keeps the structured history of sector state transitions
+type SectorEventService struct {
+	repo.SectorEventRepo
+}
+
+func NewSectorEventService(repo repo.Repo) *SectorEventService {
+	return &SectorEventService{SectorEventRepo: repo.SectorEventRepo()}
+}
+
+// Record appends the event, its duration is the time spent since the previous event of the sector
+func (sectorEventService *SectorEventService) Record(event *types.SectorEvent) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	prev, err := sectorEventService.Latest(event.SectorNumber)
+	switch {
+	case err == nil:
+		event.Duration = event.Time.Sub(prev.Time)
+	case xerrors.Is(err, gorm.ErrRecordNotFound):
+	default:
+		return xerrors.Errorf("get latest event of sector %d: %w", event.SectorNumber, err)
+	}
+
+	return sectorEventService.Append(event)
+}
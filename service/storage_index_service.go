@@ -0,0 +1,137 @@
+package service
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+var _ stores.IndexStore = (*StorageIndexService)(nil)
+
+// StorageIndexService persists the sector storage index in the sealer database
+type StorageIndexService struct {
+	repo repo.StorageIndexRepo
+}
+
+func NewStorageIndexService(repo repo.Repo) *StorageIndexService {
+	return &StorageIndexService{repo: repo.StorageIndexRepo()}
+}
+
+func (storageIndexService *StorageIndexService) SaveStorage(st stores.PersistedStorage) error {
+	return storageIndexService.repo.SaveStorage(&types.StorageRecord{
+		ID:            string(st.Info.ID),
+		URLs:          st.Info.URLs,
+		Weight:        st.Info.Weight,
+		MaxStorage:    st.Info.MaxStorage,
+		CanSeal:       st.Info.CanSeal,
+		CanStore:      st.Info.CanStore,
+		Groups:        st.Info.Groups,
+		AllowTo:       st.Info.AllowTo,
+		AllowTypes:    st.Info.AllowTypes,
+		DenyTypes:     st.Info.DenyTypes,
+		AllowKinds:    kindNames(st.Info.AllowKinds),
+		LastHeartbeat: st.LastHeartbeat,
+		HeartbeatErr:  st.HeartbeatErr,
+	})
+}
+
+func (storageIndexService *StorageIndexService) UpdateHeartbeat(id stores.ID, at time.Time, heartbeatErr string) error {
+	return storageIndexService.repo.UpdateHeartbeat(string(id), at, heartbeatErr)
+}
+
+func (storageIndexService *StorageIndexService) ListStorages() ([]stores.PersistedStorage, error) {
+	records, err := storageIndexService.repo.ListStorages()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]stores.PersistedStorage, len(records))
+	for i, rec := range records {
+		out[i] = stores.PersistedStorage{
+			Info: stores.StorageInfo{
+				ID:         stores.ID(rec.ID),
+				URLs:       rec.URLs,
+				Weight:     rec.Weight,
+				MaxStorage: rec.MaxStorage,
+				CanSeal:    rec.CanSeal,
+				CanStore:   rec.CanStore,
+
+				PlacementRules: stores.PlacementRules{
+					Groups:     rec.Groups,
+					AllowTo:    rec.AllowTo,
+					AllowTypes: rec.AllowTypes,
+					DenyTypes:  rec.DenyTypes,
+					AllowKinds: sectorKinds(rec.AllowKinds),
+				},
+			},
+			LastHeartbeat: rec.LastHeartbeat,
+			HeartbeatErr:  rec.HeartbeatErr,
+		}
+	}
+	return out, nil
+}
+
+func (storageIndexService *StorageIndexService) DeclareSector(decl stores.PersistedDecl) error {
+	return storageIndexService.repo.DeclareSector(declRecord(decl))
+}
+
+func (storageIndexService *StorageIndexService) DropSector(decl stores.PersistedDecl) error {
+	return storageIndexService.repo.DropSector(declRecord(decl))
+}
+
+func (storageIndexService *StorageIndexService) ListSectorDecls() ([]stores.PersistedDecl, error) {
+	records, err := storageIndexService.repo.ListSectorDecls()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]stores.PersistedDecl, len(records))
+	for i, rec := range records {
+		out[i] = stores.PersistedDecl{
+			Decl: stores.Decl{
+				SectorID:       abi.SectorID{Miner: rec.Miner, Number: rec.Number},
+				SectorFileType: storiface.SectorFileType(rec.FileType),
+			},
+			Storage: stores.ID(rec.StorageID),
+			Primary: rec.Primary,
+		}
+	}
+	return out, nil
+}
+
+func declRecord(decl stores.PersistedDecl) *types.SectorDeclRecord {
+	return &types.SectorDeclRecord{
+		StorageID: string(decl.Storage),
+		Miner:     decl.Miner,
+		Number:    decl.Number,
+		FileType:  int(decl.SectorFileType),
+		Primary:   decl.Primary,
+	}
+}
+
+func kindNames(kinds []storiface.SectorKind) []string {
+	if kinds == nil {
+		return nil
+	}
+	out := make([]string, len(kinds))
+	for i, kind := range kinds {
+		out[i] = string(kind)
+	}
+	return out
+}
+
+func sectorKinds(names []string) []storiface.SectorKind {
+	if names == nil {
+		return nil
+	}
+	out := make([]storiface.SectorKind, len(names))
+	for i, name := range names {
+		out[i] = storiface.SectorKind(name)
+	}
+	return out
+}
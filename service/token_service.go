@@ -0,0 +1,100 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// TokenService keeps the minted api tokens, tokens are cached so that verifying a token doesn't hit the db
+type TokenService struct {
+	repo.TokenRepo
+
+	lk     sync.Mutex
+	tokens map[string]*types.TokenInfo
+}
+
+func NewTokenService(repo repo.Repo) *TokenService {
+	return &TokenService{
+		TokenRepo: repo.TokenRepo(),
+		tokens:    map[string]*types.TokenInfo{},
+	}
+}
+
+// Create saves a new token, a zero ttl never expires
+func (tokenService *TokenService) Create(name string, perms, methods []string, ttl time.Duration) (*types.TokenInfo, error) {
+	now := time.Now()
+	token := &types.TokenInfo{
+		ID:      uuid.New().String(),
+		Name:    name,
+		Perms:   perms,
+		Methods: methods,
+		Created: now,
+	}
+	if ttl > 0 {
+		token.Expires = now.Add(ttl)
+	}
+
+	if err := tokenService.TokenRepo.Save(token); err != nil {
+		return nil, xerrors.Errorf("saving token: %w", err)
+	}
+
+	tokenService.lk.Lock()
+	tokenService.tokens[token.ID] = token
+	tokenService.lk.Unlock()
+	return token, nil
+}
+
+// Check returns an error if the token is unknown, revoked or expired
+func (tokenService *TokenService) Check(id string) error {
+	tokenService.lk.Lock()
+	token, ok := tokenService.tokens[id]
+	tokenService.lk.Unlock()
+
+	if !ok {
+		loaded, err := tokenService.TokenRepo.Get(id)
+		if xerrors.Is(err, gorm.ErrRecordNotFound) {
+			return xerrors.Errorf("unknown token %s", id)
+		}
+		if err != nil {
+			return xerrors.Errorf("loading token %s: %w", id, err)
+		}
+
+		// a revoke during the load already cached the revoked token
+		tokenService.lk.Lock()
+		if token, ok = tokenService.tokens[id]; !ok {
+			token = loaded
+			tokenService.tokens[id] = token
+		}
+		tokenService.lk.Unlock()
+	}
+
+	if token.Revoked {
+		return xerrors.Errorf("token %s was revoked", id)
+	}
+	if token.Expired(time.Now()) {
+		return xerrors.Errorf("token %s expired at %s", id, token.Expires)
+	}
+	return nil
+}
+
+// Revoke revokes a token, the token is refused from now on
+func (tokenService *TokenService) Revoke(id string) error {
+	if err := tokenService.TokenRepo.Revoke(id); err != nil {
+		if xerrors.Is(err, gorm.ErrRecordNotFound) {
+			return xerrors.Errorf("unknown token %s", id)
+		}
+		return err
+	}
+
+	tokenService.lk.Lock()
+	tokenService.tokens[id] = &types.TokenInfo{ID: id, Revoked: true}
+	tokenService.lk.Unlock()
+	return nil
+}
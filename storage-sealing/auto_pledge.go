@@ -0,0 +1,170 @@
+package sealing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin/miner"
+	"github.com/filecoin-project/venus/pkg/types"
+
+	"github.com/filecoin-project/venus-sealer/storage-sealing/sealiface"
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+// AutoPledgeInterval is how often the sealer checks whether to pledge a CC sector to fill the pipeline
+var AutoPledgeInterval = time.Minute
+
+// runAutoPledge pledges a CC sector every AutoPledgeInterval while the pipeline is below
+// TargetSealingSectors, until ctx is done
+func (m *Sealing) runAutoPledge(ctx context.Context) {
+	ticker := time.NewTicker(AutoPledgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.autoPledge(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Sealing) autoPledge(ctx context.Context) {
+	waiting, err := m.autoPledgeWaiting(ctx)
+	if err != nil {
+		log.Warnf("checking auto pledge: %+v", err)
+		waiting = err.Error()
+	}
+
+	var pledged bool
+	if waiting == "" {
+		sector, err := m.PledgeSector(ctx)
+		if err != nil {
+			log.Warnf("auto pledging sector: %+v", err)
+			waiting = err.Error()
+		} else {
+			log.Infow("auto pledged CC sector", "sector", sector.ID.Number)
+			pledged = true
+		}
+	}
+
+	m.pledgeLk.Lock()
+	defer m.pledgeLk.Unlock()
+
+	now := time.Now()
+	m.pledgeStatus.LastCheck = now
+	m.pledgeStatus.Waiting = waiting
+	if pledged {
+		m.pledgeStatus.Pledged++
+		m.pledgeStatus.LastPledge = now
+	}
+}
+
+// autoPledgeWaiting returns why a CC sector shouldn't be pledged now, or an empty string if it should
+func (m *Sealing) autoPledgeWaiting(ctx context.Context) (string, error) {
+	cfg, err := m.getConfig()
+	if err != nil {
+		return "", xerrors.Errorf("getting config: %w", err)
+	}
+
+	if waiting := pipelineWaiting(cfg, m.stats.CurSealing(), m.stats.CurTotal()); waiting != "" {
+		return waiting, nil
+	}
+
+	spt, err := m.currentSealProof(ctx)
+	if err != nil {
+		return "", xerrors.Errorf("getting seal proof type: %w", err)
+	}
+
+	idle, err := m.sealer.SealingIdle(ctx, spt)
+	if err != nil {
+		return "", xerrors.Errorf("checking worker capacity: %w", err)
+	}
+	if !idle {
+		return "no idle worker capacity", nil
+	}
+
+	return m.fundsWaiting(ctx, spt, m.stats.CurSealing())
+}
+
+// pipelineWaiting applies the pipeline targets and limits of cfg
+func pipelineWaiting(cfg sealiface.Config, sealing, total uint64) string {
+	switch {
+	case cfg.TargetSealingSectors == 0:
+		return "disabled"
+	case sealing >= cfg.TargetSealingSectors:
+		return fmt.Sprintf("pipeline full (%d sealing, target %d)", sealing, cfg.TargetSealingSectors)
+	case cfg.MaxSealingSectors > 0 && sealing >= cfg.MaxSealingSectors:
+		return fmt.Sprintf("too many sectors sealing (%d sealing, max %d)", sealing, cfg.MaxSealingSectors)
+	case cfg.TargetSectors > 0 && total >= cfg.TargetSectors:
+		return fmt.Sprintf("target sectors reached (%d sectors, target %d)", total, cfg.TargetSectors)
+	}
+	return ""
+}
+
+// fundsWaiting checks that the available miner balance and the worker balance cover the initial pledge of
+// the sectors sealing and one more. Sectors already precommitted are counted too, so this errs on the safe side.
+func (m *Sealing) fundsWaiting(ctx context.Context, spt abi.RegisteredSealProof, sealing uint64) (string, error) {
+	tok, _, err := m.api.ChainHead(ctx)
+	if err != nil {
+		return "", xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	expiration, err := m.pcp.Expiration(ctx)
+	if err != nil {
+		return "", xerrors.Errorf("getting sector expiration: %w", err)
+	}
+
+	collateral, err := m.api.StateMinerInitialPledgeCollateral(ctx, m.maddr, miner.SectorPreCommitInfo{
+		SealProof:  spt,
+		Expiration: expiration,
+	}, tok)
+	if err != nil {
+		return "", xerrors.Errorf("getting initial pledge collateral: %w", err)
+	}
+
+	available, err := m.api.StateMinerAvailableBalance(ctx, m.maddr, tok)
+	if err != nil {
+		return "", xerrors.Errorf("getting miner available balance: %w", err)
+	}
+
+	mi, err := m.api.StateMinerInfo(ctx, m.maddr, tok)
+	if err != nil {
+		return "", xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	wb, err := m.api.WalletBalance(ctx, mi.Worker)
+	if err != nil {
+		return "", xerrors.Errorf("getting worker balance: %w", err)
+	}
+
+	need := big.Mul(collateral, big.NewInt(int64(sealing+1)))
+	if have := big.Add(available, wb); have.LessThan(need) {
+		return fmt.Sprintf("not enough funds (have %s, need %s for %d sectors)", types.FIL(have).Short(), types.FIL(need).Short(), sealing+1), nil
+	}
+	return "", nil
+}
+
+// AutoPledgeStatus returns the state of the automatic CC pledging
+func (m *Sealing) AutoPledgeStatus() (types2.AutoPledgeStatus, error) {
+	cfg, err := m.getConfig()
+	if err != nil {
+		return types2.AutoPledgeStatus{}, xerrors.Errorf("getting config: %w", err)
+	}
+
+	m.pledgeLk.Lock()
+	out := m.pledgeStatus
+	m.pledgeLk.Unlock()
+
+	out.TargetSealing = cfg.TargetSealingSectors
+	out.TargetSectors = cfg.TargetSectors
+	out.Sealing = m.stats.CurSealing()
+	out.Total = m.stats.CurTotal()
+	return out, nil
+}
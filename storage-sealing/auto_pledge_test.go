@@ -0,0 +1,26 @@
+package sealing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus-sealer/storage-sealing/sealiface"
+)
+
+func TestPipelineWaiting(t *testing.T) {
+	cfg := sealiface.Config{}
+	require.Equal(t, "disabled", pipelineWaiting(cfg, 0, 0))
+
+	cfg.TargetSealingSectors = 4
+	require.Empty(t, pipelineWaiting(cfg, 3, 100))
+	require.Contains(t, pipelineWaiting(cfg, 4, 100), "pipeline full")
+
+	cfg.MaxSealingSectors = 2
+	require.Contains(t, pipelineWaiting(cfg, 2, 100), "too many sectors sealing")
+	require.Empty(t, pipelineWaiting(cfg, 1, 100))
+
+	cfg.TargetSectors = 100
+	require.Contains(t, pipelineWaiting(cfg, 1, 100), "target sectors reached")
+	require.Empty(t, pipelineWaiting(cfg, 1, 99))
+}
@@ -59,7 +59,7 @@ type CommitBatcher struct {
 	maddr     address.Address
 	mctx      context.Context
 	addrSel   AddrSel
-	feeCfg    config.MinerFeeConfig
+	feeCfg    config.GetFeeConfigFunc
 	getConfig types.GetSealingConfigFunc
 	prover    ffiwrapper.Prover
 
@@ -74,7 +74,7 @@ type CommitBatcher struct {
 	networkParams *config.NetParamsConfig
 }
 
-func NewCommitBatcher(mctx context.Context, networkParams *config.NetParamsConfig, maddr address.Address, api CommitBatcherApi, addrSel AddrSel, feeCfg config.MinerFeeConfig, getConfig types.GetSealingConfigFunc, prov ffiwrapper.Prover) *CommitBatcher {
+func NewCommitBatcher(mctx context.Context, networkParams *config.NetParamsConfig, maddr address.Address, api CommitBatcherApi, addrSel AddrSel, feeCfg config.GetFeeConfigFunc, getConfig types.GetSealingConfigFunc, prov ffiwrapper.Prover) *CommitBatcher {
 	b := &CommitBatcher{
 		api:       api,
 		maddr:     maddr,
@@ -329,7 +329,8 @@ func (b *CommitBatcher) processBatch(cfg sealiface.Config) ([]sealiface.CommitBa
 		return []sealiface.CommitBatchRes{res}, xerrors.Errorf("couldn't get miner info: %w", err)
 	}
 
-	maxFee := b.feeCfg.MaxCommitBatchGasFee.FeeForSectors(len(infos))
+	feeCfg := b.feeCfg()
+	maxFee := feeCfg.MaxCommitBatchGasFee.FeeForSectors(len(infos))
 
 	bf, err := b.api.ChainBaseFee(b.mctx, tok)
 	if err != nil {
@@ -414,14 +415,14 @@ func (b *CommitBatcher) processSingle(mi miner.MinerInfo, sn abi.SectorNumber, i
 		return "", err
 	}
 
-	goodFunds := big.Add(collateral, big.Int(b.feeCfg.MaxCommitGasFee))
+	goodFunds := big.Add(collateral, big.Int(b.feeCfg().MaxCommitGasFee))
 
 	from, _, err := b.addrSel(b.mctx, mi, api.CommitAddr, goodFunds, collateral)
 	if err != nil {
 		return "", xerrors.Errorf("no good address to send commit message from: %w", err)
 	}
 
-	uid, err := b.api.MessagerSendMsg(b.mctx, from, b.maddr, miner.Methods.ProveCommitSector, collateral, big.Int(b.feeCfg.MaxCommitGasFee), enc.Bytes())
+	uid, err := b.api.MessagerSendMsg(b.mctx, from, b.maddr, miner.Methods.ProveCommitSector, collateral, big.Int(b.feeCfg().MaxCommitGasFee), enc.Bytes())
 	if err != nil {
 		return "", xerrors.Errorf("pushing message to mpool: %w", err)
 	}
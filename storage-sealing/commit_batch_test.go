@@ -362,7 +362,7 @@ func TestCommitBatcher(t *testing.T) {
 				UpgradeIgnitionHeight:  94000,
 				ForkLengthThreshold:    policy.ChainFinality,
 				BlockDelaySecs:         30,
-			}, t0123, pcapi, as, fc, cfg, &fakeProver{})
+			}, t0123, pcapi, as, func() config.MinerFeeConfig { return fc }, cfg, &fakeProver{})
 
 			var promises []promise
 
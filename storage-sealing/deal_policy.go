@@ -0,0 +1,106 @@
+package sealing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+var ErrDealRejected = xerrors.New("deal rejected by deal policy")
+
+// DefaultExpectedSealDuration is used when the expected seal duration is not set and no sector has been sealed yet
+var DefaultExpectedSealDuration = 24 * time.Hour
+
+const (
+	// number of latest proving sectors the seal duration is measured over
+	sealDurationSamples = 20
+	// how long a measured seal duration is reused before reading the logs again
+	sealDurationCacheTTL = 10 * time.Minute
+)
+
+// checkDealPolicy rejects deals that the persisted deal policy doesn't allow
+func (m *Sealing) checkDealPolicy(deal types2.DealInfo, offline bool) error {
+	policy, err := m.metadataService.GetDealPolicy()
+	if err != nil {
+		return xerrors.Errorf("getting deal policy: %w", err)
+	}
+
+	if offline && !policy.ConsiderOfflineStorageDeals {
+		return xerrors.Errorf("deal %d: offline storage deals are not considered: %w", deal.DealID, ErrDealRejected)
+	}
+	if !offline && !policy.ConsiderOnlineStorageDeals {
+		return xerrors.Errorf("deal %d: online storage deals are not considered: %w", deal.DealID, ErrDealRejected)
+	}
+
+	if deal.DealProposal.VerifiedDeal && !policy.ConsiderVerifiedStorageDeals {
+		return xerrors.Errorf("deal %d: verified storage deals are not considered: %w", deal.DealID, ErrDealRejected)
+	}
+	if !deal.DealProposal.VerifiedDeal && !policy.ConsiderUnverifiedStorageDeals {
+		return xerrors.Errorf("deal %d: unverified storage deals are not considered: %w", deal.DealID, ErrDealRejected)
+	}
+
+	if policy.IsBlocklisted(deal.DealProposal.PieceCID) {
+		return xerrors.Errorf("deal %d: piece cid %s is blocklisted: %w", deal.DealID, deal.DealProposal.PieceCID, ErrDealRejected)
+	}
+
+	return nil
+}
+
+// ExpectedSealDuration returns the expected seal duration set in the deal policy, when it is not set
+// the duration is measured from the logs of the latest proving sectors
+func (m *Sealing) ExpectedSealDuration() (time.Duration, error) {
+	policy, err := m.metadataService.GetDealPolicy()
+	if err != nil {
+		return 0, xerrors.Errorf("getting deal policy: %w", err)
+	}
+	if policy.ExpectedSealDuration > 0 {
+		return policy.ExpectedSealDuration, nil
+	}
+
+	m.sealDurationLk.Lock()
+	defer m.sealDurationLk.Unlock()
+
+	if time.Since(m.measuredSealAt) > sealDurationCacheTTL {
+		measured, err := m.logService.MeasureSealDuration(fmt.Sprintf("event;%T", SectorProving{}), sealDurationSamples)
+		if err != nil {
+			return 0, xerrors.Errorf("measuring seal duration: %w", err)
+		}
+		m.measuredSealDuration = measured
+		m.measuredSealAt = time.Now()
+	}
+
+	if m.measuredSealDuration == 0 {
+		return DefaultExpectedSealDuration, nil
+	}
+	return m.measuredSealDuration, nil
+}
+
+// checkDealStart rejects deals which start before a sector can be sealed
+func (m *Sealing) checkDealStart(ctx context.Context, deal types2.DealInfo) error {
+	expected, err := m.ExpectedSealDuration()
+	if err != nil {
+		return err
+	}
+
+	_, height, err := m.api.ChainHead(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	earliest := height
+	if m.networkParams.BlockDelaySecs > 0 {
+		earliest += abi.ChainEpoch(expected / (time.Duration(m.networkParams.BlockDelaySecs) * time.Second))
+	}
+
+	if deal.DealSchedule.StartEpoch < earliest {
+		return xerrors.Errorf("deal %d: start epoch %d is earlier than epoch %d, the expected seal duration is %s: %w",
+			deal.DealID, deal.DealSchedule.StartEpoch, earliest, expected, ErrDealRejected)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package sealing
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	market2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/market"
+	tutils "github.com/filecoin-project/specs-actors/v2/support/testing"
+
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/models/sqlite"
+	"github.com/filecoin-project/venus-sealer/service"
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+func newDealPolicyTestSealing(t *testing.T) *Sealing {
+	r, err := sqlite.OpenSqlite(&config.SqliteConfig{Path: filepath.Join(t.TempDir(), "sealer.db")})
+	require.NoError(t, err)
+	require.NoError(t, r.AutoMigrate())
+
+	return &Sealing{
+		metadataService: service.NewMetadataService(r),
+		logService:      service.NewLogService(r),
+	}
+}
+
+func TestCheckDealPolicy(t *testing.T) {
+	m := newDealPolicyTestSealing(t)
+
+	pieceCid := tutils.MakeCID("piece", &market2.PieceCIDPrefix)
+	deal := types2.DealInfo{
+		DealID: 1,
+		DealProposal: &market2.DealProposal{
+			PieceCID:     pieceCid,
+			VerifiedDeal: true,
+		},
+	}
+
+	// default policy accepts everything
+	require.NoError(t, m.checkDealPolicy(deal, false))
+	require.NoError(t, m.checkDealPolicy(deal, true))
+
+	require.NoError(t, m.metadataService.UpdateDealPolicy(func(policy *types2.DealPolicy) {
+		policy.ConsiderOfflineStorageDeals = false
+	}))
+	require.NoError(t, m.checkDealPolicy(deal, false))
+	require.True(t, xerrors.Is(m.checkDealPolicy(deal, true), ErrDealRejected))
+
+	require.NoError(t, m.metadataService.UpdateDealPolicy(func(policy *types2.DealPolicy) {
+		policy.ConsiderVerifiedStorageDeals = false
+	}))
+	require.True(t, xerrors.Is(m.checkDealPolicy(deal, false), ErrDealRejected))
+
+	deal.DealProposal.VerifiedDeal = false
+	require.NoError(t, m.checkDealPolicy(deal, false))
+
+	require.NoError(t, m.metadataService.UpdateDealPolicy(func(policy *types2.DealPolicy) {
+		policy.PieceCidBlocklist = append(policy.PieceCidBlocklist, pieceCid)
+	}))
+	require.True(t, xerrors.Is(m.checkDealPolicy(deal, false), ErrDealRejected))
+}
+
+func TestExpectedSealDuration(t *testing.T) {
+	m := newDealPolicyTestSealing(t)
+
+	// nothing measured yet
+	d, err := m.ExpectedSealDuration()
+	require.NoError(t, err)
+	require.Equal(t, DefaultExpectedSealDuration, d)
+
+	provingKind := fmt.Sprintf("event;%T", SectorProving{})
+	for sector, hours := range []uint64{2, 4, 9} {
+		sn := abi.SectorNumber(sector)
+		require.NoError(t, m.logService.Append(&types2.Log{SectorNumber: sn, Timestamp: 1000, Kind: "event;sealing.SectorStart"}))
+		require.NoError(t, m.logService.Append(&types2.Log{SectorNumber: sn, Timestamp: 1000 + hours*3600, Kind: provingKind}))
+	}
+
+	// the measured value is cached
+	d, err = m.ExpectedSealDuration()
+	require.NoError(t, err)
+	require.Equal(t, DefaultExpectedSealDuration, d)
+
+	m.measuredSealAt = time.Time{}
+	d, err = m.ExpectedSealDuration()
+	require.NoError(t, err)
+	require.Equal(t, 4*time.Hour, d)
+
+	require.NoError(t, m.metadataService.UpdateDealPolicy(func(policy *types2.DealPolicy) {
+		policy.ExpectedSealDuration = time.Hour
+	}))
+	d, err = m.ExpectedSealDuration()
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, d)
+}
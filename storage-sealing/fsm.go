@@ -309,10 +309,12 @@ func (m *Sealing) plan(events []statemachine.Event, state *types.SectorInfo) (fu
 		}
 	}
 
+	before := state.State
 	processed, err := p(events, state)
 	if err != nil {
 		return nil, 0, xerrors.Errorf("running planner for state %s failed: %w", state.State, err)
 	}
+	m.recordEvent(events[:processed], before, state)
 
 	/////
 	// Now decide what to do next
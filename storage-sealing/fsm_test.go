@@ -1,6 +1,7 @@
 package sealing
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/filecoin-project/venus-sealer/config"
@@ -33,7 +34,7 @@ type test struct {
 }
 
 func newLogService(t *testing.T) *service.LogService {
-	r, err := sqlite.OpenSqlite(&config.SqliteConfig{Path: "./sealing.db"})
+	r, err := sqlite.OpenSqlite(&config.SqliteConfig{Path: filepath.Join(t.TempDir(), "sealing.db")})
 	if err != nil {
 		t.Fatal(err)
 	}
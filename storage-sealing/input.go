@@ -2,6 +2,7 @@ package sealing
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"time"
 
@@ -186,7 +187,7 @@ func (m *Sealing) handleAddPiece(ctx statemachine.Context, sector types.SectorIn
 		offset += padLength.Unpadded()
 
 		for _, p := range pads {
-			ppi, err := m.sealer.AddPiece(sectorstorage.WithPriority(ctx.Context(), types.DealSectorPriority),
+			ppi, err := m.sealer.AddPiece(types.WithDeals(sectorstorage.WithPriority(ctx.Context(), types.DealSectorPriority)),
 				m.minerSector(sector.SectorType, sector.SectorNumber),
 				pieceSizes,
 				p.Unpadded(),
@@ -203,7 +204,7 @@ func (m *Sealing) handleAddPiece(ctx statemachine.Context, sector types.SectorIn
 			})
 		}
 
-		ppi, err := m.sealer.AddPiece(sectorstorage.WithPriority(ctx.Context(), types.DealSectorPriority),
+		ppi, err := m.sealer.AddPiece(types.WithDeals(sectorstorage.WithPriority(ctx.Context(), types.DealSectorPriority)),
 			m.minerSector(sector.SectorType, sector.SectorNumber),
 			pieceSizes,
 			deal.size,
@@ -238,7 +239,35 @@ func (m *Sealing) handleAddPieceFailed(ctx statemachine.Context, sector types.Se
 }
 
 func (m *Sealing) AddPieceToAnySector(ctx context.Context, size abi.UnpaddedPieceSize, data storage.Data, deal types.DealInfo) (abi.SectorNumber, abi.PaddedPieceSize, error) {
-	log.Infof("Adding piece for deal %d (publish msg: %s)", deal.DealID, deal.PublishCid)
+	return m.addPieceToAnySector(ctx, size, data, deal, false)
+}
+
+// AddOfflinePieceToAnySector adds the imported data of an offline deal, the caller must have verified the piece commitment
+func (m *Sealing) AddOfflinePieceToAnySector(ctx context.Context, size abi.UnpaddedPieceSize, data storage.Data, deal types.DealInfo) (abi.SectorNumber, abi.PaddedPieceSize, error) {
+	return m.addPieceToAnySector(ctx, size, data, deal, true)
+}
+
+// logOfflineImport records the outcome of adding the data of an offline deal in the log of its sector
+func (m *Sealing) logOfflineImport(sn abi.SectorNumber, deal types.DealInfo, offset abi.PaddedPieceSize, importErr error) {
+	l := &types.Log{
+		SectorNumber: sn,
+		Timestamp:    uint64(time.Now().Unix()),
+		Message:      fmt.Sprintf("imported data of offline deal %d, piece %s, offset %d", deal.DealID, deal.DealProposal.PieceCID, offset),
+		Kind:         "import;OfflineDealImported",
+	}
+	if importErr != nil {
+		l.Message = fmt.Sprintf("importing data of offline deal %d, piece %s failed: %s", deal.DealID, deal.DealProposal.PieceCID, importErr)
+		l.Trace = fmt.Sprintf("%+v", importErr)
+		l.Kind = "import;OfflineDealImportFailed"
+	}
+
+	if err := m.logService.Append(l); err != nil {
+		log.Warnf("appending import log of sector %d: %+v", sn, err)
+	}
+}
+
+func (m *Sealing) addPieceToAnySector(ctx context.Context, size abi.UnpaddedPieceSize, data storage.Data, deal types.DealInfo, offline bool) (abi.SectorNumber, abi.PaddedPieceSize, error) {
+	log.Infof("Adding piece for deal %d (publish msg: %s, offline: %t)", deal.DealID, deal.PublishCid, offline)
 	if (padreader.PaddedSize(uint64(size))) != size {
 		return 0, 0, xerrors.Errorf("cannot allocate unpadded piece")
 	}
@@ -261,6 +290,14 @@ func (m *Sealing) AddPieceToAnySector(ctx context.Context, size abi.UnpaddedPiec
 		return 0, 0, xerrors.Errorf("getting proposal CID: %w", err)
 	}
 
+	if err := m.checkDealPolicy(deal, offline); err != nil {
+		return 0, 0, err
+	}
+
+	if err := m.checkDealStart(ctx, deal); err != nil {
+		return 0, 0, err
+	}
+
 	m.inputLk.Lock()
 	if _, exist := m.pendingPieces[proposalCID(deal)]; exist {
 		m.inputLk.Unlock()
@@ -296,6 +333,10 @@ func (m *Sealing) AddPieceToAnySector(ctx context.Context, size abi.UnpaddedPiec
 
 	res := <-resCh
 
+	if offline {
+		m.logOfflineImport(res.sn, deal, res.offset.Padded(), res.err)
+	}
+
 	return res.sn, res.offset.Padded(), res.err
 }
 
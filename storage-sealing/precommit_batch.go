@@ -44,7 +44,7 @@ type PreCommitBatcher struct {
 	maddr     address.Address
 	mctx      context.Context
 	addrSel   AddrSel
-	feeCfg    config.MinerFeeConfig
+	feeCfg    config.GetFeeConfigFunc
 	getConfig types.GetSealingConfigFunc
 
 	cutoffs map[abi.SectorNumber]time.Time
@@ -58,7 +58,7 @@ type PreCommitBatcher struct {
 	networkParams *config.NetParamsConfig
 }
 
-func NewPreCommitBatcher(mctx context.Context, networkParams *config.NetParamsConfig, maddr address.Address, api PreCommitBatcherApi, addrSel AddrSel, feeCfg config.MinerFeeConfig, getConfig types.GetSealingConfigFunc) *PreCommitBatcher {
+func NewPreCommitBatcher(mctx context.Context, networkParams *config.NetParamsConfig, maddr address.Address, api PreCommitBatcherApi, addrSel AddrSel, feeCfg config.GetFeeConfigFunc, getConfig types.GetSealingConfigFunc) *PreCommitBatcher {
 	b := &PreCommitBatcher{
 		api:       api,
 		maddr:     maddr,
@@ -241,7 +241,8 @@ func (b *PreCommitBatcher) processBatch(cfg sealiface.Config) ([]sealiface.PreCo
 		return []sealiface.PreCommitBatchRes{res}, xerrors.Errorf("couldn't get miner info: %w", err)
 	}
 
-	maxFee := b.feeCfg.MaxPreCommitBatchGasFee.FeeForSectors(len(params.Sectors))
+	feeCfg := b.feeCfg()
+	maxFee := feeCfg.MaxPreCommitBatchGasFee.FeeForSectors(len(params.Sectors))
 	goodFunds := big.Add(deposit, maxFee)
 
 	from, _, err := b.addrSel(b.mctx, mi, api.PreCommitAddr, goodFunds, deposit)
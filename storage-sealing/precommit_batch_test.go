@@ -233,7 +233,7 @@ func TestPrecommitBatcher(t *testing.T) {
 				UpgradeIgnitionHeight:  94000,
 				ForkLengthThreshold:    policy.ChainFinality,
 				BlockDelaySecs:         30,
-			}, t0123, pcapi, as, fc, cfg)
+			}, t0123, pcapi, as, func() config.MinerFeeConfig { return fc }, cfg)
 
 			var promises []promise
 
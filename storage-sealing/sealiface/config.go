@@ -40,4 +40,9 @@ type Config struct {
 	TerminateBatchMax  uint64
 	TerminateBatchMin  uint64
 	TerminateBatchWait time.Duration
+
+	// 0 = auto pledging disabled
+	TargetSealingSectors uint64
+	// 0 = no limit
+	TargetSectors uint64
 }
@@ -61,6 +61,7 @@ type SealingAPI interface {
 	StateMinerPreCommitDepositForPower(context.Context, address.Address, miner.SectorPreCommitInfo, types2.TipSetToken) (big.Int, error)
 	StateMinerInitialPledgeCollateral(context.Context, address.Address, miner.SectorPreCommitInfo, types2.TipSetToken) (big.Int, error)
 	StateMinerInfo(context.Context, address.Address, types2.TipSetToken) (miner.MinerInfo, error)
+	StateMinerAvailableBalance(context.Context, address.Address, types2.TipSetToken) (big.Int, error)
 	StateMinerSectorAllocated(context.Context, address.Address, abi.SectorNumber, types2.TipSetToken) (bool, error)
 	StateMarketStorageDeal(context.Context, abi.DealID, types2.TipSetToken) (*apitypes.MarketDeal, error)
 	StateMarketStorageDealProposal(context.Context, abi.DealID, types2.TipSetToken) (market.DealProposal, error)
@@ -74,6 +75,7 @@ type SealingAPI interface {
 	ChainGetRandomnessFromBeacon(ctx context.Context, tok types2.TipSetToken, personalization crypto.DomainSeparationTag, randEpoch abi.ChainEpoch, entropy []byte) (abi.Randomness, error)
 	ChainGetRandomnessFromTickets(ctx context.Context, tok types2.TipSetToken, personalization crypto.DomainSeparationTag, randEpoch abi.ChainEpoch, entropy []byte) (abi.Randomness, error)
 	ChainReadObj(context.Context, cid.Cid) ([]byte, error)
+	WalletBalance(context.Context, address.Address) (big.Int, error)
 
 	//for messager
 	MessagerWaitMsg(context.Context, string) (types2.MsgLookup, error)
@@ -87,7 +89,7 @@ type AddrSel func(ctx context.Context, mi miner.MinerInfo, use api.AddrUse, good
 
 type Sealing struct {
 	api    SealingAPI
-	feeCfg config.MinerFeeConfig
+	feeCfg config.GetFeeConfigFunc
 	events Events
 
 	startupWait sync.WaitGroup
@@ -116,6 +118,9 @@ type Sealing struct {
 
 	stats types2.SectorStats
 
+	pledgeLk     sync.Mutex
+	pledgeStatus types2.AutoPledgeStatus
+
 	terminator  *TerminateBatcher
 	precommiter *PreCommitBatcher
 	commiter    *CommitBatcher
@@ -123,8 +128,14 @@ type Sealing struct {
 	getConfig types2.GetSealingConfigFunc
 	dealInfo  *CurrentDealInfoManager
 
+	sealDurationLk       sync.Mutex
+	measuredSealDuration time.Duration
+	measuredSealAt       time.Time
+
 	//service
-	logService *service.LogService
+	logService      *service.LogService
+	eventService    *service.SectorEventService
+	metadataService *service.MetadataService
 }
 
 type openSector struct {
@@ -143,7 +154,7 @@ type pendingPiece struct {
 	accepted func(abi.SectorNumber, abi.UnpaddedPieceSize, error)
 }
 
-func New(mctx context.Context, api SealingAPI, fc config.MinerFeeConfig, events Events, maddr address.Address, metaDataService *service.MetadataService, sectorInfoService *service.SectorInfoService, logService *service.LogService, sealer sectorstorage.SectorManager, sc types2.SectorIDCounter, verif ffiwrapper.Verifier, prov ffiwrapper.Prover, pcp PreCommitPolicy, gc types2.GetSealingConfigFunc, notifee SectorStateNotifee, as AddrSel, networkParams *config.NetParamsConfig) *Sealing {
+func New(mctx context.Context, api SealingAPI, fc config.GetFeeConfigFunc, events Events, maddr address.Address, metaDataService *service.MetadataService, sectorInfoService *service.SectorInfoService, logService *service.LogService, eventService *service.SectorEventService, sealer sectorstorage.SectorManager, sc types2.SectorIDCounter, verif ffiwrapper.Verifier, prov ffiwrapper.Prover, pcp PreCommitPolicy, gc types2.GetSealingConfigFunc, notifee SectorStateNotifee, as AddrSel, networkParams *config.NetParamsConfig) *Sealing {
 	s := &Sealing{
 		api:    api,
 		feeCfg: fc,
@@ -156,6 +167,9 @@ func New(mctx context.Context, api SealingAPI, fc config.MinerFeeConfig, events
 		verif:         verif,
 		pcp:           pcp,
 		logService:    logService,
+		eventService:  eventService,
+
+		metadataService: metaDataService,
 
 		openSectors:    map[abi.SectorID]*openSector{},
 		sectorTimers:   map[abi.SectorID]*time.Timer{},
@@ -190,6 +204,8 @@ func (m *Sealing) Run(ctx context.Context) error {
 		return xerrors.Errorf("failed load sector states: %w", err)
 	}
 
+	go m.runAutoPledge(ctx)
+
 	return nil
 }
 
@@ -0,0 +1,115 @@
+package sealing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-statemachine"
+
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+// errorClass returns the class of a failure event, empty if evt isn't a failure
+func errorClass(evt interface{}) string {
+	switch evt.(type) {
+	case SectorAddPieceFailed:
+		return "addpiece"
+	case SectorSealPreCommit1Failed:
+		return "pc1"
+	case SectorSealPreCommit2Failed:
+		return "pc2"
+	case SectorChainPreCommitFailed:
+		return "precommit-chain"
+	case SectorComputeProofFailed:
+		return "compute-proof"
+	case SectorCommitFailed:
+		return "commit-chain"
+	case SectorDealsExpired:
+		return "deals"
+	case SectorTicketExpired:
+		return "ticket"
+	case SectorFinalizeFailed:
+		return "finalize"
+	case SectorTerminateFailed:
+		return "terminate"
+	case SectorRemoveFailed:
+		return "remove"
+	case SectorFatalError:
+		return "fatal"
+	case error:
+		return "other"
+	}
+	return ""
+}
+
+// eventName returns the type name of evt without the package prefix
+func eventName(evt interface{}) string {
+	name := fmt.Sprintf("%T", evt)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// sectorEvent builds the history record of a planned batch of events, nil if nothing worth recording happened
+func sectorEvent(events []statemachine.Event, sector abi.SectorNumber, from, to types2.SectorState, worker string) *types2.SectorEvent {
+	var last interface{}
+	for _, event := range events {
+		if event.User == (SectorRestart{}) {
+			continue
+		}
+		last = event.User
+	}
+	if last == nil {
+		return nil
+	}
+
+	record := &types2.SectorEvent{
+		SectorNumber: sector,
+		Time:         time.Now(),
+		Event:        eventName(last),
+		From:         from,
+		To:           to,
+		Worker:       worker,
+		ErrorClass:   errorClass(last),
+	}
+	if err, ok := last.(error); ok {
+		record.Error = err.Error()
+	}
+	if from == to && record.ErrorClass == "" {
+		return nil
+	}
+	return record
+}
+
+// recordEvent appends the history record of a planned batch of events, failing to record doesn't stop the sector
+func (m *Sealing) recordEvent(events []statemachine.Event, from types2.SectorState, state *types2.SectorInfo) {
+	if m.eventService == nil {
+		return
+	}
+
+	record := sectorEvent(events, state.SectorNumber, from, state.State, m.sealer.SectorWorker(m.minerSectorID(state.SectorNumber)))
+	if record == nil {
+		return
+	}
+	if err := m.eventService.Record(record); err != nil {
+		log.Warnw("recording sector event", "sector", state.SectorNumber, "error", err)
+	}
+}
+
+// recordCommit1 splits the time spent in Committing between Commit1 and Commit2
+func (m *Sealing) recordCommit1(sector types2.SectorInfo) {
+	if m.eventService == nil {
+		return
+	}
+
+	err := m.eventService.Record(&types2.SectorEvent{
+		SectorNumber: sector.SectorNumber,
+		Event:        types2.SectorEventCommit1Done,
+		From:         types2.Committing,
+		To:           types2.Committing,
+		Worker:       m.sealer.SectorWorker(m.minerSectorID(sector.SectorNumber)),
+	})
+	if err != nil {
+		log.Warnw("recording sector event", "sector", sector.SectorNumber, "error", err)
+	}
+}
@@ -0,0 +1,32 @@
+package sealing
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-statemachine"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+func TestSectorEvent(t *testing.T) {
+	require.Nil(t, sectorEvent([]statemachine.Event{{User: SectorRestart{}}}, 1, types2.PreCommit1, types2.PreCommit1, ""))
+	require.Nil(t, sectorEvent([]statemachine.Event{{User: SectorRetryWaitSeed{}}}, 1, types2.WaitSeed, types2.WaitSeed, ""))
+
+	record := sectorEvent([]statemachine.Event{{User: SectorRestart{}}, {User: SectorPreCommit1{}}}, 1, types2.PreCommit1, types2.PreCommit2, "worker-1")
+	require.NotNil(t, record)
+	require.Equal(t, "SectorPreCommit1", record.Event)
+	require.Equal(t, types2.PreCommit1, record.From)
+	require.Equal(t, types2.PreCommit2, record.To)
+	require.Equal(t, "worker-1", record.Worker)
+	require.Empty(t, record.ErrorClass)
+
+	record = sectorEvent([]statemachine.Event{{User: SectorSealPreCommit2Failed{xerrors.New("boom")}}}, 1, types2.PreCommit2, types2.SealPreCommit2Failed, "")
+	require.Equal(t, "pc2", record.ErrorClass)
+	require.Equal(t, "boom", record.Error)
+
+	// errors are recorded even if the state doesn't change
+	record = sectorEvent([]statemachine.Event{{User: SectorFatalError{xerrors.New("fatal")}}}, 1, types2.Committing, types2.Committing, "")
+	require.Equal(t, "fatal", record.ErrorClass)
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"sync"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
@@ -23,18 +24,35 @@ type addrSelectApi interface {
 }
 
 type AddressSelector struct {
+	lk sync.RWMutex
 	api.AddressConfig
 }
 
+// Config returns the control addresses in use
+func (as *AddressSelector) Config() api.AddressConfig {
+	as.lk.RLock()
+	defer as.lk.RUnlock()
+	return as.AddressConfig
+}
+
+// SetConfig replaces the control addresses
+func (as *AddressSelector) SetConfig(cfg api.AddressConfig) {
+	as.lk.Lock()
+	defer as.lk.Unlock()
+	as.AddressConfig = cfg
+}
+
 func (as *AddressSelector) AddressFor(ctx context.Context, a addrSelectApi, am addrMessager, mi miner.MinerInfo, use api.AddrUse, goodFunds, minFunds abi.TokenAmount) (address.Address, abi.TokenAmount, error) {
+	cfg := as.Config()
+
 	var addrs []address.Address
 	switch use {
 	case api.PreCommitAddr:
-		addrs = append(addrs, as.PreCommitControl...)
+		addrs = append(addrs, cfg.PreCommitControl...)
 	case api.CommitAddr:
-		addrs = append(addrs, as.CommitControl...)
+		addrs = append(addrs, cfg.CommitControl...)
 	case api.TerminateSectorsAddr:
-		addrs = append(addrs, as.TerminateControl...)
+		addrs = append(addrs, cfg.TerminateControl...)
 	default:
 		defaultCtl := map[address.Address]struct{}{}
 		for _, a := range mi.ControlAddresses {
@@ -43,9 +61,9 @@ func (as *AddressSelector) AddressFor(ctx context.Context, a addrSelectApi, am a
 		delete(defaultCtl, mi.Owner)
 		delete(defaultCtl, mi.Worker)
 
-		configCtl := append([]address.Address{}, as.PreCommitControl...)
-		configCtl = append(configCtl, as.CommitControl...)
-		configCtl = append(configCtl, as.TerminateControl...)
+		configCtl := append([]address.Address{}, cfg.PreCommitControl...)
+		configCtl = append(configCtl, cfg.CommitControl...)
+		configCtl = append(configCtl, cfg.TerminateControl...)
 
 		for _, addr := range configCtl {
 			if addr.Protocol() != address.ID {
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/ipfs/go-cid"
@@ -53,10 +54,11 @@ type Miner struct {
 	metadataService   *service.MetadataService
 	sectorInfoService *service.SectorInfoService
 	logService        *service.LogService
+	eventService      *service.SectorEventService
 	networkParams     *config.NetParamsConfig
 
 	api    fullNodeFilteredAPI
-	feeCfg config.MinerFeeConfig
+	feeCfg config.GetFeeConfigFunc
 	sealer sectorstorage.SectorManager
 
 	sc      types2.SectorIDCounter
@@ -71,6 +73,10 @@ type Miner struct {
 
 	sealingEvtType journal.EventType
 
+	// extend messages sent since start
+	extendLk   sync.Mutex
+	extendMsgs []types2.ExtendMessage
+
 	journal journal.Journal
 }
 
@@ -89,10 +95,12 @@ type fullNodeFilteredAPI interface {
 	// Call a read only method on actors (no interaction with the chain required)
 	StateCall(context.Context, *types.Message, types.TipSetKey) (*apitypes.InvocResult, error)
 	StateMinerSectors(context.Context, address.Address, *bitfield.BitField, types.TipSetKey) ([]*miner.SectorOnChainInfo, error)
+	StateMinerActiveSectors(context.Context, address.Address, types.TipSetKey) ([]*miner.SectorOnChainInfo, error)
 	StateSectorPreCommitInfo(context.Context, address.Address, abi.SectorNumber, types.TipSetKey) (miner.SectorPreCommitOnChainInfo, error)
 	StateSectorGetInfo(context.Context, address.Address, abi.SectorNumber, types.TipSetKey) (*miner.SectorOnChainInfo, error)
 	StateSectorPartition(ctx context.Context, maddr address.Address, sectorNumber abi.SectorNumber, tok types.TipSetKey) (*miner.SectorLocation, error)
 	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (miner.MinerInfo, error)
+	StateMinerAvailableBalance(context.Context, address.Address, types.TipSetKey) (types.BigInt, error)
 	StateMinerDeadlines(context.Context, address.Address, types.TipSetKey) ([]apitypes.Deadline, error)
 	StateMinerPartitions(context.Context, address.Address, uint64, types.TipSetKey) ([]apitypes.Partition, error)
 	StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error)
@@ -139,12 +147,13 @@ func NewMiner(api fullNodeFilteredAPI,
 	metaService *service.MetadataService,
 	sectorInfoService *service.SectorInfoService,
 	logService *service.LogService,
+	eventService *service.SectorEventService,
 	sealer sectorstorage.SectorManager,
 	sc types2.SectorIDCounter,
 	verif ffiwrapper.Verifier,
 	prover ffiwrapper.Prover,
 	gsd types2.GetSealingConfigFunc,
-	feeCfg config.MinerFeeConfig,
+	feeCfg config.GetFeeConfigFunc,
 	journal journal.Journal,
 	as *AddressSelector,
 	networkParams *config.NetParamsConfig) (*Miner, error) {
@@ -164,6 +173,7 @@ func NewMiner(api fullNodeFilteredAPI,
 		getSealConfig:     gsd,
 		journal:           journal,
 		logService:        logService,
+		eventService:      eventService,
 		sealingEvtType:    journal.RegisterEventType("storage", "sealing_states"),
 	}
 
@@ -209,7 +219,7 @@ func (m *Miner) Run(ctx context.Context) error {
 	)
 
 	// Instantiate the sealing FSM.
-	m.sealing = sealing.New(ctx, adaptedAPI, m.feeCfg, evtsAdapter, m.maddr, m.metadataService, m.sectorInfoService, m.logService, m.sealer, m.sc, m.verif, m.prover,
+	m.sealing = sealing.New(ctx, adaptedAPI, m.feeCfg, evtsAdapter, m.maddr, m.metadataService, m.sectorInfoService, m.logService, m.eventService, m.sealer, m.sc, m.verif, m.prover,
 		&pcp, cfg, m.handleSealingNotifications, as, m.networkParams)
 
 	// Run the sealing FSM.
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
@@ -22,6 +23,14 @@ func (m *Miner) AddPieceToAnySector(ctx context.Context, size abi.UnpaddedPieceS
 	return m.sealing.AddPieceToAnySector(ctx, size, r, d)
 }
 
+func (m *Miner) AddOfflinePieceToAnySector(ctx context.Context, size abi.UnpaddedPieceSize, r io.Reader, d types.DealInfo) (abi.SectorNumber, abi.PaddedPieceSize, error) {
+	return m.sealing.AddOfflinePieceToAnySector(ctx, size, r, d)
+}
+
+func (m *Miner) ExpectedSealDuration() (time.Duration, error) {
+	return m.sealing.ExpectedSealDuration()
+}
+
 func (m *Miner) StartPackingSector(sectorNum abi.SectorNumber) error {
 	return m.sealing.StartPacking(sectorNum)
 }
@@ -66,6 +75,10 @@ func (m *Miner) SectorPreCommitPending(ctx context.Context) ([]abi.SectorID, err
 	return m.sealing.SectorPreCommitPending(ctx)
 }
 
+func (m *Miner) AutoPledgeStatus() (types.AutoPledgeStatus, error) {
+	return m.sealing.AutoPledgeStatus()
+}
+
 func (m *Miner) CommitFlush(ctx context.Context) ([]sealiface.CommitBatchRes, error) {
 	return m.sealing.CommitFlush(ctx)
 }
@@ -0,0 +1,358 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/network"
+	miner3 "github.com/filecoin-project/specs-actors/v3/actors/builtin/miner"
+
+	types3 "github.com/filecoin-project/venus-messager/types"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin/miner"
+	"github.com/filecoin-project/venus/pkg/specactors/policy"
+	"github.com/filecoin-project/venus/pkg/types"
+
+	"github.com/filecoin-project/venus-sealer/constants"
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+// extendGroup is a set of sectors of one partition extended to the same expiration
+type extendGroup struct {
+	loc        miner.SectorLocation
+	expiration abi.ChainEpoch
+	sectors    []uint64
+}
+
+// ExtendSectors plans the ExtendSectorExpiration messages of req, sectors are grouped by partition and the
+// messages respect the declaration and addressed sector limits of the network. Unless req.DryRun is set the
+// messages are sent through the messager.
+func (m *Miner) ExtendSectors(ctx context.Context, req types2.ExtendRequest) (types2.ExtendPlan, error) {
+	head, err := m.api.ChainHead(ctx)
+	if err != nil {
+		return types2.ExtendPlan{}, xerrors.Errorf("getting chain head: %w", err)
+	}
+	tsk := head.Key()
+
+	nv, err := m.api.StateNetworkVersion(ctx, tsk)
+	if err != nil {
+		return types2.ExtendPlan{}, xerrors.Errorf("getting network version: %w", err)
+	}
+
+	var sectors []*miner.SectorOnChainInfo
+	if len(req.Sectors) > 0 {
+		for _, num := range req.Sectors {
+			si, err := m.api.StateSectorGetInfo(ctx, m.maddr, num, tsk)
+			if err != nil {
+				return types2.ExtendPlan{}, xerrors.Errorf("getting sector %d info: %w", num, err)
+			}
+			if si == nil {
+				return types2.ExtendPlan{}, xerrors.Errorf("sector %d not found on chain", num)
+			}
+			sectors = append(sectors, si)
+		}
+	} else {
+		sectors, err = m.api.StateMinerActiveSectors(ctx, m.maddr, tsk)
+		if err != nil {
+			return types2.ExtendPlan{}, xerrors.Errorf("getting active sectors: %w", err)
+		}
+	}
+
+	locations, err := m.sectorLocations(ctx, tsk)
+	if err != nil {
+		return types2.ExtendPlan{}, err
+	}
+
+	all, skipped, err := groupExtensions(req, sectors, locations, head.Height(), nv)
+	if err != nil {
+		return types2.ExtendPlan{}, err
+	}
+	plan := types2.ExtendPlan{Sectors: uint64(len(sectors)) - skipped, Skipped: skipped}
+
+	mi, err := m.api.StateMinerInfo(ctx, m.maddr, tsk)
+	if err != nil {
+		return types2.ExtendPlan{}, xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	maxFee := abi.TokenAmount(m.feeCfg().MaxExtendGasFee)
+	plan.EstimatedFee = big.Zero()
+	var msgs []*extendMessage
+	for _, exts := range packExtensions(all, policy.GetAddressedSectorsMax(nv), policy.GetDeclarationsMax(nv)) {
+		msg, err := m.extendMessage(ctx, mi.Worker, exts, maxFee, tsk)
+		if err != nil {
+			return types2.ExtendPlan{}, err
+		}
+		msgs = append(msgs, msg)
+		plan.Messages = append(plan.Messages, *msg.info)
+		plan.EstimatedFee = big.Add(plan.EstimatedFee, msg.info.EstimatedFee)
+	}
+	if req.DryRun {
+		return plan, nil
+	}
+
+	for i, msg := range msgs {
+		uid, err := m.messager.PushMessage(ctx, msg.msg, &types3.MsgMeta{MaxFee: maxFee})
+		if err != nil {
+			return plan, xerrors.Errorf("pushing extend message %d of %d: %w", i+1, len(msgs), err)
+		}
+		plan.Messages[i].MsgID = uid
+		log.Infow("sent extend sector expiration message", "uid", uid, "sectors", msg.info.Sectors)
+
+		m.extendLk.Lock()
+		m.extendMsgs = append(m.extendMsgs, plan.Messages[i])
+		m.extendLk.Unlock()
+
+		go m.waitExtend(uid)
+	}
+
+	return plan, nil
+}
+
+type extendMessage struct {
+	msg  *types.Message
+	info *types2.ExtendMessage
+}
+
+func (m *Miner) extendMessage(ctx context.Context, from address.Address, exts []types2.ExtendExtension, maxFee abi.TokenAmount, tsk types.TipSetKey) (*extendMessage, error) {
+	params := miner3.ExtendSectorExpirationParams{}
+	var count uint64
+	for _, ext := range exts {
+		params.Extensions = append(params.Extensions, miner3.ExpirationExtension{
+			Deadline:      ext.Deadline,
+			Partition:     ext.Partition,
+			Sectors:       ext.Sectors,
+			NewExpiration: ext.NewExpiration,
+		})
+		count += ext.Count
+	}
+
+	enc := new(bytes.Buffer)
+	if err := params.MarshalCBOR(enc); err != nil {
+		return nil, xerrors.Errorf("serializing extend params: %w", err)
+	}
+
+	msg := &types.Message{
+		From:   from,
+		To:     m.maddr,
+		Method: miner.Methods.ExtendSectorExpiration,
+		Value:  big.Zero(),
+		Params: enc.Bytes(),
+	}
+	gm, err := m.api.GasEstimateMessageGas(ctx, msg, &types.MessageSendSpec{MaxFee: maxFee}, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("estimating gas of extend message: %w", err)
+	}
+
+	return &extendMessage{
+		msg: msg,
+		info: &types2.ExtendMessage{
+			Extensions:   exts,
+			Sectors:      count,
+			GasLimit:     gm.GasLimit,
+			EstimatedFee: big.Mul(gm.GasFeeCap, big.NewInt(gm.GasLimit)),
+		},
+	}, nil
+}
+
+func (m *Miner) waitExtend(uid string) {
+	rec, err := m.messager.WaitMessage(context.TODO(), uid, constants.MessageConfidence)
+	if err != nil {
+		log.Errorf("waiting for extend message %s: %+v", uid, err)
+		return
+	}
+	if rec.Receipt.ExitCode != 0 {
+		log.Errorf("extend message %s failed: exit %d", uid, rec.Receipt.ExitCode)
+		return
+	}
+	log.Infof("extend message %s landed", uid)
+}
+
+// ExtendStatus returns the messager state of the extend messages with the given ids, or of the ones sent
+// since the sealer started if ids is empty
+func (m *Miner) ExtendStatus(ctx context.Context, ids []string) ([]types2.ExtendMessage, error) {
+	var out []types2.ExtendMessage
+	if len(ids) == 0 {
+		m.extendLk.Lock()
+		out = append(out, m.extendMsgs...)
+		m.extendLk.Unlock()
+	} else {
+		for _, id := range ids {
+			out = append(out, types2.ExtendMessage{MsgID: id})
+		}
+	}
+
+	for i := range out {
+		msg, err := m.messager.GetMessageByUid(ctx, out[i].MsgID)
+		if err != nil {
+			out[i].State = "unknown"
+			out[i].Error = err.Error()
+			continue
+		}
+		out[i].State, out[i].Error = messageState(msg)
+	}
+	return out, nil
+}
+
+func messageState(msg *types3.Message) (string, string) {
+	switch msg.State {
+	case types3.UnFillMsg, types3.FillMsg:
+		return "pending", ""
+	case types3.OnChainMsg, types3.ReplacedMsg:
+		if msg.Receipt != nil && msg.Receipt.ExitCode != 0 {
+			return "failed", fmt.Sprintf("exit %d", msg.Receipt.ExitCode)
+		}
+		return "on chain", ""
+	case types3.FailedMsg:
+		var reason string
+		if msg.Receipt != nil {
+			reason = string(msg.Receipt.ReturnValue)
+		}
+		return "failed", reason
+	}
+	return "unknown", ""
+}
+
+// sectorLocations returns the deadline and partition of every sector of the miner
+func (m *Miner) sectorLocations(ctx context.Context, tsk types.TipSetKey) (map[abi.SectorNumber]miner.SectorLocation, error) {
+	deadlines, err := m.api.StateMinerDeadlines(ctx, m.maddr, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("getting deadlines: %w", err)
+	}
+
+	out := map[abi.SectorNumber]miner.SectorLocation{}
+	for dlIdx := range deadlines {
+		partitions, err := m.api.StateMinerPartitions(ctx, m.maddr, uint64(dlIdx), tsk)
+		if err != nil {
+			return nil, xerrors.Errorf("getting partitions of deadline %d: %w", dlIdx, err)
+		}
+		for partIdx, part := range partitions {
+			err := part.AllSectors.ForEach(func(num uint64) error {
+				out[abi.SectorNumber(num)] = miner.SectorLocation{Deadline: uint64(dlIdx), Partition: uint64(partIdx)}
+				return nil
+			})
+			if err != nil {
+				return nil, xerrors.Errorf("iterating sectors of partition %d/%d: %w", dlIdx, partIdx, err)
+			}
+		}
+	}
+	return out, nil
+}
+
+// extendedExpiration returns the new expiration of si, false if si shouldn't be extended
+func extendedExpiration(req types2.ExtendRequest, si *miner.SectorOnChainInfo, height, maxLifetime abi.ChainEpoch) (abi.ChainEpoch, bool) {
+	if req.V1Only && si.SealProof >= abi.RegisteredSealProof_StackedDrg2KiBV1_1 {
+		return 0, false
+	}
+	if req.OnlyCC && len(si.DealIDs) > 0 {
+		return 0, false
+	}
+	if req.Cutoff > 0 && si.Expiration > height+req.Cutoff {
+		return 0, false
+	}
+
+	// the maximum lifetime less two proving periods
+	maxExp := si.Activation + maxLifetime - miner3.WPoStProvingPeriod*2
+	if maxExtension := height + policy.GetMaxSectorExpirationExtension(); maxExp > maxExtension {
+		maxExp = maxExtension
+	}
+
+	newExp := req.NewExpiration
+	if newExp == 0 || newExp > maxExp {
+		newExp = maxExp
+	}
+	if newExp-si.Expiration <= req.Tolerance || newExp <= si.Expiration {
+		return 0, false
+	}
+	return newExp, true
+}
+
+// groupExtensions groups the sectors to extend by partition and new expiration, ordered by deadline, partition
+// and expiration. A sector only joins a group whose expiration is within the tolerance below its own new
+// expiration, so no group is extended past the lifetime cap of one of its sectors. Returns the number of
+// sectors skipped
+func groupExtensions(req types2.ExtendRequest, sectors []*miner.SectorOnChainInfo, locations map[abi.SectorNumber]miner.SectorLocation, height abi.ChainEpoch, nv network.Version) ([]*extendGroup, uint64, error) {
+	var skipped uint64
+	groups := map[miner.SectorLocation][]*extendGroup{}
+	for _, si := range sectors {
+		newExp, ok := extendedExpiration(req, si, height, policy.GetSectorMaxLifetime(si.SealProof, nv))
+		if !ok {
+			skipped++
+			continue
+		}
+
+		loc, found := locations[si.SectorNumber]
+		if !found {
+			return nil, 0, xerrors.Errorf("sector %d not found in any partition", si.SectorNumber)
+		}
+
+		added := false
+		for _, g := range groups[loc] {
+			if g.expiration <= newExp && newExp-g.expiration <= req.Tolerance {
+				g.sectors = append(g.sectors, uint64(si.SectorNumber))
+				added = true
+				break
+			}
+		}
+		if !added {
+			groups[loc] = append(groups[loc], &extendGroup{loc: loc, expiration: newExp, sectors: []uint64{uint64(si.SectorNumber)}})
+		}
+	}
+
+	var all []*extendGroup
+	for _, gs := range groups {
+		all = append(all, gs...)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].loc.Deadline != all[j].loc.Deadline {
+			return all[i].loc.Deadline < all[j].loc.Deadline
+		}
+		if all[i].loc.Partition != all[j].loc.Partition {
+			return all[i].loc.Partition < all[j].loc.Partition
+		}
+		return all[i].expiration < all[j].expiration
+	})
+	return all, skipped, nil
+}
+
+// packExtensions packs the groups into messages with at most maxSectors sectors and maxDecls declarations,
+// groups larger than maxSectors are split
+func packExtensions(groups []*extendGroup, maxSectors, maxDecls int) [][]types2.ExtendExtension {
+	var out [][]types2.ExtendExtension
+	var cur []types2.ExtendExtension
+	count := 0
+
+	for _, g := range groups {
+		rest := g.sectors
+		for len(rest) > 0 {
+			if len(cur) == maxDecls || count == maxSectors {
+				out = append(out, cur)
+				cur, count = nil, 0
+			}
+
+			n := len(rest)
+			if n > maxSectors-count {
+				n = maxSectors - count
+			}
+			cur = append(cur, types2.ExtendExtension{
+				Deadline:      g.loc.Deadline,
+				Partition:     g.loc.Partition,
+				Sectors:       bitfield.NewFromSet(rest[:n]),
+				Count:         uint64(n),
+				NewExpiration: g.expiration,
+			})
+			count += n
+			rest = rest[n:]
+		}
+	}
+	if len(cur) > 0 {
+		out = append(out, cur)
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/network"
+	miner3 "github.com/filecoin-project/specs-actors/v3/actors/builtin/miner"
+
+	"github.com/filecoin-project/venus/pkg/specactors/builtin/miner"
+	"github.com/filecoin-project/venus/pkg/specactors/policy"
+
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+func TestPackExtensions(t *testing.T) {
+	seq := func(from, n uint64) []uint64 {
+		var out []uint64
+		for i := from; i < from+n; i++ {
+			out = append(out, i)
+		}
+		return out
+	}
+	groups := []*extendGroup{
+		{loc: miner.SectorLocation{Deadline: 0, Partition: 0}, expiration: 1000, sectors: seq(0, 5)},
+		{loc: miner.SectorLocation{Deadline: 0, Partition: 1}, expiration: 1000, sectors: seq(100, 12)},
+		{loc: miner.SectorLocation{Deadline: 1, Partition: 0}, expiration: 2000, sectors: seq(200, 1)},
+		{loc: miner.SectorLocation{Deadline: 2, Partition: 0}, expiration: 2000, sectors: seq(300, 1)},
+	}
+
+	msgs := packExtensions(groups, 10, 2)
+	var sectors uint64
+	for _, msg := range msgs {
+		require.LessOrEqual(t, len(msg), 2)
+		var count uint64
+		for _, ext := range msg {
+			n, err := ext.Sectors.Count()
+			require.NoError(t, err)
+			require.Equal(t, ext.Count, n)
+			count += n
+		}
+		require.LessOrEqual(t, count, uint64(10))
+		sectors += count
+	}
+	require.Equal(t, uint64(19), sectors)
+	require.Len(t, msgs, 3)
+
+	require.Empty(t, packExtensions(nil, 10, 2))
+}
+
+func TestExtendedExpiration(t *testing.T) {
+	height := abi.ChainEpoch(100000)
+	maxLifetime := abi.ChainEpoch(5 * 365 * 2880)
+	si := &miner.SectorOnChainInfo{
+		SealProof:  abi.RegisteredSealProof_StackedDrg32GiBV1_1,
+		Activation: 1000,
+		Expiration: 200000,
+	}
+
+	exp, ok := extendedExpiration(types2.ExtendRequest{NewExpiration: 300000}, si, height, maxLifetime)
+	require.True(t, ok)
+	require.Equal(t, abi.ChainEpoch(300000), exp)
+
+	// extending by less than the tolerance is skipped
+	_, ok = extendedExpiration(types2.ExtendRequest{NewExpiration: 210000, Tolerance: 20160}, si, height, maxLifetime)
+	require.False(t, ok)
+
+	// shortening is skipped
+	_, ok = extendedExpiration(types2.ExtendRequest{NewExpiration: 150000}, si, height, maxLifetime)
+	require.False(t, ok)
+
+	// the maximum lifetime by default
+	exp, ok = extendedExpiration(types2.ExtendRequest{}, si, height, maxLifetime)
+	require.True(t, ok)
+	require.LessOrEqual(t, exp, si.Activation+maxLifetime-miner3.WPoStProvingPeriod*2)
+
+	_, ok = extendedExpiration(types2.ExtendRequest{NewExpiration: 300000, V1Only: true}, si, height, maxLifetime)
+	require.False(t, ok)
+
+	_, ok = extendedExpiration(types2.ExtendRequest{NewExpiration: 300000, Cutoff: 50000}, si, height, maxLifetime)
+	require.False(t, ok)
+
+	si.DealIDs = []abi.DealID{1}
+	_, ok = extendedExpiration(types2.ExtendRequest{NewExpiration: 300000, OnlyCC: true}, si, height, maxLifetime)
+	require.False(t, ok)
+}
+
+func TestGroupExtensionsLifetimeCap(t *testing.T) {
+	nv := network.Version13
+	proof := abi.RegisteredSealProof_StackedDrg32GiBV1_1
+	height := policy.GetSectorMaxLifetime(proof, nv) - 200000
+	req := types2.ExtendRequest{Tolerance: 20160}
+
+	// the same partition, sector 2 was activated earlier so its lifetime ends earlier
+	older := &miner.SectorOnChainInfo{SectorNumber: 2, SealProof: proof, Activation: 1000, Expiration: height + 100}
+	newer := &miner.SectorOnChainInfo{SectorNumber: 1, SealProof: proof, Activation: 5000, Expiration: height + 100}
+	loc := miner.SectorLocation{Deadline: 3, Partition: 0}
+	locations := map[abi.SectorNumber]miner.SectorLocation{1: loc, 2: loc}
+
+	caps := map[uint64]abi.ChainEpoch{}
+	for _, si := range []*miner.SectorOnChainInfo{older, newer} {
+		exp, ok := extendedExpiration(req, si, height, policy.GetSectorMaxLifetime(proof, nv))
+		require.True(t, ok)
+		caps[uint64(si.SectorNumber)] = exp
+	}
+	require.Less(t, int64(caps[2]), int64(caps[1]))
+	require.LessOrEqual(t, int64(caps[1]-caps[2]), int64(req.Tolerance))
+
+	for _, sectors := range [][]*miner.SectorOnChainInfo{{newer, older}, {older, newer}} {
+		groups, skipped, err := groupExtensions(req, sectors, locations, height, nv)
+		require.NoError(t, err)
+		require.Zero(t, skipped)
+
+		var count int
+		for _, g := range groups {
+			for _, num := range g.sectors {
+				require.LessOrEqual(t, int64(g.expiration), int64(caps[num]), "sector %d", num)
+				count++
+			}
+		}
+		require.Equal(t, 2, count)
+	}
+
+	// the later sector joins the group of the earlier cap
+	groups, _, err := groupExtensions(req, []*miner.SectorOnChainInfo{older, newer}, locations, height, nv)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Equal(t, caps[2], groups[0].expiration)
+}
@@ -62,21 +62,35 @@ func (st *SectorBlocks) AddPiece(ctx context.Context, size abi.UnpaddedPieceSize
 		return 0, 0, err
 	}
 
+	return sn, offset, st.writeRef(d, sn, offset, size)
+}
+
+// AddOfflinePiece adds the imported data of an offline deal
+func (st *SectorBlocks) AddOfflinePiece(ctx context.Context, size abi.UnpaddedPieceSize, r io.Reader, d types.DealInfo) (abi.SectorNumber, abi.PaddedPieceSize, error) {
+	sn, offset, err := st.Miner.AddOfflinePieceToAnySector(ctx, size, r, d)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return sn, offset, st.writeRef(d, sn, offset, size)
+}
+
+func (st *SectorBlocks) writeRef(d types.DealInfo, sn abi.SectorNumber, offset abi.PaddedPieceSize, size abi.UnpaddedPieceSize) error {
 	// TODO: DealID has very low finality here
 	st.keyLk.Lock() // TODO: make this multithreaded
 	defer st.keyLk.Unlock()
 
 	//todo save more db to database
-	err = st.keys.Save(uint64(d.DealID), types.SealedRef{
+	err := st.keys.Save(uint64(d.DealID), types.SealedRef{
 		SectorID: sn,
 		Offset:   offset,
 		Size:     size,
 	}, d.DealProposal) // TODO: batch somehow
 	if err != nil {
-		return 0, 0, xerrors.Errorf("writeRef: %w", err)
+		return xerrors.Errorf("writeRef: %w", err)
 	}
 
-	return sn, offset, nil
+	return nil
 }
 
 func (st *SectorBlocks) List() (map[uint64][]types.SealedRef, error) {
@@ -3,6 +3,7 @@ package storage
 import (
 	"bytes"
 	"context"
+	"sync"
 	"time"
 
 	"github.com/filecoin-project/go-address"
@@ -218,12 +219,14 @@ func (s *WindowPoStScheduler) checkSectors(ctx context.Context, check bitfield.B
 		})
 	}
 
-	bad, err := s.faultTracker.CheckProvable(ctx, s.proofType, tocheck, nil)
+	checks, err := s.faultTracker.CheckProvable(ctx, s.proofType, tocheck, nil)
 	if err != nil {
 		return bitfield.BitField{}, xerrors.Errorf("checking provable sectors: %w", err)
 	}
-	for id := range bad {
-		delete(sectors, id.Number)
+	for _, check := range checks {
+		if check.Faulty() {
+			delete(sectors, check.Sector.Number)
+		}
 	}
 
 	log.Warnw("Checked sectors", "checked", len(tocheck), "good", len(sectors))
@@ -321,12 +324,12 @@ func (s *WindowPoStScheduler) declareRecoveries(ctx context.Context, dlIdx uint6
 		Params: enc,
 		Value:  types.NewInt(0),
 	}
-	spec := &types.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)}
+	spec := &types.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg().MaxWindowPoStGasFee)}
 	if err := s.prepareMessage(ctx, msg, spec); err != nil {
 		return recoveries, nil, err
 	}
 
-	uid, err := s.Messager.PushMessage(ctx, msg, &types3.MsgMeta{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)})
+	uid, err := s.Messager.PushMessage(ctx, msg, &types3.MsgMeta{MaxFee: abi.TokenAmount(s.feeCfg().MaxWindowPoStGasFee)})
 	if err != nil {
 		return recoveries, nil, xerrors.Errorf("pushing message to mpool: %w", err)
 	}
@@ -423,7 +426,7 @@ func (s *WindowPoStScheduler) declareFaults(ctx context.Context, dlIdx uint64, p
 		Params: enc,
 		Value:  types.NewInt(0), // TODO: Is there a fee?
 	}
-	spec := &types.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)}
+	spec := &types.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg().MaxWindowPoStGasFee)}
 	if err := s.prepareMessage(ctx, msg, spec); err != nil {
 		return faults, nil, err
 	}
@@ -555,179 +558,200 @@ func (s *WindowPoStScheduler) runPoStCycle(ctx context.Context, di dline.Info, t
 		return nil, err
 	}
 
-	// Generate proofs in batches
-	posts := make([]miner.SubmitWindowedPoStParams, 0, len(partitionBatches))
-	for batchIdx, batch := range partitionBatches {
-		batchPartitionStartIdx := 0
-		for _, batch := range partitionBatches[:batchIdx] {
-			batchPartitionStartIdx += len(batch)
-		}
-
-		params := miner.SubmitWindowedPoStParams{
-			Deadline:   di.Index,
-			Partitions: make([]miner.PoStPartition, 0, len(batch)),
-			Proofs:     nil,
-		}
-
-		skipCount := uint64(0)
-		postSkipped := bitfield.New()
-		somethingToProve := false
-
-		// Retry until we run out of sectors to prove.
-		for retries := 0; ; retries++ {
-			var partitions []miner.PoStPartition
-			var sinfos []proof2.SectorInfo
-			for partIdx, partition := range batch {
-				// TODO: Can do this in parallel
-				toProve, err := bitfield.SubtractBitField(partition.LiveSectors, partition.FaultySectors)
-				if err != nil {
-					return nil, xerrors.Errorf("removing faults from set of sectors to prove: %w", err)
-				}
-				toProve, err = bitfield.MergeBitFields(toProve, partition.RecoveringSectors)
-				if err != nil {
-					return nil, xerrors.Errorf("adding recoveries to set of sectors to prove: %w", err)
-				}
-
-				good, err := s.checkSectors(ctx, toProve, ts.Key())
-				if err != nil {
-					return nil, xerrors.Errorf("checking sectors to skip: %w", err)
-				}
-
-				good, err = bitfield.SubtractBitField(good, postSkipped)
-				if err != nil {
-					return nil, xerrors.Errorf("toProve - postSkipped: %w", err)
-				}
+	mid, err := address.IDFromAddress(s.actor)
+	if err != nil {
+		return nil, err
+	}
 
-				skipped, err := bitfield.SubtractBitField(toProve, good)
-				if err != nil {
-					return nil, xerrors.Errorf("toProve - good: %w", err)
-				}
+	// Generate proofs in batches, the batches are proven in parallel so that the sector manager can spread them
+	// across the workers
+	results := make([]*miner.SubmitWindowedPoStParams, len(partitionBatches))
+	errs := make([]error, len(partitionBatches))
+	var wg sync.WaitGroup
+	batchPartitionStartIdx := 0
+	for batchIdx, batch := range partitionBatches {
+		wg.Add(1)
+		go func(batchIdx, batchPartitionStartIdx int, batch []apitypes.Partition) {
+			defer wg.Done()
+			results[batchIdx], errs[batchIdx] = s.proveBatch(ctx, di, ts, abi.ActorID(mid), abi.PoStRandomness(rand), buf.Bytes(), batchIdx, batchPartitionStartIdx, batch)
+		}(batchIdx, batchPartitionStartIdx, batch)
+		batchPartitionStartIdx += len(batch)
+	}
+	wg.Wait()
 
-				sc, err := skipped.Count()
-				if err != nil {
-					return nil, xerrors.Errorf("getting skipped sector count: %w", err)
-				}
+	posts := make([]miner.SubmitWindowedPoStParams, 0, len(partitionBatches))
+	for batchIdx, params := range results {
+		if errs[batchIdx] != nil {
+			return nil, xerrors.Errorf("proving batch %d: %w", batchIdx, errs[batchIdx])
+		}
+		if params != nil {
+			posts = append(posts, *params)
+		}
+	}
 
-				skipCount += sc
+	return posts, nil
+}
 
-				ssi, err := s.sectorsForProof(ctx, good, partition.AllSectors, ts)
-				if err != nil {
-					return nil, xerrors.Errorf("getting sorted sector info: %w", err)
-				}
+// proveBatch computes the proof of a batch of partitions, retrying without the sectors which couldn't be proven.
+// returns nil if there is nothing to prove in the batch
+func (s *WindowPoStScheduler) proveBatch(ctx context.Context, di dline.Info, ts *types.TipSet, mid abi.ActorID, rand abi.PoStRandomness, entropy []byte, batchIdx, batchPartitionStartIdx int, batch []apitypes.Partition) (*miner.SubmitWindowedPoStParams, error) {
+	params := miner.SubmitWindowedPoStParams{
+		Deadline:   di.Index,
+		Partitions: make([]miner.PoStPartition, 0, len(batch)),
+		Proofs:     nil,
+	}
+
+	skipCount := uint64(0)
+	postSkipped := bitfield.New()
+	somethingToProve := false
+
+	// Retry until we run out of sectors to prove.
+	for retries := 0; ; retries++ {
+		var partitions []miner.PoStPartition
+		var sinfos []proof2.SectorInfo
+		for partIdx, partition := range batch {
+			// TODO: Can do this in parallel
+			toProve, err := bitfield.SubtractBitField(partition.LiveSectors, partition.FaultySectors)
+			if err != nil {
+				return nil, xerrors.Errorf("removing faults from set of sectors to prove: %w", err)
+			}
+			toProve, err = bitfield.MergeBitFields(toProve, partition.RecoveringSectors)
+			if err != nil {
+				return nil, xerrors.Errorf("adding recoveries to set of sectors to prove: %w", err)
+			}
 
-				if len(ssi) == 0 {
-					continue
-				}
+			good, err := s.checkSectors(ctx, toProve, ts.Key())
+			if err != nil {
+				return nil, xerrors.Errorf("checking sectors to skip: %w", err)
+			}
 
-				sinfos = append(sinfos, ssi...)
-				partitions = append(partitions, miner.PoStPartition{
-					Index:   uint64(batchPartitionStartIdx + partIdx),
-					Skipped: skipped,
-				})
+			good, err = bitfield.SubtractBitField(good, postSkipped)
+			if err != nil {
+				return nil, xerrors.Errorf("toProve - postSkipped: %w", err)
 			}
 
-			if len(sinfos) == 0 {
-				// nothing to prove for this batch
-				break
+			skipped, err := bitfield.SubtractBitField(toProve, good)
+			if err != nil {
+				return nil, xerrors.Errorf("toProve - good: %w", err)
 			}
 
-			// Generate proof
-			log.Infow("running window post",
-				"chain-random", rand,
-				"deadline", di,
-				"height", ts.Height(),
-				"skipped", skipCount)
+			sc, err := skipped.Count()
+			if err != nil {
+				return nil, xerrors.Errorf("getting skipped sector count: %w", err)
+			}
 
-			tsStart := constants.Clock.Now()
+			skipCount += sc
 
-			mid, err := address.IDFromAddress(s.actor)
+			ssi, err := s.sectorsForProof(ctx, good, partition.AllSectors, ts)
 			if err != nil {
-				return nil, err
+				return nil, xerrors.Errorf("getting sorted sector info: %w", err)
 			}
 
-			postOut, ps, err := s.prover.GenerateWindowPoSt(ctx, abi.ActorID(mid), sinfos, append(abi.PoStRandomness{}, rand...))
-			elapsed := time.Since(tsStart)
+			if len(ssi) == 0 {
+				continue
+			}
 
-			log.Infow("computing window post", "batch", batchIdx, "elapsed", elapsed)
+			sinfos = append(sinfos, ssi...)
+			partitions = append(partitions, miner.PoStPartition{
+				Index:   uint64(batchPartitionStartIdx + partIdx),
+				Skipped: skipped,
+			})
+		}
 
-			if err == nil {
-				// If we proved nothing, something is very wrong.
-				if len(postOut) == 0 {
-					return nil, xerrors.Errorf("received no proofs back from generate window post")
-				}
+		if len(sinfos) == 0 {
+			// nothing to prove for this batch
+			break
+		}
 
-				headTs, err := s.api.ChainHead(ctx)
-				if err != nil {
-					return nil, xerrors.Errorf("getting current head: %w", err)
-				}
+		// Generate proof
+		log.Infow("running window post",
+			"chain-random", rand,
+			"deadline", di,
+			"height", ts.Height(),
+			"skipped", skipCount)
 
-				checkRand, err := s.api.ChainGetRandomnessFromBeacon(ctx, headTs.Key(), crypto.DomainSeparationTag_WindowedPoStChallengeSeed, di.Challenge, buf.Bytes())
-				if err != nil {
-					return nil, xerrors.Errorf("failed to get chain randomness from beacon for window post (ts=%d; deadline=%d): %w", ts.Height(), di, err)
-				}
+		tsStart := constants.Clock.Now()
 
-				if !bytes.Equal(checkRand, rand) {
-					log.Warnw("windowpost randomness changed", "old", rand, "new", checkRand, "ts-height", ts.Height(), "challenge-height", di.Challenge, "tsk", ts.Key())
-					continue
-				}
+		postOut, ps, err := s.prover.GenerateWindowPoSt(ctx, mid, sinfos, append(abi.PoStRandomness{}, rand...))
+		elapsed := time.Since(tsStart)
 
-				// If we generated an incorrect proof, try again.
-				if correct, err := s.verifier.VerifyWindowPoSt(ctx, proof.WindowPoStVerifyInfo{
-					Randomness:        abi.PoStRandomness(checkRand),
-					Proofs:            postOut,
-					ChallengedSectors: sinfos,
-					Prover:            abi.ActorID(mid),
-				}); err != nil {
-					log.Errorw("window post verification failed", "post", postOut, "error", err)
-					time.Sleep(5 * time.Second)
-					continue
-				} else if !correct {
-					log.Errorw("generated incorrect window post proof", "post", postOut, "error", err)
-					continue
-				}
+		log.Infow("computing window post", "batch", batchIdx, "elapsed", elapsed)
 
-				// Proof generation successful, stop retrying
-				somethingToProve = true
-				params.Partitions = partitions
-				params.Proofs = postOut
-				break
+		if err == nil {
+			// If we proved nothing, something is very wrong.
+			if len(postOut) == 0 {
+				return nil, xerrors.Errorf("received no proofs back from generate window post")
 			}
 
-			// Proof generation failed, so retry
-
-			if len(ps) == 0 {
-				// If we didn't skip any new sectors, we failed
-				// for some other reason and we need to abort.
-				return nil, xerrors.Errorf("running window post failed: %w", err)
+			headTs, err := s.api.ChainHead(ctx)
+			if err != nil {
+				return nil, xerrors.Errorf("getting current head: %w", err)
 			}
-			// TODO: maybe mark these as faulty somewhere?
 
-			log.Warnw("generate window post skipped sectors", "sectors", ps, "error", err, "try", retries)
+			checkRand, err := s.api.ChainGetRandomnessFromBeacon(ctx, headTs.Key(), crypto.DomainSeparationTag_WindowedPoStChallengeSeed, di.Challenge, entropy)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to get chain randomness from beacon for window post (ts=%d; deadline=%d): %w", ts.Height(), di, err)
+			}
 
-			// Explicitly make sure we haven't aborted this PoSt
-			// (GenerateWindowPoSt may or may not check this).
-			// Otherwise, we could try to continue proving a
-			// deadline after the deadline has ended.
-			if ctx.Err() != nil {
-				log.Warnw("aborting PoSt due to context cancellation", "error", ctx.Err(), "deadline", di.Index)
-				return nil, ctx.Err()
+			if !bytes.Equal(checkRand, rand) {
+				log.Warnw("windowpost randomness changed", "old", rand, "new", checkRand, "ts-height", ts.Height(), "challenge-height", di.Challenge, "tsk", ts.Key())
+				continue
 			}
 
-			skipCount += uint64(len(ps))
-			for _, sector := range ps {
-				postSkipped.Set(uint64(sector.Number))
+			// If we generated an incorrect proof, try again.
+			if correct, err := s.verifier.VerifyWindowPoSt(ctx, proof.WindowPoStVerifyInfo{
+				Randomness:        abi.PoStRandomness(checkRand),
+				Proofs:            postOut,
+				ChallengedSectors: sinfos,
+				Prover:            mid,
+			}); err != nil {
+				log.Errorw("window post verification failed", "post", postOut, "error", err)
+				time.Sleep(5 * time.Second)
+				continue
+			} else if !correct {
+				log.Errorw("generated incorrect window post proof", "post", postOut, "error", err)
+				continue
 			}
+
+			// Proof generation successful, stop retrying
+			somethingToProve = true
+			params.Partitions = partitions
+			params.Proofs = postOut
+			break
 		}
 
-		// Nothing to prove for this batch, try the next batch
-		if !somethingToProve {
-			continue
+		// Proof generation failed, so retry
+
+		if len(ps) == 0 {
+			// If we didn't skip any new sectors, we failed
+			// for some other reason and we need to abort.
+			return nil, xerrors.Errorf("running window post failed: %w", err)
 		}
+		// TODO: maybe mark these as faulty somewhere?
+
+		log.Warnw("generate window post skipped sectors", "sectors", ps, "error", err, "try", retries)
 
-		posts = append(posts, params)
+		// Explicitly make sure we haven't aborted this PoSt
+		// (GenerateWindowPoSt may or may not check this).
+		// Otherwise, we could try to continue proving a
+		// deadline after the deadline has ended.
+		if ctx.Err() != nil {
+			log.Warnw("aborting PoSt due to context cancellation", "error", ctx.Err(), "deadline", di.Index)
+			return nil, ctx.Err()
+		}
+
+		skipCount += uint64(len(ps))
+		for _, sector := range ps {
+			postSkipped.Set(uint64(sector.Number))
+		}
 	}
 
-	return posts, nil
+	// Nothing to prove for this batch
+	if !somethingToProve {
+		return nil, nil
+	}
+
+	return &params, nil
 }
 
 func (s *WindowPoStScheduler) batchPartitions(partitions []apitypes.Partition, nv network.Version) ([][]apitypes.Partition, error) {
@@ -831,7 +855,7 @@ func (s *WindowPoStScheduler) submitPoStMessage(ctx context.Context, proof *mine
 		Params: enc,
 		Value:  types.NewInt(0),
 	}
-	spec := &types.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)}
+	spec := &types.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg().MaxWindowPoStGasFee)}
 
 	var (
 		uid string
@@ -170,9 +170,13 @@ func (m mockVerif) GenerateWinningPoStSectorChallenge(context.Context, abi.Regis
 type mockFaultTracker struct {
 }
 
-func (m mockFaultTracker) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, rg storiface.RGetter) (map[abi.SectorID]string, error) {
-	// Returns "bad" sectors so just return empty map meaning all sectors are good
-	return map[abi.SectorID]string{}, nil
+func (m mockFaultTracker) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, rg storiface.RGetter) ([]storiface.SectorCheck, error) {
+	// All sectors are good
+	checks := make([]storiface.SectorCheck, len(sectors))
+	for i, sector := range sectors {
+		checks[i] = storiface.SectorCheck{Sector: sector.ID, Code: storiface.CheckOK}
+	}
+	return checks, nil
 }
 
 // TestWDPostDoPost verifies that doPost will send the correct number of window
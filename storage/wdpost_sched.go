@@ -34,7 +34,7 @@ type WindowPoStScheduler struct {
 	networkParams *config.NetParamsConfig
 
 	api              fullNodeFilteredAPI
-	feeCfg           config.MinerFeeConfig
+	feeCfg           config.GetFeeConfigFunc
 	addrSel          *AddressSelector
 	prover           storage.Prover
 	verifier         ffiwrapper.Verifier
@@ -55,7 +55,7 @@ type WindowPoStScheduler struct {
 // NewWindowedPoStScheduler creates a new WindowPoStScheduler scheduler.
 func NewWindowedPoStScheduler(api fullNodeFilteredAPI,
 	messager api.IMessager,
-	fc config.MinerFeeConfig,
+	fc config.GetFeeConfigFunc,
 	as *AddressSelector,
 	sp storage.Prover,
 	verif ffiwrapper.Verifier,
@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+// AutoPledgeStatus is the state of the automatic CC pledging which keeps the sealing pipeline filled
+type AutoPledgeStatus struct {
+	// TargetSealing is the configured pipeline depth, 0 when auto pledging is disabled
+	TargetSealing uint64
+	// TargetSectors stops auto pledging once the sealer has this many sectors, 0 = no limit
+	TargetSectors uint64
+
+	// Sealing counts the sectors in the sealing pipeline, including failed ones
+	Sealing uint64
+	// Total counts the sectors sealing or sealed
+	Total uint64
+
+	// Pledged counts the CC sectors pledged automatically since the sealer started
+	Pledged    uint64
+	LastPledge time.Time
+	LastCheck  time.Time
+	// Waiting is why the last check didn't pledge a sector, empty if it did
+	Waiting string
+}
@@ -0,0 +1,44 @@
+package types
+
+import (
+	"time"
+
+	"github.com/ipfs/go-cid"
+)
+
+// DealPolicy decides which deals the sealer accepts, it is persisted in the metadata repo.
+// there is no retrieval market in the sealer, the retrieval switches are only kept for market tooling
+type DealPolicy struct {
+	ConsiderOnlineStorageDeals     bool
+	ConsiderOfflineStorageDeals    bool
+	ConsiderOnlineRetrievalDeals   bool
+	ConsiderOfflineRetrievalDeals  bool
+	ConsiderVerifiedStorageDeals   bool
+	ConsiderUnverifiedStorageDeals bool
+	PieceCidBlocklist              []cid.Cid
+	// ExpectedSealDuration is the time a sector is expected to take to seal, 0 means not set
+	ExpectedSealDuration time.Duration
+}
+
+// DefaultDealPolicy accepts every kind of deal
+func DefaultDealPolicy() *DealPolicy {
+	return &DealPolicy{
+		ConsiderOnlineStorageDeals:     true,
+		ConsiderOfflineStorageDeals:    true,
+		ConsiderOnlineRetrievalDeals:   true,
+		ConsiderOfflineRetrievalDeals:  true,
+		ConsiderVerifiedStorageDeals:   true,
+		ConsiderUnverifiedStorageDeals: true,
+		PieceCidBlocklist:              []cid.Cid{},
+	}
+}
+
+// IsBlocklisted returns whether piece is in the piece cid blocklist
+func (p *DealPolicy) IsBlocklisted(piece cid.Cid) bool {
+	for _, blocked := range p.PieceCidBlocklist {
+		if blocked.Equals(piece) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// ExtendRequest selects the sectors to extend and their new expiration
+type ExtendRequest struct {
+	// Sectors to extend, all active sectors when empty
+	Sectors []abi.SectorNumber
+	// NewExpiration of the sectors, 0 extends every sector to its maximum lifetime
+	NewExpiration abi.ChainEpoch
+	// Cutoff skips sectors expiring more than this many epochs from now, 0 = no cutoff
+	Cutoff abi.ChainEpoch
+	// Tolerance skips sectors which would be extended by fewer epochs, sectors whose new expirations are
+	// this close are extended to the same epoch
+	Tolerance abi.ChainEpoch
+	// OnlyCC skips sectors with deals
+	OnlyCC bool
+	// V1Only skips sectors sealed with v1.1 proofs
+	V1Only bool
+	// DryRun plans the messages without sending them
+	DryRun bool
+}
+
+// ExtendExtension extends the sectors of one partition to NewExpiration
+type ExtendExtension struct {
+	Deadline      uint64
+	Partition     uint64
+	Sectors       bitfield.BitField
+	Count         uint64
+	NewExpiration abi.ChainEpoch
+}
+
+// ExtendMessage is one ExtendSectorExpiration message of a plan
+type ExtendMessage struct {
+	Extensions []ExtendExtension
+	Sectors    uint64
+	GasLimit   int64
+	// EstimatedFee is the gas limit times the estimated fee cap
+	EstimatedFee abi.TokenAmount
+
+	// MsgID is the messager id of the message, empty on dry runs
+	MsgID string
+	// State is the messager state of the message, filled in by SectorsExtendStatus
+	State string
+	Error string
+}
+
+// ExtendPlan is the set of messages extending the requested sectors
+type ExtendPlan struct {
+	Messages     []ExtendMessage
+	Sectors      uint64
+	Skipped      uint64
+	EstimatedFee abi.TokenAmount
+}
@@ -0,0 +1,30 @@
+package types
+
+import "time"
+
+// ProofGatewayState is the connection state of a gateway the sealer serves winning PoSt requests for
+type ProofGatewayState string
+
+const (
+	GatewayConnecting ProofGatewayState = "connecting"
+	GatewayConnected  ProofGatewayState = "connected"
+	GatewayBackoff    ProofGatewayState = "backoff"
+	GatewayStopped    ProofGatewayState = "stopped"
+)
+
+// ProofGatewayStatus is the connection state and winning PoSt counters of one gateway
+type ProofGatewayStatus struct {
+	URL         string
+	State       ProofGatewayState
+	ConnectedAt time.Time
+	// Reconnects counts connection attempts after the first one
+	Reconnects  uint64
+	LastError   string
+	LastErrorAt time.Time
+	// NextRetry is set while the connection is in backoff
+	NextRetry time.Time
+
+	ProofsServed     uint64
+	ProofsFailed     uint64
+	LastProofLatency time.Duration
+}
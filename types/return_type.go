@@ -14,4 +14,8 @@ const (
 	ReturnUnsealPiece     ReturnType = "ReturnUnsealPiece"
 	ReturnReadPiece       ReturnType = "ReturnReadPiece"
 	ReturnFetch           ReturnType = "ReturnFetch"
+
+	ReturnGenerateWindowPoSt        ReturnType = "ReturnGenerateWindowPoSt"
+	ReturnGenerateWindowPoStVanilla ReturnType = "ReturnGenerateWindowPoStVanilla"
+	ReturnGenerateWinningPoSt       ReturnType = "ReturnGenerateWinningPoSt"
 )
@@ -0,0 +1,66 @@
+package types
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// sealing stages measured by SealingStats, in pipeline order
+const (
+	StageAddPiece         = "AP"
+	StagePreCommit1       = "PC1"
+	StagePreCommit2       = "PC2"
+	StagePreCommitLanding = "PreCommitLanding"
+	StageCommit1          = "C1"
+	StageCommit2          = "C2"
+	StageCommitLanding    = "CommitLanding"
+	StageFinalize         = "Finalize"
+)
+
+var SealingStages = []string{
+	StageAddPiece,
+	StagePreCommit1,
+	StagePreCommit2,
+	StagePreCommitLanding,
+	StageCommit1,
+	StageCommit2,
+	StageCommitLanding,
+	StageFinalize,
+}
+
+// StageStats are the durations of the runs of a sealing stage which finished successfully, and its failures
+type StageStats struct {
+	Stage       string
+	Count       int
+	Failures    int
+	FailureRate float64
+
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// SealingStatsGroup are the stats of the sectors of one size on one worker, chain stages are grouped under an
+// empty Worker
+type SealingStatsGroup struct {
+	Worker     string
+	SectorSize abi.SectorSize
+	// Completed is the number of sectors which reached Proving, the worker is the one which finalized them
+	Completed int
+	Stages    []StageStats
+}
+
+type SealingStats struct {
+	Since time.Time
+	Until time.Time
+
+	Completed int
+	PerHour   float64
+	PerDay    float64
+
+	// Stages are the stats of every worker and sector size
+	Stages []StageStats
+	Groups []SealingStatsGroup
+}
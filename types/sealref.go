@@ -1,6 +1,9 @@
 package types
 
-import "github.com/filecoin-project/go-state-types/abi"
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+)
 
 type SealedRef struct {
 	SectorID abi.SectorNumber
@@ -11,3 +14,11 @@ type SealedRef struct {
 type SealedRefs struct {
 	Refs []SealedRef
 }
+
+// PieceRef is a deal piece and where it is sealed, PieceCID and PayloadCID are nil when unknown
+type PieceRef struct {
+	DealID     uint64
+	PieceCID   *cid.Cid
+	PayloadCID *cid.Cid
+	SealedRef
+}
@@ -0,0 +1,19 @@
+package types
+
+import (
+	"context"
+)
+
+type sectorDealsCtxKey int
+
+var SectorDealsKey sectorDealsCtxKey
+
+// WithDeals marks the sealing calls of a sector holding deals
+func WithDeals(ctx context.Context) context.Context {
+	return context.WithValue(ctx, SectorDealsKey, true)
+}
+
+func HasDeals(ctx context.Context) bool {
+	deals, _ := ctx.Value(SectorDealsKey).(bool)
+	return deals
+}
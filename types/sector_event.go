@@ -0,0 +1,43 @@
+package types
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// SectorEvent is one state transition of a sector
+type SectorEvent struct {
+	ID           int64
+	SectorNumber abi.SectorNumber
+	Time         time.Time
+	// Event is the type of the fsm event which caused the transition, eg. SectorPreCommit2
+	Event string
+	From  SectorState
+	To    SectorState
+	// Worker is the hostname of the worker which ran the last task of the sector, if any
+	Worker string
+	// Duration is how long the sector was in From
+	Duration   time.Duration
+	Error      string
+	ErrorClass string
+}
+
+// SectorEventFilter selects sector events, zero fields match everything
+type SectorEventFilter struct {
+	Sectors []abi.SectorNumber
+	Since   time.Time
+	Until   time.Time
+	// State matches the events entering or leaving the state
+	State      SectorState
+	Event      string
+	Worker     string
+	ErrorClass string
+	// OnlyErrors matches the events with an error
+	OnlyErrors bool
+	// Limit returns the latest Limit matching events, 0 = no limit
+	Limit int
+}
+
+// SectorEventCommit1Done is recorded when Commit1 of a sector finishes, Committing runs both Commit1 and Commit2
+const SectorEventCommit1Done = "Commit1Done"
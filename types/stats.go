@@ -84,3 +84,11 @@ func (ss *SectorStats) CurStaging() uint64 {
 
 	return ss.curStagingLocked()
 }
+
+// return the number of sectors in the sealing pipeline or sealed
+func (ss *SectorStats) CurTotal() uint64 {
+	ss.lk.Lock()
+	defer ss.lk.Unlock()
+
+	return ss.curSealingLocked() + ss.Totals[SstProving]
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// StorageRecord is a sector storage of the persisted storage index
+type StorageRecord struct {
+	ID         string
+	URLs       []string
+	Weight     uint64
+	MaxStorage uint64
+	CanSeal    bool
+	CanStore   bool
+
+	// placement rules
+	Groups     []string
+	AllowTo    []string
+	AllowTypes []string
+	DenyTypes  []string
+	AllowKinds []string
+
+	LastHeartbeat time.Time
+	HeartbeatErr  string
+}
+
+// SectorDeclRecord is a sector file of the persisted storage index, FileType is a single storiface.SectorFileType
+type SectorDeclRecord struct {
+	StorageID string
+	Miner     abi.ActorID
+	Number    abi.SectorNumber
+	FileType  int
+	Primary   bool
+}
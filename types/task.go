@@ -13,6 +13,9 @@ const (
 
 	TTFetch  TaskType = "seal/v0/fetch"
 	TTUnseal TaskType = "seal/v0/unseal"
+
+	TTGenerateWindowPoSt  TaskType = "post/v0/windowproof"
+	TTGenerateWinningPoSt TaskType = "post/v0/winningproof"
 )
 
 var order = map[TaskType]int{
@@ -23,7 +26,10 @@ var order = map[TaskType]int{
 	TTCommit1:    2,
 	TTUnseal:     1,
 	TTFetch:      -1,
-	TTFinalize:   -2, // most priority
+	TTFinalize:   -2,
+
+	TTGenerateWindowPoSt:  -3,
+	TTGenerateWinningPoSt: -4, // most priority
 }
 
 var shortNames = map[TaskType]string{
@@ -38,6 +44,9 @@ var shortNames = map[TaskType]string{
 
 	TTFetch:  "GET",
 	TTUnseal: "UNS",
+
+	TTGenerateWindowPoSt:  "WDP",
+	TTGenerateWinningPoSt: "WNP",
 }
 
 func (a TaskType) MuchLess(b TaskType) (bool, bool) {
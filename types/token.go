@@ -0,0 +1,22 @@
+package types
+
+import "time"
+
+// TokenInfo describes an API token minted by TokenCreate, the token itself is not stored
+type TokenInfo struct {
+	ID   string
+	Name string
+
+	Perms   []string
+	Methods []string
+
+	Created time.Time
+	// zero if the token doesn't expire
+	Expires time.Time
+	Revoked bool
+}
+
+// Expired reports whether the token is expired at now
+func (t *TokenInfo) Expired(now time.Time) bool {
+	return !t.Expires.IsZero() && !now.Before(t.Expires)
+}
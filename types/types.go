@@ -20,6 +20,12 @@ import (
 )
 
 var DealSectorPriority = 1024
+
+// WinningPoStPriority puts winning post ahead of every other task, a late proof loses the block
+var WinningPoStPriority = 1 << 30
+
+// WindowPoStPriority puts window post and the checks of the sectors before it ahead of sealing work
+var WindowPoStPriority = 1 << 29
 var MaxTicketAge = policy.MaxPreCommitRandomnessLookback
 
 // Piece is a tuple of piece and deal info
@@ -162,7 +168,7 @@ func (t *SectorInfo) SealingCtx(ctx context.Context) context.Context {
 	//  we need sealed sooner
 
 	if t.HasDeals() {
-		return WithPriority(ctx, DealSectorPriority)
+		return WithDeals(WithPriority(ctx, DealSectorPriority))
 	}
 
 	return ctx